@@ -11,11 +11,13 @@ import (
 	"github.com/DanLavine/goasync"
 	"github.com/DanLavine/urlrouter"
 	"github.com/DanLavine/willow/internal/config"
-	"github.com/DanLavine/willow/internal/helpers"
+	"github.com/DanLavine/willow/internal/heartbeater"
 	"github.com/DanLavine/willow/internal/reporting"
 	"github.com/DanLavine/willow/internal/willow/api"
 	"github.com/DanLavine/willow/internal/willow/api/v1/handlers"
 	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/constructor"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/outbox"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/reconcile"
 	"github.com/DanLavine/willow/internal/willow/brokers/queues"
 	"github.com/DanLavine/willow/pkg/clients"
 	"go.uber.org/zap"
@@ -23,8 +25,6 @@ import (
 	v1router "github.com/DanLavine/willow/internal/willow/api/v1/router"
 	queuechannels "github.com/DanLavine/willow/internal/willow/brokers/queue_channels"
 	limiterclient "github.com/DanLavine/willow/pkg/clients/limiter_client"
-	v1 "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
-	"github.com/DanLavine/willow/pkg/models/datatypes"
 )
 
 func main() {
@@ -68,20 +68,8 @@ func main() {
 		break
 	}
 
-	// setup the rule if it does not exist for the willow limits
-	ruleResp, err := limiterClient.CreateRule(context.Background(), &v1.Rule{
-		Spec: &v1.RuleSpec{
-			DBDefinition: &v1.RuleDBDefinition{
-				GroupByKeyValues: datatypes.KeyValues{
-					"_willow_queue_name": datatypes.Any(),
-					"_willow_enqueued":   datatypes.Any(),
-				},
-			},
-			Properties: &v1.RuleProperties{
-				Limit: helpers.PointerOf[int64](0), // by default, all queues have a limit of 0
-			},
-		},
-	})
+	// setup the rule if it does not exist for the willow limits, or reuse the rule from a previous process
+	limiterRuleID, err := queues.SetupLimiterRule(reporting.StripedContext(logger), limiterClient)
 	if err != nil {
 		logger.Fatal("Failed to setup Limiter enqueue rule", zap.Error(err))
 	}
@@ -90,12 +78,25 @@ func main() {
 	//// using strict config ensures that if any process fails, the server will ty and shutdown gracefully
 	taskManager := goasync.NewTaskManager(goasync.StrictConfig())
 
+	// retries any Limiter counter updates that fail, so the Limiter can be unavailable without stopping Willow
+	limiterOutbox := outbox.New(logger, limiterClient)
+	taskManager.AddExecuteTask("limiter outbox", limiterOutbox)
+
+	// times out all processing items on a single timer wheel
+	heartbeats := heartbeater.NewManager(heartbeater.DefaultTick)
+	taskManager.AddExecuteTask("heartbeats", heartbeats)
+
 	// queue channels client
-	queueChannelsConstructor, err := constructor.NewQueueChannelConstructor("memory", limiterClient)
+	queueChannelsConstructor, err := constructor.NewQueueChannelConstructor("memory", heartbeats, limiterClient, limiterOutbox)
 	if err != nil {
 		logger.Fatal("Failed to setup queue channels constructor", zap.Error(err))
 	}
-	queueChannelsClient := queuechannels.NewLocalQueueChannelsClient(queueChannelsConstructor)
+	mirrorClientConfig := &clients.Config{
+		CAFile:        *cfg.MirrorClientCA,
+		ClientKeyFile: *cfg.MirrorClientKey,
+		ClientCRTFile: *cfg.MirrorClientCRT,
+	}
+	queueChannelsClient := queuechannels.NewLocalQueueChannelsClient(queueChannelsConstructor, mirrorClientConfig)
 	taskManager.AddExecuteTask("queue channels client", queueChannelsClient)
 
 	// queue client
@@ -103,13 +104,23 @@ func main() {
 	if err != nil {
 		logger.Fatal("Failed to setup queue constructor", zap.Error(err))
 	}
-	queueClient := queues.NewLocalQueueClient(queueConstructor, queueChannelsClient, ruleResp.State.ID)
+	queueClient := queues.NewLocalQueueClient(logger, queueConstructor, queueChannelsClient, limiterRuleID)
+	taskManager.AddExecuteTask("queue client", queueClient)
+
+	// corrects any Limiter counters that drift from the items Willow holds
+	limiterReconciler := reconcile.New(logger, limiterClient, limiterOutbox, *cfg.ReconcileInterval, queueClient.LimiterCounters)
+	taskManager.AddExecuteTask("limiter reconciler", limiterReconciler)
+
+	//// no queues exist yet, so this removes any stale counters left by a previous process
+	if _, err := limiterReconciler.Reconcile(reporting.StripedContext(logger)); err != nil {
+		logger.Fatal("Failed to reset stale Limiter counters", zap.String("error", err.Message))
+	}
 
 	// setup willow server
 	willowMux := urlrouter.New()
 	//// v1 api handlers
-	v1router.AddV1WillowRoutes(logger, willowMux, handlers.NewV1QueueHandler(queueClient, ruleResp.State.ID))
-	taskManager.AddTask("tcp_server", api.NewWillowTCP(logger, cfg, willowMux))
+	v1router.AddV1WillowRoutes(logger, willowMux, handlers.NewV1QueueHandler(queueClient, limiterRuleID, limiterReconciler))
+	taskManager.AddTask("tcp_server", api.NewWillowTCP(logger, cfg, willowMux, limiterOutbox, limiterReconciler))
 
 	// start all processes
 	if errs := taskManager.Run(shutdown); errs != nil {
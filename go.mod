@@ -10,7 +10,11 @@ require (
 	github.com/DanLavine/goasync v1.0.2
 	github.com/DanLavine/gonotify v0.0.0-20221228000906-77ad21d2336e
 	github.com/DanLavine/urlrouter v0.0.0-20231102214216-aa9c83986dc4
+	github.com/go-openapi/errors v0.20.4
 	github.com/go-openapi/runtime v0.26.2
+	github.com/go-openapi/spec v0.20.11
+	github.com/go-openapi/strfmt v0.21.9
+	github.com/go-openapi/validate v0.22.3
 	github.com/golang/mock v1.6.0
 	github.com/google/uuid v1.4.0
 	github.com/onsi/gomega v1.33.1
@@ -23,14 +27,10 @@ require (
 require (
 	github.com/asaskevich/govalidator v0.0.0-20230301143203-a9d515a09cc2 // indirect
 	github.com/go-openapi/analysis v0.21.4 // indirect
-	github.com/go-openapi/errors v0.20.4 // indirect
 	github.com/go-openapi/jsonpointer v0.20.0 // indirect
 	github.com/go-openapi/jsonreference v0.20.2 // indirect
 	github.com/go-openapi/loads v0.21.2 // indirect
-	github.com/go-openapi/spec v0.20.11 // indirect
-	github.com/go-openapi/strfmt v0.21.9 // indirect
 	github.com/go-openapi/swag v0.22.4 // indirect
-	github.com/go-openapi/validate v0.22.3 // indirect
 	github.com/google/go-cmp v0.6.0 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
@@ -157,6 +157,10 @@ func StartWillow(g *WithT, limiterURL string) *IntegrationTestConstruct {
 		"-limiter-client-ca", filepath.Join(currentDir, "..", "..", "..", "testhelpers", "tls-keys", "ca.crt"),
 		"-limiter-client-key", filepath.Join(currentDir, "..", "..", "..", "testhelpers", "tls-keys", "client.key"),
 		"-limiter-client-crt", filepath.Join(currentDir, "..", "..", "..", "testhelpers", "tls-keys", "client.crt"),
+		// configuration to point to any queue mirrors
+		"-mirror-client-ca", filepath.Join(currentDir, "..", "..", "..", "testhelpers", "tls-keys", "ca.crt"),
+		"-mirror-client-key", filepath.Join(currentDir, "..", "..", "..", "testhelpers", "tls-keys", "client.key"),
+		"-mirror-client-crt", filepath.Join(currentDir, "..", "..", "..", "testhelpers", "tls-keys", "client.crt"),
 	}
 
 	willowExe := exec.Command(willowPath, cmdLineFlags...)
@@ -256,6 +256,59 @@ func Test_Lock(t *testing.T) {
 	})
 }
 
+func Test_Lock_Heartbeats(t *testing.T) {
+	t.Parallel()
+
+	g := NewGomegaWithT(t)
+
+	t.Run("It keeps all locks from the same client by heartbeating them together", func(t *testing.T) {
+		t.Parallel()
+
+		testConstruct := StartLocker(g)
+		defer testConstruct.Shutdown(g)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		lockerClient := setupClient(g, testConstruct.ServerURL)
+
+		var locks []lockerclient.Lock
+		for i := 0; i < 5; i++ {
+			lockRequest := &v1locker.Lock{
+				Spec: &v1locker.LockSpec{
+					DBDefinition: &v1locker.LockDBDefinition{
+						KeyValues: datatypes.KeyValues{
+							"key1": datatypes.Int(i),
+						},
+					},
+					Properties: &v1locker.LockProperties{
+						Timeout: helpers.PointerOf(time.Second),
+					},
+				},
+			}
+
+			lock, err := lockerClient.ObtainLock(ctx, lockRequest, func(keyValue datatypes.KeyValues, err error) {
+				fmt.Println("failed to heartbeat:", err)
+			})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(lock).ToNot(BeNil())
+
+			locks = append(locks, lock)
+		}
+
+		for _, lock := range locks {
+			g.Consistently(lock.Done(), time.Second).ShouldNot(BeClosed())
+		}
+
+		// releasing one lock does not affect the others
+		g.Expect(locks[0].Release(nil)).ToNot(HaveOccurred())
+		for _, lock := range locks[1:] {
+			g.Consistently(lock.Done(), time.Second).ShouldNot(BeClosed())
+			g.Expect(lock.Release(nil)).ToNot(HaveOccurred())
+		}
+	})
+}
+
 // This would really be an admin API, and I don't think the client should have this implemented?
 // if it was a "list", it would just list the locks that the client currently holds
 func TestLocker_List_API(t *testing.T) {
@@ -68,7 +68,7 @@ func Test_Queue_Enqueue(t *testing.T) {
 				},
 			},
 		}
-		err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
+		_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		// ensure the counters are setup properly
@@ -122,7 +122,7 @@ func Test_Queue_Enqueue(t *testing.T) {
 				},
 			},
 		}
-		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem1)).ToNot(HaveOccurred())
+		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem1)).Error().ToNot(HaveOccurred())
 
 		enqueueQueueItem2 := &v1willow.Item{ // updates the previous item
 			Spec: &v1willow.ItemSpec{
@@ -140,7 +140,7 @@ func Test_Queue_Enqueue(t *testing.T) {
 				},
 			},
 		}
-		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem2)).ToNot(HaveOccurred())
+		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem2)).Error().ToNot(HaveOccurred())
 
 		enqueueQueueItem3 := &v1willow.Item{
 			Spec: &v1willow.ItemSpec{
@@ -159,7 +159,7 @@ func Test_Queue_Enqueue(t *testing.T) {
 				},
 			},
 		}
-		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem3)).ToNot(HaveOccurred())
+		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem3)).Error().ToNot(HaveOccurred())
 
 		enqueueQueueItem4 := &v1willow.Item{
 			Spec: &v1willow.ItemSpec{
@@ -179,7 +179,7 @@ func Test_Queue_Enqueue(t *testing.T) {
 				},
 			},
 		}
-		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem4)).ToNot(HaveOccurred())
+		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem4)).Error().ToNot(HaveOccurred())
 
 		enqueueQueueItem5 := &v1willow.Item{
 			Spec: &v1willow.ItemSpec{
@@ -199,7 +199,7 @@ func Test_Queue_Enqueue(t *testing.T) {
 				},
 			},
 		}
-		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem5)).ToNot(HaveOccurred())
+		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem5)).Error().ToNot(HaveOccurred())
 
 		// ensure the counters are setup properly
 		counters, err := limiterClient.QueryCounters(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
@@ -316,7 +316,7 @@ func Test_Queue_Enqueue(t *testing.T) {
 					},
 				},
 			}
-			g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)).ToNot(HaveOccurred())
+			g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)).Error().ToNot(HaveOccurred())
 		}
 
 		// next item enqueued should error
@@ -336,7 +336,7 @@ func Test_Queue_Enqueue(t *testing.T) {
 				},
 			},
 		}
-		err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
+		_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
 		g.Expect(err).To(HaveOccurred())
 		g.Expect(err.Error()).To(ContainSubstring("Queue has reached the total number of allowed queue items"))
 
@@ -366,6 +366,75 @@ func Test_Queue_Enqueue(t *testing.T) {
 	})
 }
 
+func Test_Queue_EnqueueDedupKey(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It replaces the enqueued item with the same DedupKey", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems: helpers.PointerOf[int64](2),
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		enqueueItem := func(data, dedupKey string, moveToBack bool) *v1willow.Item {
+			return &v1willow.Item{
+				Spec: &v1willow.ItemSpec{
+					DBDefinition: &v1willow.ItemDBDefinition{
+						KeyValues: datatypes.KeyValues{
+							"repo": datatypes.String("willow"),
+						},
+					},
+					Properties: &v1willow.ItemProperties{
+						Data:            []byte(data),
+						Updateable:      helpers.PointerOf(false),
+						RetryAttempts:   helpers.PointerOf[uint64](0),
+						RetryPosition:   helpers.PointerOf("front"),
+						TimeoutDuration: helpers.PointerOf(time.Second),
+						DedupKey:        helpers.PointerOf(dedupKey),
+						DedupMoveToBack: helpers.PointerOf(moveToBack),
+					},
+				},
+			}
+		}
+
+		_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueItem("pr-1 commit 1", "pr-1", false))
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueItem("pr-2 commit 1", "pr-2", false))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// replacing the item does not count towards the max items
+		_, err = willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueItem("pr-1 commit 2", "pr-1", true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// the latest commit for each PR is processed, with the replaced item moved to the back
+		for _, expectedData := range []string{"pr-2 commit 1", "pr-1 commit 2"} {
+			item, err := willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(item.Data()).To(Equal([]byte(expectedData)))
+			g.Expect(item.ACK(context.Background(), true)).ToNot(HaveOccurred())
+		}
+	})
+}
+
 func Test_Queue_Dequeue(t *testing.T) {
 	t.Parallel()
 
@@ -463,7 +532,7 @@ func Test_Queue_Dequeue(t *testing.T) {
 				},
 			},
 		}
-		err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
+		_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		// dequeue the item
@@ -574,7 +643,7 @@ func Test_Queue_Dequeue(t *testing.T) {
 				},
 			},
 		}
-		err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
+		_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		// dequeue should recieve
@@ -625,6 +694,149 @@ func Test_Queue_Dequeue(t *testing.T) {
 	})
 }
 
+func Test_Queue_DequeueMultipleQueues(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	setupQueues := func(willowClient willowclient.WillowServiceClient) {
+		for _, queueName := range []string{"orders-1", "orders-2", "payments"} {
+			createQueue := &v1willow.Queue{
+				Spec: &v1willow.QueueSpec{
+					DBDefinition: &v1willow.QueueDBDefinition{
+						Name: helpers.PointerOf(queueName),
+					},
+					Properties: &v1willow.QueueProperties{
+						MaxItems: helpers.PointerOf[int64](5),
+					},
+				},
+			}
+			g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+		}
+	}
+
+	enqueueItem := func(data string) *v1willow.Item {
+		return &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{
+						"one": datatypes.Int(1),
+					},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(data),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](0),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+	}
+
+	t.Run("It returns an error if a named queue does not exist", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+		setupQueues(willowClient)
+
+		dequeueQueues := &v1willow.DequeueQueues{
+			Queues: []*v1willow.DequeueQueue{
+				{Name: helpers.PointerOf("payments"), ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}},
+				{Name: helpers.PointerOf("not found"), ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}},
+			},
+		}
+
+		item, err := willowClient.DequeueQueueItems(context.Background(), dequeueQueues)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("failed to find queue 'not found' by name"))
+		g.Expect(item).To(BeNil())
+	})
+
+	t.Run("It dequeues items from any queue in the name list", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+		setupQueues(willowClient)
+
+		_, err := willowClient.EnqueueQueueItem(context.Background(), "payments", enqueueItem("payment item"))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		dequeueQueues := &v1willow.DequeueQueues{
+			Queues: []*v1willow.DequeueQueue{
+				{Name: helpers.PointerOf("orders-1"), ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}},
+				{Name: helpers.PointerOf("payments"), ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}},
+			},
+		}
+
+		item, err := willowClient.DequeueQueueItems(context.Background(), dequeueQueues)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(item.Data()).To(Equal([]byte("payment item")))
+		g.Expect(item.QueueName()).To(Equal("payments"))
+		g.Expect(item.ACK(context.Background(), true)).ToNot(HaveOccurred())
+	})
+
+	t.Run("It waits for items on any queue that matches the name pattern", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+		setupQueues(willowClient)
+
+		dequeueQueues := &v1willow.DequeueQueues{
+			Queues: []*v1willow.DequeueQueue{
+				{NamePattern: helpers.PointerOf("orders-*"), ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}},
+			},
+		}
+
+		var item *willowclient.Item
+		var dequeueErr error
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			item, dequeueErr = willowClient.DequeueQueueItems(context.Background(), dequeueQueues)
+		}()
+
+		// an item on a queue that does not match the pattern is ignored
+		_, err := willowClient.EnqueueQueueItem(context.Background(), "payments", enqueueItem("payment item"))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Consistently(done).ShouldNot(BeClosed())
+
+		_, err = willowClient.EnqueueQueueItem(context.Background(), "orders-2", enqueueItem("order item"))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Eventually(done).Should(BeClosed())
+		g.Expect(dequeueErr).ToNot(HaveOccurred())
+		g.Expect(item.Data()).To(Equal([]byte("order item")))
+		g.Expect(item.QueueName()).To(Equal("orders-2"))
+		g.Expect(item.ACK(context.Background(), true)).ToNot(HaveOccurred())
+	})
+}
+
 func Test_Queue_DeleteChannel(t *testing.T) {
 	t.Parallel()
 
@@ -675,7 +887,7 @@ func Test_Queue_DeleteChannel(t *testing.T) {
 				},
 			},
 		}
-		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem1)).ToNot(HaveOccurred())
+		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem1)).Error().ToNot(HaveOccurred())
 
 		enqueueQueueItem2 := &v1willow.Item{ // updates the previous item
 			Spec: &v1willow.ItemSpec{
@@ -693,7 +905,7 @@ func Test_Queue_DeleteChannel(t *testing.T) {
 				},
 			},
 		}
-		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem2)).ToNot(HaveOccurred())
+		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem2)).Error().ToNot(HaveOccurred())
 
 		enqueueQueueItem3 := &v1willow.Item{
 			Spec: &v1willow.ItemSpec{
@@ -712,7 +924,7 @@ func Test_Queue_DeleteChannel(t *testing.T) {
 				},
 			},
 		}
-		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem3)).ToNot(HaveOccurred())
+		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem3)).Error().ToNot(HaveOccurred())
 
 		enqueueQueueItem4 := &v1willow.Item{
 			Spec: &v1willow.ItemSpec{
@@ -732,7 +944,7 @@ func Test_Queue_DeleteChannel(t *testing.T) {
 				},
 			},
 		}
-		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem4)).ToNot(HaveOccurred())
+		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem4)).Error().ToNot(HaveOccurred())
 
 		enqueueQueueItem5 := &v1willow.Item{
 			Spec: &v1willow.ItemSpec{
@@ -752,7 +964,7 @@ func Test_Queue_DeleteChannel(t *testing.T) {
 				},
 			},
 		}
-		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem5)).ToNot(HaveOccurred())
+		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem5)).Error().ToNot(HaveOccurred())
 
 		// delete a channel
 		err := willowClient.DeleteQueueChannel(context.Background(), "test queue", datatypes.KeyValues{"one": datatypes.Int(1)})
@@ -804,3 +1016,227 @@ func Test_Queue_DeleteChannel(t *testing.T) {
 		))
 	})
 }
+
+func Test_Queue_ChannelWeights(t *testing.T) {
+	t.Parallel()
+
+	g := NewGomegaWithT(t)
+
+	t.Run("It dequeues items in proportion to the channel weights", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+
+		teamA := datatypes.KeyValues{"team": datatypes.String("a")}
+		teamB := datatypes.KeyValues{"team": datatypes.String("b")}
+
+		// setup queue
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems: helpers.PointerOf[int64](100),
+					ChannelWeights: []*v1willow.ChannelWeight{
+						{ChannelQuery: queryassociatedaction.KeyValuesToExactAssociatedActionQuery(teamA), Weight: helpers.PointerOf[int64](3)},
+						{ChannelQuery: queryassociatedaction.KeyValuesToExactAssociatedActionQuery(teamB), Weight: helpers.PointerOf[int64](1)},
+					},
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		queue, err := willowClient.GetQueue(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(queue.Spec.Properties.ChannelWeights)).To(Equal(2))
+
+		// enqueue the same number of items to both teams
+		for team, keyValues := range map[string]datatypes.KeyValues{"a": teamA, "b": teamB} {
+			for i := 0; i < 20; i++ {
+				enqueueItem := &v1willow.Item{
+					Spec: &v1willow.ItemSpec{
+						DBDefinition: &v1willow.ItemDBDefinition{
+							KeyValues: keyValues,
+						},
+						Properties: &v1willow.ItemProperties{
+							Data:            []byte(team),
+							Updateable:      helpers.PointerOf(false),
+							RetryAttempts:   helpers.PointerOf[uint64](0),
+							RetryPosition:   helpers.PointerOf("front"),
+							TimeoutDuration: helpers.PointerOf(5 * time.Second),
+						},
+					},
+				}
+
+				_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueItem)
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		}
+
+		// dequeue half of the items
+		dequeued := map[string]int{}
+		for i := 0; i < 20; i++ {
+			item, err := willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			dequeued[string(item.Data())]++
+			g.Expect(item.ACK(context.Background(), true)).ToNot(HaveOccurred())
+		}
+
+		g.Expect(dequeued["a"]).To(BeNumerically("~", 15, 2))
+		g.Expect(dequeued["b"]).To(BeNumerically("~", 5, 2))
+
+		// the weights and deficits are reported in the channel state
+		channels, err := willowClient.QueryQueueChannels(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(channels)).To(Equal(2))
+
+		for _, channel := range channels {
+			switch channel.Spec.DBDefinition.KeyValues["team"].Data.(string) {
+			case "a":
+				g.Expect(channel.State.Weight).To(Equal(int64(3)))
+				g.Expect(channel.State.Deficit).To(BeNumerically("<=", 3))
+			default:
+				g.Expect(channel.State.Weight).To(Equal(int64(1)))
+				g.Expect(channel.State.Deficit).To(BeNumerically("<=", 1))
+			}
+		}
+	})
+}
+
+func Test_Queue_DiagnoseChannels(t *testing.T) {
+	t.Parallel()
+
+	g := NewGomegaWithT(t)
+
+	t.Run("It returns an error when the queue does not exist", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+
+		channelDiagnoses, err := willowClient.DiagnoseQueueChannels(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("failed to find queue 'test queue' by name"))
+		g.Expect(channelDiagnoses).To(BeNil())
+	})
+
+	t.Run("It reports the enqueued items and the Limiter rules blocking each channel", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+		limiterClient := setupLimitterClient(g, limiterTestConstruct.ServerURL)
+
+		// setup queue
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems: helpers.PointerOf[int64](5),
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		// no channels match the query yet
+		channelDiagnoses, err := willowClient.DiagnoseQueueChannels(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(channelDiagnoses).To(BeEmpty())
+
+		// only allow 1 item to run at a time
+		rule, err := limiterClient.CreateRule(context.Background(), &v1limiter.Rule{
+			Spec: &v1limiter.RuleSpec{
+				DBDefinition: &v1limiter.RuleDBDefinition{
+					GroupByKeyValues: datatypes.KeyValues{
+						"one":             datatypes.Any(),
+						"_willow_running": datatypes.Any(),
+					},
+				},
+				Properties: &v1limiter.RuleProperties{
+					Limit: helpers.PointerOf[int64](1),
+				},
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// enqueue 2 items
+		for i := 0; i < 2; i++ {
+			enqueueItem := &v1willow.Item{
+				Spec: &v1willow.ItemSpec{
+					DBDefinition: &v1willow.ItemDBDefinition{
+						KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+					},
+					Properties: &v1willow.ItemProperties{
+						Data:            []byte(fmt.Sprintf("%d", i)),
+						Updateable:      helpers.PointerOf(false),
+						RetryAttempts:   helpers.PointerOf[uint64](0),
+						RetryPosition:   helpers.PointerOf("front"),
+						TimeoutDuration: helpers.PointerOf(5 * time.Second),
+					},
+				},
+			}
+
+			_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueItem)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		channelDiagnoses, err = willowClient.DiagnoseQueueChannels(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(channelDiagnoses)).To(Equal(1))
+		g.Expect(channelDiagnoses[0].KeyValues).To(Equal(datatypes.KeyValues{"one": datatypes.Int(1)}))
+		g.Expect(channelDiagnoses[0].EnqueuedItems).To(Equal(int64(2)))
+		g.Expect(channelDiagnoses[0].BlockingLimits).To(BeEmpty())
+
+		// dequeue an item so the rule's limit is reached
+		item, err := willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		channelDiagnoses, err = willowClient.DiagnoseQueueChannels(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(channelDiagnoses)).To(Equal(1))
+		g.Expect(channelDiagnoses[0].EnqueuedItems).To(Equal(int64(1)))
+		g.Expect(channelDiagnoses[0].ProcessingItems).To(Equal(int64(1)))
+		g.Expect(channelDiagnoses[0].BlockingLimits).To(Equal(v1willow.BlockingLimits{
+			{RuleID: rule.State.ID, Limit: 1, Count: 1},
+		}))
+
+		// once the item is processed, the channel is no longer blocked
+		g.Expect(item.ACK(context.Background(), true)).ToNot(HaveOccurred())
+
+		channelDiagnoses, err = willowClient.DiagnoseQueueChannels(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(channelDiagnoses)).To(Equal(1))
+		g.Expect(channelDiagnoses[0].EnqueuedItems).To(Equal(int64(1)))
+		g.Expect(channelDiagnoses[0].ProcessingItems).To(Equal(int64(0)))
+		g.Expect(channelDiagnoses[0].BlockingLimits).To(BeEmpty())
+	})
+}
@@ -66,7 +66,7 @@ func Test_Queue_ItemACK(t *testing.T) {
 					},
 				},
 			}
-			err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
+			_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
 			g.Expect(err).ToNot(HaveOccurred())
 
 			// dequeue the item
@@ -174,7 +174,7 @@ func Test_Queue_ItemACK(t *testing.T) {
 					},
 				},
 			}
-			err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
+			_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
 			g.Expect(err).ToNot(HaveOccurred())
 
 			// dequeue the item
@@ -281,3 +281,924 @@ func Test_Queue_ItemACK(t *testing.T) {
 		})
 	})
 }
+
+func Test_Queue_ItemCancel(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It informs the dequeued item and does not retry the canceled item", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+		limiterClient := setupLimitterClient(g, limiterTestConstruct.ServerURL)
+
+		// setup queue
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems: helpers.PointerOf[int64](5),
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		// enqueue the item
+		enqueueQueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{
+						"one": datatypes.Int(1),
+					},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(`data for first item`),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](3),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		itemState, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(itemState.ID).ToNot(BeEmpty())
+
+		// dequeue the item
+		item, err := willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// cancel the item from the producer side
+		cancel := &v1willow.Cancel{
+			ItemID:    itemState.ID,
+			KeyValues: enqueueQueueItem.Spec.DBDefinition.KeyValues,
+		}
+		g.Expect(willowClient.CancelQueueItem(context.Background(), "test queue", cancel)).ToNot(HaveOccurred())
+
+		// the next heartbeat reports the cancel
+		g.Eventually(item.Canceled(), 2*time.Second).Should(BeClosed())
+		g.Consistently(item.Done()).ShouldNot(BeClosed())
+
+		// failing the item removes it without a retry
+		g.Expect(item.ACK(context.Background(), false)).ToNot(HaveOccurred())
+
+		counters, err := limiterClient.QueryCounters(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(counters)).To(Equal(0))
+	})
+}
+
+func Test_Queue_ItemDelete(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It deletes the enqueued and processing items with the headers", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+		limiterClient := setupLimitterClient(g, limiterTestConstruct.ServerURL)
+
+		// setup queue
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems: helpers.PointerOf[int64](5),
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		// enqueue 2 items with the same headers
+		for _, data := range []string{"first item", "second item"} {
+			enqueueQueueItem := &v1willow.Item{
+				Spec: &v1willow.ItemSpec{
+					DBDefinition: &v1willow.ItemDBDefinition{
+						KeyValues: datatypes.KeyValues{
+							"one": datatypes.Int(1),
+						},
+					},
+					Properties: &v1willow.ItemProperties{
+						Data:            []byte(data),
+						Headers:         map[string]string{"batch": "1"},
+						Updateable:      helpers.PointerOf(false),
+						RetryAttempts:   helpers.PointerOf[uint64](3),
+						RetryPosition:   helpers.PointerOf("front"),
+						TimeoutDuration: helpers.PointerOf(time.Second),
+					},
+				},
+			}
+			_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		// dequeue the first item
+		item, err := willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// delete both items
+		itemDelete := &v1willow.ItemDelete{
+			Headers:   map[string]string{"batch": "1"},
+			KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+		}
+		g.Expect(willowClient.DeleteQueueItems(context.Background(), "test queue", itemDelete)).ToNot(HaveOccurred())
+
+		items, err := willowClient.QueryQueueItems(context.Background(), "test queue", &v1willow.ItemQuery{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(items).To(BeEmpty())
+
+		// the processing item can no longer be completed
+		g.Expect(item.ACK(context.Background(), true)).To(HaveOccurred())
+
+		// deleting again finds nothing
+		err = willowClient.DeleteQueueItems(context.Background(), "test queue", itemDelete)
+		g.Expect(err).To(MatchError(willowclient.ErrItemsNotFound))
+
+		counters, err := limiterClient.QueryCounters(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(counters)).To(Equal(0))
+	})
+}
+
+func Test_Queue_ItemProgress(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It records the progress reported through heartbeats for item queries", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+
+		// setup queue
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems: helpers.PointerOf[int64](5),
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		// enqueue the item
+		enqueueQueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{
+						"one": datatypes.Int(1),
+					},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(`data for first item`),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](0),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		itemState, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		itemQuery := &v1willow.ItemQuery{
+			ChannelQuery: &queryassociatedaction.AssociatedActionQuery{},
+			ItemID:       &itemState.ID,
+		}
+
+		// item is only enqueued
+		items, err := willowClient.QueryQueueItems(context.Background(), "test queue", itemQuery)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(items)).To(Equal(1))
+		g.Expect(items[0].State.Processing).To(BeFalse())
+		g.Expect(items[0].State.Progress).To(BeNil())
+
+		// dequeue the item
+		item, err := willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// report the progress
+		progress := &v1willow.ItemProgress{
+			Percent:   helpers.PointerOf(25.0),
+			Status:    helpers.PointerOf("compiling"),
+			KeyValues: datatypes.KeyValues{"step": datatypes.String("build")},
+		}
+		g.Expect(item.ReportProgress(progress)).ToNot(HaveOccurred())
+
+		// the progress is sent on the next heartbeat
+		g.Eventually(func() *v1willow.ItemProgress {
+			items, err := willowClient.QueryQueueItems(context.Background(), "test queue", itemQuery)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(len(items)).To(Equal(1))
+			g.Expect(items[0].State.Processing).To(BeTrue())
+
+			return items[0].State.Progress
+		}, 2*time.Second).Should(Equal(progress))
+
+		g.Expect(item.ACK(context.Background(), true)).ToNot(HaveOccurred())
+	})
+}
+
+func Test_Queue_ItemMaxRunDuration(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It fails items that run longer than the queue's max run duration even when heartbeating", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+		limiterClient := setupLimitterClient(g, limiterTestConstruct.ServerURL)
+
+		// setup queue
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems:       helpers.PointerOf[int64](5),
+					MaxRunDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		queue, err := willowClient.GetQueue(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(*queue.Spec.Properties.MaxRunDuration).To(Equal(time.Second))
+
+		// enqueue the item without a max run duration
+		enqueueQueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{
+						"one": datatypes.Int(1),
+					},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(`data for first item`),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](0),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(500 * time.Millisecond),
+				},
+			},
+		}
+		_, err = willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// dequeue the item which heartbeats automatically
+		item, err := willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// the item is failed by the service once the queue's max run duration is reached
+		g.Eventually(item.Done(), 3*time.Second).Should(BeClosed())
+
+		g.Eventually(func() int {
+			counters, err := limiterClient.QueryCounters(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			return len(counters)
+		}).Should(Equal(0))
+	})
+}
+
+func Test_Queue_ItemCompletedRetention(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It retains completed items for the queue's retention period", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+
+		// setup queue that only allows a single item
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems:           helpers.PointerOf[int64](1),
+					CompletedRetention: helpers.PointerOf(2 * time.Second),
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		enqueueQueueItem := func(data string) *v1willow.Item {
+			return &v1willow.Item{
+				Spec: &v1willow.ItemSpec{
+					DBDefinition: &v1willow.ItemDBDefinition{
+						KeyValues: datatypes.KeyValues{
+							"commit": datatypes.String("abc123"),
+						},
+					},
+					Properties: &v1willow.ItemProperties{
+						Data:            []byte(data),
+						Updateable:      helpers.PointerOf(false),
+						RetryAttempts:   helpers.PointerOf[uint64](0),
+						RetryPosition:   helpers.PointerOf("front"),
+						TimeoutDuration: helpers.PointerOf(time.Second),
+					},
+				},
+			}
+		}
+
+		// process the item
+		itemState, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem("build commit"))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		item, err := willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(item.ACKWithResult(context.Background(), true, []byte(`build passed`))).ToNot(HaveOccurred())
+
+		// the completed item is only returned when requested
+		itemQuery := &v1willow.ItemQuery{
+			ChannelQuery: &queryassociatedaction.AssociatedActionQuery{},
+		}
+		items, err := willowClient.QueryQueueItems(context.Background(), "test queue", itemQuery)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(items)).To(Equal(0))
+
+		itemQuery.IncludeCompleted = true
+		items, err = willowClient.QueryQueueItems(context.Background(), "test queue", itemQuery)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(items)).To(Equal(1))
+		g.Expect(items[0].State.ID).To(Equal(itemState.ID))
+		g.Expect(items[0].Spec.DBDefinition.KeyValues).To(Equal(datatypes.KeyValues{"commit": datatypes.String("abc123")}))
+		g.Expect(items[0].Spec.Properties.Data).To(Equal([]byte(`build commit`)))
+		g.Expect(items[0].State.Completed).ToNot(BeNil())
+		g.Expect(items[0].State.Completed.Result).To(Equal([]byte(`build passed`)))
+		g.Expect(len(items[0].State.Attempts)).To(Equal(1))
+		g.Expect(items[0].State.Attempts[0].Result).To(Equal(v1willow.ItemAttemptPassed))
+
+		// the retained item does not count towards the max items
+		_, err = willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem("another commit"))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// the completed item is evicted after the retention period
+		g.Eventually(func() int {
+			items, err := willowClient.QueryQueueItems(context.Background(), "test queue", itemQuery)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			completed := 0
+			for _, item := range items {
+				if item.State.Completed != nil {
+					completed++
+				}
+			}
+
+			return completed
+		}, 4*time.Second).Should(Equal(0))
+	})
+}
+
+func Test_Queue_ItemIdempotencyKey(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	enqueueQueueItem := func(keyValues datatypes.KeyValues, idempotencyKey string) *v1willow.Item {
+		return &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: keyValues,
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(`build commit`),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](0),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+					IdempotencyKey:  helpers.PointerOf(idempotencyKey),
+				},
+			},
+		}
+	}
+
+	t.Run("It rejects idempotency keys when the queue has no IdempotencyWindow", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems: helpers.PointerOf[int64](5),
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem(datatypes.KeyValues{"one": datatypes.Int(1)}, "build-abc123"))
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("does not accept idempotency keys"))
+	})
+
+	t.Run("It returns the existing item for the same key across channels within the window", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems:          helpers.PointerOf[int64](5),
+					IdempotencyWindow: helpers.PointerOf(2 * time.Second),
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		// enqueue the original item
+		itemState, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem(datatypes.KeyValues{"one": datatypes.Int(1)}, "build-abc123"))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(itemState.Duplicate).To(BeFalse())
+
+		// retrying the enqueue on a different channel returns the original item
+		duplicateState, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem(datatypes.KeyValues{"two": datatypes.Int(2)}, "build-abc123"))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(duplicateState.Duplicate).To(BeTrue())
+		g.Expect(duplicateState.ID).To(Equal(itemState.ID))
+		g.Expect(duplicateState.Processing).To(BeFalse())
+
+		// only the original item is enqueued
+		items, err := willowClient.QueryQueueItems(context.Background(), "test queue", &v1willow.ItemQuery{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(items)).To(Equal(1))
+
+		// the item can be looked up by the key
+		items, err = willowClient.QueryQueueItems(context.Background(), "test queue", &v1willow.ItemQuery{
+			ChannelQuery:   &queryassociatedaction.AssociatedActionQuery{},
+			IdempotencyKey: helpers.PointerOf("build-abc123"),
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(items)).To(Equal(1))
+		g.Expect(items[0].State.ID).To(Equal(itemState.ID))
+		g.Expect(*items[0].Spec.Properties.IdempotencyKey).To(Equal("build-abc123"))
+		g.Expect(items[0].Spec.DBDefinition.KeyValues).To(Equal(datatypes.KeyValues{"one": datatypes.Int(1)}))
+
+		// after the window, the key can be used again
+		g.Eventually(func() bool {
+			newState, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem(datatypes.KeyValues{"two": datatypes.Int(2)}, "build-abc123"))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			return newState.Duplicate
+		}, 4*time.Second, 500*time.Millisecond).Should(BeFalse())
+	})
+}
+
+func Test_Queue_ItemHeaders(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	enqueueQueueItem := func(data string, headers map[string]string) *v1willow.Item {
+		return &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(data),
+					Headers:         headers,
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](1),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+	}
+
+	t.Run("It dequeues only the items that match the headers filter and preserves the headers through retries", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems: helpers.PointerOf[int64](5),
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem("version 1", map[string]string{"schema": "v1"}))
+		g.Expect(err).ToNot(HaveOccurred())
+		itemState, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem("version 2", map[string]string{"schema": "v2"}))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// a client that only knows how to decode the v2 schema skips the first item
+		dequeueQueues := &v1willow.DequeueQueues{
+			Queues:        []*v1willow.DequeueQueue{{Name: helpers.PointerOf("test queue"), ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}}},
+			HeadersFilter: map[string]string{"schema": "v2"},
+		}
+		item, err := willowClient.DequeueQueueItems(context.Background(), dequeueQueues)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(item.Data()).To(Equal([]byte(`version 2`)))
+		g.Expect(item.Headers()).To(Equal(map[string]string{"schema": "v2"}))
+
+		// fail the item so it is retried
+		g.Expect(item.ACK(context.Background(), false)).ToNot(HaveOccurred())
+
+		// the retried item still has the headers
+		items, err := willowClient.QueryQueueItems(context.Background(), "test queue", &v1willow.ItemQuery{
+			ChannelQuery: &queryassociatedaction.AssociatedActionQuery{},
+			ItemID:       &itemState.ID,
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(items)).To(Equal(1))
+		g.Expect(items[0].Spec.Properties.Headers).To(Equal(map[string]string{"schema": "v2"}))
+
+		item, err = willowClient.DequeueQueueItems(context.Background(), dequeueQueues)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(item.Data()).To(Equal([]byte(`version 2`)))
+		g.Expect(item.Headers()).To(Equal(map[string]string{"schema": "v2"}))
+		g.Expect(item.ACK(context.Background(), true)).ToNot(HaveOccurred())
+
+		// the first item is still available to other clients
+		item, err = willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(item.Data()).To(Equal([]byte(`version 1`)))
+		g.Expect(item.Headers()).To(Equal(map[string]string{"schema": "v1"}))
+		g.Expect(item.ACK(context.Background(), true)).ToNot(HaveOccurred())
+	})
+}
+
+func Test_Queue_ItemPayloadValidation(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	enqueueQueueItem := func(data string, headers map[string]string) *v1willow.Item {
+		return &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(data),
+					Headers:         headers,
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](0),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+	}
+
+	jsonHeaders := map[string]string{v1willow.HeaderContentType: "application/json; charset=utf-8"}
+
+	t.Run("It rejects a DataSchema with a remote reference", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems:   helpers.PointerOf[int64](5),
+					DataSchema: []byte(`{"$ref": "http://example.com/schema.json"}`),
+				},
+			},
+		}
+
+		err := willowClient.CreateQueue(context.Background(), createQueue)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("DataSchema: '$ref' must be a local reference"))
+	})
+
+	t.Run("It rejects items that violate the queue's MaxPayloadSize or DataSchema", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+
+		dataSchema := []byte(`{"type":"object","required":["commit"],"properties":{"commit":{"type":"string"},"retries":{"type":"integer","minimum":0}}}`)
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems:       helpers.PointerOf[int64](5),
+					MaxPayloadSize: helpers.PointerOf[int64](64),
+					DataSchema:     dataSchema,
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		queue, err := willowClient.GetQueue(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(*queue.Spec.Properties.MaxPayloadSize).To(Equal(int64(64)))
+		g.Expect(queue.Spec.Properties.DataSchema).To(MatchJSON(dataSchema))
+
+		// payload is too large
+		_, err = willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem(`{"commit":"abc123","padding":"this payload is over the max size of the queue"}`, jsonHeaders))
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("Spec.Properties.Data: is 78 bytes which exceeds the queue's MaxPayloadSize of 64 bytes"))
+
+		// payload is not JSON
+		_, err = willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem(`not json`, jsonHeaders))
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("Spec.Properties.Data: is not valid JSON"))
+
+		// payload does not satisfy the schema
+		_, err = willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem(`{"commit":"abc123","retries":-1}`, jsonHeaders))
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("Spec.Properties.Data.retries: retries in body should be greater than or equal to 0"))
+
+		_, err = willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem(`{"retries":1}`, jsonHeaders))
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("Spec.Properties.Data.commit: commit in body is required"))
+
+		// valid JSON items and items with other content types are enqueued
+		_, err = willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem(`{"commit":"abc123","retries":1}`, jsonHeaders))
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem(`not json`, map[string]string{v1willow.HeaderContentType: "text/plain"}))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		items, err := willowClient.QueryQueueItems(context.Background(), "test queue", &v1willow.ItemQuery{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(items)).To(Equal(2))
+	})
+}
+
+func Test_Queue_ItemPriorityAging(t *testing.T) {
+	t.Parallel()
+
+	g := NewGomegaWithT(t)
+
+	t.Run("It eventually dequeues low priority items that have been waiting", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems: helpers.PointerOf[int64](5),
+					PriorityAging: &v1willow.PriorityAging{
+						Interval: helpers.PointerOf(100 * time.Millisecond),
+						MaxBoost: helpers.PointerOf[int64](20),
+					},
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		queue, err := willowClient.GetQueue(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(*queue.Spec.Properties.PriorityAging.MaxBoost).To(Equal(int64(20)))
+
+		priorityItem := func(data string, priority int64) *v1willow.Item {
+			return &v1willow.Item{
+				Spec: &v1willow.ItemSpec{
+					DBDefinition: &v1willow.ItemDBDefinition{
+						KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+					},
+					Properties: &v1willow.ItemProperties{
+						Data:            []byte(data),
+						Updateable:      helpers.PointerOf(false),
+						RetryAttempts:   helpers.PointerOf[uint64](0),
+						RetryPosition:   helpers.PointerOf("front"),
+						TimeoutDuration: helpers.PointerOf(5 * time.Second),
+						Priority:        helpers.PointerOf(priority),
+					},
+				},
+			}
+		}
+
+		_, err = willowClient.EnqueueQueueItem(context.Background(), "test queue", priorityItem("nightly", -5))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// wait for the nightly item to be boosted above the urgent item
+		time.Sleep(time.Second)
+
+		_, err = willowClient.EnqueueQueueItem(context.Background(), "test queue", priorityItem("urgent", 1))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// both the base and effective priorities are reported
+		items, err := willowClient.QueryQueueItems(context.Background(), "test queue", &v1willow.ItemQuery{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(items)).To(Equal(2))
+		for _, item := range items {
+			switch string(item.Spec.Properties.Data) {
+			case "nightly":
+				g.Expect(*item.Spec.Properties.Priority).To(Equal(int64(-5)))
+				g.Expect(*item.State.EffectivePriority).To(BeNumerically(">=", 5))
+			default:
+				g.Expect(*item.Spec.Properties.Priority).To(Equal(int64(1)))
+				g.Expect(*item.State.EffectivePriority).To(BeNumerically("<", 5))
+			}
+		}
+
+		item, err := willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(item.Data()).To(Equal([]byte(`nightly`)))
+		g.Expect(item.ACK(context.Background(), true)).ToNot(HaveOccurred())
+
+		item, err = willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(item.Data()).To(Equal([]byte(`urgent`)))
+		g.Expect(item.ACK(context.Background(), true)).ToNot(HaveOccurred())
+	})
+}
+
+func Test_Queue_ItemHeartbeats(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It heartbeats all items dequeued from the same client across queues", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+
+		var items []*willowclient.Item
+		var itemStates []*v1willow.ItemState
+		for _, queueName := range []string{"queue one", "queue two"} {
+			// setup queue
+			createQueue := &v1willow.Queue{
+				Spec: &v1willow.QueueSpec{
+					DBDefinition: &v1willow.QueueDBDefinition{
+						Name: helpers.PointerOf(queueName),
+					},
+					Properties: &v1willow.QueueProperties{
+						MaxItems: helpers.PointerOf[int64](5),
+					},
+				},
+			}
+			g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+			for i := 0; i < 2; i++ {
+				// enqueue the item
+				enqueueQueueItem := &v1willow.Item{
+					Spec: &v1willow.ItemSpec{
+						DBDefinition: &v1willow.ItemDBDefinition{
+							KeyValues: datatypes.KeyValues{
+								"one": datatypes.Int(i),
+							},
+						},
+						Properties: &v1willow.ItemProperties{
+							Data:            []byte(`data`),
+							Updateable:      helpers.PointerOf(false),
+							RetryAttempts:   helpers.PointerOf[uint64](0),
+							RetryPosition:   helpers.PointerOf("front"),
+							TimeoutDuration: helpers.PointerOf(time.Second),
+						},
+					},
+				}
+				itemState, err := willowClient.EnqueueQueueItem(context.Background(), queueName, enqueueQueueItem)
+				g.Expect(err).ToNot(HaveOccurred())
+				itemStates = append(itemStates, itemState)
+
+				// dequeue the item
+				item, err := willowClient.DequeueQueueItem(context.Background(), queueName, &queryassociatedaction.AssociatedActionQuery{})
+				g.Expect(err).ToNot(HaveOccurred())
+				items = append(items, item)
+			}
+		}
+
+		// all items keep processing past their timeout
+		for _, item := range items {
+			g.Consistently(item.Done(), 2*time.Second).ShouldNot(BeClosed())
+		}
+
+		// cancel a single item from the producer side
+		cancel := &v1willow.Cancel{
+			ItemID:    itemStates[3].ID,
+			KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+		}
+		g.Expect(willowClient.CancelQueueItem(context.Background(), "queue two", cancel)).ToNot(HaveOccurred())
+
+		// only the canceled item is informed
+		g.Eventually(items[3].Canceled(), 2*time.Second).Should(BeClosed())
+		for _, item := range items[:3] {
+			g.Expect(item.Canceled()).ToNot(BeClosed())
+		}
+
+		for _, item := range items {
+			g.Expect(item.ACK(context.Background(), true)).ToNot(HaveOccurred())
+		}
+	})
+}
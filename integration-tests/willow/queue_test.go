@@ -2,6 +2,7 @@ package willow_integration_tests
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"runtime"
@@ -14,7 +15,9 @@ import (
 
 	limiterclient "github.com/DanLavine/willow/pkg/clients/limiter_client"
 	willowclient "github.com/DanLavine/willow/pkg/clients/willow_client"
+	v1 "github.com/DanLavine/willow/pkg/models/api/common/v1"
 	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
 	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
 
 	. "github.com/DanLavine/willow/integration-tests/integrationhelpers"
@@ -309,6 +312,59 @@ func Test_Queue_Delete(t *testing.T) {
 		g.Expect(len(overrides)).To(Equal(0))
 	})
 
+	t.Run("It unblocks any clients waiting to dequeue from the queue", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+
+		// create
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems: helpers.PointerOf[int64](5),
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		// block a client waiting for an item
+		done := make(chan struct{})
+		var dequeueErr error
+		go func() {
+			defer close(done)
+			_, dequeueErr = willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		}()
+		g.Consistently(done).ShouldNot(BeClosed())
+
+		// delete
+		deleted := make(chan error, 1)
+		go func() {
+			deleted <- willowClient.DeleteQueue(context.Background(), "test queue")
+		}()
+		g.Eventually(deleted).Should(Receive(BeNil()))
+
+		g.Eventually(done).Should(BeClosed())
+		g.Expect(errors.Is(dequeueErr, willowclient.ErrDeleted)).To(BeTrue())
+		g.Expect(dequeueErr.Error()).To(ContainSubstring("Queue 'test queue' was deleted while waiting to dequeue an item"))
+
+		// the queue no longer exists for new clients
+		_, err := willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("failed to find queue 'test queue' by name"))
+	})
+
 	t.Run("It can delete a queue and all Limiter counters for enqueued items", func(t *testing.T) {
 		t.Parallel()
 
@@ -359,7 +415,7 @@ func Test_Queue_Delete(t *testing.T) {
 				},
 			},
 		}
-		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem1)).ToNot(HaveOccurred())
+		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem1)).Error().ToNot(HaveOccurred())
 
 		enqueueQueueItem2 := &v1willow.Item{ // updates the previous item
 			Spec: &v1willow.ItemSpec{
@@ -377,7 +433,7 @@ func Test_Queue_Delete(t *testing.T) {
 				},
 			},
 		}
-		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem2)).ToNot(HaveOccurred())
+		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem2)).Error().ToNot(HaveOccurred())
 
 		enqueueQueueItem3 := &v1willow.Item{
 			Spec: &v1willow.ItemSpec{
@@ -396,7 +452,7 @@ func Test_Queue_Delete(t *testing.T) {
 				},
 			},
 		}
-		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem3)).ToNot(HaveOccurred())
+		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem3)).Error().ToNot(HaveOccurred())
 
 		enqueueQueueItem4 := &v1willow.Item{
 			Spec: &v1willow.ItemSpec{
@@ -416,7 +472,7 @@ func Test_Queue_Delete(t *testing.T) {
 				},
 			},
 		}
-		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem4)).ToNot(HaveOccurred())
+		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem4)).Error().ToNot(HaveOccurred())
 
 		enqueueQueueItem5 := &v1willow.Item{
 			Spec: &v1willow.ItemSpec{
@@ -436,7 +492,7 @@ func Test_Queue_Delete(t *testing.T) {
 				},
 			},
 		}
-		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem5)).ToNot(HaveOccurred())
+		g.Expect(willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueQueueItem5)).Error().ToNot(HaveOccurred())
 
 		// delete a channel
 		err = willowClient.DeleteQueue(context.Background(), "test queue")
@@ -448,3 +504,674 @@ func Test_Queue_Delete(t *testing.T) {
 		g.Expect(len(counters)).To(Equal(0))
 	})
 }
+
+func Test_Queue_IdleTTL(t *testing.T) {
+	t.Parallel()
+
+	g := NewGomegaWithT(t)
+
+	t.Run("It deletes a queue with no items or activity for the IdleTTL", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+		limiterClient := setupLimitterClient(g, limiterTestConstruct.ServerURL)
+
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems: helpers.PointerOf[int64](5),
+					IdleTTL:  helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		queue, err := willowClient.GetQueue(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(*queue.Spec.Properties.IdleTTL).To(Equal(time.Second))
+
+		// enqueue an item so the queue is not idle
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(`hello world`),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](0),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(5 * time.Second),
+				},
+			},
+		}
+		_, err = willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueItem)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// the queue is not deleted while it has items
+		g.Consistently(func() error {
+			_, err := willowClient.GetQueue(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+			return err
+		}, 2*time.Second, 200*time.Millisecond).ShouldNot(HaveOccurred())
+
+		item, err := willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(item.ACK(context.Background(), true)).ToNot(HaveOccurred())
+
+		// once idle, the queue and the Limiter override are deleted
+		g.Eventually(func() error {
+			_, err := willowClient.GetQueue(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+			return err
+		}, 5*time.Second, 200*time.Millisecond).Should(HaveOccurred())
+
+		rules, err := limiterClient.QueryRules(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(rules)).To(Equal(1))
+
+		overrides, err := limiterClient.QueryOverrides(context.Background(), rules[0].State.ID, &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(overrides)).To(Equal(0))
+
+		g.Expect(willowTestConstruct.ServerStdout.String()).To(ContainSubstring("deleted idle queue"))
+	})
+}
+
+func Test_Queue_Mirror(t *testing.T) {
+	t.Parallel()
+
+	g := NewGomegaWithT(t)
+
+	t.Run("It streams all item changes to another Willow service", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		// each Willow service requires its own Limiter
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		mirrorLimiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer mirrorLimiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		mirrorWillowTestConstruct := StartWillow(g, mirrorLimiterTestConstruct.ServerURL)
+		defer mirrorWillowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+		mirrorWillowClient := setupWillowClient(g, mirrorWillowTestConstruct.ServerURL)
+
+		// setup the queue without a mirror
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems: helpers.PointerOf[int64](5),
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		enqueueItem := func(data string, updateable bool) string {
+			itemState, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", &v1willow.Item{
+				Spec: &v1willow.ItemSpec{
+					DBDefinition: &v1willow.ItemDBDefinition{
+						KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+					},
+					Properties: &v1willow.ItemProperties{
+						Data:            []byte(data),
+						Updateable:      helpers.PointerOf(updateable),
+						RetryAttempts:   helpers.PointerOf[uint64](0),
+						RetryPosition:   helpers.PointerOf("front"),
+						TimeoutDuration: helpers.PointerOf(5 * time.Second),
+					},
+				},
+			})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			return itemState.ID
+		}
+
+		mirroredItems := func() map[string]string {
+			items, err := mirrorWillowClient.QueryQueueItems(context.Background(), "mirrored queue", &v1willow.ItemQuery{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}})
+			if err != nil {
+				return nil
+			}
+
+			// source item ID -> data
+			mirrored := map[string]string{}
+			for _, item := range items {
+				mirrored[item.Spec.Properties.Headers[v1willow.MirrorItemIDHeader]] = string(item.Spec.Properties.Data)
+			}
+
+			return mirrored
+		}
+
+		// 1. the mirror catches up with any items enqueued before it was set
+		firstID := enqueueItem("first", false)
+
+		updateQueue := &v1willow.QueueProperties{
+			MaxItems: helpers.PointerOf[int64](5),
+			Mirror: &v1willow.QueueMirror{
+				URL:       helpers.PointerOf(mirrorWillowTestConstruct.ServerURL),
+				QueueName: helpers.PointerOf("mirrored queue"),
+			},
+		}
+		g.Expect(willowClient.UpdateQueue(context.Background(), "test queue", updateQueue)).ToNot(HaveOccurred())
+		g.Eventually(mirroredItems, 5*time.Second).Should(Equal(map[string]string{firstID: "first"}))
+
+		// 2. enqueues and updates are streamed
+		secondID := enqueueItem("second", true)
+		g.Expect(enqueueItem("second updated", true)).To(Equal(secondID))
+		g.Eventually(mirroredItems, 5*time.Second).Should(Equal(map[string]string{firstID: "first", secondID: "second updated"}))
+
+		// 3. acked items are removed
+		item, err := willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(item.ACK(context.Background(), true)).ToNot(HaveOccurred())
+		g.Eventually(mirroredItems, 5*time.Second).Should(Equal(map[string]string{secondID: "second updated"}))
+
+		// 4. dead lettered items are removed
+		item, err = willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(item.ACK(context.Background(), false)).ToNot(HaveOccurred())
+		g.Eventually(mirroredItems, 5*time.Second).Should(BeEmpty())
+
+		// 5. the lag is reported on the queue
+		g.Eventually(func() *v1willow.QueueMirrorState {
+			queue, err := willowClient.GetQueue(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(*queue.Spec.Properties.Mirror.URL).To(Equal(mirrorWillowTestConstruct.ServerURL))
+
+			return queue.State.Mirror
+		}).Should(Equal(&v1willow.QueueMirrorState{CatchingUp: false, Lag: 0}))
+	})
+}
+
+func Test_Queue_ExportImport(t *testing.T) {
+	t.Parallel()
+
+	g := NewGomegaWithT(t)
+
+	t.Run("It can recreate queues on another Willow service", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		// each Willow service requires its own Limiter
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		importLimiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer importLimiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		importWillowTestConstruct := StartWillow(g, importLimiterTestConstruct.ServerURL)
+		defer importWillowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+		importWillowClient := setupWillowClient(g, importWillowTestConstruct.ServerURL)
+		importLimiterClient := setupLimitterClient(g, importLimiterTestConstruct.ServerURL)
+
+		// setup the queue
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems: helpers.PointerOf[int64](5),
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		enqueueItem := func(data string, keyValues datatypes.KeyValues) string {
+			itemState, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", &v1willow.Item{
+				Spec: &v1willow.ItemSpec{
+					DBDefinition: &v1willow.ItemDBDefinition{
+						KeyValues: keyValues,
+					},
+					Properties: &v1willow.ItemProperties{
+						Data:            []byte(data),
+						Updateable:      helpers.PointerOf(false),
+						RetryAttempts:   helpers.PointerOf[uint64](2),
+						RetryPosition:   helpers.PointerOf("back"),
+						TimeoutDuration: helpers.PointerOf(5 * time.Second),
+					},
+				},
+			})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			return itemState.ID
+		}
+
+		firstID := enqueueItem("first", datatypes.KeyValues{"one": datatypes.Int(1)})
+		secondID := enqueueItem("second", datatypes.KeyValues{"one": datatypes.Int(1)})
+		thirdID := enqueueItem("third", datatypes.KeyValues{"two": datatypes.Int(2)})
+
+		// fail the first item so it is retried at the back of the channel
+		item, err := willowClient.DequeueQueueItem(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{
+			Selection: &queryassociatedaction.Selection{
+				KeyValues: queryassociatedaction.SelectionKeyValues{
+					"one": {Value: datatypes.Int(1), Comparison: v1.Equals, TypeRestrictions: v1.TypeRestrictions{MinDataType: datatypes.T_int, MaxDataType: datatypes.T_int}},
+				},
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(item.ACK(context.Background(), false)).ToNot(HaveOccurred())
+
+		// export
+		queueExport, err := willowClient.ExportQueues(context.Background(), &v1willow.ExportQuery{QueueName: helpers.PointerOf("test queue")})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(queueExport.Version).To(Equal(v1willow.QueueExportVersion))
+		g.Expect(len(queueExport.Queues)).To(Equal(1))
+		g.Expect(queueExport.Queues[0].Name).To(Equal("test queue"))
+		g.Expect(*queueExport.Queues[0].Properties.MaxItems).To(Equal(int64(5)))
+		g.Expect(len(queueExport.Queues[0].Channels)).To(Equal(2))
+
+		exportedItems := map[string][]*v1willow.ExportedItem{}
+		for _, exportedChannel := range queueExport.Queues[0].Channels {
+			for _, exportedItem := range exportedChannel.Items {
+				exportedItems[exportedItem.ID] = append(exportedItems[exportedItem.ID], exportedItem)
+			}
+		}
+		g.Expect(exportedItems).To(HaveLen(3))
+		g.Expect(exportedItems[firstID][0].RetryCount).To(Equal(uint64(1)))
+		g.Expect(exportedItems[secondID][0].RetryCount).To(Equal(uint64(0)))
+		g.Expect(exportedItems[thirdID][0].RetryCount).To(Equal(uint64(0)))
+
+		// import
+		g.Expect(importWillowClient.ImportQueues(context.Background(), queueExport)).ToNot(HaveOccurred())
+
+		// the queue and items are recreated in order
+		queue, err := importWillowClient.GetQueue(context.Background(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(*queue.Spec.Properties.MaxItems).To(Equal(int64(5)))
+
+		dequeueQuery := &queryassociatedaction.AssociatedActionQuery{
+			Selection: &queryassociatedaction.Selection{
+				KeyValues: queryassociatedaction.SelectionKeyValues{
+					"one": {Value: datatypes.Int(1), Comparison: v1.Equals, TypeRestrictions: v1.TypeRestrictions{MinDataType: datatypes.T_int, MaxDataType: datatypes.T_int}},
+				},
+			},
+		}
+
+		item, err = importWillowClient.DequeueQueueItem(context.Background(), "test queue", dequeueQuery)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(item.Data()).To(Equal([]byte("second")))
+		g.Expect(item.ACK(context.Background(), true)).ToNot(HaveOccurred())
+
+		item, err = importWillowClient.DequeueQueueItem(context.Background(), "test queue", dequeueQuery)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(item.Data()).To(Equal([]byte("first")))
+
+		// the Limiter override and enqueued counters are recreated
+		rules, err := importLimiterClient.QueryRules(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(rules)).To(Equal(1))
+
+		overrides, err := importLimiterClient.QueryOverrides(context.Background(), rules[0].State.ID, &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(overrides)).To(Equal(1))
+		g.Expect(*overrides[0].Spec.Properties.Limit).To(Equal(int64(5)))
+
+		counters, err := importLimiterClient.QueryCounters(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		enqueued := int64(0)
+		for _, counter := range counters {
+			if _, ok := counter.Spec.DBDefinition.KeyValues["_willow_enqueued"]; ok {
+				enqueued += *counter.Spec.Properties.Counters
+			}
+		}
+		g.Expect(enqueued).To(Equal(int64(2)))
+
+		// importing the same queue again is a conflict
+		err = importWillowClient.ImportQueues(context.Background(), queueExport)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("already exists"))
+	})
+
+	t.Run("It imports nothing when any item does not satisfy its queue's rules", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+		limiterClient := setupLimitterClient(g, limiterTestConstruct.ServerURL)
+
+		exportedItem := func(itemID string, data string) *v1willow.ExportedItem {
+			return &v1willow.ExportedItem{
+				ID: itemID,
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(data),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](0),
+					RetryPosition:   helpers.PointerOf("back"),
+					TimeoutDuration: helpers.PointerOf(5 * time.Second),
+				},
+			}
+		}
+
+		queueExport := &v1willow.QueueExport{
+			Version: v1willow.QueueExportVersion,
+			Queues: []*v1willow.ExportedQueue{
+				{
+					Name:       "valid queue",
+					Properties: &v1willow.QueueProperties{MaxItems: helpers.PointerOf[int64](5)},
+					Channels: []*v1willow.ExportedChannel{
+						{KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)}, Items: []*v1willow.ExportedItem{exportedItem("item 1", "ok")}},
+					},
+				},
+				{
+					Name:       "limited queue",
+					Properties: &v1willow.QueueProperties{MaxItems: helpers.PointerOf[int64](5), MaxPayloadSize: helpers.PointerOf[int64](4)},
+					Channels: []*v1willow.ExportedChannel{
+						{KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)}, Items: []*v1willow.ExportedItem{exportedItem("item 2", "too large")}},
+					},
+				},
+			},
+		}
+
+		err := willowClient.ImportQueues(context.Background(), queueExport)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("MaxPayloadSize"))
+
+		// the queue created before the error is removed along with its items
+		queues, err := willowClient.ListQueues(context.Background())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(queues).To(BeEmpty())
+
+		g.Eventually(func() int64 {
+			counters, err := limiterClient.QueryCounters(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			enqueued := int64(0)
+			for _, counter := range counters {
+				if _, ok := counter.Spec.DBDefinition.KeyValues["_willow_enqueued"]; ok {
+					enqueued += *counter.Spec.Properties.Counters
+				}
+			}
+
+			return enqueued
+		}).Should(Equal(int64(0)))
+
+		// the same import can be retried once it is fixed
+		queueExport.Queues[1].Channels[0].Items[0].Properties.Data = []byte("ok")
+		g.Expect(willowClient.ImportQueues(context.Background(), queueExport)).ToNot(HaveOccurred())
+
+		queues, err = willowClient.ListQueues(context.Background())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(queues).To(HaveLen(2))
+	})
+
+	t.Run("It records the IdempotencyKeys of imported items", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+
+		properties := &v1willow.ItemProperties{
+			Data:            []byte("imported"),
+			Updateable:      helpers.PointerOf(false),
+			RetryAttempts:   helpers.PointerOf[uint64](0),
+			RetryPosition:   helpers.PointerOf("back"),
+			TimeoutDuration: helpers.PointerOf(5 * time.Second),
+			IdempotencyKey:  helpers.PointerOf("build 1"),
+		}
+
+		queueExport := &v1willow.QueueExport{
+			Version: v1willow.QueueExportVersion,
+			Queues: []*v1willow.ExportedQueue{
+				{
+					Name:       "test queue",
+					Properties: &v1willow.QueueProperties{MaxItems: helpers.PointerOf[int64](5), IdempotencyWindow: helpers.PointerOf(time.Hour)},
+					Channels: []*v1willow.ExportedChannel{
+						{KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)}, Items: []*v1willow.ExportedItem{{ID: "imported id", Properties: properties}}},
+					},
+				},
+			},
+		}
+		g.Expect(willowClient.ImportQueues(context.Background(), queueExport)).ToNot(HaveOccurred())
+
+		enqueueProperties := *properties
+		enqueueProperties.Data = []byte("duplicate")
+		itemState, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)}},
+				Properties:   &enqueueProperties,
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(itemState.ID).To(Equal("imported id"))
+		g.Expect(itemState.Duplicate).To(BeTrue())
+
+		items, err := willowClient.QueryQueueItems(context.Background(), "test queue", &v1willow.ItemQuery{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(items).To(HaveLen(1))
+	})
+}
+
+func Test_Queue_ReconcileLimiter(t *testing.T) {
+	t.Parallel()
+
+	g := NewGomegaWithT(t)
+
+	t.Run("It corrects counters that drifted and removes counters for channels that no longer exist", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer willowTestConstruct.Shutdown(g)
+
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+		limiterClient := setupLimitterClient(g, limiterTestConstruct.ServerURL)
+
+		// setup the queue
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems: helpers.PointerOf[int64](5),
+				},
+			},
+		}
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+		for i := 0; i < 2; i++ {
+			_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", &v1willow.Item{
+				Spec: &v1willow.ItemSpec{
+					DBDefinition: &v1willow.ItemDBDefinition{
+						KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+					},
+					Properties: &v1willow.ItemProperties{
+						Data:            []byte(fmt.Sprintf("%d", i)),
+						Updateable:      helpers.PointerOf(false),
+						RetryAttempts:   helpers.PointerOf[uint64](0),
+						RetryPosition:   helpers.PointerOf("front"),
+						TimeoutDuration: helpers.PointerOf(5 * time.Second),
+					},
+				},
+			})
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		// nothing to correct
+		limiterReconcile, err := willowClient.ReconcileLimiter(context.Background())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(limiterReconcile.Corrections).To(BeEmpty())
+
+		// corrupt the counters on the Limiter
+		setCounters := func(keyValues datatypes.KeyValues, counters int64) {
+			g.Expect(limiterClient.SetCounters(context.Background(), &v1limiter.Counter{
+				Spec: &v1limiter.CounterSpec{
+					DBDefinition: &v1limiter.CounterDBDefinition{KeyValues: keyValues},
+					Properties:   &v1limiter.CounteProperties{Counters: helpers.PointerOf(counters)},
+				},
+			})).ToNot(HaveOccurred())
+		}
+
+		setCounters(datatypes.KeyValues{
+			"_willow_queue_name": datatypes.String("test queue"),
+			"_willow_enqueued":   datatypes.String("true"),
+			"_willow_one":        datatypes.Int(1),
+		}, 4)
+		setCounters(datatypes.KeyValues{
+			"_willow_queue_name": datatypes.String("test queue"),
+			"_willow_enqueued":   datatypes.String("true"),
+			"_willow_deleted":    datatypes.Int(1),
+		}, 3)
+		setCounters(datatypes.KeyValues{
+			"_willow_queue_name": datatypes.String("test queue"),
+			"_willow_running":    datatypes.String("true"),
+			"deleted":            datatypes.Int(1),
+		}, 1)
+
+		limiterReconcile, err = willowClient.ReconcileLimiter(context.Background())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(limiterReconcile.Corrections).To(HaveLen(3))
+
+		// only the counters for the channel's items remain
+		counters, err := limiterClient.QueryCounters(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(counters)).To(Equal(1))
+		g.Expect(counters[0].Spec.DBDefinition.KeyValues["_willow_one"]).To(Equal(datatypes.Int(1)))
+		g.Expect(*counters[0].Spec.Properties.Counters).To(Equal(int64(2)))
+	})
+}
+
+func Test_Queue_Restart(t *testing.T) {
+	t.Parallel()
+
+	g := NewGomegaWithT(t)
+
+	t.Run("It reuses the Limiter rule and resets the queue limits and counters from a previous process", func(t *testing.T) {
+		t.Parallel()
+
+		lockerTestConstruct := StartLocker(g)
+		defer lockerTestConstruct.Shutdown(g)
+
+		limiterTestConstruct := StartLimiter(g, lockerTestConstruct.ServerURL)
+		defer limiterTestConstruct.Shutdown(g)
+
+		limiterClient := setupLimitterClient(g, limiterTestConstruct.ServerURL)
+
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{
+					Name: helpers.PointerOf[string]("test queue"),
+				},
+				Properties: &v1willow.QueueProperties{
+					MaxItems: helpers.PointerOf[int64](2),
+				},
+			},
+		}
+
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte("data"),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](0),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(5 * time.Second),
+				},
+			},
+		}
+
+		// fill the queue on the first Willow process
+		willowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		willowClient := setupWillowClient(g, willowTestConstruct.ServerURL)
+
+		g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+		for i := 0; i < 2; i++ {
+			_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueItem)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		rules, err := limiterClient.QueryRules(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(rules)).To(Equal(1))
+		ruleID := rules[0].State.ID
+
+		willowTestConstruct.Shutdown(g)
+
+		// restart against the same Limiter
+		restartedWillowTestConstruct := StartWillow(g, limiterTestConstruct.ServerURL)
+		defer restartedWillowTestConstruct.Shutdown(g)
+		restartedWillowClient := setupWillowClient(g, restartedWillowTestConstruct.ServerURL)
+
+		// the rule is reused and the orphaned override and counters are removed
+		rules, err = limiterClient.QueryRules(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(rules)).To(Equal(1))
+		g.Expect(rules[0].State.ID).To(Equal(ruleID))
+
+		overrides, err := limiterClient.QueryOverrides(context.Background(), ruleID, &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(overrides).To(BeEmpty())
+
+		counters, err := limiterClient.QueryCounters(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(counters).To(BeEmpty())
+
+		// the queue can be created and filled again
+		g.Expect(restartedWillowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+		for i := 0; i < 2; i++ {
+			_, err := restartedWillowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueItem)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		_, err = restartedWillowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueItem)
+		g.Expect(err).To(HaveOccurred())
+
+		// the queue can be updated and deleted
+		g.Expect(restartedWillowClient.UpdateQueue(context.Background(), "test queue", &v1willow.QueueProperties{MaxItems: helpers.PointerOf[int64](3)})).ToNot(HaveOccurred())
+		g.Expect(restartedWillowClient.DeleteQueue(context.Background(), "test queue")).ToNot(HaveOccurred())
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 )
 
 var (
@@ -29,6 +30,15 @@ type WillowConfig struct {
 	LimiterClientKey *string
 	LimiterClientCRT *string
 
+	// certificates for clients connecting to any queue mirrors
+	MirrorClientCA  *string
+	MirrorClientKey *string
+	MirrorClientCRT *string
+
+	// how often the Limiter's counters are reconciled with the items Willow holds. When 0, counters are
+	// only reconciled through the admin api
+	ReconcileInterval *time.Duration
+
 	// global storage configurations
 	StorageConfig *StorageConfig
 }
@@ -57,16 +67,20 @@ All flags will use the env vars if they are set instead of command line paramete
 	}
 
 	willowConfig := &WillowConfig{
-		logLevel:         willowFlagSet.String("log-level", "info", "log level [debug | info]. Can be set by env var LOG_LEVEL"),
-		Port:             willowFlagSet.String("port", "8080", "default port for the Willow server to run on. Can be set by env var WILLOW_PORT"),
-		InsecureHttp:     willowFlagSet.Bool("insecure-http", false, "Can be used to run the server in an unsecure http mode. Can be set be env var WILLOW_INSECURE_HTTP"),
-		ServerCA:         willowFlagSet.String("server-ca", "", "CA file used to generate server certs iff one was used. Can be set by env var WILLOW_CA"),
-		ServerKey:        willowFlagSet.String("server-key", "", "Server private key location on disk. Can be set by env var WILLOW_SERVER_KEY"),
-		ServerCRT:        willowFlagSet.String("server-crt", "", "Server ssl certificate location on disk. Can be st by env var WILLOW_SERVER_CRT"),
-		LimiterURL:       willowFlagSet.String("limiter-url", "", "CA file used to generate server certs iff one was used. Can be set by env var WILLOW_LIMITER_URL"),
-		LimiterClientCA:  willowFlagSet.String("limiter-client-ca", "", "CA file used to generate server certs iff one was used. Can be set by env var WILLOW_LIMITER_CLIENT_CA"),
-		LimiterClientKey: willowFlagSet.String("limiter-client-key", "", "Client private key location on disk. Can be set by env var WILLOW_LIMITER_CLIENT_KEY"),
-		LimiterClientCRT: willowFlagSet.String("limiter-client-crt", "", "Client ssl certificate location on disk. Can be set by env var WILLOW_LIMITER_CLIENT_CRT"),
+		logLevel:          willowFlagSet.String("log-level", "info", "log level [debug | info]. Can be set by env var LOG_LEVEL"),
+		Port:              willowFlagSet.String("port", "8080", "default port for the Willow server to run on. Can be set by env var WILLOW_PORT"),
+		InsecureHttp:      willowFlagSet.Bool("insecure-http", false, "Can be used to run the server in an unsecure http mode. Can be set be env var WILLOW_INSECURE_HTTP"),
+		ServerCA:          willowFlagSet.String("server-ca", "", "CA file used to generate server certs iff one was used. Can be set by env var WILLOW_CA"),
+		ServerKey:         willowFlagSet.String("server-key", "", "Server private key location on disk. Can be set by env var WILLOW_SERVER_KEY"),
+		ServerCRT:         willowFlagSet.String("server-crt", "", "Server ssl certificate location on disk. Can be st by env var WILLOW_SERVER_CRT"),
+		LimiterURL:        willowFlagSet.String("limiter-url", "", "CA file used to generate server certs iff one was used. Can be set by env var WILLOW_LIMITER_URL"),
+		LimiterClientCA:   willowFlagSet.String("limiter-client-ca", "", "CA file used to generate server certs iff one was used. Can be set by env var WILLOW_LIMITER_CLIENT_CA"),
+		LimiterClientKey:  willowFlagSet.String("limiter-client-key", "", "Client private key location on disk. Can be set by env var WILLOW_LIMITER_CLIENT_KEY"),
+		LimiterClientCRT:  willowFlagSet.String("limiter-client-crt", "", "Client ssl certificate location on disk. Can be set by env var WILLOW_LIMITER_CLIENT_CRT"),
+		MirrorClientCA:    willowFlagSet.String("mirror-client-ca", "", "CA file used to generate the queue mirror server certs iff one was used. Can be set by env var WILLOW_MIRROR_CLIENT_CA"),
+		MirrorClientKey:   willowFlagSet.String("mirror-client-key", "", "Client private key location on disk for connecting to queue mirrors. Can be set by env var WILLOW_MIRROR_CLIENT_KEY"),
+		MirrorClientCRT:   willowFlagSet.String("mirror-client-crt", "", "Client ssl certificate location on disk for connecting to queue mirrors. Can be set by env var WILLOW_MIRROR_CLIENT_CRT"),
+		ReconcileInterval: willowFlagSet.Duration("reconcile-interval", 5*time.Minute, "how often to reconcile the Limiter's counters with the items Willow holds. Set to 0 to only reconcile through the admin api. Default is 5 minutes. Can be set by env var WILLOW_RECONCILE_INTERVAL"),
 		StorageConfig: &StorageConfig{
 			Type: willowFlagSet.String("storage-type", "memory", "storage type to use for persistence [memory]. Can be set by env var STORAGE_TYPE"),
 		},
@@ -144,6 +158,31 @@ func (wc *WillowConfig) parseEnv() error {
 		wc.LimiterClientCRT = &limiterCRT
 	}
 
+	// mirror client
+	//// ca key
+	if mirrorCA := os.Getenv("WILLOW_MIRROR_CLIENT_CA"); mirrorCA != "" {
+		wc.MirrorClientCA = &mirrorCA
+	}
+	//// tls key
+	if mirrorKey := os.Getenv("WILLOW_MIRROR_CLIENT_KEY"); mirrorKey != "" {
+		wc.MirrorClientKey = &mirrorKey
+	}
+	//// tls certificate
+	if mirrorCRT := os.Getenv("WILLOW_MIRROR_CLIENT_CRT"); mirrorCRT != "" {
+		wc.MirrorClientCRT = &mirrorCRT
+	}
+
+	// limiter reconcile
+	//// interval
+	if reconcileInterval := os.Getenv("WILLOW_RECONCILE_INTERVAL"); reconcileInterval != "" {
+		interval, err := time.ParseDuration(reconcileInterval)
+		if err != nil {
+			return fmt.Errorf("error parsing 'WILLOW_RECONCILE_INTERVAL': %v", err)
+		}
+
+		wc.ReconcileInterval = &interval
+	}
+
 	// storage config
 	//// storage type
 	if storageType := os.Getenv("STORAGE_TYPE"); storageType != "" {
@@ -183,6 +222,11 @@ func (wc *WillowConfig) validate() error {
 		}
 	}
 
+	// limiter reconcile
+	if *wc.ReconcileInterval < 0 {
+		return fmt.Errorf("flag 'reconcile-interval' cannot be negative")
+	}
+
 	// storage
 	switch *wc.StorageConfig.Type {
 	case MemoryStorage:
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 )
@@ -247,4 +248,98 @@ func TestWillowConfig(t *testing.T) {
 		})
 
 	})
+
+	t.Run("Describe mirror client", func(t *testing.T) {
+		t.Run("Context mirror-client-ca", func(t *testing.T) {
+			t.Run("It can be set via command line", func(t *testing.T) {
+				cfg, err := Willow(append(baseArgs, "-mirror-client-ca", caCrt.Name()))
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(*cfg.MirrorClientCA).To(Equal(caCrt.Name()))
+			})
+
+			t.Run("It can be set via env vars", func(t *testing.T) {
+				os.Setenv("WILLOW_MIRROR_CLIENT_CA", caCrt.Name())
+				defer os.Unsetenv("WILLOW_MIRROR_CLIENT_CA")
+
+				cfg, err := Willow(baseArgs)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(*cfg.MirrorClientCA).To(Equal(caCrt.Name()))
+			})
+		})
+
+		t.Run("Context mirror-client-crt", func(t *testing.T) {
+			t.Run("It can be set via command line", func(t *testing.T) {
+				cfg, err := Willow(append(baseArgs, "-mirror-client-crt", serverCRT.Name()))
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(*cfg.MirrorClientCRT).To(Equal(serverCRT.Name()))
+			})
+
+			t.Run("It can be set via env vars", func(t *testing.T) {
+				os.Setenv("WILLOW_MIRROR_CLIENT_CRT", serverCRT.Name())
+				defer os.Unsetenv("WILLOW_MIRROR_CLIENT_CRT")
+
+				cfg, err := Willow(baseArgs)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(*cfg.MirrorClientCRT).To(Equal(serverCRT.Name()))
+			})
+		})
+
+		t.Run("Context mirror-client-key", func(t *testing.T) {
+			t.Run("It can be set via command line", func(t *testing.T) {
+				cfg, err := Willow(append(baseArgs, "-mirror-client-key", serverKey.Name()))
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(*cfg.MirrorClientKey).To(Equal(serverKey.Name()))
+			})
+
+			t.Run("It can be set via env vars", func(t *testing.T) {
+				os.Setenv("WILLOW_MIRROR_CLIENT_KEY", serverKey.Name())
+				defer os.Unsetenv("WILLOW_MIRROR_CLIENT_KEY")
+
+				cfg, err := Willow(baseArgs)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(*cfg.MirrorClientKey).To(Equal(serverKey.Name()))
+			})
+		})
+	})
+	t.Run("Describe limiter reconcile", func(t *testing.T) {
+		t.Run("Context reconcile-interval", func(t *testing.T) {
+			t.Run("It defaults to 5 minutes", func(t *testing.T) {
+				cfg, err := Willow(baseArgs)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(*cfg.ReconcileInterval).To(Equal(5 * time.Minute))
+			})
+
+			t.Run("It can be set via command line", func(t *testing.T) {
+				cfg, err := Willow(append(baseArgs, "-reconcile-interval", "30s"))
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(*cfg.ReconcileInterval).To(Equal(30 * time.Second))
+			})
+
+			t.Run("It can be set via env vars", func(t *testing.T) {
+				os.Setenv("WILLOW_RECONCILE_INTERVAL", "0s")
+				defer os.Unsetenv("WILLOW_RECONCILE_INTERVAL")
+
+				cfg, err := Willow(baseArgs)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(*cfg.ReconcileInterval).To(Equal(time.Duration(0)))
+			})
+
+			t.Run("It returns an error if the value cannot be parsed via env var", func(t *testing.T) {
+				os.Setenv("WILLOW_RECONCILE_INTERVAL", "bad")
+				defer os.Unsetenv("WILLOW_RECONCILE_INTERVAL")
+
+				cfg, err := Willow(baseArgs)
+				g.Expect(cfg).To(BeNil())
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(`error parsing 'WILLOW_RECONCILE_INTERVAL'`))
+			})
+
+			t.Run("It returns an error if the value is negative", func(t *testing.T) {
+				cfg, err := Willow(append(baseArgs, "-reconcile-interval", "-1s"))
+				g.Expect(cfg).To(BeNil())
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(`flag 'reconcile-interval' cannot be negative`))
+			})
+		})
+	})
 }
@@ -0,0 +1,271 @@
+package indexeddeque
+
+// node in the doubly linked list
+type node[T any] struct {
+	id    string
+	value T
+
+	previous *node[T]
+	next     *node[T]
+}
+
+// IndexedDeque is an ordered collection of values with unique IDs. Values can be added or removed at either end and
+// any value can be found, moved or removed by its ID in O(1).
+//
+// NOTE: this is not thread safe. Callers must guard all operations
+type IndexedDeque[T any] struct {
+	front *node[T]
+	back  *node[T]
+
+	nodes map[string]*node[T]
+}
+
+//	RETURNS:
+//	- *IndexedDeque[T] - empty deque
+//
+// New creates an empty IndexedDeque
+func New[T any]() *IndexedDeque[T] {
+	return &IndexedDeque[T]{
+		nodes: map[string]*node[T]{},
+	}
+}
+
+//	RETURNS:
+//	- int - number of values in the deque
+//
+// Len reports the number of values in the deque
+func (deque *IndexedDeque[T]) Len() int {
+	return len(deque.nodes)
+}
+
+//	PARAMETERS:
+//	- id - unique ID of the value
+//	- value - value to add to the back
+//
+//	RETURNS:
+//	- bool - false if the ID is already in the deque. In this case nothing is added
+//
+// PushBack adds a value to the back of the deque
+func (deque *IndexedDeque[T]) PushBack(id string, value T) bool {
+	if _, ok := deque.nodes[id]; ok {
+		return false
+	}
+
+	newNode := &node[T]{id: id, value: value}
+	deque.nodes[id] = newNode
+	deque.linkBack(newNode)
+
+	return true
+}
+
+//	PARAMETERS:
+//	- id - unique ID of the value
+//	- value - value to add to the front
+//
+//	RETURNS:
+//	- bool - false if the ID is already in the deque. In this case nothing is added
+//
+// PushFront adds a value to the front of the deque
+func (deque *IndexedDeque[T]) PushFront(id string, value T) bool {
+	if _, ok := deque.nodes[id]; ok {
+		return false
+	}
+
+	newNode := &node[T]{id: id, value: value}
+	deque.nodes[id] = newNode
+
+	if deque.front == nil {
+		deque.front = newNode
+		deque.back = newNode
+	} else {
+		newNode.next = deque.front
+		deque.front.previous = newNode
+		deque.front = newNode
+	}
+
+	return true
+}
+
+//	RETURNS:
+//	- string - ID of the value that was removed
+//	- T - value that was removed
+//	- bool - false if the deque is empty
+//
+// PopFront removes the value at the front of the deque
+func (deque *IndexedDeque[T]) PopFront() (string, T, bool) {
+	if deque.front == nil {
+		var empty T
+		return "", empty, false
+	}
+
+	frontNode := deque.front
+	deque.unlink(frontNode)
+	delete(deque.nodes, frontNode.id)
+
+	return frontNode.id, frontNode.value, true
+}
+
+//	RETURNS:
+//	- string - ID of the value at the front
+//	- T - value at the front
+//	- bool - false if the deque is empty
+//
+// Front returns the value at the front of the deque without removing it
+func (deque *IndexedDeque[T]) Front() (string, T, bool) {
+	if deque.front == nil {
+		var empty T
+		return "", empty, false
+	}
+
+	return deque.front.id, deque.front.value, true
+}
+
+//	RETURNS:
+//	- string - ID of the value at the back
+//	- T - value at the back
+//	- bool - false if the deque is empty
+//
+// Back returns the value at the back of the deque without removing it
+func (deque *IndexedDeque[T]) Back() (string, T, bool) {
+	if deque.back == nil {
+		var empty T
+		return "", empty, false
+	}
+
+	return deque.back.id, deque.back.value, true
+}
+
+//	PARAMETERS:
+//	- beforeID - ID of the value to insert in front of
+//	- id - unique ID of the value
+//	- value - value to add
+//
+//	RETURNS:
+//	- bool - false if the beforeID is not in the deque or the ID is already in the deque. In this case nothing is added
+//
+// InsertBefore adds a value directly in front of another value in the deque
+func (deque *IndexedDeque[T]) InsertBefore(beforeID, id string, value T) bool {
+	beforeNode, ok := deque.nodes[beforeID]
+	if !ok {
+		return false
+	}
+
+	if _, ok := deque.nodes[id]; ok {
+		return false
+	}
+
+	newNode := &node[T]{id: id, value: value, previous: beforeNode.previous, next: beforeNode}
+	deque.nodes[id] = newNode
+
+	if beforeNode.previous == nil {
+		deque.front = newNode
+	} else {
+		beforeNode.previous.next = newNode
+	}
+	beforeNode.previous = newNode
+
+	return true
+}
+
+//	PARAMETERS:
+//	- id - ID of the value to find
+//
+//	RETURNS:
+//	- T - value for the ID
+//	- bool - false if the ID is not in the deque
+//
+// Get returns the value for an ID without removing it
+func (deque *IndexedDeque[T]) Get(id string) (T, bool) {
+	foundNode, ok := deque.nodes[id]
+	if !ok {
+		var empty T
+		return empty, false
+	}
+
+	return foundNode.value, true
+}
+
+//	PARAMETERS:
+//	- id - ID of the value to remove
+//
+//	RETURNS:
+//	- T - value that was removed
+//	- bool - false if the ID is not in the deque
+//
+// Remove takes the value for an ID out of the deque, wherever it is
+func (deque *IndexedDeque[T]) Remove(id string) (T, bool) {
+	foundNode, ok := deque.nodes[id]
+	if !ok {
+		var empty T
+		return empty, false
+	}
+
+	deque.unlink(foundNode)
+	delete(deque.nodes, id)
+
+	return foundNode.value, true
+}
+
+//	PARAMETERS:
+//	- id - ID of the value to move
+//
+//	RETURNS:
+//	- bool - false if the ID is not in the deque
+//
+// MoveToBack moves the value for an ID to the back of the deque
+func (deque *IndexedDeque[T]) MoveToBack(id string) bool {
+	foundNode, ok := deque.nodes[id]
+	if !ok {
+		return false
+	}
+
+	if foundNode != deque.back {
+		deque.unlink(foundNode)
+		deque.linkBack(foundNode)
+	}
+
+	return true
+}
+
+//	PARAMETERS:
+//	- onIterate - called for each value from front to back. Return false to stop iterating
+//
+// Iterate over all values in order. The deque must not be modified while iterating
+func (deque *IndexedDeque[T]) Iterate(onIterate func(id string, value T) bool) {
+	for current := deque.front; current != nil; current = current.next {
+		if !onIterate(current.id, current.value) {
+			return
+		}
+	}
+}
+
+// linkBack adds a node that is not in the list to the back
+func (deque *IndexedDeque[T]) linkBack(newNode *node[T]) {
+	if deque.back == nil {
+		deque.front = newNode
+		deque.back = newNode
+		return
+	}
+
+	newNode.previous = deque.back
+	deque.back.next = newNode
+	deque.back = newNode
+}
+
+// unlink removes a node from the list, but not the index
+func (deque *IndexedDeque[T]) unlink(oldNode *node[T]) {
+	if oldNode.previous == nil {
+		deque.front = oldNode.next
+	} else {
+		oldNode.previous.next = oldNode.next
+	}
+
+	if oldNode.next == nil {
+		deque.back = oldNode.previous
+	} else {
+		oldNode.next.previous = oldNode.previous
+	}
+
+	oldNode.previous = nil
+	oldNode.next = nil
+}
@@ -0,0 +1,277 @@
+package indexeddeque
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// ids returns all IDs from front to back
+func ids[T any](deque *IndexedDeque[T]) []string {
+	found := []string{}
+	deque.Iterate(func(id string, _ T) bool {
+		found = append(found, id)
+		return true
+	})
+
+	return found
+}
+
+// idsReversed returns all IDs from back to front, to ensure the previous links are correct
+func idsReversed[T any](deque *IndexedDeque[T]) []string {
+	found := []string{}
+	for current := deque.back; current != nil; current = current.previous {
+		found = append(found, current.id)
+	}
+
+	return found
+}
+
+func TestIndexedDeque_Push(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It adds values to either end in order", func(t *testing.T) {
+		deque := New[int]()
+
+		g.Expect(deque.PushBack("2", 2)).To(BeTrue())
+		g.Expect(deque.PushBack("3", 3)).To(BeTrue())
+		g.Expect(deque.PushFront("1", 1)).To(BeTrue())
+
+		g.Expect(deque.Len()).To(Equal(3))
+		g.Expect(ids(deque)).To(Equal([]string{"1", "2", "3"}))
+		g.Expect(idsReversed(deque)).To(Equal([]string{"3", "2", "1"}))
+	})
+
+	t.Run("It does not add an ID that already exists", func(t *testing.T) {
+		deque := New[int]()
+
+		g.Expect(deque.PushBack("1", 1)).To(BeTrue())
+		g.Expect(deque.PushBack("1", 2)).To(BeFalse())
+		g.Expect(deque.PushFront("1", 3)).To(BeFalse())
+
+		value, ok := deque.Get("1")
+		g.Expect(ok).To(BeTrue())
+		g.Expect(value).To(Equal(1))
+		g.Expect(deque.Len()).To(Equal(1))
+	})
+}
+
+func TestIndexedDeque_InsertBefore(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It adds values in front of another value", func(t *testing.T) {
+		deque := New[int]()
+		deque.PushBack("2", 2)
+		deque.PushBack("4", 4)
+
+		g.Expect(deque.InsertBefore("4", "3", 3)).To(BeTrue())
+		g.Expect(deque.InsertBefore("2", "1", 1)).To(BeTrue())
+
+		g.Expect(deque.Len()).To(Equal(4))
+		g.Expect(ids(deque)).To(Equal([]string{"1", "2", "3", "4"}))
+		g.Expect(idsReversed(deque)).To(Equal([]string{"4", "3", "2", "1"}))
+	})
+
+	t.Run("It does not add a value when the before ID is missing or the ID already exists", func(t *testing.T) {
+		deque := New[int]()
+		deque.PushBack("1", 1)
+
+		g.Expect(deque.InsertBefore("missing", "2", 2)).To(BeFalse())
+		g.Expect(deque.InsertBefore("1", "1", 1)).To(BeFalse())
+		g.Expect(ids(deque)).To(Equal([]string{"1"}))
+	})
+}
+
+func TestIndexedDeque_PopFront(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It returns false when the deque is empty", func(t *testing.T) {
+		deque := New[int]()
+
+		_, _, ok := deque.PopFront()
+		g.Expect(ok).To(BeFalse())
+
+		_, _, ok = deque.Front()
+		g.Expect(ok).To(BeFalse())
+
+		_, _, ok = deque.Back()
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("It removes values from the front in order", func(t *testing.T) {
+		deque := New[int]()
+		deque.PushBack("1", 1)
+		deque.PushBack("2", 2)
+
+		id, value, ok := deque.PopFront()
+		g.Expect(ok).To(BeTrue())
+		g.Expect(id).To(Equal("1"))
+		g.Expect(value).To(Equal(1))
+
+		id, _, _ = deque.Front()
+		g.Expect(id).To(Equal("2"))
+		id, _, _ = deque.Back()
+		g.Expect(id).To(Equal("2"))
+
+		_, _, _ = deque.PopFront()
+		g.Expect(deque.Len()).To(Equal(0))
+		g.Expect(deque.front).To(BeNil())
+		g.Expect(deque.back).To(BeNil())
+
+		// the ID can be used again
+		g.Expect(deque.PushBack("1", 1)).To(BeTrue())
+	})
+}
+
+func TestIndexedDeque_Remove(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	setup := func() *IndexedDeque[int] {
+		deque := New[int]()
+		for i := 1; i <= 3; i++ {
+			deque.PushBack(fmt.Sprintf("%d", i), i)
+		}
+
+		return deque
+	}
+
+	t.Run("It returns false when the ID does not exist", func(t *testing.T) {
+		_, ok := setup().Remove("4")
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("It can remove a value from anywhere in the deque", func(t *testing.T) {
+		for _, testCase := range []struct {
+			id       string
+			expected []string
+		}{
+			{id: "1", expected: []string{"2", "3"}},
+			{id: "2", expected: []string{"1", "3"}},
+			{id: "3", expected: []string{"1", "2"}},
+		} {
+			deque := setup()
+
+			value, ok := deque.Remove(testCase.id)
+			g.Expect(ok).To(BeTrue())
+			g.Expect(fmt.Sprintf("%d", value)).To(Equal(testCase.id))
+
+			g.Expect(ids(deque)).To(Equal(testCase.expected))
+			g.Expect(idsReversed(deque)).To(ConsistOf(testCase.expected))
+
+			_, ok = deque.Get(testCase.id)
+			g.Expect(ok).To(BeFalse())
+		}
+	})
+}
+
+func TestIndexedDeque_MoveToBack(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It returns false when the ID does not exist", func(t *testing.T) {
+		g.Expect(New[int]().MoveToBack("1")).To(BeFalse())
+	})
+
+	t.Run("It moves a value to the back", func(t *testing.T) {
+		deque := New[int]()
+		for i := 1; i <= 3; i++ {
+			deque.PushBack(fmt.Sprintf("%d", i), i)
+		}
+
+		g.Expect(deque.MoveToBack("1")).To(BeTrue())
+		g.Expect(ids(deque)).To(Equal([]string{"2", "3", "1"}))
+		g.Expect(idsReversed(deque)).To(Equal([]string{"1", "3", "2"}))
+
+		g.Expect(deque.MoveToBack("1")).To(BeTrue())
+		g.Expect(ids(deque)).To(Equal([]string{"2", "3", "1"}))
+	})
+}
+
+func TestIndexedDeque_Iterate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It stops iterating when the callback returns false", func(t *testing.T) {
+		deque := New[int]()
+		for i := 1; i <= 3; i++ {
+			deque.PushBack(fmt.Sprintf("%d", i), i)
+		}
+
+		found := []int{}
+		deque.Iterate(func(_ string, value int) bool {
+			found = append(found, value)
+			return value < 2
+		})
+		g.Expect(found).To(Equal([]int{1, 2}))
+	})
+}
+
+// setupBenchmark creates a deque with a million values
+func setupBenchmark(b *testing.B) (*IndexedDeque[int], []string) {
+	const items = 1_000_000
+
+	deque := New[int]()
+	itemIDs := make([]string, items)
+	for i := 0; i < items; i++ {
+		itemIDs[i] = fmt.Sprintf("%d", i)
+		deque.PushBack(itemIDs[i], i)
+	}
+
+	b.ResetTimer()
+	return deque, itemIDs
+}
+
+func BenchmarkIndexedDeque_EnqueueDequeue(b *testing.B) {
+	deque, _ := setupBenchmark(b)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		id, value, _ := deque.PopFront()
+		deque.PushBack(id, value)
+	}
+}
+
+func BenchmarkIndexedDeque_RequeueFront(b *testing.B) {
+	deque, _ := setupBenchmark(b)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		id, value, _ := deque.PopFront()
+		deque.PushFront(id, value)
+	}
+}
+
+func BenchmarkIndexedDeque_RequeueBack(b *testing.B) {
+	deque, itemIDs := setupBenchmark(b)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		deque.MoveToBack(itemIDs[i%len(itemIDs)])
+	}
+}
+
+func BenchmarkIndexedDeque_RemoveByID(b *testing.B) {
+	deque, itemIDs := setupBenchmark(b)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		// remove from the middle of the deque and add it back so the size stays the same
+		id := itemIDs[(len(itemIDs)/2+i)%len(itemIDs)]
+		value, _ := deque.Remove(id)
+		deque.PushBack(id, value)
+	}
+}
+
+// baseline of the previous slice of IDs, to compare against the deque
+func BenchmarkSlice_RequeueFront(b *testing.B) {
+	itemIDs := make([]string, 1_000_000)
+	for i := range itemIDs {
+		itemIDs[i] = fmt.Sprintf("%d", i)
+	}
+	b.ResetTimer()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		id := itemIDs[0]
+		itemIDs = append([]string{id}, itemIDs[1:]...)
+	}
+}
@@ -0,0 +1,166 @@
+package heartbeater
+
+import (
+	"sync"
+	"time"
+)
+
+type leaseState int
+
+const (
+	leasePending leaseState = iota
+	leaseRunning
+	leaseCanceled
+	leaseTimedOut
+)
+
+// Lease is a single resource that must be heartbeated before its timeout or the onTimeout callback is called
+type Lease struct {
+	manager *Manager
+
+	// lock guards the lease's state and timing
+	lock  *sync.Mutex
+	state leaseState
+
+	// how long until the lease times out if no heartbeats are received
+	timeout time.Duration
+	// how long the lease can run in total before timing out, even when heartbeats are received. 0 means no limit
+	maxRunDuration time.Duration
+
+	// deadlines are the time since the manager started
+	heartbeatDeadline time.Duration
+	maxRunDeadline    time.Duration
+	lastHeartbeat     time.Time
+
+	// callback to run when the lease times out
+	onTimeout func()
+
+	// position in the manager's wheel. Guarded by the manager's lock
+	expireTick     uint64
+	slot           **Lease
+	previous, next *Lease
+}
+
+//	RETURNS:
+//	- bool - TRUE iff the lease was started through this call
+//
+// Start tracking the lease's timeout. Returns false if the lease was canceled or the manager has stopped
+func (lease *Lease) Start() bool {
+	lease.manager.lock.Lock()
+	defer lease.manager.lock.Unlock()
+	lease.lock.Lock()
+	defer lease.lock.Unlock()
+
+	if lease.state != leasePending || lease.manager.stopped {
+		return false
+	}
+
+	now := lease.manager.now()
+	lease.state = leaseRunning
+	lease.heartbeatDeadline = now + lease.timeout
+	if lease.maxRunDuration > 0 {
+		lease.maxRunDeadline = now + lease.maxRunDuration
+	}
+	lease.lastHeartbeat = time.Now()
+
+	lease.manager.add(lease)
+
+	return true
+}
+
+//	RETURNS:
+//	- bool - TRUE iff the heartbeat was processed
+//
+// Heartbeat resets the lease's timeout. Returns false if the lease already timed out, was canceled or ran
+// for its max run duration, even when the manager has not processed the timeout yet
+func (lease *Lease) Heartbeat() bool {
+	lease.lock.Lock()
+	defer lease.lock.Unlock()
+
+	switch lease.state {
+	case leasePending:
+		lease.lastHeartbeat = time.Now()
+		return true
+	case leaseRunning:
+		now := lease.manager.now()
+		if lease.maxRunDeadline != 0 && now >= lease.maxRunDeadline {
+			return false
+		}
+
+		lease.heartbeatDeadline = now + lease.timeout
+		lease.lastHeartbeat = time.Now()
+		return true
+	default:
+		return false
+	}
+}
+
+//	RETURNS:
+//	- bool - TRUE iff the lease was canceled through this call. When false, the lease either timed out or was
+//	         already canceled
+//
+// Cancel stops tracking the lease. The onTimeout callback will never be called after Cancel returns true
+func (lease *Lease) Cancel() bool {
+	lease.lock.Lock()
+	if lease.state != leasePending && lease.state != leaseRunning {
+		lease.lock.Unlock()
+		return false
+	}
+	lease.state = leaseCanceled
+	lease.lock.Unlock()
+
+	lease.manager.lock.Lock()
+	defer lease.manager.lock.Unlock()
+
+	lease.manager.remove(lease)
+
+	return true
+}
+
+// LastHeartbeat is a thread safe way to get the time for when the last heartbeat occurred
+func (lease *Lease) LastHeartbeat() time.Time {
+	lease.lock.Lock()
+	defer lease.lock.Unlock()
+
+	if (lease.lastHeartbeat == time.Time{}) {
+		return time.Now()
+	}
+
+	return lease.lastHeartbeat
+}
+
+// deadline is when the lease times out, if there are no more heartbeats
+//
+// NOTE: must hold the lock
+func (lease *Lease) deadline() time.Duration {
+	if lease.maxRunDeadline != 0 && lease.maxRunDeadline < lease.heartbeatDeadline {
+		return lease.maxRunDeadline
+	}
+
+	return lease.heartbeatDeadline
+}
+
+// expired is called by the manager when the lease's slot is reached. If the lease received heartbeats since it was
+// placed in the slot, the expire tick is updated for the new deadline
+//
+// RETURNS:
+// - bool - true iff the lease timed out and the onTimeout callback needs to be called
+//
+// NOTE: must hold the manager's lock
+func (lease *Lease) expired(now time.Duration) bool {
+	lease.lock.Lock()
+	defer lease.lock.Unlock()
+
+	if lease.state != leaseRunning {
+		// canceled leases are removed from the wheel, but this is safe if there is ever a race
+		return false
+	}
+
+	if deadline := lease.deadline(); deadline > now {
+		lease.expireTick = lease.manager.deadlineTick(deadline)
+		return false
+	}
+
+	lease.state = leaseTimedOut
+	return true
+}
@@ -0,0 +1,139 @@
+package heartbeater
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Lease_Start(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It does not track the timeout until started", func(t *testing.T) {
+		manager := NewManager(time.Millisecond)
+
+		lease, err := manager.NewLease(time.Millisecond, 0, func() { panic("should not time out") })
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(manager.Len()).To(Equal(0))
+
+		g.Expect(elapse(manager, time.Second)).To(BeFalse())
+
+		g.Expect(lease.Start()).To(BeTrue())
+		g.Expect(manager.Len()).To(Equal(1))
+	})
+
+	t.Run("It returns false on multiple calls to Start", func(t *testing.T) {
+		lease, err := NewManager(time.Millisecond).NewLease(time.Second, 0, func() {})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(lease.Start()).To(BeTrue())
+		g.Expect(lease.Start()).To(BeFalse())
+	})
+
+	t.Run("It returns false if the lease was canceled before starting", func(t *testing.T) {
+		lease, err := NewManager(time.Millisecond).NewLease(time.Second, 0, func() {})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(lease.Cancel()).To(BeTrue())
+		g.Expect(lease.Start()).To(BeFalse())
+	})
+
+	t.Run("It returns false if the manager stopped", func(t *testing.T) {
+		manager := NewManager(time.Millisecond)
+		lease, err := manager.NewLease(time.Second, 0, func() {})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		g.Expect(manager.Execute(ctx)).ToNot(HaveOccurred())
+
+		g.Expect(lease.Start()).To(BeFalse())
+	})
+}
+
+func Test_Lease_Heartbeat(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It accepts heartbeats before the lease is started", func(t *testing.T) {
+		lease, err := NewManager(time.Millisecond).NewLease(time.Second, 0, func() {})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(lease.Heartbeat()).To(BeTrue())
+	})
+
+	t.Run("It records the last heartbeat", func(t *testing.T) {
+		lease, err := NewManager(time.Millisecond).Register(time.Second, 0, func() {})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		startTime := lease.LastHeartbeat()
+		time.Sleep(time.Millisecond)
+
+		g.Expect(lease.Heartbeat()).To(BeTrue())
+		g.Expect(lease.LastHeartbeat()).To(BeTemporally(">", startTime))
+	})
+
+	t.Run("It returns false once the lease timed out", func(t *testing.T) {
+		manager := NewManager(time.Millisecond)
+
+		timedOut := new(atomic.Bool)
+		lease, err := manager.Register(10*time.Millisecond, 0, func() { timedOut.Store(true) })
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(elapse(manager, 20*time.Millisecond)).To(BeFalse())
+		g.Eventually(timedOut.Load).Should(BeTrue())
+
+		g.Expect(lease.Heartbeat()).To(BeFalse())
+		g.Expect(lease.Cancel()).To(BeFalse())
+	})
+
+	t.Run("It times out after the max run duration, even when heartbeating", func(t *testing.T) {
+		manager := NewManager(time.Millisecond)
+
+		timedOut := new(atomic.Bool)
+		lease, err := manager.Register(100*time.Millisecond, 250*time.Millisecond, func() { timedOut.Store(true) })
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for i := 0; i < 2; i++ {
+			g.Expect(elapse(manager, 90*time.Millisecond)).To(BeTrue())
+			g.Expect(lease.Heartbeat()).To(BeTrue())
+		}
+		g.Expect(timedOut.Load()).To(BeFalse())
+
+		g.Expect(elapse(manager, 90*time.Millisecond)).To(BeFalse())
+		g.Eventually(timedOut.Load).Should(BeTrue())
+	})
+
+	t.Run("It rejects heartbeats after the max run duration, before the timeout is processed", func(t *testing.T) {
+		manager := NewManager(time.Millisecond)
+
+		lease, err := manager.Register(time.Second, 100*time.Millisecond, func() {})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// move the manager's clock without processing the wheel
+		manager.start = manager.start.Add(-100 * time.Millisecond)
+
+		g.Expect(lease.Heartbeat()).To(BeFalse())
+		g.Expect(manager.Len()).To(Equal(1))
+	})
+}
+
+func Test_Lease_Cancel(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It never times out a canceled lease", func(t *testing.T) {
+		manager := NewManager(time.Millisecond)
+
+		lease, err := manager.Register(10*time.Millisecond, 0, func() { panic("should not time out") })
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(lease.Cancel()).To(BeTrue())
+		g.Expect(lease.Cancel()).To(BeFalse())
+		g.Expect(lease.Heartbeat()).To(BeFalse())
+
+		g.Expect(elapse(manager, time.Second)).To(BeFalse())
+		g.Expect(manager.Len()).To(Equal(0))
+	})
+}
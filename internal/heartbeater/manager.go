@@ -0,0 +1,336 @@
+package heartbeater
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTick is how often the Manager checks for leases that timed out. A timeout fires at most 1 tick late
+	DefaultTick = 10 * time.Millisecond
+
+	// each level of the wheel has 64 slots. Every level covers 64 times as much time as the level below it
+	slotBits      = 6
+	slotsPerLevel = 1 << slotBits
+	slotMask      = slotsPerLevel - 1
+	levels        = 4
+)
+
+// Manager is a hierarchical timer wheel that tracks the timeouts for any number of leases on a single goroutine.
+//
+// Each lease is placed in the slot for the tick it expires on. The lowest level has a slot for each of the next 64
+// ticks, and each level above has slots that cover 64 slots of the level below. When the ticks reach a higher level
+// slot, the leases in it are moved down to the lower levels. Leases that expire past the highest level wait in an
+// overflow list until the highest level wraps around.
+//
+// Heartbeats never move a lease in the wheel. They only update the lease's deadline. When the lease's slot is reached,
+// the deadline is checked and the lease is either placed back in the wheel or times out. So the cost of a heartbeat
+// is independent of the number of leases.
+type Manager struct {
+	tick  time.Duration
+	start time.Time
+
+	// wake the Execute loop when the first lease is added and the wheel is idle
+	wake chan struct{}
+
+	// lock guards all the wheel operations and the lease's position in the wheel
+	lock *sync.Mutex
+	// set once the Execute loop stops. No new leases can be started
+	stopped bool
+
+	// number of ticks since start that have been processed
+	currentTick uint64
+	// the head of each slot's list of leases
+	wheel    [levels][slotsPerLevel]*Lease
+	overflow *Lease
+	// number of leases in the wheel
+	leases int
+}
+
+//	PARAMETERS:
+//	- tick - how often to check for leases that timed out
+//
+//	RETURNS:
+//	- *Manager - manager that can be added to a goasync task manager
+//
+// NewManager creates a heartbeat manager. Leases only time out while Execute is running
+func NewManager(tick time.Duration) *Manager {
+	if tick <= 0 {
+		panic("tick must be greater than 0")
+	}
+
+	return &Manager{
+		tick:  tick,
+		start: time.Now(),
+		wake:  make(chan struct{}, 1),
+		lock:  new(sync.Mutex),
+	}
+}
+
+//	PARAMETERS:
+//	- timeout - how long until timeout occurs since the last heartbeat
+//	- maxRunDuration - how long until a timeout occurs since Start, regardless of any heartbeats. 0 means no limit
+//	- onTimeout - callback function to run when a timeout occurs
+//
+//	RETURNS:
+//	- *Lease - lease that can be started. The timeout is not tracked until Start is called
+//	- error - error with any of the parameters or if the manager has stopped
+//
+// NewLease creates a lease managed by the heartbeat manager
+func (manager *Manager) NewLease(timeout, maxRunDuration time.Duration, onTimeout func()) (*Lease, error) {
+	if onTimeout == nil {
+		return nil, fmt.Errorf("onTimeout cannot be nil")
+	}
+
+	if timeout <= 0 {
+		return nil, fmt.Errorf("timeout must be greater than 0")
+	}
+
+	if maxRunDuration < 0 {
+		return nil, fmt.Errorf("maxRunDuration cannot be negative")
+	}
+
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	if manager.stopped {
+		return nil, fmt.Errorf("heartbeat manager has stopped")
+	}
+
+	return &Lease{
+		manager:        manager,
+		lock:           new(sync.Mutex),
+		timeout:        timeout,
+		maxRunDuration: maxRunDuration,
+		onTimeout:      onTimeout,
+	}, nil
+}
+
+//	PARAMETERS:
+//	- timeout - how long until timeout occurs since the last heartbeat
+//	- maxRunDuration - how long until a timeout occurs, regardless of any heartbeats. 0 means no limit
+//	- onTimeout - callback function to run when a timeout occurs
+//
+//	RETURNS:
+//	- *Lease - lease that is already started
+//	- error - error with any of the parameters or if the manager has stopped
+//
+// Register creates a lease and starts tracking its timeout
+func (manager *Manager) Register(timeout, maxRunDuration time.Duration, onTimeout func()) (*Lease, error) {
+	lease, err := manager.NewLease(timeout, maxRunDuration, onTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if !lease.Start() {
+		return nil, fmt.Errorf("heartbeat manager has stopped")
+	}
+
+	return lease, nil
+}
+
+func (manager *Manager) Initialize() error { return nil }
+func (manager *Manager) Cleanup() error    { return nil }
+
+// Execute processes the timeouts for all leases until the context is canceled. Once stopped, no leases will time out
+func (manager *Manager) Execute(ctx context.Context) error {
+	// only tick while there are leases to check
+	ticker := time.NewTicker(manager.tick)
+	ticker.Stop()
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			manager.lock.Lock()
+			manager.stopped = true
+			manager.lock.Unlock()
+
+			return nil
+		case <-manager.wake:
+			ticker.Reset(manager.tick)
+		case <-ticker.C:
+			if !manager.advance() {
+				ticker.Stop()
+			}
+		}
+	}
+}
+
+// Len reports the number of leases that are started and have not yet timed out or been canceled
+func (manager *Manager) Len() int {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	return manager.leases
+}
+
+// now is the time since the manager was created, which is used for all lease deadlines
+func (manager *Manager) now() time.Duration {
+	return time.Since(manager.start)
+}
+
+// advance processes every tick up to now and runs the callbacks for all leases that timed out
+//
+// RETURNS:
+// - bool - false if there are no more leases in the wheel
+func (manager *Manager) advance() bool {
+	now := manager.now()
+	targetTick := uint64(now / manager.tick)
+	timedOut := []*Lease{}
+
+	manager.lock.Lock()
+	for manager.currentTick < targetTick && manager.leases > 0 {
+		manager.currentTick++
+		manager.cascade()
+
+		// detach the slot before processing, since leases can be placed back into the wheel
+		expired := manager.wheel[0][manager.currentTick&slotMask]
+		manager.wheel[0][manager.currentTick&slotMask] = nil
+
+		for expired != nil {
+			lease := expired
+			expired = lease.next
+			lease.previous, lease.next, lease.slot = nil, nil, nil
+
+			if lease.expired(now) {
+				manager.leases--
+				timedOut = append(timedOut, lease)
+			} else {
+				manager.schedule(lease)
+			}
+		}
+	}
+
+	// nothing to check, so jump straight to the current tick
+	if manager.leases == 0 && manager.currentTick < targetTick {
+		manager.currentTick = targetTick
+	}
+	hasLeases := manager.leases > 0
+	manager.lock.Unlock()
+
+	// the callbacks can take any locks the callers need, so never run them while holding the manager's lock
+	for _, lease := range timedOut {
+		go lease.onTimeout()
+	}
+
+	return hasLeases
+}
+
+// cascade moves the leases down from all higher level slots that are reached at the current tick. The highest levels
+// are processed first so leases can fall through multiple levels on the same tick
+//
+// NOTE: must hold the lock
+func (manager *Manager) cascade() {
+	if manager.currentTick&(1<<(slotBits*levels)-1) == 0 {
+		manager.rescheduleAll(&manager.overflow)
+	}
+
+	for level := levels - 1; level >= 1; level-- {
+		if manager.currentTick&(1<<(slotBits*level)-1) == 0 {
+			manager.rescheduleAll(&manager.wheel[level][(manager.currentTick>>(slotBits*level))&slotMask])
+		}
+	}
+}
+
+// rescheduleAll places every lease in the slot back into the wheel
+//
+// NOTE: must hold the lock
+func (manager *Manager) rescheduleAll(slot **Lease) {
+	leases := *slot
+	*slot = nil
+
+	for leases != nil {
+		lease := leases
+		leases = lease.next
+		lease.previous, lease.next, lease.slot = nil, nil, nil
+
+		manager.schedule(lease)
+	}
+}
+
+// add a lease that is not in the wheel, expiring at its deadline
+//
+// NOTE: must hold the lock
+func (manager *Manager) add(lease *Lease) {
+	// when idle, the current tick is not kept up to date
+	if manager.leases == 0 {
+		manager.currentTick = uint64(manager.now() / manager.tick)
+
+		select {
+		case manager.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	manager.leases++
+
+	// the current tick has already been processed
+	lease.expireTick = manager.deadlineTick(lease.deadline())
+	if lease.expireTick <= manager.currentTick {
+		lease.expireTick = manager.currentTick + 1
+	}
+
+	manager.schedule(lease)
+}
+
+// schedule places a lease in the slot for its expire tick. The lease's slot shares all the higher level bits with the
+// current tick, so it is reached before the current tick moves past it
+//
+// NOTE: must hold the lock
+func (manager *Manager) schedule(lease *Lease) {
+	if lease.expireTick < manager.currentTick {
+		lease.expireTick = manager.currentTick
+	}
+
+	for level := 0; level < levels; level++ {
+		shift := slotBits * (level + 1)
+		if lease.expireTick>>shift == manager.currentTick>>shift {
+			manager.push(&manager.wheel[level][(lease.expireTick>>(slotBits*level))&slotMask], lease)
+			return
+		}
+	}
+
+	manager.push(&manager.overflow, lease)
+}
+
+// deadlineTick is the first tick that is processed on or after the deadline
+func (manager *Manager) deadlineTick(deadline time.Duration) uint64 {
+	return uint64((deadline + manager.tick - 1) / manager.tick)
+}
+
+// push a lease to the front of a slot's list
+//
+// NOTE: must hold the lock
+func (manager *Manager) push(slot **Lease, lease *Lease) {
+	lease.slot = slot
+	lease.next = *slot
+	if *slot != nil {
+		(*slot).previous = lease
+	}
+	*slot = lease
+}
+
+// remove a lease from the wheel if it is still in a slot
+//
+// NOTE: must hold the lock
+func (manager *Manager) remove(lease *Lease) {
+	if lease.slot == nil {
+		return
+	}
+
+	if lease.previous == nil {
+		*lease.slot = lease.next
+	} else {
+		lease.previous.next = lease.next
+	}
+
+	if lease.next != nil {
+		lease.next.previous = lease.previous
+	}
+
+	lease.previous, lease.next, lease.slot = nil, nil, nil
+	manager.leases--
+}
@@ -0,0 +1,350 @@
+package heartbeater
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// elapse moves the manager's clock forward and processes all the ticks, without running Execute
+func elapse(manager *Manager, duration time.Duration) bool {
+	manager.start = manager.start.Add(-duration)
+	return manager.advance()
+}
+
+func Test_NewManager(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It panics if the tick is not positive", func(t *testing.T) {
+		g.Expect(func() { NewManager(0) }).To(Panic())
+	})
+}
+
+func Test_Manager_NewLease(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It returns an error if onTimeout is nil", func(t *testing.T) {
+		lease, err := NewManager(time.Millisecond).NewLease(time.Second, 0, nil)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(Equal("onTimeout cannot be nil"))
+		g.Expect(lease).To(BeNil())
+	})
+
+	t.Run("It returns an error if the timeout is not positive", func(t *testing.T) {
+		lease, err := NewManager(time.Millisecond).NewLease(0, 0, func() {})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(Equal("timeout must be greater than 0"))
+		g.Expect(lease).To(BeNil())
+	})
+
+	t.Run("It returns an error if maxRunDuration is negative", func(t *testing.T) {
+		lease, err := NewManager(time.Millisecond).NewLease(time.Second, -1, func() {})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(Equal("maxRunDuration cannot be negative"))
+		g.Expect(lease).To(BeNil())
+	})
+
+	t.Run("It returns an error once the manager has stopped", func(t *testing.T) {
+		manager := NewManager(time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		g.Expect(manager.Execute(ctx)).ToNot(HaveOccurred())
+
+		lease, err := manager.NewLease(time.Second, 0, func() {})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(Equal("heartbeat manager has stopped"))
+		g.Expect(lease).To(BeNil())
+
+		lease, err = manager.Register(time.Second, 0, func() {})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(lease).To(BeNil())
+	})
+}
+
+func Test_Manager_Execute(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It times out leases that do not heartbeat", func(t *testing.T) {
+		manager := NewManager(time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_ = manager.Execute(ctx)
+		}()
+
+		timedOut := make(chan struct{})
+		_, err := manager.Register(20*time.Millisecond, 0, func() { close(timedOut) })
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Eventually(timedOut).Should(BeClosed())
+		g.Expect(manager.Len()).To(Equal(0))
+	})
+
+	t.Run("It keeps ticking when leases are added after the manager is idle", func(t *testing.T) {
+		manager := NewManager(time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_ = manager.Execute(ctx)
+		}()
+
+		for i := 0; i < 3; i++ {
+			timedOut := make(chan struct{})
+			_, err := manager.Register(5*time.Millisecond, 0, func() { close(timedOut) })
+			g.Expect(err).ToNot(HaveOccurred())
+
+			g.Eventually(timedOut).Should(BeClosed())
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+
+	t.Run("It does not time out any leases once stopped", func(t *testing.T) {
+		manager := NewManager(time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = manager.Execute(ctx)
+		}()
+
+		_, err := manager.Register(5*time.Millisecond, 0, func() { panic("should not time out") })
+		g.Expect(err).ToNot(HaveOccurred())
+
+		cancel()
+		g.Eventually(done).Should(BeClosed())
+		g.Consistently(manager.Len, 20*time.Millisecond).Should(Equal(1))
+	})
+}
+
+func Test_Manager_Wheel(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It times out leases at every level of the wheel on the expected tick", func(t *testing.T) {
+		// one tick is 1ms, so these cover each level of the wheel and the overflow
+		for _, timeout := range []time.Duration{
+			time.Millisecond,
+			5 * time.Millisecond,
+			64 * time.Millisecond,
+			100 * time.Millisecond,
+			4096 * time.Millisecond,
+			5 * time.Second,
+			5 * time.Minute,
+			5 * time.Hour,
+		} {
+			manager := NewManager(time.Millisecond)
+
+			timedOut := new(atomic.Bool)
+			_, err := manager.Register(timeout, 0, func() { timedOut.Store(true) })
+			g.Expect(err).ToNot(HaveOccurred())
+
+			// stop just before the timeout. the manager was created slightly before the lease, so leave a tick
+			g.Expect(elapse(manager, timeout-time.Millisecond)).To(BeTrue(), timeout.String())
+			g.Expect(timedOut.Load()).To(BeFalse(), timeout.String())
+
+			g.Expect(elapse(manager, 2*time.Millisecond)).To(BeFalse(), timeout.String())
+			g.Eventually(timedOut.Load).Should(BeTrue(), timeout.String())
+		}
+	})
+
+	t.Run("It reschedules leases that received heartbeats", func(t *testing.T) {
+		manager := NewManager(time.Millisecond)
+
+		timedOut := new(atomic.Bool)
+		lease, err := manager.Register(100*time.Millisecond, 0, func() { timedOut.Store(true) })
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for i := 0; i < 10; i++ {
+			g.Expect(elapse(manager, 90*time.Millisecond)).To(BeTrue())
+			g.Expect(lease.Heartbeat()).To(BeTrue())
+		}
+		g.Expect(timedOut.Load()).To(BeFalse())
+
+		g.Expect(elapse(manager, 110*time.Millisecond)).To(BeFalse())
+		g.Eventually(timedOut.Load).Should(BeTrue())
+	})
+
+	t.Run("It only times out the leases that expired", func(t *testing.T) {
+		manager := NewManager(time.Millisecond)
+
+		timedOut := new(atomic.Int64)
+		for i := 1; i <= 100; i++ {
+			_, err := manager.Register(time.Duration(i)*10*time.Millisecond, 0, func() { timedOut.Add(1) })
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		g.Expect(elapse(manager, 505*time.Millisecond)).To(BeTrue())
+		g.Eventually(timedOut.Load).Should(Equal(int64(50)))
+		g.Expect(manager.Len()).To(Equal(50))
+	})
+
+	t.Run("It removes canceled leases from the wheel", func(t *testing.T) {
+		manager := NewManager(time.Millisecond)
+
+		leases := []*Lease{}
+		for i := 0; i < 3; i++ {
+			lease, err := manager.Register(time.Duration(i+1)*time.Second, 0, func() { panic("should not time out") })
+			g.Expect(err).ToNot(HaveOccurred())
+			leases = append(leases, lease)
+		}
+
+		for _, lease := range []*Lease{leases[1], leases[0], leases[2]} {
+			g.Expect(lease.Cancel()).To(BeTrue())
+		}
+
+		g.Expect(manager.Len()).To(Equal(0))
+		g.Expect(elapse(manager, 5*time.Second)).To(BeFalse())
+	})
+}
+
+// goroutineHeartbeater is the previous design, where each lease has its own goroutine and timer
+type goroutineHeartbeater struct {
+	heartbeat chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func newGoroutineHeartbeater(timeout time.Duration, onTimeout func()) *goroutineHeartbeater {
+	heartbeater := &goroutineHeartbeater{
+		heartbeat: make(chan struct{}),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	go func() {
+		defer close(heartbeater.done)
+
+		timer := time.NewTicker(timeout)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-heartbeater.stop:
+				return
+			case <-timer.C:
+				onTimeout()
+				return
+			case <-heartbeater.heartbeat:
+				timer.Reset(timeout)
+			}
+		}
+	}()
+
+	return heartbeater
+}
+
+// memoryInUse reports the heap and goroutine stack sizes after a garbage collection
+func memoryInUse() int64 {
+	runtime.GC()
+
+	memStats := runtime.MemStats{}
+	runtime.ReadMemStats(&memStats)
+
+	return int64(memStats.HeapInuse + memStats.StackInuse)
+}
+
+// Load test with 100k concurrent leases. Each operation starts every lease, heartbeats every lease and then cancels
+// them all. Reports the memory and goroutines used per lease, while all leases are running
+func Benchmark_100kLeases(b *testing.B) {
+	const leases = 100_000
+	onTimeout := func() { panic("should not time out") }
+
+	b.Run("timer wheel", func(b *testing.B) {
+		manager := NewManager(DefaultTick)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_ = manager.Execute(ctx)
+		}()
+
+		runningLeases := make([]*Lease, leases)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			memoryBefore, goroutinesBefore := memoryInUse(), runtime.NumGoroutine()
+			for j := 0; j < leases; j++ {
+				runningLeases[j], _ = manager.Register(time.Minute, 0, onTimeout)
+			}
+
+			b.StopTimer()
+			b.ReportMetric(float64(memoryInUse()-memoryBefore)/leases, "memory-B/lease")
+			b.ReportMetric(float64(runtime.NumGoroutine()-goroutinesBefore)/leases, "goroutines/lease")
+			b.StartTimer()
+
+			for j := 0; j < leases; j++ {
+				if !runningLeases[j].Heartbeat() {
+					b.Fatal("failed to heartbeat")
+				}
+			}
+
+			for j := 0; j < leases; j++ {
+				if !runningLeases[j].Cancel() {
+					b.Fatal("failed to cancel")
+				}
+				runningLeases[j] = nil
+			}
+		}
+	})
+
+	b.Run("goroutine per lease", func(b *testing.B) {
+		runningLeases := make([]*goroutineHeartbeater, leases)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			memoryBefore, goroutinesBefore := memoryInUse(), runtime.NumGoroutine()
+			for j := 0; j < leases; j++ {
+				runningLeases[j] = newGoroutineHeartbeater(time.Minute, onTimeout)
+			}
+
+			b.StopTimer()
+			b.ReportMetric(float64(memoryInUse()-memoryBefore)/leases, "memory-B/lease")
+			b.ReportMetric(float64(runtime.NumGoroutine()-goroutinesBefore)/leases, "goroutines/lease")
+			b.StartTimer()
+
+			for j := 0; j < leases; j++ {
+				runningLeases[j].heartbeat <- struct{}{}
+			}
+
+			for j := 0; j < leases; j++ {
+				close(runningLeases[j].stop)
+				<-runningLeases[j].done
+				runningLeases[j] = nil
+			}
+		}
+	})
+}
+
+// Benchmark heartbeating a single lease while 100k other leases are running
+func Benchmark_Manager_Heartbeat(b *testing.B) {
+	manager := NewManager(DefaultTick)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = manager.Execute(ctx)
+	}()
+
+	for i := 0; i < 100_000; i++ {
+		if _, err := manager.Register(time.Minute, 0, func() {}); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	lease, _ := manager.Register(time.Minute, 0, func() {})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lease.Heartbeat()
+	}
+}
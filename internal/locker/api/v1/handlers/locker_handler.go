@@ -28,6 +28,9 @@ type V1LockerHandler interface {
 
 	// Heartbeat is used to ensure that clients are still active and have the obtained locks
 	Heartbeat(w http.ResponseWriter, r *http.Request)
+
+	// Heartbeats is the same as Heartbeat, but for any number of locks in a single request
+	Heartbeats(w http.ResponseWriter, r *http.Request)
 }
 
 type lockerHandler struct {
@@ -103,6 +106,25 @@ func (lh *lockerHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
 	_, _ = api.ModelEncodeResponse(w, http.StatusOK, nil)
 }
 
+func (lh *lockerHandler) Heartbeats(w http.ResponseWriter, r *http.Request) {
+	// grab the request middleware objects
+	ctx, logger := middleware.GetNamedMiddlewareLogger(r.Context(), "Heartbeats")
+
+	logger.Debug("starting request")
+	defer logger.Debug("processed request")
+
+	// parse the heartbeats
+	lockHeartbeats := &v1locker.LockHeartbeats{}
+	if err := api.ModelDecodeRequest(r, lockHeartbeats); err != nil {
+		logger.Warn("failed to decode and validate request", zap.Error(err))
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	// each heartbeat reports its own result, so the request as a whole always succeeds
+	_, _ = api.ModelEncodeResponse(w, http.StatusOK, lh.generalLocker.Heartbeats(ctx, lockHeartbeats))
+}
+
 func (lh *lockerHandler) Query(w http.ResponseWriter, r *http.Request) {
 	// grab the request middleware objects
 	ctx, logger := middleware.GetNamedMiddlewareLogger(r.Context(), "Query")
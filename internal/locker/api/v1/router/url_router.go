@@ -35,6 +35,7 @@ func AddV1LockerRoutes(baseLogger *zap.Logger, mux *urlrouter.Router, v1Handler
 	mux.HandleFunc("POST", "/v1/locks", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1Handler.Create))))
 	mux.HandleFunc("DELETE", "/v1/locks/:lock_id", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1Handler.Release))))
 	mux.HandleFunc("POST", "/v1/locks/:lock_id/heartbeat", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1Handler.Heartbeat))))
+	mux.HandleFunc("POST", "/v1/locks/heartbeat", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1Handler.Heartbeats))))
 
 	// Admin APIs
 	// TODO: Need to actual account for auth for this
@@ -7,6 +7,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/DanLavine/willow/internal/heartbeater"
 	"github.com/DanLavine/willow/internal/idgenerator"
 	"github.com/DanLavine/willow/pkg/models/api/common/errors"
 	v1locker "github.com/DanLavine/willow/pkg/models/api/locker/v1"
@@ -30,9 +31,9 @@ type exclusiveLock struct {
 	sessionChannel chan string
 	sessionIDLock  *sync.RWMutex
 
-	// channels to manage heartbeat operations
-	heartbeat         chan *v1locker.LockClaim
-	heartbeatResponse chan bool
+	// shared heartbeat manager and the lease for the current session. The lease is guarded by the sessionIDLock
+	heartbeats *heartbeater.Manager
+	lease      *heartbeater.Lease
 
 	// channels to manager releasing a lock
 	release         chan *v1locker.LockClaim
@@ -42,9 +43,9 @@ type exclusiveLock struct {
 	clientLost         chan struct{}
 	clientLostResponse chan bool
 
-	// channels to manage lock operations
+	// channels to manage lock operations. The response is closed when the claim times out
 	claim         chan func(time.Duration) string
-	claimResponse chan time.Duration
+	claimResponse chan chan struct{}
 
 	// timers for clients to know how long a lock is still valid for
 	lastHeartbeatLock *sync.RWMutex
@@ -61,7 +62,7 @@ type exclusiveLock struct {
 	clientsWaitingForClaim *atomic.Uint64
 }
 
-func newExclusiveLock(timeout func()) *exclusiveLock {
+func newExclusiveLock(heartbeats *heartbeater.Manager, timeout func()) *exclusiveLock {
 	clientsWaitingForClaim := new(atomic.Uint64)
 
 	return &exclusiveLock{
@@ -71,8 +72,7 @@ func newExclusiveLock(timeout func()) *exclusiveLock {
 		sessionChannel: make(chan string),
 		sessionIDLock:  new(sync.RWMutex),
 
-		heartbeat:         make(chan *v1locker.LockClaim),
-		heartbeatResponse: make(chan bool),
+		heartbeats: heartbeats,
 
 		release:         make(chan *v1locker.LockClaim),
 		releaseResponse: make(chan releaseResponseCode),
@@ -81,7 +81,7 @@ func newExclusiveLock(timeout func()) *exclusiveLock {
 		clientLostResponse: make(chan bool),
 
 		claim:         make(chan func(time.Duration) string),
-		claimResponse: make(chan time.Duration),
+		claimResponse: make(chan chan struct{}),
 
 		lastHeartbeatLock: new(sync.RWMutex),
 
@@ -128,10 +128,8 @@ func (exclusiveLock *exclusiveLock) Execute(ctx context.Context) error {
 		// NOTE: this is a write operation so the caller can get a callback function to call. This way they do not
 		// need to save off a variable for the lock in the exclusive_locker
 		case exclusiveLock.claim <- exclusiveLock.processClaim:
-			lockTimeout := <-exclusiveLock.claimResponse
-
-			// start the new heartbeat loop operation
-			ticker := time.NewTicker(lockTimeout)
+			// the lease for the claim is already started
+			expired := <-exclusiveLock.claimResponse
 
 		HEARTBEAT_LOOP:
 			for {
@@ -162,30 +160,12 @@ func (exclusiveLock *exclusiveLock) Execute(ctx context.Context) error {
 					}
 					exclusiveLock.clientLostResponse <- false
 
-				// heartbeat
-				case claim := <-exclusiveLock.heartbeat:
-					if claim.SessionID == exclusiveLock.sessionID {
-						// setup the last heartbeat record
-						exclusiveLock.lastHeartbeatLock.Lock()
-						exclusiveLock.lastHeartbeat = time.Now()
-						exclusiveLock.lastHeartbeatLock.Unlock()
-
-						// update the ticker
-						ticker.Reset(exclusiveLock.lockTimeout)
-
-						exclusiveLock.heartbeatResponse <- true
-					} else {
-						exclusiveLock.heartbeatResponse <- false
-					}
-
 				// releasing a claim
 				case claim := <-exclusiveLock.release:
 					// this is the case that a release occured with proper session id
-					if claim.SessionID == exclusiveLock.sessionID {
-						// reset the session id
-						exclusiveLock.sessionIDLock.Lock()
-						exclusiveLock.sessionID = ""
-						exclusiveLock.sessionIDLock.Unlock()
+					if claim.SessionID == exclusiveLock.getSessionID() {
+						// reset the session id and stop the timeout
+						exclusiveLock.clearSession()
 
 						// set the current timeout
 						exclusiveLock.lockTimeoutLock.Lock()
@@ -197,9 +177,6 @@ func (exclusiveLock *exclusiveLock) Execute(ctx context.Context) error {
 						exclusiveLock.lastHeartbeat = time.Time{}
 						exclusiveLock.lastHeartbeatLock.Unlock()
 
-						// stop the timeout ticker
-						ticker.Stop()
-
 						if exclusiveLock.clientsWaitingForClaim.Add(^uint64(0)) == 0 {
 							exclusiveLock.releaseResponse <- processedAndDestroy
 							return nil
@@ -214,13 +191,12 @@ func (exclusiveLock *exclusiveLock) Execute(ctx context.Context) error {
 					exclusiveLock.releaseResponse <- failedRelease
 
 				// timed out
-				case <-ticker.C:
-					ticker.Stop()
+				case <-expired:
+					// closed channels are always ready, so only process the timeout once
+					expired = nil
 
 					// clear the session id
-					exclusiveLock.sessionIDLock.Lock()
-					exclusiveLock.sessionID = ""
-					exclusiveLock.sessionIDLock.Unlock()
+					exclusiveLock.clearSession()
 
 					// set the current timeout
 					exclusiveLock.lockTimeoutLock.Lock()
@@ -266,10 +242,16 @@ func (exclusiveLock *exclusiveLock) Execute(ctx context.Context) error {
 //
 // processClaim is the callback the exclusive_locker will call when a client obtains a lock
 func (exclusiveLock *exclusiveLock) processClaim(lockTimeout time.Duration) string {
+	// start the timeout before the client can heartbeat. If the manager has stopped, the server is shutting down
+	// and the claim never times out
+	expired := make(chan struct{})
+	lease, _ := exclusiveLock.heartbeats.Register(lockTimeout, 0, func() { close(expired) })
+
 	// setup the new session id
 	exclusiveLock.sessionIDLock.Lock()
 	sessionID := idgenerator.UUID().ID()
 	exclusiveLock.sessionID = sessionID
+	exclusiveLock.lease = lease
 	exclusiveLock.sessionIDLock.Unlock()
 
 	// set the current timeout
@@ -283,7 +265,7 @@ func (exclusiveLock *exclusiveLock) processClaim(lockTimeout time.Duration) stri
 	exclusiveLock.lastHeartbeatLock.Unlock()
 
 	// inform the async process to continue
-	exclusiveLock.claimResponse <- lockTimeout
+	exclusiveLock.claimResponse <- expired
 
 	return sessionID
 }
@@ -307,15 +289,33 @@ func (exclusiveLock *exclusiveLock) GetClaimChannel() <-chan func(lockTimeout ti
 // Heartbeat a currently held lock
 func (exclusiveLock *exclusiveLock) Heartbeat(claim *v1locker.LockClaim) *errors.ServerError {
 	select {
-	case exclusiveLock.heartbeat <- claim:
-		if passed := <-exclusiveLock.heartbeatResponse; passed {
-			return nil
-		}
+	case <-exclusiveLock.done:
+		return errors.ServerShutdown
+	default:
+	}
 
+	exclusiveLock.sessionIDLock.RLock()
+	defer exclusiveLock.sessionIDLock.RUnlock()
+
+	if exclusiveLock.sessionID == "" || claim.SessionID != exclusiveLock.sessionID {
 		return &errors.ServerError{Message: "SessionID for the claim is invalid", StatusCode: http.StatusConflict}
-	case <-exclusiveLock.done:
+	}
+
+	if exclusiveLock.lease == nil {
 		return errors.ServerShutdown
 	}
+
+	// the lease already timed out, but the session has not been cleared yet
+	if !exclusiveLock.lease.Heartbeat() {
+		return &errors.ServerError{Message: "SessionID for the claim is invalid", StatusCode: http.StatusConflict}
+	}
+
+	// setup the last heartbeat record
+	exclusiveLock.lastHeartbeatLock.Lock()
+	exclusiveLock.lastHeartbeat = time.Now()
+	exclusiveLock.lastHeartbeatLock.Unlock()
+
+	return nil
 }
 
 //	PARAMETERS:
@@ -384,6 +384,19 @@ func (exclusiveLock *exclusiveLock) getLockTimeout() time.Duration {
 	return exclusiveLock.lockTimeout
 }
 
+// clearSession removes the session id and stops the lease's timeout
+func (exclusiveLock *exclusiveLock) clearSession() {
+	exclusiveLock.sessionIDLock.Lock()
+	defer exclusiveLock.sessionIDLock.Unlock()
+
+	if exclusiveLock.lease != nil {
+		_ = exclusiveLock.lease.Cancel()
+		exclusiveLock.lease = nil
+	}
+
+	exclusiveLock.sessionID = ""
+}
+
 func (exclusiveLock *exclusiveLock) getSessionID() string {
 	exclusiveLock.sessionIDLock.RLock()
 	defer exclusiveLock.sessionIDLock.RUnlock()
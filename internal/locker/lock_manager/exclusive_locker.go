@@ -7,6 +7,7 @@ import (
 
 	"github.com/DanLavine/goasync"
 	btreeassociated "github.com/DanLavine/willow/internal/datastructures/btree_associated"
+	"github.com/DanLavine/willow/internal/heartbeater"
 	"github.com/DanLavine/willow/internal/middleware"
 	"github.com/DanLavine/willow/internal/reporting"
 	"github.com/DanLavine/willow/pkg/models/api/common/errors"
@@ -24,6 +25,9 @@ type ExcluiveLocker interface {
 	// Heartbeat any number of locks so we know they are still running properly
 	Heartbeat(ctx context.Context, lockID string, lockClaim *v1locker.LockClaim) *errors.ServerError
 
+	// Heartbeats refreshes many locks at once and reports the result for each one
+	Heartbeats(ctx context.Context, lockHeartbeats *v1locker.LockHeartbeats) *v1locker.LockHeartbeatsResponse
+
 	// Find all locks currently held in the tree
 	LocksQuery(ctx context.Context, query *queryassociatedaction.AssociatedActionQuery) v1locker.Locks
 
@@ -35,14 +39,23 @@ type exclusiveLocker struct {
 	// association trees for all possible locks
 	exclusiveLocks btreeassociated.BTreeAssociated
 
+	// times out all claimed locks on a single timer wheel
+	heartbeats *heartbeater.Manager
+
 	// task manger ensures shutdown requests are processsed properly
 	taskManager goasync.AsyncTaskManager
 }
 
 func NewExclusiveLocker() *exclusiveLocker {
+	heartbeats := heartbeater.NewManager(heartbeater.DefaultTick)
+
+	taskManager := goasync.NewTaskManager(goasync.RelaxedConfig())
+	_ = taskManager.AddExecuteTask("heartbeats", heartbeats)
+
 	return &exclusiveLocker{
 		exclusiveLocks: btreeassociated.NewThreadSafe(),
-		taskManager:    goasync.NewTaskManager(goasync.RelaxedConfig()),
+		heartbeats:     heartbeats,
+		taskManager:    taskManager,
 	}
 }
 
@@ -67,7 +80,7 @@ func (exclusiveLocker *exclusiveLocker) ObtainLock(ctx context.Context, createLo
 	var claimChannel <-chan func(time.Duration) string
 
 	onCreate := func() any {
-		lock := newExclusiveLock(func() {
+		lock := newExclusiveLock(exclusiveLocker.heartbeats, func() {
 			exclusiveLocker.timeout(reporting.BaseLogger(logger), createLockRequest.Spec.DBDefinition.KeyValues)
 		})
 
@@ -152,18 +165,19 @@ func (exclusiveLocker *exclusiveLocker) LocksQuery(ctx context.Context, query *q
 			expireTime = time.Since(lastHeartbeatTime)
 		}
 
+		lockTimeout := exclusiveLock.getLockTimeout()
 		locks = append(locks, &v1locker.Lock{
 			Spec: &v1locker.LockSpec{
 				DBDefinition: &v1locker.LockDBDefinition{
 					KeyValues: associatedKeyValues.KeyValues(),
 				},
 				Properties: &v1locker.LockProperties{
-					Timeout: &exclusiveLock.lockTimeout,
+					Timeout: &lockTimeout,
 				},
 			},
 			State: &v1locker.LockState{
 				LockID:             associatedKeyValues.AssociatedID(),
-				SessionID:          exclusiveLock.sessionID,
+				SessionID:          exclusiveLock.getSessionID(),
 				TimeTillExipre:     expireTime,
 				LocksHeldOrWaiting: exclusiveLock.clientsWaitingForClaim.Load(),
 			},
@@ -202,6 +216,22 @@ func (exclusiveLocker *exclusiveLocker) Heartbeat(ctx context.Context, lockID st
 	return heartbeaterErr
 }
 
+func (exclusiveLocker *exclusiveLocker) Heartbeats(ctx context.Context, lockHeartbeats *v1locker.LockHeartbeats) *v1locker.LockHeartbeatsResponse {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "Heartbeats")
+	logger.Debug("attempting to heartbeat many locks")
+
+	lockHeartbeatsResponse := &v1locker.LockHeartbeatsResponse{Results: make([]*v1locker.LockHeartbeatResult, 0, len(lockHeartbeats.Heartbeats))}
+	for _, lockHeartbeat := range lockHeartbeats.Heartbeats {
+		if heartbeatErr := exclusiveLocker.Heartbeat(ctx, lockHeartbeat.LockID, &v1locker.LockClaim{SessionID: lockHeartbeat.SessionID}); heartbeatErr != nil {
+			lockHeartbeatsResponse.Results = append(lockHeartbeatsResponse.Results, &v1locker.LockHeartbeatResult{Error: &errors.Error{Message: heartbeatErr.Message}})
+		} else {
+			lockHeartbeatsResponse.Results = append(lockHeartbeatsResponse.Results, &v1locker.LockHeartbeatResult{})
+		}
+	}
+
+	return lockHeartbeatsResponse
+}
+
 func (exclusiveLocker *exclusiveLocker) Release(ctx context.Context, lockID string, claim *v1locker.LockClaim) *errors.ServerError {
 	_, logger := middleware.GetNamedMiddlewareLogger(ctx, "Release")
 	logger.Debug("releasing the lock")
@@ -505,6 +505,82 @@ func TestExclusiveLocker_Heartbeat(t *testing.T) {
 	})
 }
 
+func TestExclusiveLocker_Heartbeats(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	query := &queryassociatedaction.AssociatedActionQuery{}
+	g.Expect(query.Validate()).ToNot(HaveOccurred())
+
+	t.Run("It reports the result for each lock in the same order as the request", func(t *testing.T) {
+		ctx, cancel := testhelpers.NewCancelContextWithMiddlewareSetup()
+		defer cancel()
+
+		exclusiveLocker := NewExclusiveLocker()
+		go func() {
+			exclusiveLocker.Execute(ctx)
+		}()
+
+		lockResp := exclusiveLocker.ObtainLock(ctx, defaultLockCreateRequest())
+		g.Expect(lockResp).ToNot(BeNil())
+
+		lockHeartbeats := &v1locker.LockHeartbeats{
+			Heartbeats: []*v1locker.LockHeartbeat{
+				{LockID: "bad id", SessionID: lockResp.State.SessionID},
+				{LockID: lockResp.State.LockID, SessionID: lockResp.State.SessionID},
+				{LockID: lockResp.State.LockID, SessionID: "nope"},
+			},
+		}
+		g.Expect(lockHeartbeats.Validate()).ToNot(HaveOccurred())
+
+		lockHeartbeatsResponse := exclusiveLocker.Heartbeats(ctx, lockHeartbeats)
+		g.Expect(lockHeartbeatsResponse.Results).To(HaveLen(3))
+		g.Expect(lockHeartbeatsResponse.Results[0].Error).ToNot(BeNil())
+		g.Expect(lockHeartbeatsResponse.Results[0].Error.Error()).To(ContainSubstring("LockID could not be found"))
+		g.Expect(lockHeartbeatsResponse.Results[1].Error).To(BeNil())
+		g.Expect(lockHeartbeatsResponse.Results[2].Error).ToNot(BeNil())
+		g.Expect(lockHeartbeatsResponse.Results[2].Error.Error()).To(ContainSubstring("SessionID for the claim is invalid"))
+	})
+
+	t.Run("It keeps all the locks around as long as the heartbeats are received", func(t *testing.T) {
+		ctx, cancel := testhelpers.NewCancelContextWithMiddlewareSetup()
+		defer cancel()
+
+		exclusiveLocker := NewExclusiveLocker()
+		go func() {
+			exclusiveLocker.Execute(ctx)
+		}()
+
+		lockRequest1 := defaultLockCreateRequest()
+		lockRequest1.Spec.Properties.Timeout = helpers.PointerOf(100 * time.Millisecond)
+		lockResp1 := exclusiveLocker.ObtainLock(ctx, lockRequest1)
+		g.Expect(lockResp1).ToNot(BeNil())
+
+		lockRequest2 := overlapOneKeyValue()
+		lockRequest2.Spec.Properties.Timeout = helpers.PointerOf(100 * time.Millisecond)
+		lockResp2 := exclusiveLocker.ObtainLock(ctx, lockRequest2)
+		g.Expect(lockResp2).ToNot(BeNil())
+
+		lockHeartbeats := &v1locker.LockHeartbeats{
+			Heartbeats: []*v1locker.LockHeartbeat{
+				{LockID: lockResp1.State.LockID, SessionID: lockResp1.State.SessionID},
+				{LockID: lockResp2.State.LockID, SessionID: lockResp2.State.SessionID},
+			},
+		}
+		g.Expect(lockHeartbeats.Validate()).ToNot(HaveOccurred())
+
+		// this timer is longer than the heartbeat timeout
+		for i := 0; i < 3; i++ {
+			time.Sleep(60 * time.Millisecond)
+			for _, result := range exclusiveLocker.Heartbeats(ctx, lockHeartbeats).Results {
+				g.Expect(result.Error).To(BeNil())
+			}
+		}
+
+		locks := exclusiveLocker.LocksQuery(ctx, query)
+		g.Expect(len(locks)).To(Equal(2))
+	})
+}
+
 func TestExclusiveLocker_Release(t *testing.T) {
 	g := NewGomegaWithT(t)
 
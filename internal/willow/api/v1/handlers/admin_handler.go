@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/DanLavine/willow/internal/middleware"
+	"github.com/DanLavine/willow/pkg/models/api"
+	"go.uber.org/zap"
+
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+)
+
+func (qh queueHandler) Export(w http.ResponseWriter, r *http.Request) {
+	// grab the request middleware objects
+	ctx, logger := middleware.GetNamedMiddlewareLogger(r.Context(), "Export")
+	logger.Debug("starting request")
+	defer logger.Debug("processed request")
+
+	// parse the export query
+	exportQuery := &v1willow.ExportQuery{}
+	if err := api.ModelDecodeRequest(r, exportQuery); err != nil {
+		logger.Warn("failed to decode and validate request", zap.Error(err))
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	queueExport, err := qh.queueClient.ExportQueues(ctx, exportQuery)
+	if err != nil {
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	_, _ = api.ModelEncodeResponse(w, http.StatusOK, queueExport)
+}
+
+func (qh queueHandler) Import(w http.ResponseWriter, r *http.Request) {
+	// grab the request middleware objects
+	ctx, logger := middleware.GetNamedMiddlewareLogger(r.Context(), "Import")
+	logger.Debug("starting request")
+	defer logger.Debug("processed request")
+
+	// parse the export document
+	queueExport := &v1willow.QueueExport{}
+	if err := api.ModelDecodeRequest(r, queueExport); err != nil {
+		logger.Warn("failed to decode and validate request", zap.Error(err))
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	if err := qh.queueClient.ImportQueues(ctx, queueExport); err != nil {
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	_, _ = api.ModelEncodeResponse(w, http.StatusCreated, nil)
+}
+
+func (qh queueHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	// grab the request middleware objects
+	ctx, logger := middleware.GetNamedMiddlewareLogger(r.Context(), "Reconcile")
+	logger.Debug("starting request")
+	defer logger.Debug("processed request")
+
+	limiterReconcile, err := qh.limiterReconciler.Reconcile(ctx)
+	if err != nil {
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	_, _ = api.ModelEncodeResponse(w, http.StatusOK, limiterReconcile)
+}
@@ -63,3 +63,27 @@ func (qh queueHandler) ChannelDelete(w http.ResponseWriter, r *http.Request) {
 
 	_, _ = api.ModelEncodeResponse(w, http.StatusNoContent, nil)
 }
+
+func (qh queueHandler) ChannelDiagnose(w http.ResponseWriter, r *http.Request) {
+	// grab the request middleware objects
+	ctx, logger := middleware.GetNamedMiddlewareLogger(r.Context(), "ChannelDiagnose")
+	logger.Debug("starting request")
+	defer logger.Debug("processed request")
+
+	// parse the request
+	query := &queryassociatedaction.AssociatedActionQuery{}
+	if err := api.ModelDecodeRequest(r, query); err != nil {
+		logger.Warn("failed to decode and validate request", zap.Error(err))
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	channelDiagnoses, err := qh.queueClient.DiagnoseChannels(ctx, urlrouter.GetNamedParamters(r.Context())["queue_name"], query)
+	if err != nil {
+		logger.Warn("failed to diagnose channels", zap.Error(err))
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	_, _ = api.ModelEncodeResponse(w, http.StatusOK, channelDiagnoses)
+}
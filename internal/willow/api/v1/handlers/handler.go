@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/reconcile"
 	"github.com/DanLavine/willow/internal/willow/brokers/queues"
 )
 
@@ -19,22 +20,37 @@ type V1QueueHandler interface {
 	// channel handlers
 	ChannelQuery(w http.ResponseWriter, r *http.Request)
 	ChannelDelete(w http.ResponseWriter, r *http.Request)
+	ChannelDiagnose(w http.ResponseWriter, r *http.Request)
 
 	// item handlers
 	ChannelEnqueue(w http.ResponseWriter, r *http.Request)
 	ChannelDequeue(w http.ResponseWriter, r *http.Request)
+	QueuesDequeue(w http.ResponseWriter, r *http.Request)
 	ItemACK(w http.ResponseWriter, r *http.Request)
 	ItemHeartbeat(w http.ResponseWriter, r *http.Request)
+	ItemsHeartbeat(w http.ResponseWriter, r *http.Request)
+	ItemCancel(w http.ResponseWriter, r *http.Request)
+	ItemDelete(w http.ResponseWriter, r *http.Request)
+	ItemQuery(w http.ResponseWriter, r *http.Request)
+
+	// admin handlers
+	Export(w http.ResponseWriter, r *http.Request)
+	Import(w http.ResponseWriter, r *http.Request)
+	Reconcile(w http.ResponseWriter, r *http.Request)
 }
 
 type queueHandler struct {
 	limiterRuleID string
 	queueClient   queues.QueuesClient
+
+	// reconciles the Limiter's counters with the items Willow holds
+	limiterReconciler *reconcile.Reconciler
 }
 
-func NewV1QueueHandler(queueClient queues.QueuesClient, limiterRuleID string) *queueHandler {
+func NewV1QueueHandler(queueClient queues.QueuesClient, limiterRuleID string, limiterReconciler *reconcile.Reconciler) *queueHandler {
 	return &queueHandler{
-		limiterRuleID: limiterRuleID,
-		queueClient:   queueClient,
+		limiterRuleID:     limiterRuleID,
+		queueClient:       queueClient,
+		limiterReconciler: limiterReconciler,
 	}
 }
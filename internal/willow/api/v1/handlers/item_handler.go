@@ -25,12 +25,19 @@ func (qh queueHandler) ChannelEnqueue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := qh.queueClient.Enqueue(ctx, urlrouter.GetNamedParamters(r.Context())["queue_name"], queueItem); err != nil {
+	itemState, err := qh.queueClient.Enqueue(ctx, urlrouter.GetNamedParamters(r.Context())["queue_name"], queueItem)
+	if err != nil {
 		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
 		return
 	}
 
-	_, _ = api.ModelEncodeResponse(w, http.StatusCreated, nil)
+	// an item with the same idempotency key already existed, so nothing was created
+	if itemState.Duplicate {
+		_, _ = api.ModelEncodeResponse(w, http.StatusOK, itemState)
+		return
+	}
+
+	_, _ = api.ModelEncodeResponse(w, http.StatusCreated, itemState)
 }
 
 func (qh queueHandler) ChannelDequeue(w http.ResponseWriter, r *http.Request) {
@@ -61,6 +68,34 @@ func (qh queueHandler) ChannelDequeue(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (qh queueHandler) QueuesDequeue(w http.ResponseWriter, r *http.Request) {
+	// grab the request middleware objects
+	ctx, logger := middleware.GetNamedMiddlewareLogger(r.Context(), "QueuesDequeue")
+	logger.Debug("starting request")
+	defer logger.Debug("processed request")
+
+	// parse the dequeue request
+	dequeueQueues := &v1willow.DequeueQueues{}
+	if err := api.ModelDecodeRequest(r, dequeueQueues); err != nil {
+		logger.Warn("failed to decode and validate request", zap.Error(err))
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	dequeueItem, successCallback, failureCallback, err := qh.queueClient.DequeueQueues(ctx, dequeueQueues)
+	if err != nil {
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	if _, responseErr := api.ModelEncodeResponse(w, http.StatusOK, dequeueItem); responseErr != nil {
+		logger.Warn("Failed so send the response back to the client")
+		failureCallback()
+	} else {
+		successCallback()
+	}
+}
+
 func (qh queueHandler) ItemACK(w http.ResponseWriter, r *http.Request) {
 	// grab the request middleware objects
 	ctx, logger := middleware.GetNamedMiddlewareLogger(r.Context(), "ItemACK")
@@ -97,10 +132,97 @@ func (qh queueHandler) ItemHeartbeat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := qh.queueClient.Heartbeat(ctx, urlrouter.GetNamedParamters(r.Context())["queue_name"], heartbeat); err != nil {
+	heartbeatResponse, err := qh.queueClient.Heartbeat(ctx, urlrouter.GetNamedParamters(r.Context())["queue_name"], heartbeat)
+	if err != nil {
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	_, _ = api.ModelEncodeResponse(w, http.StatusOK, heartbeatResponse)
+}
+
+func (qh queueHandler) ItemsHeartbeat(w http.ResponseWriter, r *http.Request) {
+	// grab the request middleware objects
+	ctx, logger := middleware.GetNamedMiddlewareLogger(r.Context(), "ItemsHeartbeat")
+	logger.Debug("starting request")
+	defer logger.Debug("processed request")
+
+	// parse the batch of heartbeats
+	heartbeats := &v1willow.Heartbeats{}
+	if err := api.ModelDecodeRequest(r, heartbeats); err != nil {
+		logger.Warn("failed to decode and validate request", zap.Error(err))
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	// each heartbeat reports its own result, so the request as a whole always succeeds
+	_, _ = api.ModelEncodeResponse(w, http.StatusOK, qh.queueClient.Heartbeats(ctx, heartbeats))
+}
+
+func (qh queueHandler) ItemCancel(w http.ResponseWriter, r *http.Request) {
+	// grab the request middleware objects
+	ctx, logger := middleware.GetNamedMiddlewareLogger(r.Context(), "ItemCancel")
+	logger.Debug("starting request")
+	defer logger.Debug("processed request")
+
+	// parse the cancel request
+	cancel := &v1willow.Cancel{}
+	if err := api.ModelDecodeRequest(r, cancel); err != nil {
+		logger.Warn("failed to decode and validate request", zap.Error(err))
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	if err := qh.queueClient.Cancel(ctx, urlrouter.GetNamedParamters(r.Context())["queue_name"], cancel); err != nil {
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	_, _ = api.ModelEncodeResponse(w, http.StatusOK, nil)
+}
+
+func (qh queueHandler) ItemDelete(w http.ResponseWriter, r *http.Request) {
+	// grab the request middleware objects
+	ctx, logger := middleware.GetNamedMiddlewareLogger(r.Context(), "ItemDelete")
+	logger.Debug("starting request")
+	defer logger.Debug("processed request")
+
+	// parse the delete request
+	itemDelete := &v1willow.ItemDelete{}
+	if err := api.ModelDecodeRequest(r, itemDelete); err != nil {
+		logger.Warn("failed to decode and validate request", zap.Error(err))
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	if err := qh.queueClient.DeleteItems(ctx, urlrouter.GetNamedParamters(r.Context())["queue_name"], itemDelete); err != nil {
 		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
 		return
 	}
 
 	_, _ = api.ModelEncodeResponse(w, http.StatusOK, nil)
 }
+
+func (qh queueHandler) ItemQuery(w http.ResponseWriter, r *http.Request) {
+	// grab the request middleware objects
+	ctx, logger := middleware.GetNamedMiddlewareLogger(r.Context(), "ItemQuery")
+	logger.Debug("starting request")
+	defer logger.Debug("processed request")
+
+	// parse the query request
+	itemQuery := &v1willow.ItemQuery{}
+	if err := api.ModelDecodeRequest(r, itemQuery); err != nil {
+		logger.Warn("failed to decode and validate request", zap.Error(err))
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	items, err := qh.queueClient.QueryItems(ctx, urlrouter.GetNamedParamters(r.Context())["queue_name"], itemQuery)
+	if err != nil {
+		logger.Warn("failed to query items", zap.Error(err))
+		_, _ = api.ModelEncodeResponse(w, err.StatusCode, err)
+		return
+	}
+
+	_, _ = api.ModelEncodeResponse(w, http.StatusOK, items)
+}
@@ -42,8 +42,9 @@ func AddV1WillowRoutes(baseLogger *zap.Logger, mux *urlrouter.Router, v1QueueHan
 
 	// message channels
 	//// queues
-	mux.HandleFunc("GET", "/v1/queues/:queue_name/channels", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.ChannelQuery))))     // Get a channel's details
-	mux.HandleFunc("DELETE", "/v1/queues/:queue_name/channels", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.ChannelDelete)))) // Delete a channel by key values
+	mux.HandleFunc("GET", "/v1/queues/:queue_name/channels", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.ChannelQuery))))             // Get a channel's details
+	mux.HandleFunc("DELETE", "/v1/queues/:queue_name/channels", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.ChannelDelete))))         // Delete a channel by key values
+	mux.HandleFunc("GET", "/v1/queues/:queue_name/channels/diagnose", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.ChannelDiagnose)))) // Explain why a dequeue is not receiving items
 
 	// item handlers
 	//// queues
@@ -51,4 +52,15 @@ func AddV1WillowRoutes(baseLogger *zap.Logger, mux *urlrouter.Router, v1QueueHan
 	mux.HandleFunc("GET", "/v1/queues/:queue_name/channels/items", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.ChannelDequeue))))
 	mux.HandleFunc("POST", "/v1/queues/:queue_name/channels/items/ack", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.ItemACK))))
 	mux.HandleFunc("POST", "/v1/queues/:queue_name/channels/items/heartbeat", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.ItemHeartbeat))))
+	mux.HandleFunc("POST", "/v1/queues/:queue_name/channels/items/cancel", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.ItemCancel))))
+	mux.HandleFunc("POST", "/v1/queues/:queue_name/channels/items/delete", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.ItemDelete))))
+	mux.HandleFunc("GET", "/v1/queues/:queue_name/channels/items/query", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.ItemQuery)))) // inspect enqueued and processing items
+	//// multiple queues
+	mux.HandleFunc("GET", "/v1/items/dequeue", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.QueuesDequeue))))     // dequeue from any number of queues
+	mux.HandleFunc("POST", "/v1/items/heartbeat", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.ItemsHeartbeat)))) // heartbeat any number of processing items
+
+	// admin handlers
+	mux.HandleFunc("GET", "/v1/admin/export", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.Export))))        // dump one or all queues with their items
+	mux.HandleFunc("POST", "/v1/admin/import", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.Import))))       // recreate queues from an export
+	mux.HandleFunc("POST", "/v1/admin/reconcile", middleware.SetupTracer(middleware.AddLogger(baseLogger, middleware.ValidateReqHeaders(v1QueueHandler.Reconcile)))) // correct any Limiter counters that drifted
 }
@@ -10,8 +10,13 @@ import (
 
 	"github.com/DanLavine/urlrouter"
 	"github.com/DanLavine/willow/internal/config"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/outbox"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/reconcile"
+	"github.com/DanLavine/willow/pkg/models/api"
 	"go.uber.org/zap"
 	"golang.org/x/net/http2"
+
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
 )
 
 type willowTCP struct {
@@ -22,14 +27,22 @@ type willowTCP struct {
 	server *http.Server
 
 	mux *urlrouter.Router
+
+	// reports any Limiter counter updates that are still being retried
+	limiterOutbox *outbox.Outbox
+
+	// reports any Limiter counters that were corrected
+	limiterReconciler *reconcile.Reconciler
 }
 
-func NewWillowTCP(logger *zap.Logger, config *config.WillowConfig, mux *urlrouter.Router) *willowTCP {
+func NewWillowTCP(logger *zap.Logger, config *config.WillowConfig, mux *urlrouter.Router, limiterOutbox *outbox.Outbox, limiterReconciler *reconcile.Reconciler) *willowTCP {
 	return &willowTCP{
-		closed: false,
-		logger: logger.Named("willow_tcp_server"),
-		config: config,
-		mux:    mux,
+		closed:            false,
+		logger:            logger.Named("willow_tcp_server"),
+		config:            config,
+		mux:               mux,
+		limiterOutbox:     limiterOutbox,
+		limiterReconciler: limiterReconciler,
 	}
 }
 
@@ -81,9 +94,8 @@ func (willow *willowTCP) Execute(willowCTX context.Context) error {
 	logger := willow.logger
 
 	// health api doesn't have a version associated with it
-	willow.mux.HandleFunc("GET", "/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
+	willow.mux.HandleFunc("GET", "/health", willow.health)
+	willow.mux.HandleFunc("GET", "/metrics", willow.metrics)
 
 	willow.server.Handler = willow.mux
 
@@ -138,3 +150,51 @@ func (willow *willowTCP) Execute(willowCTX context.Context) error {
 	logger.Info("server shutdown successfully")
 	return nil
 }
+
+// health always responds with 200 while the server is running. When Limiter counter updates are being retried,
+// the status is "limiter out of sync" instead of failing the health check, since Willow can still serve requests
+func (willow *willowTCP) health(w http.ResponseWriter, r *http.Request) {
+	health := &v1willow.Health{
+		Status:        v1willow.HealthStatusOK,
+		LimiterOutbox: willow.limiterOutbox.State(),
+	}
+
+	if health.LimiterOutbox.PendingUpdates != 0 {
+		health.Status = v1willow.HealthStatusLimiterOutOfSync
+	}
+
+	_, _ = api.ModelEncodeResponse(w, http.StatusOK, health)
+}
+
+// metrics reports the state and reconciliation of the Limiter counters in the Prometheus text format
+func (willow *willowTCP) metrics(w http.ResponseWriter, r *http.Request) {
+	limiterOutbox := willow.limiterOutbox.State()
+	limiterReconcile := willow.limiterReconciler.State()
+
+	outOfSync := 0
+	if limiterOutbox.PendingUpdates != 0 {
+		outOfSync = 1
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "# HELP willow_limiter_out_of_sync Set to 1 when Limiter counter updates are waiting to be retried.\n")
+	fmt.Fprintf(w, "# TYPE willow_limiter_out_of_sync gauge\n")
+	fmt.Fprintf(w, "willow_limiter_out_of_sync %d\n", outOfSync)
+	fmt.Fprintf(w, "# HELP willow_limiter_pending_updates Number of Limiter counters with updates waiting to be retried.\n")
+	fmt.Fprintf(w, "# TYPE willow_limiter_pending_updates gauge\n")
+	fmt.Fprintf(w, "willow_limiter_pending_updates %d\n", limiterOutbox.PendingUpdates)
+	fmt.Fprintf(w, "# HELP willow_limiter_out_of_sync_seconds How long the oldest pending Limiter counter update has been waiting.\n")
+	fmt.Fprintf(w, "# TYPE willow_limiter_out_of_sync_seconds gauge\n")
+	fmt.Fprintf(w, "willow_limiter_out_of_sync_seconds %g\n", limiterOutbox.OutOfSyncDuration.Seconds())
+	fmt.Fprintf(w, "# HELP willow_limiter_update_retries_total Total number of retried Limiter counter updates.\n")
+	fmt.Fprintf(w, "# TYPE willow_limiter_update_retries_total counter\n")
+	fmt.Fprintf(w, "willow_limiter_update_retries_total %d\n", limiterOutbox.Retries)
+	fmt.Fprintf(w, "# HELP willow_limiter_reconcile_runs_total Total number of times the Limiter counters were reconciled.\n")
+	fmt.Fprintf(w, "# TYPE willow_limiter_reconcile_runs_total counter\n")
+	fmt.Fprintf(w, "willow_limiter_reconcile_runs_total %d\n", limiterReconcile.Runs)
+	fmt.Fprintf(w, "# HELP willow_limiter_reconcile_corrections_total Total number of Limiter counters that drifted from Willow and were corrected.\n")
+	fmt.Fprintf(w, "# TYPE willow_limiter_reconcile_corrections_total counter\n")
+	fmt.Fprintf(w, "willow_limiter_reconcile_corrections_total %d\n", limiterReconcile.Corrections)
+}
@@ -0,0 +1,169 @@
+package queuechannels
+
+import (
+	"context"
+
+	"github.com/DanLavine/channelops"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+
+	v1common "github.com/DanLavine/willow/pkg/models/api/common/v1"
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+)
+
+type clientWaiting struct {
+	// queues the client is waiting on and the query used to match any channels against to see if they can provide values for the client
+	queueQueries map[string]*queryassociatedaction.AssociatedActionQuery
+	// channelOPS is the collection of channels attempting to be read
+	channelOPS channelops.RepeatableMergeReadChannelOperator[func(ctx context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func())]
+
+	// queues that were deleted while the client was waiting. Once all queues are deleted, the client is canceled
+	deletedQueues map[string]struct{}
+	// cancel stops the client from waiting with the reason returned to the client
+	cancel context.CancelCauseFunc
+}
+
+// indexKeyValue is a key value that every channel must have to match a client's query
+type indexKeyValue struct {
+	key   string
+	value datatypes.EncapsulatedValue
+}
+
+// queueClientsWaiting are all the clients waiting on a single queue
+type queueClientsWaiting struct {
+	// clients with a query that can only match channels with the key value
+	indexed map[indexKeyValue]map[*clientWaiting]struct{}
+
+	// clients with a query that can match channels with any key values. These are always checked
+	unindexed map[*clientWaiting]struct{}
+}
+
+// clientsWaitingIndex records the clients waiting to dequeue by the queue names and an equality key value from
+// their queries. When a channel is created, only the clients that could match the channel need to be checked
+//
+// NOTE: this is not thread safe and is guarded by the queueChannelsClientLocal's clientsWaitingLock
+type clientsWaitingIndex struct {
+	queues map[string]*queueClientsWaiting
+}
+
+func newClientsWaitingIndex() *clientsWaitingIndex {
+	return &clientsWaitingIndex{
+		queues: map[string]*queueClientsWaiting{},
+	}
+}
+
+// add the client to the index of every queue it is waiting on
+func (index *clientsWaitingIndex) add(client *clientWaiting) {
+	for queueName, query := range client.queueQueries {
+		queueClients, ok := index.queues[queueName]
+		if !ok {
+			queueClients = &queueClientsWaiting{
+				indexed:   map[indexKeyValue]map[*clientWaiting]struct{}{},
+				unindexed: map[*clientWaiting]struct{}{},
+			}
+			index.queues[queueName] = queueClients
+		}
+
+		if keyValue, ok := queryIndexKeyValue(query); ok {
+			if _, ok := queueClients.indexed[keyValue]; !ok {
+				queueClients.indexed[keyValue] = map[*clientWaiting]struct{}{}
+			}
+			queueClients.indexed[keyValue][client] = struct{}{}
+		} else {
+			queueClients.unindexed[client] = struct{}{}
+		}
+	}
+}
+
+// remove the client from the index of every queue it is waiting on
+func (index *clientsWaitingIndex) remove(client *clientWaiting) {
+	for queueName, query := range client.queueQueries {
+		queueClients, ok := index.queues[queueName]
+		if !ok {
+			continue
+		}
+
+		if keyValue, ok := queryIndexKeyValue(query); ok {
+			delete(queueClients.indexed[keyValue], client)
+			if len(queueClients.indexed[keyValue]) == 0 {
+				delete(queueClients.indexed, keyValue)
+			}
+		} else {
+			delete(queueClients.unindexed, client)
+		}
+
+		if len(queueClients.indexed) == 0 && len(queueClients.unindexed) == 0 {
+			delete(index.queues, queueName)
+		}
+	}
+}
+
+// forEach calls onClient for every client waiting on the queue
+func (index *clientsWaitingIndex) forEach(queueName string, onClient func(client *clientWaiting)) {
+	queueClients, ok := index.queues[queueName]
+	if !ok {
+		return
+	}
+
+	for _, clients := range queueClients.indexed {
+		for client := range clients {
+			onClient(client)
+		}
+	}
+
+	for client := range queueClients.unindexed {
+		onClient(client)
+	}
+}
+
+// forEachCandidate calls onClient for every client waiting on the queue with a query that could match the channel's
+// key values. Each candidate still needs to check its query against the channel
+func (index *clientsWaitingIndex) forEachCandidate(queueName string, channelKeyValues datatypes.KeyValues, onClient func(client *clientWaiting)) {
+	queueClients, ok := index.queues[queueName]
+	if !ok {
+		return
+	}
+
+	// each client is only indexed by one key value, so no client is found twice
+	for key, value := range channelKeyValues {
+		for client := range queueClients.indexed[indexKeyValue{key: key, value: value}] {
+			onClient(client)
+		}
+	}
+
+	for client := range queueClients.unindexed {
+		onClient(client)
+	}
+}
+
+// len reports the number of clients waiting across all queues
+func (index *clientsWaitingIndex) len() int {
+	clients := map[*clientWaiting]struct{}{}
+	for queueName := range index.queues {
+		index.forEach(queueName, func(client *clientWaiting) {
+			clients[client] = struct{}{}
+		})
+	}
+
+	return len(clients)
+}
+
+// queryIndexKeyValue finds a key value that every channel matching the query must have. The first key in sorted order
+// that requires an exact value is used, so the same query is always indexed by the same key value
+func queryIndexKeyValue(query *queryassociatedaction.AssociatedActionQuery) (indexKeyValue, bool) {
+	// any of the Or queries could match a channel without the key value
+	if query == nil || query.Selection == nil || len(query.Or) != 0 {
+		return indexKeyValue{}, false
+	}
+
+	for _, key := range query.Selection.KeyValues.SortedKeys() {
+		valueQuery := query.Selection.KeyValues[key]
+
+		// channels can never have a T_any value, so an exact value must match the channel's value
+		if valueQuery.Comparison == v1common.Equals && datatypes.GeneralDataTypes[valueQuery.Value.Type] {
+			return indexKeyValue{key: key, value: valueQuery.Value}, true
+		}
+	}
+
+	return indexKeyValue{}, false
+}
@@ -0,0 +1,192 @@
+package queuechannels
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DanLavine/channelops"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+	"github.com/DanLavine/willow/testhelpers/testmodels"
+
+	v1 "github.com/DanLavine/willow/pkg/models/api/common/v1"
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+
+	. "github.com/onsi/gomega"
+)
+
+func newTestClientWaiting(queueQueries map[string]*queryassociatedaction.AssociatedActionQuery) *clientWaiting {
+	return &clientWaiting{queueQueries: queueQueries, deletedQueues: map[string]struct{}{}}
+}
+
+func candidates(index *clientsWaitingIndex, queueName string, channelKeyValues datatypes.KeyValues) []*clientWaiting {
+	clients := []*clientWaiting{}
+	index.forEachCandidate(queueName, channelKeyValues, func(client *clientWaiting) {
+		clients = append(clients, client)
+	})
+
+	return clients
+}
+
+func Test_queryIndexKeyValue(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It indexes an exact query by the first key in sorted order", func(t *testing.T) {
+		query := queryassociatedaction.KeyValuesToExactAssociatedActionQuery(datatypes.KeyValues{"b": datatypes.Int(2), "a": datatypes.String("1")})
+
+		keyValue, ok := queryIndexKeyValue(query)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(keyValue).To(Equal(indexKeyValue{key: "a", value: datatypes.String("1")}))
+	})
+
+	t.Run("It skips keys that do not require an exact value", func(t *testing.T) {
+		query := &queryassociatedaction.AssociatedActionQuery{
+			Selection: &queryassociatedaction.Selection{
+				KeyValues: queryassociatedaction.SelectionKeyValues{
+					"a": {Value: datatypes.Any(), Comparison: v1.Equals, TypeRestrictions: testmodels.NoTypeRestrictions(g)},
+					"b": {Value: datatypes.Int(1), Comparison: v1.NotEquals, TypeRestrictions: testmodels.NoTypeRestrictions(g)},
+					"c": {Value: datatypes.Int(3), Comparison: v1.Equals, TypeRestrictions: testmodels.NoTypeRestrictions(g)},
+				},
+			},
+		}
+		g.Expect(query.Validate()).ToNot(HaveOccurred())
+
+		keyValue, ok := queryIndexKeyValue(query)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(keyValue).To(Equal(indexKeyValue{key: "c", value: datatypes.Int(3)}))
+	})
+
+	t.Run("It does not index queries that can match any channel", func(t *testing.T) {
+		_, ok := queryIndexKeyValue(&queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(ok).To(BeFalse())
+
+		query := queryassociatedaction.KeyValuesToExactAssociatedActionQuery(datatypes.KeyValues{"a": datatypes.Int(1)})
+		query.Or = []*queryassociatedaction.AssociatedActionQuery{queryassociatedaction.KeyValuesToExactAssociatedActionQuery(datatypes.KeyValues{"b": datatypes.Int(1)})}
+
+		_, ok = queryIndexKeyValue(query)
+		g.Expect(ok).To(BeFalse())
+	})
+}
+
+func Test_clientsWaitingIndex(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It only returns candidates indexed by the channel's key values and unindexed clients", func(t *testing.T) {
+		index := newClientsWaitingIndex()
+
+		matching := newTestClientWaiting(map[string]*queryassociatedaction.AssociatedActionQuery{"queue": queryassociatedaction.KeyValuesToExactAssociatedActionQuery(datatypes.KeyValues{"a": datatypes.Int(1)})})
+		other := newTestClientWaiting(map[string]*queryassociatedaction.AssociatedActionQuery{"queue": queryassociatedaction.KeyValuesToExactAssociatedActionQuery(datatypes.KeyValues{"a": datatypes.Int(2)})})
+		anyChannel := newTestClientWaiting(map[string]*queryassociatedaction.AssociatedActionQuery{"queue": {}})
+		otherQueue := newTestClientWaiting(map[string]*queryassociatedaction.AssociatedActionQuery{"other queue": {}})
+
+		for _, client := range []*clientWaiting{matching, other, anyChannel, otherQueue} {
+			index.add(client)
+		}
+		g.Expect(index.len()).To(Equal(4))
+
+		g.Expect(candidates(index, "queue", datatypes.KeyValues{"a": datatypes.Int(1), "b": datatypes.Int(2)})).To(ConsistOf(matching, anyChannel))
+		g.Expect(candidates(index, "queue", datatypes.KeyValues{"a": datatypes.Int8(1)})).To(ConsistOf(anyChannel))
+		g.Expect(candidates(index, "not found", datatypes.KeyValues{"a": datatypes.Int(1)})).To(BeEmpty())
+	})
+
+	t.Run("It indexes clients waiting on multiple queues in each queue", func(t *testing.T) {
+		index := newClientsWaitingIndex()
+
+		client := newTestClientWaiting(map[string]*queryassociatedaction.AssociatedActionQuery{
+			"queue 1": queryassociatedaction.KeyValuesToExactAssociatedActionQuery(datatypes.KeyValues{"a": datatypes.Int(1)}),
+			"queue 2": {},
+		})
+		index.add(client)
+		g.Expect(index.len()).To(Equal(1))
+
+		g.Expect(candidates(index, "queue 1", datatypes.KeyValues{"a": datatypes.Int(1)})).To(ConsistOf(client))
+		g.Expect(candidates(index, "queue 2", datatypes.KeyValues{"b": datatypes.Int(1)})).To(ConsistOf(client))
+
+		clients := []*clientWaiting{}
+		index.forEach("queue 1", func(client *clientWaiting) { clients = append(clients, client) })
+		g.Expect(clients).To(ConsistOf(client))
+	})
+
+	t.Run("It removes the clients from every queue", func(t *testing.T) {
+		index := newClientsWaitingIndex()
+
+		client := newTestClientWaiting(map[string]*queryassociatedaction.AssociatedActionQuery{
+			"queue 1": queryassociatedaction.KeyValuesToExactAssociatedActionQuery(datatypes.KeyValues{"a": datatypes.Int(1)}),
+			"queue 2": {},
+		})
+		index.add(client)
+		index.remove(client)
+
+		g.Expect(index.len()).To(Equal(0))
+		g.Expect(index.queues).To(BeEmpty())
+	})
+}
+
+// setupBenchmarkClientsWaiting creates clients that are each waiting on their own channel in a single queue
+func setupBenchmarkClientsWaiting(b *testing.B, clients int) []*clientWaiting {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.Cleanup(cancel)
+
+	clientsWaiting := make([]*clientWaiting, 0, clients)
+	for i := 0; i < clients; i++ {
+		channelOps, _ := channelops.NewRepeatableMergeRead[func(ctx context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func())](false, ctx)
+		clientsWaiting = append(clientsWaiting, &clientWaiting{
+			queueQueries:  map[string]*queryassociatedaction.AssociatedActionQuery{"queue": queryassociatedaction.KeyValuesToExactAssociatedActionQuery(datatypes.KeyValues{"id": datatypes.Int(i), "type": datatypes.String("work")})},
+			channelOPS:    channelOps,
+			deletedQueues: map[string]struct{}{},
+		})
+	}
+
+	return clientsWaiting
+}
+
+// Benchmark finding the clients interested in a newly created channel when 10k clients are waiting on other channels
+func Benchmark_clientsWaiting_NewChannel(b *testing.B) {
+	clientsWaiting := setupBenchmarkClientsWaiting(b, 10_000)
+	channelKeyValues := datatypes.KeyValues{"id": datatypes.Int(-1), "type": datatypes.String("work")}
+
+	b.Run("linear scan", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, clientWaiting := range clientsWaiting {
+				if query, ok := clientWaiting.queueQueries["queue"]; ok && query.MatchTags(channelKeyValues) {
+					b.Fatal("no client should match the channel")
+				}
+			}
+		}
+	})
+
+	b.Run("indexed", func(b *testing.B) {
+		index := newClientsWaitingIndex()
+		for _, clientWaiting := range clientsWaiting {
+			index.add(clientWaiting)
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			index.forEachCandidate("queue", channelKeyValues, func(clientWaiting *clientWaiting) {
+				if clientWaiting.queueQueries["queue"].MatchTags(channelKeyValues) {
+					b.Fatal("no client should match the channel")
+				}
+			})
+		}
+	})
+}
+
+// Benchmark adding and removing a client while 10k clients are waiting
+func Benchmark_clientsWaiting_AddRemove(b *testing.B) {
+	clientsWaiting := setupBenchmarkClientsWaiting(b, 10_001)
+
+	index := newClientsWaitingIndex()
+	for _, clientWaiting := range clientsWaiting[1:] {
+		index.add(clientWaiting)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.add(clientsWaiting[0])
+		index.remove(clientsWaiting[0])
+	}
+}
@@ -13,6 +13,8 @@ import (
 	reflect "reflect"
 
 	constructor "github.com/DanLavine/willow/internal/willow/brokers/queue_channels/constructor"
+	fairshare "github.com/DanLavine/willow/internal/willow/brokers/queue_channels/fairshare"
+	mirror "github.com/DanLavine/willow/internal/willow/brokers/queue_channels/mirror"
 	datatypes "github.com/DanLavine/willow/pkg/models/datatypes"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -41,15 +43,15 @@ func (m *MockQueueChannelsConstrutor) EXPECT() *MockQueueChannelsConstrutorMockR
 }
 
 // New mocks base method.
-func (m *MockQueueChannelsConstrutor) New(arg0 func(), arg1 string, arg2 datatypes.KeyValues) constructor.QueueChannel {
+func (m *MockQueueChannelsConstrutor) New(arg0 func(), arg1 string, arg2 datatypes.KeyValues, arg3 *fairshare.Scheduler, arg4 *mirror.Mirror) constructor.QueueChannel {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "New", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "New", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(constructor.QueueChannel)
 	return ret0
 }
 
 // New indicates an expected call of New.
-func (mr *MockQueueChannelsConstrutorMockRecorder) New(arg0, arg1, arg2 any) *gomock.Call {
+func (mr *MockQueueChannelsConstrutorMockRecorder) New(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "New", reflect.TypeOf((*MockQueueChannelsConstrutor)(nil).New), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "New", reflect.TypeOf((*MockQueueChannelsConstrutor)(nil).New), arg0, arg1, arg2, arg3, arg4)
 }
@@ -13,8 +13,11 @@ import (
 	context "context"
 	reflect "reflect"
 
+	fairshare "github.com/DanLavine/willow/internal/willow/brokers/queue_channels/fairshare"
+	mirror "github.com/DanLavine/willow/internal/willow/brokers/queue_channels/mirror"
 	errors "github.com/DanLavine/willow/pkg/models/api/common/errors"
-	v1 "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+	v1 "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
+	v10 "github.com/DanLavine/willow/pkg/models/api/willow/v1"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -42,12 +45,13 @@ func (m *MockQueueChannel) EXPECT() *MockQueueChannelMockRecorder {
 }
 
 // ACK mocks base method.
-func (m *MockQueueChannel) ACK(arg0 context.Context, arg1 *v1.ACK) (bool, *errors.ServerError) {
+func (m *MockQueueChannel) ACK(arg0 context.Context, arg1 *v10.ACK) (bool, *v10.Item, *errors.ServerError) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ACK", arg0, arg1)
 	ret0, _ := ret[0].(bool)
-	ret1, _ := ret[1].(*errors.ServerError)
-	return ret0, ret1
+	ret1, _ := ret[1].(*v10.Item)
+	ret2, _ := ret[2].(*errors.ServerError)
+	return ret0, ret1, ret2
 }
 
 // ACK indicates an expected call of ACK.
@@ -56,6 +60,20 @@ func (mr *MockQueueChannelMockRecorder) ACK(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ACK", reflect.TypeOf((*MockQueueChannel)(nil).ACK), arg0, arg1)
 }
 
+// Cancel mocks base method.
+func (m *MockQueueChannel) Cancel(arg0 context.Context, arg1 *v10.Cancel) *errors.ServerError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", arg0, arg1)
+	ret0, _ := ret[0].(*errors.ServerError)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockQueueChannelMockRecorder) Cancel(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockQueueChannel)(nil).Cancel), arg0, arg1)
+}
+
 // Delete mocks base method.
 func (m *MockQueueChannel) Delete() bool {
 	m.ctrl.T.Helper()
@@ -70,11 +88,26 @@ func (mr *MockQueueChannelMockRecorder) Delete() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockQueueChannel)(nil).Delete))
 }
 
+// DeleteItems mocks base method.
+func (m *MockQueueChannel) DeleteItems(arg0 context.Context, arg1 *v10.ItemDelete) (bool, *errors.ServerError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteItems", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(*errors.ServerError)
+	return ret0, ret1
+}
+
+// DeleteItems indicates an expected call of DeleteItems.
+func (mr *MockQueueChannelMockRecorder) DeleteItems(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteItems", reflect.TypeOf((*MockQueueChannel)(nil).DeleteItems), arg0, arg1)
+}
+
 // Dequeue mocks base method.
-func (m *MockQueueChannel) Dequeue() <-chan func(context.Context) (*v1.Item, func(), func()) {
+func (m *MockQueueChannel) Dequeue() <-chan func(context.Context, map[string]string) (*v10.Item, func(), func()) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Dequeue")
-	ret0, _ := ret[0].(<-chan func(context.Context) (*v1.Item, func(), func()))
+	ret0, _ := ret[0].(<-chan func(context.Context, map[string]string) (*v10.Item, func(), func()))
 	return ret0
 }
 
@@ -84,12 +117,28 @@ func (mr *MockQueueChannelMockRecorder) Dequeue() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dequeue", reflect.TypeOf((*MockQueueChannel)(nil).Dequeue))
 }
 
+// Diagnose mocks base method.
+func (m *MockQueueChannel) Diagnose(arg0 context.Context) (*v10.ChannelDiagnosis, *errors.ServerError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Diagnose", arg0)
+	ret0, _ := ret[0].(*v10.ChannelDiagnosis)
+	ret1, _ := ret[1].(*errors.ServerError)
+	return ret0, ret1
+}
+
+// Diagnose indicates an expected call of Diagnose.
+func (mr *MockQueueChannelMockRecorder) Diagnose(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Diagnose", reflect.TypeOf((*MockQueueChannel)(nil).Diagnose), arg0)
+}
+
 // Enqueue mocks base method.
-func (m *MockQueueChannel) Enqueue(arg0 context.Context, arg1 *v1.Item) *errors.ServerError {
+func (m *MockQueueChannel) Enqueue(arg0 context.Context, arg1 *v10.Item) (*v10.ItemState, *errors.ServerError) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Enqueue", arg0, arg1)
-	ret0, _ := ret[0].(*errors.ServerError)
-	return ret0
+	ret0, _ := ret[0].(*v10.ItemState)
+	ret1, _ := ret[1].(*errors.ServerError)
+	return ret0, ret1
 }
 
 // Enqueue indicates an expected call of Enqueue.
@@ -112,6 +161,34 @@ func (mr *MockQueueChannelMockRecorder) Execute(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Execute", reflect.TypeOf((*MockQueueChannel)(nil).Execute), arg0)
 }
 
+// Export mocks base method.
+func (m *MockQueueChannel) Export(arg0 context.Context) *v10.ExportedChannel {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", arg0)
+	ret0, _ := ret[0].(*v10.ExportedChannel)
+	return ret0
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockQueueChannelMockRecorder) Export(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockQueueChannel)(nil).Export), arg0)
+}
+
+// FairShare mocks base method.
+func (m *MockQueueChannel) FairShare() fairshare.State {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FairShare")
+	ret0, _ := ret[0].(fairshare.State)
+	return ret0
+}
+
+// FairShare indicates an expected call of FairShare.
+func (mr *MockQueueChannelMockRecorder) FairShare() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FairShare", reflect.TypeOf((*MockQueueChannel)(nil).FairShare))
+}
+
 // ForceDelete mocks base method.
 func (m *MockQueueChannel) ForceDelete(arg0 context.Context) {
 	m.ctrl.T.Helper()
@@ -125,11 +202,12 @@ func (mr *MockQueueChannelMockRecorder) ForceDelete(arg0 any) *gomock.Call {
 }
 
 // Heartbeat mocks base method.
-func (m *MockQueueChannel) Heartbeat(arg0 context.Context, arg1 *v1.Heartbeat) *errors.ServerError {
+func (m *MockQueueChannel) Heartbeat(arg0 context.Context, arg1 *v10.Heartbeat) (*v10.HeartbeatResponse, *errors.ServerError) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Heartbeat", arg0, arg1)
-	ret0, _ := ret[0].(*errors.ServerError)
-	return ret0
+	ret0, _ := ret[0].(*v10.HeartbeatResponse)
+	ret1, _ := ret[1].(*errors.ServerError)
+	return ret0, ret1
 }
 
 // Heartbeat indicates an expected call of Heartbeat.
@@ -137,3 +215,57 @@ func (mr *MockQueueChannelMockRecorder) Heartbeat(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Heartbeat", reflect.TypeOf((*MockQueueChannel)(nil).Heartbeat), arg0, arg1)
 }
+
+// Import mocks base method.
+func (m *MockQueueChannel) Import(arg0 context.Context, arg1 []*v10.ExportedItem) *errors.ServerError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", arg0, arg1)
+	ret0, _ := ret[0].(*errors.ServerError)
+	return ret0
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockQueueChannelMockRecorder) Import(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockQueueChannel)(nil).Import), arg0, arg1)
+}
+
+// Items mocks base method.
+func (m *MockQueueChannel) Items(arg0 context.Context, arg1 *string) v10.Items {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Items", arg0, arg1)
+	ret0, _ := ret[0].(v10.Items)
+	return ret0
+}
+
+// Items indicates an expected call of Items.
+func (mr *MockQueueChannelMockRecorder) Items(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Items", reflect.TypeOf((*MockQueueChannel)(nil).Items), arg0, arg1)
+}
+
+// LimiterCounters mocks base method.
+func (m *MockQueueChannel) LimiterCounters(arg0 context.Context) v1.Counters {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LimiterCounters", arg0)
+	ret0, _ := ret[0].(v1.Counters)
+	return ret0
+}
+
+// LimiterCounters indicates an expected call of LimiterCounters.
+func (mr *MockQueueChannelMockRecorder) LimiterCounters(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LimiterCounters", reflect.TypeOf((*MockQueueChannel)(nil).LimiterCounters), arg0)
+}
+
+// SetMirror mocks base method.
+func (m *MockQueueChannel) SetMirror(arg0 *mirror.Mirror) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetMirror", arg0)
+}
+
+// SetMirror indicates an expected call of SetMirror.
+func (mr *MockQueueChannelMockRecorder) SetMirror(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMirror", reflect.TypeOf((*MockQueueChannel)(nil).SetMirror), arg0)
+}
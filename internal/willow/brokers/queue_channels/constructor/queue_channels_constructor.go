@@ -4,12 +4,17 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/DanLavine/willow/internal/heartbeater"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/fairshare"
 	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/memory"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/mirror"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/outbox"
 
 	"github.com/DanLavine/willow/pkg/models/api/common/errors"
 	"github.com/DanLavine/willow/pkg/models/datatypes"
 
 	limiterclient "github.com/DanLavine/willow/pkg/clients/limiter_client"
+	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
 	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
 )
 
@@ -23,25 +28,49 @@ type QueueChannel interface {
 
 	Execute(ctx context.Context) error
 
-	Enqueue(ctx context.Context, enqueueItem *v1willow.Item) *errors.ServerError
+	Enqueue(ctx context.Context, enqueueItem *v1willow.Item) (*v1willow.ItemState, *errors.ServerError)
 
-	Dequeue() <-chan func(ctx context.Context) (*v1willow.Item, func(), func())
+	Dequeue() <-chan func(ctx context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func())
 
-	ACK(ctx context.Context, ack *v1willow.ACK) (bool, *errors.ServerError)
+	ACK(ctx context.Context, ack *v1willow.ACK) (bool, *v1willow.Item, *errors.ServerError)
 
-	Heartbeat(ctx context.Context, heartbeat *v1willow.Heartbeat) *errors.ServerError
+	Heartbeat(ctx context.Context, heartbeat *v1willow.Heartbeat) (*v1willow.HeartbeatResponse, *errors.ServerError)
+
+	Cancel(ctx context.Context, cancel *v1willow.Cancel) *errors.ServerError
+
+	// delete all enqueued and processing items with the headers
+	DeleteItems(ctx context.Context, itemDelete *v1willow.ItemDelete) (bool, *errors.ServerError)
+
+	Items(ctx context.Context, itemID *string) v1willow.Items
+
+	FairShare() fairshare.State
+
+	// explain why items are not being dequeued from the channel
+	Diagnose(ctx context.Context) (*v1willow.ChannelDiagnosis, *errors.ServerError)
+
+	// export and import the channel's items in the order they will be dequeued
+	Export(ctx context.Context) *v1willow.ExportedChannel
+	Import(ctx context.Context, exportedItems []*v1willow.ExportedItem) *errors.ServerError
+
+	// report the Limiter counters for the items the channel holds
+	LimiterCounters(ctx context.Context) v1limiter.Counters
+
+	// replace the mirror that records all item changes. Nil when the queue is not mirrored
+	SetMirror(mirror *mirror.Mirror)
 }
 
 //go:generate mockgen -destination=constructorfakes/queue_channel_constructor_mock.go -package=constructorfakes github.com/DanLavine/willow/internal/willow/brokers/queue_channels/constructor QueueChannelsConstrutor
 type QueueChannelsConstrutor interface {
-	New(deleteCallback func(), queueName string, channelKeyValues datatypes.KeyValues) QueueChannel
+	New(deleteCallback func(), queueName string, channelKeyValues datatypes.KeyValues, scheduler *fairshare.Scheduler, mirror *mirror.Mirror) QueueChannel
 }
 
-func NewQueueChannelConstructor(constructorType string, limiterClient limiterclient.LimiterClient) (QueueChannelsConstrutor, error) {
+func NewQueueChannelConstructor(constructorType string, heartbeats *heartbeater.Manager, limiterClient limiterclient.LimiterClient, limiterOutbox *outbox.Outbox) (QueueChannelsConstrutor, error) {
 	switch constructorType {
 	case "memory":
 		return &memoryConstructor{
+			heartbeats:    heartbeats,
 			limiterClient: limiterClient,
+			limiterOutbox: limiterOutbox,
 		}, nil
 	default:
 		return nil, fmt.Errorf("unkown constructor type")
@@ -50,9 +79,11 @@ func NewQueueChannelConstructor(constructorType string, limiterClient limitercli
 
 // memory constructor
 type memoryConstructor struct {
+	heartbeats    *heartbeater.Manager
 	limiterClient limiterclient.LimiterClient
+	limiterOutbox *outbox.Outbox
 }
 
-func (mc *memoryConstructor) New(deleteCallback func(), queueName string, channelKeyValues datatypes.KeyValues) QueueChannel {
-	return memory.New(mc.limiterClient, deleteCallback, queueName, channelKeyValues)
+func (mc *memoryConstructor) New(deleteCallback func(), queueName string, channelKeyValues datatypes.KeyValues, scheduler *fairshare.Scheduler, mirror *mirror.Mirror) QueueChannel {
+	return memory.New(mc.heartbeats, mc.limiterClient, mc.limiterOutbox, deleteCallback, queueName, channelKeyValues, scheduler, mirror)
 }
@@ -0,0 +1,231 @@
+package fairshare
+
+import (
+	"sync"
+
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+)
+
+// maxSkips is how many times in a row a channel can be refused before it is allowed to dequeue anyways. This
+// ensures clients that can only read from a channel without any deficit are not starved
+const maxSkips = 8
+
+// Scheduler uses deficit round robin to hand out items approximately in proportion to the weight of each
+// channel in a queue. Each round, every channel with enqueued items has its deficit increased by its weight
+// and each dequeue from a channel consumes 1 of its deficit. A new round starts when none of the channels
+// with enqueued items have any deficit left.
+//
+// The Scheduler also holds the queue's PriorityAging policy, used by each channel to order its own items
+type Scheduler struct {
+	lock *sync.Mutex
+
+	weights  []*v1willow.ChannelWeight
+	channels map[*Channel]struct{}
+
+	priorityAging *v1willow.PriorityAging
+}
+
+// Channel is a single channel's record in the Scheduler
+type Channel struct {
+	scheduler *Scheduler
+
+	keyValues datatypes.KeyValues
+	enqueued  func() int
+
+	weight  int64
+	deficit int64
+	skips   int
+
+	// set when the last Take consumed a deficit that can be refunded
+	charged bool
+}
+
+// State of a single channel in the Scheduler
+type State struct {
+	Weight  int64
+	Deficit int64
+}
+
+//	PARAMETERS:
+//	- weights - weights for any channels that match the queries. Can be nil to give all channels the same weight
+//
+//	RETURNS:
+//	- *Scheduler - scheduler for a single queue's channels
+//
+// New creates a fair share scheduler for a queue's channels
+func New(weights []*v1willow.ChannelWeight) *Scheduler {
+	return &Scheduler{
+		lock:     new(sync.Mutex),
+		weights:  weights,
+		channels: map[*Channel]struct{}{},
+	}
+}
+
+//	PARAMETERS:
+//	- weights - weights for any channels that match the queries. Can be nil to give all channels the same weight
+//
+// SetWeights updates the weights for all channels and starts a new round
+func (s *Scheduler) SetWeights(weights []*v1willow.ChannelWeight) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.weights = weights
+	for channel := range s.channels {
+		channel.weight = s.weightFor(channel.keyValues)
+		channel.deficit = 0
+		channel.skips = 0
+		channel.charged = false
+	}
+}
+
+//	PARAMETERS:
+//	- priorityAging - policy to raise the priority of waiting items. Can be nil to never raise priorities
+//
+// SetPriorityAging updates the priority aging policy for all channels
+func (s *Scheduler) SetPriorityAging(priorityAging *v1willow.PriorityAging) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.priorityAging = priorityAging
+}
+
+//	PARAMETERS:
+//	- keyValues - key values that define the channel
+//	- enqueued - callback to report the number of items enqueued for the channel. Must not call the Scheduler
+//
+//	RETURNS:
+//	- *Channel - channel's record to check dequeues against
+//
+// Register adds a channel to the Scheduler
+func (s *Scheduler) Register(keyValues datatypes.KeyValues, enqueued func() int) *Channel {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	channel := &Channel{
+		scheduler: s,
+		keyValues: keyValues,
+		enqueued:  enqueued,
+		weight:    s.weightFor(keyValues),
+	}
+	s.channels[channel] = struct{}{}
+
+	return channel
+}
+
+// weightFor returns the first matching weight for the key values. Must hold the lock
+func (s *Scheduler) weightFor(keyValues datatypes.KeyValues) int64 {
+	for _, channelWeight := range s.weights {
+		if channelWeight.ChannelQuery.MatchTags(keyValues) {
+			return *channelWeight.Weight
+		}
+	}
+
+	return 1
+}
+
+//	RETURNS:
+//	- bool - true if the channel can dequeue an item now
+//
+// Take is called when a channel is about to dequeue an item. When it returns false, another channel with
+// enqueued items has a larger share of the current round and should be dequeued from first
+func (c *Channel) Take() bool {
+	s := c.scheduler
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	c.charged = false
+	if len(s.weights) == 0 {
+		return true
+	}
+
+	if c.deficit >= 1 {
+		c.deficit--
+		c.skips = 0
+		c.charged = true
+		return true
+	}
+
+	// check to see if any other channels still have a share of the current round
+	othersHaveDeficit := false
+	for channel := range s.channels {
+		if channel == c {
+			continue
+		}
+
+		if channel.enqueued() == 0 {
+			// channels without any items don't keep their share of the round
+			channel.deficit = 0
+			continue
+		}
+
+		if channel.deficit >= 1 {
+			othersHaveDeficit = true
+		}
+	}
+
+	if othersHaveDeficit {
+		if c.skips < maxSkips {
+			c.skips++
+			return false
+		}
+
+		// allow the dequeue without taking away from the next round
+		c.skips = 0
+		return true
+	}
+
+	// start a new round for every channel that has items
+	for channel := range s.channels {
+		if channel == c || channel.enqueued() != 0 {
+			channel.deficit += channel.weight
+		}
+	}
+
+	c.deficit--
+	c.skips = 0
+	c.charged = true
+	return true
+}
+
+// Refund is called when a dequeue allowed by Take failed, to give back the channel's share of the round
+func (c *Channel) Refund() {
+	c.scheduler.lock.Lock()
+	defer c.scheduler.lock.Unlock()
+
+	if c.charged {
+		c.deficit++
+		c.charged = false
+	}
+}
+
+// Remove the channel from the Scheduler when the channel is deleted
+func (c *Channel) Remove() {
+	c.scheduler.lock.Lock()
+	defer c.scheduler.lock.Unlock()
+
+	delete(c.scheduler.channels, c)
+}
+
+//	RETURNS:
+//	- *v1willow.PriorityAging - the queue's priority aging policy. Nil when priorities are never raised
+//
+// PriorityAging returns the policy used to order the channel's items
+func (c *Channel) PriorityAging() *v1willow.PriorityAging {
+	c.scheduler.lock.Lock()
+	defer c.scheduler.lock.Unlock()
+
+	return c.scheduler.priorityAging
+}
+
+//	RETURNS:
+//	- State - current weight and deficit of the channel
+//
+// State reports the channel's current weight and deficit
+func (c *Channel) State() State {
+	c.scheduler.lock.Lock()
+	defer c.scheduler.lock.Unlock()
+
+	return State{Weight: c.weight, Deficit: c.deficit}
+}
@@ -0,0 +1,172 @@
+package fairshare
+
+import (
+	"testing"
+
+	"github.com/DanLavine/willow/internal/helpers"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+	"github.com/DanLavine/willow/testhelpers/testmodels"
+
+	. "github.com/onsi/gomega"
+
+	v1 "github.com/DanLavine/willow/pkg/models/api/common/v1"
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+)
+
+func teamWeight(g *GomegaWithT, team string, weight int64) *v1willow.ChannelWeight {
+	channelWeight := &v1willow.ChannelWeight{
+		ChannelQuery: &queryassociatedaction.AssociatedActionQuery{
+			Selection: &queryassociatedaction.Selection{
+				KeyValues: queryassociatedaction.SelectionKeyValues{
+					"team": {
+						Value:            datatypes.String(team),
+						Comparison:       v1.Equals,
+						TypeRestrictions: testmodels.NoTypeRestrictions(g),
+					},
+				},
+			},
+		},
+		Weight: helpers.PointerOf(weight),
+	}
+	g.Expect(channelWeight.Validate()).ToNot(HaveOccurred())
+
+	return channelWeight
+}
+
+func Test_Scheduler_Register(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It uses the first matching weight", func(t *testing.T) {
+		scheduler := New([]*v1willow.ChannelWeight{teamWeight(g, "a", 3), teamWeight(g, "a", 5)})
+
+		channel := scheduler.Register(datatypes.KeyValues{"team": datatypes.String("a")}, func() int { return 1 })
+		g.Expect(channel.State()).To(Equal(State{Weight: 3, Deficit: 0}))
+	})
+
+	t.Run("It defaults to a weight of 1 when no weights match", func(t *testing.T) {
+		scheduler := New([]*v1willow.ChannelWeight{teamWeight(g, "a", 3)})
+
+		channel := scheduler.Register(datatypes.KeyValues{"team": datatypes.String("b")}, func() int { return 1 })
+		g.Expect(channel.State()).To(Equal(State{Weight: 1, Deficit: 0}))
+	})
+}
+
+func Test_Scheduler_SetWeights(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It updates the weights and resets the deficits for all channels", func(t *testing.T) {
+		scheduler := New(nil)
+
+		channel := scheduler.Register(datatypes.KeyValues{"team": datatypes.String("a")}, func() int { return 1 })
+		g.Expect(channel.State()).To(Equal(State{Weight: 1, Deficit: 0}))
+
+		scheduler.SetWeights([]*v1willow.ChannelWeight{teamWeight(g, "a", 3)})
+		g.Expect(channel.Take()).To(BeTrue())
+		g.Expect(channel.State()).To(Equal(State{Weight: 3, Deficit: 2}))
+
+		scheduler.SetWeights([]*v1willow.ChannelWeight{teamWeight(g, "a", 2)})
+		g.Expect(channel.State()).To(Equal(State{Weight: 2, Deficit: 0}))
+	})
+}
+
+func Test_Channel_Take(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It always allows dequeues when there are no weights", func(t *testing.T) {
+		scheduler := New(nil)
+
+		channelA := scheduler.Register(datatypes.KeyValues{"team": datatypes.String("a")}, func() int { return 1 })
+		_ = scheduler.Register(datatypes.KeyValues{"team": datatypes.String("b")}, func() int { return 1 })
+
+		for i := 0; i < 20; i++ {
+			g.Expect(channelA.Take()).To(BeTrue())
+		}
+	})
+
+	t.Run("It hands out dequeues in proportion to the weights", func(t *testing.T) {
+		scheduler := New([]*v1willow.ChannelWeight{teamWeight(g, "a", 3), teamWeight(g, "b", 1)})
+
+		channelA := scheduler.Register(datatypes.KeyValues{"team": datatypes.String("a")}, func() int { return 1 })
+		channelB := scheduler.Register(datatypes.KeyValues{"team": datatypes.String("b")}, func() int { return 1 })
+
+		// alternate between asking each channel, like a client with both channels ready would
+		dequeued := map[*Channel]int{}
+		for attempt := 0; dequeued[channelA]+dequeued[channelB] < 80; attempt++ {
+			channel := channelA
+			if attempt%2 == 1 {
+				channel = channelB
+			}
+
+			if channel.Take() {
+				dequeued[channel]++
+			}
+		}
+
+		g.Expect(dequeued[channelA]).To(BeNumerically("~", 60, 2))
+		g.Expect(dequeued[channelB]).To(BeNumerically("~", 20, 2))
+	})
+
+	t.Run("It does not wait for channels that have no enqueued items", func(t *testing.T) {
+		scheduler := New([]*v1willow.ChannelWeight{teamWeight(g, "a", 3), teamWeight(g, "b", 1)})
+
+		enqueuedA := 1
+		channelA := scheduler.Register(datatypes.KeyValues{"team": datatypes.String("a")}, func() int { return enqueuedA })
+		channelB := scheduler.Register(datatypes.KeyValues{"team": datatypes.String("b")}, func() int { return 1 })
+
+		g.Expect(channelB.Take()).To(BeTrue())
+		g.Expect(channelA.State()).To(Equal(State{Weight: 3, Deficit: 3}))
+
+		// channel a has a deficit, but no items so channel b starts a new round
+		enqueuedA = 0
+		g.Expect(channelB.Take()).To(BeTrue())
+		g.Expect(channelA.State()).To(Equal(State{Weight: 3, Deficit: 0}))
+		g.Expect(channelB.State()).To(Equal(State{Weight: 1, Deficit: 0}))
+	})
+
+	t.Run("It allows a refused channel to dequeue after too many skips", func(t *testing.T) {
+		scheduler := New([]*v1willow.ChannelWeight{teamWeight(g, "a", 3), teamWeight(g, "b", 1)})
+
+		_ = scheduler.Register(datatypes.KeyValues{"team": datatypes.String("a")}, func() int { return 1 })
+		channelB := scheduler.Register(datatypes.KeyValues{"team": datatypes.String("b")}, func() int { return 1 })
+
+		g.Expect(channelB.Take()).To(BeTrue())
+		for i := 0; i < maxSkips; i++ {
+			g.Expect(channelB.Take()).To(BeFalse())
+		}
+		g.Expect(channelB.Take()).To(BeTrue())
+	})
+}
+
+func Test_Channel_Refund(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It gives back the deficit consumed by the last Take", func(t *testing.T) {
+		scheduler := New([]*v1willow.ChannelWeight{teamWeight(g, "a", 3)})
+
+		channel := scheduler.Register(datatypes.KeyValues{"team": datatypes.String("a")}, func() int { return 1 })
+		g.Expect(channel.Take()).To(BeTrue())
+		g.Expect(channel.State()).To(Equal(State{Weight: 3, Deficit: 2}))
+
+		channel.Refund()
+		channel.Refund()
+		g.Expect(channel.State()).To(Equal(State{Weight: 3, Deficit: 3}))
+	})
+}
+
+func Test_Channel_Remove(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It no longer waits on removed channels", func(t *testing.T) {
+		scheduler := New([]*v1willow.ChannelWeight{teamWeight(g, "a", 3), teamWeight(g, "b", 1)})
+
+		channelA := scheduler.Register(datatypes.KeyValues{"team": datatypes.String("a")}, func() int { return 1 })
+		channelB := scheduler.Register(datatypes.KeyValues{"team": datatypes.String("b")}, func() int { return 1 })
+
+		g.Expect(channelB.Take()).To(BeTrue())
+		g.Expect(channelB.Take()).To(BeFalse())
+
+		channelA.Remove()
+		g.Expect(channelB.Take()).To(BeTrue())
+	})
+}
@@ -5,15 +5,18 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/DanLavine/goasync"
 	"github.com/DanLavine/gonotify"
 	"github.com/DanLavine/willow/internal/datastructures/btree"
-	"github.com/DanLavine/willow/internal/helpers"
+	"github.com/DanLavine/willow/internal/heartbeater"
 	"github.com/DanLavine/willow/internal/idgenerator"
 	"github.com/DanLavine/willow/internal/middleware"
 	"github.com/DanLavine/willow/internal/reporting"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/fairshare"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/mirror"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/outbox"
 	"github.com/DanLavine/willow/pkg/models/api/common/errors"
 	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
 	querymatchaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_match_action"
@@ -27,8 +30,8 @@ import (
 )
 
 type memoryQueueChannel struct {
-	// heartbeat manager to run the tasks async
-	asyncManager goasync.AsyncTaskManager
+	// shared heartbeat manager that times out any processing items
+	heartbeats *heartbeater.Manager
 
 	// callback to delete this channel from the channel client's perspective
 	deleteChan     chan struct{}
@@ -44,22 +47,36 @@ type memoryQueueChannel struct {
 	// 2. dequeuing to ensure the user didn't set up some limit
 	limiterClient limiterclient.LimiterClient
 
+	// all counter updates go through the outbox, so releasing counters is retried when the Limiter is unavailable
+	limiterOutbox *outbox.Outbox
+
 	// notifieer is used to indicate that there is something to process
 	notifier *gonotify.Notify
 
 	// channel all items will be dequeued from
-	dequeueChan         chan func(ctx context.Context) (*v1willow.Item, func(), func()) // func() (*v1willow.DequeueQueueItem, func(), func())
-	dequeueResponseChan chan bool                                                       // indicates if there is an issue with the limiter on the last request. need to wait for this to be lower
+	dequeueChan         chan func(ctx context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func()) // func() (*v1willow.DequeueQueueItem, func(), func())
+	dequeueResponseChan chan bool                                                                                        // indicates if there is an issue with the limiter on the last request. need to wait for this to be lower
 
 	// all the saved items are a QueueItem
 	idGenerator idgenerator.UniqueIDs
 	items       btree.BTree
 
-	itemsLock       *sync.RWMutex
-	itemIDsEnqueued []string
+	// items waiting to be dequeued in order. Guarded by the itemsLock
+	itemsLock     *sync.RWMutex
+	itemsEnqueued *enqueuedItems
+
+	// DedupKey -> ID for items that are still enqueued. Guarded by the itemsLock
+	dedupItemIDs map[string]string
+
+	// fair share record for the channel in the queue's scheduler. Nil when there is no scheduler
+	fairShare *fairshare.Channel
+
+	// records all item changes to send to another Willow service. Nil when there is no mirror. Replaced
+	// when the queue's mirror is updated
+	mirror atomic.Pointer[mirror.Mirror]
 }
 
-func New(limiterClient limiterclient.LimiterClient, deleteCallback func(), queueName string, channelKeyValues datatypes.KeyValues) *memoryQueueChannel {
+func New(heartbeats *heartbeater.Manager, limiterClient limiterclient.LimiterClient, limiterOutbox *outbox.Outbox, deleteCallback func(), queueName string, channelKeyValues datatypes.KeyValues, scheduler *fairshare.Scheduler, mirror *mirror.Mirror) *memoryQueueChannel {
 	tree, err := btree.NewThreadSafe(2)
 	if err != nil {
 		panic(err)
@@ -69,8 +86,8 @@ func New(limiterClient limiterclient.LimiterClient, deleteCallback func(), queue
 		panic("delete callback can not be nil")
 	}
 
-	return &memoryQueueChannel{
-		asyncManager: goasync.NewTaskManager(goasync.RelaxedConfig()),
+	mqc := &memoryQueueChannel{
+		heartbeats: heartbeats,
 
 		deleteChan:     make(chan struct{}),
 		deleteOnce:     new(sync.Once),
@@ -80,16 +97,53 @@ func New(limiterClient limiterclient.LimiterClient, deleteCallback func(), queue
 		channelKeyValues: channelKeyValues,
 
 		limiterClient:       limiterClient,
+		limiterOutbox:       limiterOutbox,
 		notifier:            gonotify.New(),
-		dequeueChan:         make(chan func(ctx context.Context) (*v1willow.Item, func(), func())),
+		dequeueChan:         make(chan func(ctx context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func())),
 		dequeueResponseChan: make(chan bool),
 
 		idGenerator: idgenerator.UUID(),
 		items:       tree,
 
-		itemsLock:       new(sync.RWMutex),
-		itemIDsEnqueued: []string{},
+		itemsLock:     new(sync.RWMutex),
+		itemsEnqueued: newEnqueuedItems(),
+		dedupItemIDs:  map[string]string{},
+	}
+	mqc.mirror.Store(mirror)
+
+	if scheduler != nil {
+		mqc.fairShare = scheduler.Register(channelKeyValues, mqc.enqueuedCount)
+	}
+
+	return mqc
+}
+
+// enqueuedCount reports the number of items waiting to be dequeued
+func (mqc *memoryQueueChannel) enqueuedCount() int {
+	mqc.itemsLock.RLock()
+	defer mqc.itemsLock.RUnlock()
+
+	return mqc.itemsEnqueued.Len()
+}
+
+//	RETURNS:
+//	- fairshare.State - the channel's weight and deficit in the queue's fair share scheduler
+//
+// FairShare reports the channel's state in the queue's fair share scheduler
+func (mqc *memoryQueueChannel) FairShare() fairshare.State {
+	if mqc.fairShare == nil {
+		return fairshare.State{Weight: 1}
 	}
+
+	return mqc.fairShare.State()
+}
+
+//	PARAMETERS:
+//	- queueMirror - mirror to record all item changes to. Nil when the queue is no longer mirrored
+//
+// SetMirror replaces the mirror when the queue's mirror is set or removed after the channel was created
+func (mqc *memoryQueueChannel) SetMirror(queueMirror *mirror.Mirror) {
+	mqc.mirror.Store(queueMirror)
 }
 
 // this is write loked from the client in a "Destroy" call
@@ -97,6 +151,7 @@ func (mqc *memoryQueueChannel) Delete() bool {
 	if mqc.items.Empty() {
 		mqc.deleteOnce.Do(func() {
 			close(mqc.deleteChan)
+			mqc.removeFairShare()
 		})
 
 		return true
@@ -105,24 +160,27 @@ func (mqc *memoryQueueChannel) Delete() bool {
 	return false
 }
 
+// removeFairShare stops the queue's scheduler from tracking this channel
+func (mqc *memoryQueueChannel) removeFairShare() {
+	if mqc.fairShare != nil {
+		mqc.fairShare.Remove()
+	}
+}
+
 // force delete is used when a channel is being destroyed and we do not care about the channel being empty.
 // in this case, the channel should always just be destroyed
 func (mqc *memoryQueueChannel) ForceDelete(ctx context.Context) {
-	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "ForceDelete")
+	ctx, _ = middleware.GetNamedMiddlewareLogger(ctx, "ForceDelete")
 
 	// stop processing on this channel
 	mqc.deleteOnce.Do(func() {
 		close(mqc.deleteChan)
+		mqc.removeFairShare()
 	})
 
 	// delete the running and enqueued counters
-	if err := mqc.setLimiterEnqueuedValue(ctx); err != nil {
-		logger.Fatal("TODO fix this panic error with a queue of Limiter values to retry", zap.Error(err))
-	}
-
-	if err := mqc.setLimterRunningValue(ctx); err != nil {
-		logger.Fatal("TODO fix this panic error with a queue of Limiter values to retry", zap.Error(err))
-	}
+	mqc.setLimiterEnqueuedValue(ctx)
+	mqc.setLimterRunningValue(ctx)
 
 	canDelete := func(key datatypes.EncapsulatedValue, treeItem any) bool {
 		queueItem := treeItem.(*item)
@@ -141,16 +199,6 @@ func (mqc *memoryQueueChannel) ForceDelete(ctx context.Context) {
 
 // Handler for the GoAsync manager to ensure this process stops processing when the server is shutdown
 func (mqc *memoryQueueChannel) Execute(ctx context.Context) error {
-	// run our own async manager for the heartbeats
-	asyncCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	stoppedHeartbeating := make(chan struct{})
-	go func() {
-		defer close(stoppedHeartbeating)
-		_ = mqc.asyncManager.Run(asyncCtx)
-	}()
-
 	for {
 		select {
 		case <-ctx.Done():
@@ -158,7 +206,6 @@ func (mqc *memoryQueueChannel) Execute(ctx context.Context) error {
 			goto BREAK_DEQUEUE
 		case <-mqc.deleteChan:
 			// delete of the channel itself because there are no more items, or the queue is being destroyed
-			cancel()
 			goto BREAK_DEQUEUE
 		case <-mqc.notifier.Ready():
 			// we received an item to enqueue, so try to send on this channel
@@ -170,7 +217,6 @@ func (mqc *memoryQueueChannel) Execute(ctx context.Context) error {
 			goto BREAK_DEQUEUE
 		case <-mqc.deleteChan:
 			// delete of the channel itself because there are no more items, or the queue is being destroyed
-			cancel()
 			goto BREAK_DEQUEUE
 		case mqc.dequeueChan <- mqc.dequeue:
 			// sent an item that is going to be dequeued by a client
@@ -190,18 +236,20 @@ func (mqc *memoryQueueChannel) Execute(ctx context.Context) error {
 				}
 
 				for {
-					// find any rules we might be at the limit for
+					// find any rules we might be at the limit for. When the Limiter can't be reached, treat
+					// the channel as still at the limit and check again
+					underLimit := true
 					rules, err := mqc.limiterClient.MatchRules(context.Background(), querymatchaction.KeyValuesToAnyMatchActionQuery(erroredKeyValues))
 					if err != nil {
-						panic(err)
+						underLimit = false
 					}
 
 					// for each rule, query the counters to know if there is an issue
-					underLimit := true
 					for _, rule := range rules {
 						overrides, err := mqc.limiterClient.MatchOverrides(context.Background(), rule.State.ID, querymatchaction.KeyValuesToAnyMatchActionQuery(erroredKeyValues))
 						if err != nil {
-							panic(err)
+							underLimit = false
+							break
 						}
 
 						switch len(overrides) {
@@ -239,7 +287,8 @@ func (mqc *memoryQueueChannel) Execute(ctx context.Context) error {
 
 							counters, err := mqc.limiterClient.QueryCounters(context.Background(), query)
 							if err != nil {
-								panic(err)
+								underLimit = false
+								break
 							}
 
 							totalCount := int64(0)
@@ -285,7 +334,8 @@ func (mqc *memoryQueueChannel) Execute(ctx context.Context) error {
 
 								counters, err := mqc.limiterClient.QueryCounters(context.Background(), query)
 								if err != nil {
-									panic(err)
+									underLimit = false
+									break
 								}
 
 								totalCount := int64(0)
@@ -317,7 +367,6 @@ func (mqc *memoryQueueChannel) Execute(ctx context.Context) error {
 						goto BREAK_DEQUEUE
 					case <-mqc.deleteChan:
 						// delete of the channel itself because there are no more items, or the queue is being destroyed
-						cancel()
 						goto BREAK_DEQUEUE
 					case <-time.After(5 * time.Second):
 					}
@@ -331,85 +380,221 @@ BREAK_DEQUEUE:
 	close(mqc.dequeueChan)
 	mqc.notifier.ForceStop()
 
-	// wait for any heartbeat operations to finish
-	<-stoppedHeartbeating
-
 	return nil
 }
 
-// Try to Enqueue an item and record on the limiter what is being saved
-func (mqc *memoryQueueChannel) Enqueue(ctx context.Context, enqueueItem *v1willow.Item) *errors.ServerError {
+// Try to Enqueue an item and record on the limiter what is being saved. On success, the state of the
+// item that was created or updated is returned
+func (mqc *memoryQueueChannel) Enqueue(ctx context.Context, enqueueItem *v1willow.Item) (*v1willow.ItemState, *errors.ServerError) {
 	ctx, _ = middleware.GetNamedMiddlewareLogger(ctx, "Enqueue")
 
 	mqc.itemsLock.Lock() // need this lock so multiple enqueue requests can all be squashed into 1
 	defer mqc.itemsLock.Unlock()
 
-	lastItemIndex := len(mqc.itemIDsEnqueued) - 1
+	itemState, err := mqc.enqueue(ctx, enqueueItem)
+	if err != nil {
+		return nil, err
+	}
 
-	// attempt to update the last item enqueued
-	if lastItemIndex >= 0 {
-		lastItemID := mqc.itemIDsEnqueued[lastItemIndex]
+	// record the change while holding the lock, so the mirror receives all changes in order
+	mqc.mirror.Load().Enqueued(mqc.channelKeyValues, itemState.ID, enqueueItem)
 
+	return itemState, nil
+}
+
+// enqueue creates or updates an item. Must hold the itemsLock
+func (mqc *memoryQueueChannel) enqueue(ctx context.Context, enqueueItem *v1willow.Item) (*v1willow.ItemState, *errors.ServerError) {
+	// attempt to replace an enqueued item with the same dedup key
+	if itemState := mqc.replaceDedupItem(enqueueItem); itemState != nil {
+		return itemState, nil
+	}
+
+	// attempt to update the last item enqueued
+	if lastItemID, lastItem, ok := mqc.itemsEnqueued.Back(); ok {
 		updated := false
-		onFind := func(key datatypes.EncapsulatedValue, treeItem any) bool {
-			queueItem := treeItem.(*item)
-			queueItem.lock.Lock()
-			defer queueItem.lock.Unlock()
+		func() {
+			lastItem.lock.Lock()
+			defer lastItem.lock.Unlock()
 
 			// update the last item
-			if queueItem.updateable {
+			if lastItem.updateable {
 				updated = true
 
-				queueItem.data = enqueueItem.Spec.Properties.Data
-				queueItem.updateable = *enqueueItem.Spec.Properties.Updateable
-				queueItem.maxRetryAttempts = *enqueueItem.Spec.Properties.RetryAttempts
-				queueItem.retryPosition = *enqueueItem.Spec.Properties.RetryPosition
-				queueItem.retryCount = 0
+				// the item's dedup key can change with the update
+				mqc.removeDedupKey(lastItemID, lastItem.dedupKey)
+				lastItem.dedupKey = dedupKey(enqueueItem)
+				mqc.addDedupKey(lastItemID, lastItem.dedupKey)
+
+				lastItem.data = enqueueItem.Spec.Properties.Data
+				lastItem.headers = enqueueItem.Spec.Properties.Headers
+				lastItem.updateable = *enqueueItem.Spec.Properties.Updateable
+				lastItem.maxRetryAttempts = *enqueueItem.Spec.Properties.RetryAttempts
+				lastItem.retryPosition = *enqueueItem.Spec.Properties.RetryPosition
+				lastItem.maxRunDuration = maxRunDuration(enqueueItem)
+				lastItem.idempotencyKey = idempotencyKey(enqueueItem)
+				lastItem.priority = priority(enqueueItem)
+				lastItem.retryCount = 0
 			}
-
-			return false
-		}
-
-		// try to update the last item enqueud
-		if err := mqc.items.Find(datatypes.String(lastItemID), v1common.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onFind); err != nil {
-			panic(err)
-		}
+		}()
 
 		if updated {
-			return nil
+			mqc.itemsEnqueued.UpdatePriority(lastItemID)
+			return &v1willow.ItemState{ID: lastItemID}, nil
 		}
 	}
 
 	// need to create the new item and append it to the list
-
 	// ensure the limits are not reached
 	if err := mqc.limiterUpdateEnqueuedValue(ctx, 1); err != nil {
-		return err
+		return nil, err
 	}
 
 	// create the new item in the channel
 	newId := mqc.idGenerator.ID()
+	newItem := newEnqueuedItem(enqueueItem)
 	onCreate := func() any {
-		return newItem(
-			enqueueItem.Spec.Properties.Data,
-			*enqueueItem.Spec.Properties.Updateable,
-			*enqueueItem.Spec.Properties.RetryAttempts,
-			*enqueueItem.Spec.Properties.RetryPosition,
-			*enqueueItem.Spec.Properties.TimeoutDuration,
-		)
+		return newItem
 	}
 
 	if err := mqc.items.Create(datatypes.String(newId), onCreate); err != nil {
 		panic(err)
 	}
 
-	// add the item id to the list of processing items
-	mqc.itemIDsEnqueued = append(mqc.itemIDsEnqueued, newId)
+	// add the item to the back of the enqueued items
+	mqc.itemsEnqueued.PushBack(newId, newItem)
+	mqc.addDedupKey(newId, dedupKey(enqueueItem))
 
 	// signal to the notifier that we have something to process
 	_ = mqc.notifier.Add() // in the case of an error we are shutting down so just drop it
 
-	return nil
+	return &v1willow.ItemState{ID: newId}, nil
+}
+
+// newEnqueuedItem creates the item to save for an enqueue request
+func newEnqueuedItem(enqueueItem *v1willow.Item) *item {
+	queueItem := newItem(
+		enqueueItem.Spec.Properties.Data,
+		*enqueueItem.Spec.Properties.Updateable,
+		*enqueueItem.Spec.Properties.RetryAttempts,
+		*enqueueItem.Spec.Properties.RetryPosition,
+		*enqueueItem.Spec.Properties.TimeoutDuration,
+		maxRunDuration(enqueueItem),
+	)
+	queueItem.headers = enqueueItem.Spec.Properties.Headers
+	queueItem.idempotencyKey = idempotencyKey(enqueueItem)
+	queueItem.dedupKey = dedupKey(enqueueItem)
+	queueItem.priority = priority(enqueueItem)
+
+	return queueItem
+}
+
+// priority returns the optional priority for an item, where 0 is the default
+func priority(enqueueItem *v1willow.Item) int64 {
+	if enqueueItem.Spec.Properties.Priority == nil {
+		return 0
+	}
+
+	return *enqueueItem.Spec.Properties.Priority
+}
+
+// maxRunDuration returns the optional max run duration for an item, where 0 means there is no limit
+func maxRunDuration(enqueueItem *v1willow.Item) time.Duration {
+	if enqueueItem.Spec.Properties.MaxRunDuration == nil {
+		return 0
+	}
+
+	return *enqueueItem.Spec.Properties.MaxRunDuration
+}
+
+//	RETURNS:
+//	- *v1willow.ItemState - state of the item that was replaced. Nil if there is no enqueued item with the same dedup key
+//
+// replaceDedupItem replaces the details of an enqueued item with the same dedup key as the new item.
+//
+// NOTE: must hold the itemsLock
+func (mqc *memoryQueueChannel) replaceDedupItem(enqueueItem *v1willow.Item) *v1willow.ItemState {
+	itemDedupKey := dedupKey(enqueueItem)
+	if itemDedupKey == "" {
+		return nil
+	}
+
+	itemID, ok := mqc.dedupItemIDs[itemDedupKey]
+	if !ok {
+		return nil
+	}
+
+	// ensure the item is still enqueued. It could be dequeued before the key is cleaned up
+	queueItem, ok := mqc.itemsEnqueued.Get(itemID)
+	if !ok {
+		delete(mqc.dedupItemIDs, itemDedupKey)
+		return nil
+	}
+
+	queueItem.lock.Lock()
+	queueItem.data = enqueueItem.Spec.Properties.Data
+	queueItem.headers = enqueueItem.Spec.Properties.Headers
+	queueItem.updateable = *enqueueItem.Spec.Properties.Updateable
+	queueItem.maxRetryAttempts = *enqueueItem.Spec.Properties.RetryAttempts
+	queueItem.retryPosition = *enqueueItem.Spec.Properties.RetryPosition
+	queueItem.heartbeatTimeout = *enqueueItem.Spec.Properties.TimeoutDuration
+	queueItem.maxRunDuration = maxRunDuration(enqueueItem)
+	queueItem.idempotencyKey = idempotencyKey(enqueueItem)
+	queueItem.priority = priority(enqueueItem)
+	queueItem.retryCount = 0
+	queueItem.lock.Unlock()
+
+	mqc.itemsEnqueued.UpdatePriority(itemID)
+
+	// optionally move the item to the back of the channel
+	if enqueueItem.Spec.Properties.DedupMoveToBack != nil && *enqueueItem.Spec.Properties.DedupMoveToBack {
+		mqc.itemsEnqueued.MoveToBack(itemID)
+	}
+
+	return &v1willow.ItemState{ID: itemID}
+}
+
+// addDedupKey records an enqueued item's dedup key, if no other enqueued item is using it.
+//
+// NOTE: must hold the itemsLock
+func (mqc *memoryQueueChannel) addDedupKey(itemID, itemDedupKey string) {
+	if itemDedupKey == "" {
+		return
+	}
+
+	if _, ok := mqc.dedupItemIDs[itemDedupKey]; !ok {
+		mqc.dedupItemIDs[itemDedupKey] = itemID
+	}
+}
+
+// removeDedupKey removes an item's dedup key when it is no longer enqueued.
+//
+// NOTE: must hold the itemsLock
+func (mqc *memoryQueueChannel) removeDedupKey(itemID, itemDedupKey string) {
+	if itemDedupKey == "" {
+		return
+	}
+
+	if mqc.dedupItemIDs[itemDedupKey] == itemID {
+		delete(mqc.dedupItemIDs, itemDedupKey)
+	}
+}
+
+// dedupKey returns the optional dedup key for an item, where "" means there is no key
+func dedupKey(enqueueItem *v1willow.Item) string {
+	if enqueueItem.Spec.Properties.DedupKey == nil {
+		return ""
+	}
+
+	return *enqueueItem.Spec.Properties.DedupKey
+}
+
+// idempotencyKey returns the optional idempotency key for an item, where "" means there is no key
+func idempotencyKey(enqueueItem *v1willow.Item) string {
+	if enqueueItem.Spec.Properties.IdempotencyKey == nil {
+		return ""
+	}
+
+	return *enqueueItem.Spec.Properties.IdempotencyKey
 }
 
 //	PARAMETERS:
@@ -418,15 +603,18 @@ func (mqc *memoryQueueChannel) Enqueue(ctx context.Context, enqueueItem *v1willo
 //
 //	RETURNS:
 //	- bool - indicates if the entire tree can be removed
+//	- *v1willow.Item - details of the completed item. Only set when the item was successfully acked
 //	- *errors.ServerError - api error if one is encountered when acking an item
 //
 // ACK an item. On successful, the item is removed entierly. On a failure, the itemwill try to be requeued.
 //
 // NOTE: Write locked from the queue_channel_client
-func (mqc *memoryQueueChannel) ACK(ctx context.Context, ack *v1willow.ACK) (bool, *errors.ServerError) {
+func (mqc *memoryQueueChannel) ACK(ctx context.Context, ack *v1willow.ACK) (bool, *v1willow.Item, *errors.ServerError) {
 	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "ACK")
 	ackErr := &errors.ServerError{Message: "failed to find processing item by id", StatusCode: http.StatusNotFound}
 
+	var completedItem *v1willow.Item
+
 	switch ack.Passed {
 	case true:
 		canDelete := func(_ datatypes.EncapsulatedValue, treeItem any) bool {
@@ -435,17 +623,22 @@ func (mqc *memoryQueueChannel) ACK(ctx context.Context, ack *v1willow.ACK) (bool
 			// if the queue item was stopped here, then we know there was no async timeout processed for this item
 			if queueItem.StopHeartbeater() {
 				// 2. always update counters that the item is no loger running
-				if err := mqc.limterUpdateRunningValue(ctx, -1); err != nil {
-					// what should be the actual course of action here?
-					panic(err)
-				}
+				mqc.limiterReleaseRunningValue(ctx)
 
 				// 3. update counters that an enqueued item completed
-				if err := mqc.limiterUpdateEnqueuedValue(ctx, -1); err != nil {
-					// what should we really do here? the limiter would be out of sync in this case
-					panic(err)
+				mqc.limiterReleaseEnqueuedValue(ctx)
+
+				// 4. record the completed item details for the queue to optionally retain
+				completedTime := queueItem.EndAttempt(v1willow.ItemAttemptPassed, "")
+				completedItem = queueItem.Item(mqc.queueName, ack.ItemID, mqc.channelKeyValues)
+				completedItem.State.Completed = &v1willow.ItemCompleted{
+					CompletedTime: completedTime,
+					Result:        ack.Result,
 				}
 
+				// 5. remove the item from any mirror
+				mqc.mirror.Load().Removed(mqc.channelKeyValues, ack.ItemID)
+
 				logger.Debug("removed item from the channel")
 				ackErr = nil
 				return true
@@ -460,15 +653,15 @@ func (mqc *memoryQueueChannel) ACK(ctx context.Context, ack *v1willow.ACK) (bool
 			panic(err)
 		}
 	default:
-		if mqc.failItem(ctx, ack.ItemID, false) {
+		if mqc.failItem(ctx, ack.ItemID, false, ack.Reason) {
 			ackErr = nil
 		}
 	}
 
-	return mqc.items.Empty(), ackErr
+	return mqc.items.Empty(), completedItem, ackErr
 }
 
-func (mqc *memoryQueueChannel) failItem(ctx context.Context, itemID string, timedOut bool) bool {
+func (mqc *memoryQueueChannel) failItem(ctx context.Context, itemID string, timedOut bool, reason string) bool {
 	ctx, _ = middleware.GetNamedMiddlewareLogger(ctx, "failItem")
 
 	mqc.itemsLock.Lock()
@@ -476,6 +669,7 @@ func (mqc *memoryQueueChannel) failItem(ctx context.Context, itemID string, time
 
 	attemptedDelete := false
 	backID := ""
+	var failedItem *item
 
 	// attempt to delete or requeue the item
 	canDelete := func(_ datatypes.EncapsulatedValue, treeItem any) bool {
@@ -489,10 +683,7 @@ func (mqc *memoryQueueChannel) failItem(ctx context.Context, itemID string, time
 		// if the queue item was stopped here, then we know there was no async timeout processed for this item
 		if timedOut || queueItem.StopHeartbeater() {
 			// always update counters that the item is no longer running
-			if err := mqc.limterUpdateRunningValue(ctx, -1); err != nil {
-				// what should be the actual course of action here?
-				panic(err)
-			}
+			mqc.limiterReleaseRunningValue(ctx)
 
 			queueItemToDelete := treeItem.(*item)
 			queueItemToDelete.lock.Lock()
@@ -500,37 +691,54 @@ func (mqc *memoryQueueChannel) failItem(ctx context.Context, itemID string, time
 
 			queueItemToDelete.retryCount++
 
-			// hit the max retry attempts for the queue item, so remove the item from the queue
-			if queueItemToDelete.retryCount > queueItemToDelete.maxRetryAttempts {
+			switch {
+			case timedOut:
+				_ = queueItemToDelete.EndAttempt(v1willow.ItemAttemptTimedOut, "")
+			case queueItemToDelete.Canceled():
+				_ = queueItemToDelete.EndAttempt(v1willow.ItemAttemptCanceled, reason)
+			default:
+				_ = queueItemToDelete.EndAttempt(v1willow.ItemAttemptFailed, reason)
+			}
+
+			// hit the max retry attempts for the queue item or it was canceled, so remove the item from the queue
+			if queueItemToDelete.retryCount > queueItemToDelete.maxRetryAttempts || queueItemToDelete.Canceled() {
 				// when removing an item. we need to delete the total number of enqueued item
-				if err := mqc.limiterUpdateEnqueuedValue(ctx, -1); err != nil {
-					// what should we really do here? the limiter would be out of sync in this case
-					panic(err)
-				}
+				mqc.limiterReleaseEnqueuedValue(ctx)
 
+				mqc.mirror.Load().Removed(mqc.channelKeyValues, itemID)
 				return true
 			}
 
+			// any progress was for the failed attempt
+			queueItemToDelete.SetProgress(nil)
+
+			// the item starts waiting again for any priority aging
+			queueItemToDelete.waitingSince = time.Now()
+
 			// must requeue the item for processing
 			switch queueItemToDelete.retryPosition {
 			case "front":
-				if len(mqc.itemIDsEnqueued) >= 1 {
+				if mqc.itemsEnqueued.Len() >= 1 {
 					// just delete the item. since it is updateable, we want the next item in the queue to run anyways
 					if queueItemToDelete.updateable {
+						mqc.mirror.Load().Removed(mqc.channelKeyValues, itemID)
 						return true
 					}
 				}
 
 				// always append to the front
-				mqc.itemIDsEnqueued = append([]string{itemID}, mqc.itemIDsEnqueued...)
+				mqc.itemsEnqueued.PushFront(itemID, queueItemToDelete)
+				mqc.addDedupKey(itemID, queueItemToDelete.dedupKey)
 				mqc.notifier.Add()
 
 				return false
 			case "back":
-				if len(mqc.itemIDsEnqueued) >= 1 {
-					backID = mqc.itemIDsEnqueued[len(mqc.itemIDsEnqueued)-1]
+				if lastItemID, _, ok := mqc.itemsEnqueued.Back(); ok {
+					backID = lastItemID
+					failedItem = queueItemToDelete
 				} else {
-					mqc.itemIDsEnqueued = append(mqc.itemIDsEnqueued, itemID)
+					mqc.itemsEnqueued.PushBack(itemID, queueItemToDelete)
+					mqc.addDedupKey(itemID, queueItemToDelete.dedupKey)
 					mqc.notifier.Add()
 				}
 			}
@@ -545,6 +753,9 @@ func (mqc *memoryQueueChannel) failItem(ctx context.Context, itemID string, time
 
 	// need to check the last enqueued item to see if it can be dropped
 	if backID != "" {
+		// the failed item's dedup key is used again, since it is being enqueued again
+		failedDedupKey := failedItem.dedupKey
+
 		// check to see if we can delete the previous item in the enqueued list. Logicialy
 		// this is the same as updating the last enueued item
 		canDeleteLastItem := func(_ datatypes.EncapsulatedValue, treeItem any) bool {
@@ -554,11 +765,16 @@ func (mqc *memoryQueueChannel) failItem(ctx context.Context, itemID string, time
 
 			if queueItemToCheck.updateable {
 				// "update" the last item by simply dropping it
-				mqc.itemIDsEnqueued[len(mqc.itemIDsEnqueued)-1] = itemID
+				mqc.itemsEnqueued.Remove(backID)
+				mqc.itemsEnqueued.PushBack(itemID, failedItem)
+				mqc.removeDedupKey(backID, queueItemToCheck.dedupKey)
+				mqc.addDedupKey(itemID, failedDedupKey)
+				mqc.mirror.Load().Removed(mqc.channelKeyValues, backID)
 				return true
 			} else {
 				// "append" to the list the item that failed
-				mqc.itemIDsEnqueued = append(mqc.itemIDsEnqueued, itemID)
+				mqc.itemsEnqueued.PushBack(itemID, failedItem)
+				mqc.addDedupKey(itemID, failedDedupKey)
 				mqc.notifier.Add()
 				return false
 			}
@@ -582,17 +798,52 @@ func (mqc *memoryQueueChannel) failItem(ctx context.Context, itemID string, time
 //
 // The callback functions are used to ensure that the item being processed is gurranted to at least once be sent
 // to a client
-func (mqc *memoryQueueChannel) Dequeue() <-chan func(ctx context.Context) (*v1willow.Item, func(), func()) {
+func (mqc *memoryQueueChannel) Dequeue() <-chan func(ctx context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func()) {
 	return mqc.dequeueChan
 }
 
-// callback passed to the 'dequeueChan' when there is something to dequeue
-func (mqc *memoryQueueChannel) dequeue(ctx context.Context) (*v1willow.Item, func(), func()) {
+// callback passed to the 'dequeueChan' when there is something to dequeue. When the headersFilter is set,
+// the first enqueued item that matches the filter is dequeued
+func (mqc *memoryQueueChannel) dequeue(ctx context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func()) {
 	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "dequeue")
+	priorityAging := mqc.priorityAging()
+
+	// 1. ensure there is an item the client can process before updating any counters
+	if len(headersFilter) != 0 {
+		mqc.itemsLock.Lock()
+		_, found := mqc.nextItem(headersFilter, priorityAging)
+		enqueued := mqc.itemsEnqueued.Len()
+		mqc.itemsLock.Unlock()
+
+		if !found {
+			logger.Debug("no enqueued items match the headers filter")
+
+			// re-add to the notifier since the items are still enqueued for other clients. When nothing is enqueued,
+			// the notification was for an item that has since been deleted
+			if enqueued != 0 {
+				_ = mqc.notifier.Add()
+			}
+
+			mqc.dequeueResponseChan <- false
+			return nil, nil, nil
+		}
+	}
+
+	// 2. ensure that other channels in the queue with a larger share of the current round are dequeued first
+	if mqc.fairShare != nil && !mqc.fairShare.Take() {
+		logger.Debug("another channel has a larger fair share of the queue")
+
+		// re-add to the notifier since the items are still enqueued
+		_ = mqc.notifier.Add()
 
-	// 1. ensure that the item can be dequeued when running. This just forwards the key values that define the channel
+		mqc.dequeueResponseChan <- false
+		return nil, nil, nil
+	}
+
+	// 3. ensure that the item can be dequeued when running. This just forwards the key values that define the channel
 	if err := mqc.limterUpdateRunningValue(ctx, 1); err != nil {
 		logger.Error("failed to update the counter for the queue item", zap.Error(err))
+		mqc.refundFairShare()
 
 		// re-add to the notifier since we failed to process this item properly
 		_ = mqc.notifier.Add()
@@ -602,18 +853,43 @@ func (mqc *memoryQueueChannel) dequeue(ctx context.Context) (*v1willow.Item, fun
 		return nil, nil, nil
 	}
 
-	// drop the first index since we are now processing it
+	// remove the item from the enqueued items since we are now processing it
 	mqc.itemsLock.Lock()
-	firtItemID := mqc.itemIDsEnqueued[0]
-	mqc.itemIDsEnqueued = mqc.itemIDsEnqueued[1:]
+	firtItemID, found := mqc.nextItem(headersFilter, priorityAging)
+	if !found {
+		enqueued := mqc.itemsEnqueued.Len()
+		mqc.itemsLock.Unlock()
+
+		// the item was removed from the queue while updating the counters
+		mqc.limiterReleaseRunningValue(ctx)
+		mqc.refundFairShare()
+
+		if enqueued != 0 {
+			_ = mqc.notifier.Add()
+		}
+
+		mqc.dequeueResponseChan <- false
+		return nil, nil, nil
+	}
+
+	mqc.itemsEnqueued.Remove(firtItemID)
 	mqc.itemsLock.Unlock()
 
-	// 2. successfully incremented the counters, pull an item off for the client
+	// 4. successfully incremented the counters, pull an item off for the client
 	dequeueItem := &v1willow.Item{}
 
 	onFind := func(key datatypes.EncapsulatedValue, treeItem any) bool {
 		queueItem := treeItem.(*item)
 
+		var itemIdempotencyKey *string
+		if queueItem.idempotencyKey != "" {
+			itemIdempotencyKey = &queueItem.idempotencyKey
+		}
+		var itemDedupKey *string
+		if queueItem.dedupKey != "" {
+			itemDedupKey = &queueItem.dedupKey
+		}
+
 		dequeueItem = &v1willow.Item{
 			Spec: &v1willow.ItemSpec{
 				DBDefinition: &v1willow.ItemDBDefinition{
@@ -621,36 +897,42 @@ func (mqc *memoryQueueChannel) dequeue(ctx context.Context) (*v1willow.Item, fun
 				},
 				Properties: &v1willow.ItemProperties{
 					Data:            queueItem.data,
+					Headers:         queueItem.headers,
 					Updateable:      &queueItem.updateable,
 					RetryAttempts:   &queueItem.maxRetryAttempts,
 					RetryPosition:   &queueItem.retryPosition,
 					TimeoutDuration: &queueItem.heartbeatTimeout,
+					MaxRunDuration:  &queueItem.maxRunDuration,
+					IdempotencyKey:  itemIdempotencyKey,
+					DedupKey:        itemDedupKey,
+					Priority:        &queueItem.priority,
 				},
 			},
 			State: &v1willow.ItemState{
-				ID: firtItemID,
+				ID:        firtItemID,
+				QueueName: mqc.queueName,
 			},
 		}
 
-		// create the heartbeat operation in the background
+		// the item is no longer enqueued, so it can't be replaced by the dedup key
+		mqc.itemsLock.Lock()
+		mqc.removeDedupKey(firtItemID, queueItem.dedupKey)
+		mqc.itemsLock.Unlock()
 
-		// the shutdown function should remove the limiter values since this is in memory only
-		onShutdown := func() {
-			// TODO. what should this really be?
-		}
+		queueItem.StartAttempt()
 
 		// The timeout function is the same behavior as a failed ACK operation + the parent callback to try and destroy this queue channel
 		onTimeout := func() {
-			_ = mqc.failItem(reporting.StripedContext(logger), firtItemID, true)
+			_ = mqc.failItem(reporting.StripedContext(logger), firtItemID, true, "")
 
 			// if this times out, call the client to try and delete this channel
 			mqc.deleteCallback()
 		}
 
-		if err := mqc.asyncManager.AddExecuteTask(firtItemID, queueItem.CreateHeartbeater(onShutdown, onTimeout)); err != nil {
-			// failing to add happens if shutting down the server or destroying the queue happens async. In that case
-			// we want to ensure our resources are cleaned up properly
-			queueItem.UnsetHeartbeater()
+		// the timeout starts once the client receives the item
+		if err := queueItem.CreateHeartbeater(mqc.heartbeats, onTimeout); err != nil {
+			// failing to create happens if the server is shutting down. In that case the item is never timed out
+			logger.Warn("failed to create the heartbeat process", zap.Error(err))
 		}
 
 		return false
@@ -663,6 +945,41 @@ func (mqc *memoryQueueChannel) dequeue(ctx context.Context) (*v1willow.Item, fun
 	return dequeueItem, mqc.successfulDequeue(ctx, firtItemID), mqc.failedDequeue(ctx, firtItemID)
 }
 
+// refundFairShare gives back the channel's share of the round when a dequeue could not complete
+func (mqc *memoryQueueChannel) refundFairShare() {
+	if mqc.fairShare != nil {
+		mqc.fairShare.Refund()
+	}
+}
+
+//	RETURNS:
+//	- *v1willow.PriorityAging - the queue's priority aging policy. Nil when priorities are never raised
+//
+// priorityAging must be called without holding the itemsLock, since the scheduler checks other channels
+func (mqc *memoryQueueChannel) priorityAging() *v1willow.PriorityAging {
+	if mqc.fairShare == nil {
+		return nil
+	}
+
+	return mqc.fairShare.PriorityAging()
+}
+
+//	PARAMETERS:
+//	- headersFilter - optional headers that an item must have to be dequeued
+//	- priorityAging - optional policy to raise the priority of waiting items
+//
+//	RETURNS:
+//	- string - ID of the enqueued item with the highest effective priority that matches the headers filter.
+//	           Items with the same effective priority are chosen in order
+//	- bool - false if no items match
+//
+// nextItem finds the next enqueued item that can be dequeued.
+//
+// NOTE: must hold the itemsLock
+func (mqc *memoryQueueChannel) nextItem(headersFilter map[string]string, priorityAging *v1willow.PriorityAging) (string, bool) {
+	return mqc.itemsEnqueued.Next(headersFilter, priorityAging, time.Now())
+}
+
 // callback passed to the 'dequeueChan' and called when the client successfully recieved the item
 func (mqc *memoryQueueChannel) successfulDequeue(ctx context.Context, itemID string) func() {
 	return func() {
@@ -697,9 +1014,7 @@ func (mqc *memoryQueueChannel) failedDequeue(ctx context.Context, itemID string)
 		ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "failedDequeue")
 
 		// update the running counter that we are no longer processing since we failed to dequeue to the remote client
-		if err := mqc.limterUpdateRunningValue(ctx, -1); err != nil {
-			panic(err)
-		}
+		mqc.limiterReleaseRunningValue(ctx)
 
 		mqc.itemsLock.Lock()
 		defer mqc.itemsLock.Unlock()
@@ -714,20 +1029,29 @@ func (mqc *memoryQueueChannel) failedDequeue(ctx context.Context, itemID string)
 			if queueItem.StopHeartbeater() {
 				logger.Debug("stopped the heartbeat process")
 
+				// the client never received the item, so this does not count as an attempt
+				queueItem.DropAttempt()
+
+				// the item was canceled before the client received it, so there is no reason to process it again
+				if queueItem.Canceled() {
+					mqc.limiterReleaseEnqueuedValue(ctx)
+
+					return true
+				}
+
 				// if the queue item is updateable, check to see if there is something else in the queeu
 				if queueItem.updateable {
-					if len(mqc.itemIDsEnqueued) >= 1 {
+					if mqc.itemsEnqueued.Len() >= 1 {
 						// in this case there is something else in the queue that would have updated the item. so just toss this item away
-						if err := mqc.limiterUpdateEnqueuedValue(ctx, -1); err != nil {
-							panic(err)
-						}
+						mqc.limiterReleaseEnqueuedValue(ctx)
 
 						return true
 					}
 				}
 
 				// always put the item at the front of the queue to process again
-				mqc.itemIDsEnqueued = append([]string{itemID}, mqc.itemIDsEnqueued...)
+				mqc.itemsEnqueued.PushFront(itemID, queueItem)
+				mqc.addDedupKey(itemID, queueItem.dedupKey)
 				mqc.notifier.Add() // indicate to the notifier that there is something to process
 			} else {
 				// this should never happen!
@@ -746,15 +1070,26 @@ func (mqc *memoryQueueChannel) failedDequeue(ctx context.Context, itemID string)
 	}
 }
 
-func (mqc *memoryQueueChannel) Heartbeat(ctx context.Context, heartbeat *v1willow.Heartbeat) *errors.ServerError {
+//	RETURNS:
+//	- *v1willow.HeartbeatResponse - response for the client that reports if the item has been canceled
+//	- *errors.ServerError - api error if the item is not processing
+//
+// Heartbeat a processing item to keep it from timing out
+func (mqc *memoryQueueChannel) Heartbeat(ctx context.Context, heartbeat *v1willow.Heartbeat) (*v1willow.HeartbeatResponse, *errors.ServerError) {
 	_, logger := middleware.GetNamedMiddlewareLogger(ctx, "Heartbeat")
 	heartbeatErr := &errors.ServerError{Message: "failed to find processing item by id", StatusCode: http.StatusNotFound}
+	var heartbeatResponse *v1willow.HeartbeatResponse
 
 	onFind := func(key datatypes.EncapsulatedValue, treeItem any) bool {
 		queueItem := treeItem.(*item)
 
 		if queueItem.Heartbeat() {
+			if heartbeat.Progress != nil {
+				queueItem.SetProgress(heartbeat.Progress)
+			}
+
 			heartbeatErr = nil
+			heartbeatResponse = &v1willow.HeartbeatResponse{Cancel: queueItem.Canceled()}
 		}
 
 		return false
@@ -765,106 +1100,438 @@ func (mqc *memoryQueueChannel) Heartbeat(ctx context.Context, heartbeat *v1willo
 	}
 
 	// at this point, if this is an error, there should be a debug log that this timed out previously
-	return heartbeatErr
+	return heartbeatResponse, heartbeatErr
 }
 
-// limiterUpdateEnqueuedValue is used when an item is enqueued or removed from the channel. This keeps track
-// of the total 'enqueued' items for a queue and rejects when to many items are being added to the queue
-func (mqc *memoryQueueChannel) limiterUpdateEnqueuedValue(ctx context.Context, counterUpdate int64) *errors.ServerError {
-	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "limiterUpdateEnqueuedValue")
+//	RETURNS:
+//	- *errors.ServerError - api error if the item is not processing
+//
+// Cancel a processing item. The client processing the item is informed on the next heartbeat and the item will
+// not be retried when it is failed or times out
+func (mqc *memoryQueueChannel) Cancel(ctx context.Context, cancel *v1willow.Cancel) *errors.ServerError {
+	_, logger := middleware.GetNamedMiddlewareLogger(ctx, "Cancel")
+	cancelErr := &errors.ServerError{Message: "failed to find processing item by id", StatusCode: http.StatusNotFound}
 
-	enqueueKeyValues := datatypes.KeyValues{
-		"_willow_queue_name": datatypes.String(mqc.queueName),
-		"_willow_enqueued":   datatypes.String("true"),
+	onFind := func(key datatypes.EncapsulatedValue, treeItem any) bool {
+		queueItem := treeItem.(*item)
+
+		if queueItem.Cancel() {
+			logger.Debug("marked the item as canceled")
+			cancelErr = nil
+		}
+
+		return false
 	}
-	for key, value := range mqc.channelKeyValues {
-		enqueueKeyValues[fmt.Sprintf("_willow_%s", key)] = value
+
+	if err := mqc.items.Find(datatypes.String(cancel.ItemID), v1common.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onFind); err != nil {
+		logger.Fatal("failed to lookup item to cancel", zap.Error(err))
 	}
 
-	err := mqc.limiterClient.UpdateCounter(ctx, &v1limiter.Counter{
-		Spec: &v1limiter.CounterSpec{
-			DBDefinition: &v1limiter.CounterDBDefinition{
-				KeyValues: enqueueKeyValues,
-			},
-			Properties: &v1limiter.CounteProperties{
-				Counters: &counterUpdate,
-			},
-		},
+	return cancelErr
+}
+
+//	PARAMETERS:
+//	- itemDelete - headers of the items to delete
+//
+//	RETURNS:
+//	- bool - indicates if the entire tree can be removed
+//	- *errors.ServerError - api error if no items have the headers, or an item is being dequeued or timed out
+//
+// DeleteItems removes all enqueued and processing items that have the headers. Deleted items are never completed
+// or retried. A client processing a deleted item fails its next heartbeat or ACK since the item no longer exists
+//
+// NOTE: Write locked from the queue_channel_client
+func (mqc *memoryQueueChannel) DeleteItems(ctx context.Context, itemDelete *v1willow.ItemDelete) (bool, *errors.ServerError) {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "DeleteItems")
+
+	// 1. find all the items with the headers
+	itemIDs := []string{}
+	onIterate := func(key datatypes.EncapsulatedValue, treeItem any) bool {
+		if treeItem.(*item).MatchHeaders(itemDelete.Headers) {
+			itemIDs = append(itemIDs, key.Data.(string))
+		}
+
+		return true
+	}
+
+	if err := mqc.items.FindGreaterThanOrEqual(datatypes.String(""), v1common.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onIterate); err != nil {
+		logger.Fatal("failed to lookup items to delete", zap.Error(err))
+	}
+
+	if len(itemIDs) == 0 {
+		return mqc.items.Empty(), &errors.ServerError{Message: "failed to find any items with the headers", StatusCode: http.StatusNotFound}
+	}
+
+	// 2. remove each item from the channel
+	mqc.itemsLock.Lock()
+	defer mqc.itemsLock.Unlock()
+
+	var deleteErr *errors.ServerError
+	for _, itemID := range itemIDs {
+		canDelete := func(_ datatypes.EncapsulatedValue, treeItem any) bool {
+			queueItem := treeItem.(*item)
+
+			if _, ok := mqc.itemsEnqueued.Remove(itemID); ok {
+				// the item was waiting to be dequeued
+				mqc.removeDedupKey(itemID, queueItem.dedupKey)
+			} else if queueItem.StopHeartbeater() {
+				// the item was processing, so it is no longer running
+				mqc.limiterReleaseRunningValue(ctx)
+			} else {
+				// the item is between states. Either a client is dequeuing it or it timed out and is being retried
+				logger.Debug("failed to delete the item since it is being dequeued or timed out", zap.String("item_id", itemID))
+				deleteErr = &errors.ServerError{Message: fmt.Sprintf("item '%s' is being dequeued or timed out. Retry the delete", itemID), StatusCode: http.StatusConflict}
+				return false
+			}
+
+			// update counters that an enqueued item was removed
+			mqc.limiterReleaseEnqueuedValue(ctx)
+
+			// remove the item from any mirror
+			mqc.mirror.Load().Removed(mqc.channelKeyValues, itemID)
+
+			logger.Debug("deleted the item from the channel", zap.String("item_id", itemID))
+			return true
+		}
+
+		if err := mqc.items.Delete(datatypes.String(itemID), canDelete); err != nil {
+			panic(err)
+		}
+	}
+
+	return mqc.items.Empty(), deleteErr
+}
+
+//	PARAMETERS:
+//	- itemID - optional ID of a single item to return
+//
+//	RETURNS:
+//	- v1willow.Items - all enqueued and processing items in the channel
+//
+// Items is used to inspect the current state of all items in the channel
+func (mqc *memoryQueueChannel) Items(ctx context.Context, itemID *string) v1willow.Items {
+	_, logger := middleware.GetNamedMiddlewareLogger(ctx, "Items")
+	items := v1willow.Items{}
+
+	priorityAging := mqc.priorityAging()
+	now := time.Now()
+
+	onIterate := func(key datatypes.EncapsulatedValue, treeItem any) bool {
+		queueItem := treeItem.(*item)
+
+		apiItem := queueItem.Item(mqc.queueName, key.Data.(string), mqc.channelKeyValues)
+		if !apiItem.State.Processing {
+			effectivePriority := queueItem.EffectivePriority(now, priorityAging)
+			apiItem.State.EffectivePriority = &effectivePriority
+		}
+
+		items = append(items, apiItem)
+		return true
+	}
+
+	if itemID != nil {
+		if err := mqc.items.Find(datatypes.String(*itemID), v1common.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onIterate); err != nil {
+			logger.Fatal("failed to lookup item", zap.Error(err))
+		}
+	} else {
+		if err := mqc.items.FindGreaterThanOrEqual(datatypes.String(""), v1common.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onIterate); err != nil {
+			logger.Fatal("failed to lookup items", zap.Error(err))
+		}
+	}
+
+	return items
+}
+
+//	RETURNS:
+//	- *v1willow.ExportedChannel - all items in the channel in the order they will be dequeued
+//
+// Export the channel's items. Items that are processing are exported first, since they were dequeued before
+// any of the items that are still enqueued
+func (mqc *memoryQueueChannel) Export(ctx context.Context) *v1willow.ExportedChannel {
+	_, logger := middleware.GetNamedMiddlewareLogger(ctx, "Export")
+
+	mqc.itemsLock.RLock()
+	defer mqc.itemsLock.RUnlock()
+
+	exportedChannel := &v1willow.ExportedChannel{KeyValues: mqc.channelKeyValues}
+
+	exportItem := func(itemID string, queueItem *item) {
+		apiItem := queueItem.Item(mqc.queueName, itemID, mqc.channelKeyValues)
+
+		queueItem.lock.RLock()
+		retryCount := queueItem.retryCount
+		queueItem.lock.RUnlock()
+
+		exportedChannel.Items = append(exportedChannel.Items, &v1willow.ExportedItem{
+			ID:         itemID,
+			Properties: apiItem.Spec.Properties,
+			RetryCount: retryCount,
+		})
+	}
+
+	// 1. export all processing items
+	onIterate := func(key datatypes.EncapsulatedValue, treeItem any) bool {
+		if _, ok := mqc.itemsEnqueued.Get(key.Data.(string)); !ok {
+			exportItem(key.Data.(string), treeItem.(*item))
+		}
+
+		return true
+	}
+
+	if err := mqc.items.FindGreaterThanOrEqual(datatypes.String(""), v1common.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onIterate); err != nil {
+		logger.Fatal("failed to lookup items", zap.Error(err))
+	}
+
+	// 2. export all enqueued items in order
+	mqc.itemsEnqueued.Iterate(func(itemID string, queueItem *item) bool {
+		exportItem(itemID, queueItem)
+		return true
 	})
 
-	if err != nil {
-		logger.Warn("hit a limit with the total number of enqued items", zap.Error(err))
-		return &errors.ServerError{Message: "Queue has reached the total number of allowed queue items", StatusCode: http.StatusConflict}
+	return exportedChannel
+}
+
+//	PARAMETERS:
+//	- exportedItems - items to enqueue in order, with their original IDs and retry counts
+//
+//	RETURNS:
+//	- *errors.ServerError - error if an item already exists or the queue's limits are reached
+//
+// Import enqueues all items from an export and records them on the Limiter's '_willow_enqueued' counters
+func (mqc *memoryQueueChannel) Import(ctx context.Context, exportedItems []*v1willow.ExportedItem) *errors.ServerError {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "Import")
+
+	mqc.itemsLock.Lock()
+	defer mqc.itemsLock.Unlock()
+
+	for _, exportedItem := range exportedItems {
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: mqc.channelKeyValues,
+				},
+				Properties: exportedItem.Properties,
+			},
+		}
+
+		// ensure the limits are not reached
+		if err := mqc.limiterUpdateEnqueuedValue(ctx, 1); err != nil {
+			return err
+		}
+
+		queueItem := newEnqueuedItem(enqueueItem)
+		queueItem.retryCount = exportedItem.RetryCount
+		onCreate := func() any {
+			return queueItem
+		}
+
+		if err := mqc.items.Create(datatypes.String(exportedItem.ID), onCreate); err != nil {
+			// nothing was saved, so the item is no longer counted
+			mqc.limiterReleaseEnqueuedValue(ctx)
+
+			switch err {
+			case btree.ErrorKeyAlreadyExists:
+				return &errors.ServerError{Message: fmt.Sprintf("Item with ID '%s' already exists", exportedItem.ID), StatusCode: http.StatusConflict}
+			default:
+				logger.Error("failed to import item", zap.String("item_id", exportedItem.ID), zap.Error(err))
+				return errors.InternalServerError
+			}
+		}
+
+		mqc.itemsEnqueued.PushBack(exportedItem.ID, queueItem)
+		mqc.addDedupKey(exportedItem.ID, dedupKey(enqueueItem))
+		_ = mqc.notifier.Add() // in the case of an error we are shutting down so just drop it
+
+		mqc.mirror.Load().Enqueued(mqc.channelKeyValues, exportedItem.ID, enqueueItem)
 	}
 
 	return nil
 }
 
-// limterUpdateRunningValue is used when an item is dequeued channel. This keeps track
-// of the total 'running' items for a queue and rejects when a 3rd paarty rule has reached the limit setup from a user
-func (mqc *memoryQueueChannel) limterUpdateRunningValue(ctx context.Context, counterUpdate int64) error {
-	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "limiterUpdateRunningValue")
+//	RETURNS:
+//	- *v1willow.ChannelDiagnosis - current item counts and any Limiter limits blocking the channel
+//	- *errors.ServerError - error communicating with the Limiter
+//
+// Diagnose is used to explain why items are not being dequeued from the channel
+func (mqc *memoryQueueChannel) Diagnose(ctx context.Context) (*v1willow.ChannelDiagnosis, *errors.ServerError) {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "Diagnose")
 
-	counterKeyValues := datatypes.KeyValues{
+	channelDiagnosis := &v1willow.ChannelDiagnosis{
+		KeyValues:     mqc.channelKeyValues,
+		EnqueuedItems: int64(mqc.enqueuedCount()),
+	}
+
+	// 1. count all the processing items
+	onIterate := func(key datatypes.EncapsulatedValue, treeItem any) bool {
+		if treeItem.(*item).Processing() {
+			channelDiagnosis.ProcessingItems++
+		}
+
+		return true
+	}
+
+	if err := mqc.items.FindGreaterThanOrEqual(datatypes.String(""), v1common.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onIterate); err != nil {
+		logger.Fatal("failed to lookup items", zap.Error(err))
+	}
+
+	// 2. find the limits that would reject the running counter
+	runningKeyValues := datatypes.KeyValues{
 		"_willow_queue_name": datatypes.String(mqc.queueName),
 		"_willow_running":    datatypes.String("true"),
 	}
 	for key, value := range mqc.channelKeyValues {
-		counterKeyValues[key] = value
+		runningKeyValues[key] = value
 	}
 
-	counter := &v1limiter.Counter{
-		Spec: &v1limiter.CounterSpec{
-			DBDefinition: &v1limiter.CounterDBDefinition{
-				KeyValues: counterKeyValues,
-			},
-			Properties: &v1limiter.CounteProperties{
-				Counters: &counterUpdate,
-			},
+	rules, err := mqc.limiterClient.MatchRules(ctx, querymatchaction.KeyValuesToAnyMatchActionQuery(runningKeyValues))
+	if err != nil {
+		logger.Error("failed to match the limiter rules", zap.Error(err))
+		return nil, errors.InternalServerError
+	}
+
+	for _, rule := range rules {
+		overrides, err := mqc.limiterClient.MatchOverrides(ctx, rule.State.ID, querymatchaction.KeyValuesToAnyMatchActionQuery(runningKeyValues))
+		if err != nil {
+			logger.Error("failed to match the limiter overrides", zap.Error(err), zap.String("rule_id", rule.State.ID))
+			return nil, errors.InternalServerError
+		}
+
+		// when there are overrides, they replace the rule's limit
+		if len(overrides) == 0 {
+			blockingLimit, err := mqc.blockingLimit(ctx, runningKeyValues, rule.Spec.DBDefinition.GroupByKeyValues.Keys(), *rule.Spec.Properties.Limit)
+			if err != nil {
+				return nil, err
+			}
+
+			if blockingLimit != nil {
+				blockingLimit.RuleID = rule.State.ID
+				channelDiagnosis.BlockingLimits = append(channelDiagnosis.BlockingLimits, blockingLimit)
+			}
+		} else {
+			for _, override := range overrides {
+				blockingLimit, err := mqc.blockingLimit(ctx, runningKeyValues, override.Spec.DBDefinition.GroupByKeyValues.Keys(), *override.Spec.Properties.Limit)
+				if err != nil {
+					return nil, err
+				}
+
+				if blockingLimit != nil {
+					blockingLimit.RuleID = rule.State.ID
+					blockingLimit.OverrideID = override.State.ID
+					channelDiagnosis.BlockingLimits = append(channelDiagnosis.BlockingLimits, blockingLimit)
+				}
+			}
+		}
+	}
+
+	return channelDiagnosis, nil
+}
+
+// blockingLimit counts all the counters grouped by the limit's keys, using the running key values. When the count
+// is at or above the limit, a BlockingLimit is returned
+func (mqc *memoryQueueChannel) blockingLimit(ctx context.Context, runningKeyValues datatypes.KeyValues, groupByKeys []string, limit int64) (*v1willow.BlockingLimit, *errors.ServerError) {
+	_, logger := middleware.GetNamedMiddlewareLogger(ctx, "blockingLimit")
+
+	// unlimited so this can never block
+	if limit == -1 {
+		return nil, nil
+	}
+
+	// setup query for the counters based off of the group by keys and running values
+	query := &queryassociatedaction.AssociatedActionQuery{
+		Selection: &queryassociatedaction.Selection{
+			KeyValues: queryassociatedaction.SelectionKeyValues{},
 		},
 	}
 
-	if err := mqc.limiterClient.UpdateCounter(ctx, counter); err != nil {
-		logger.Warn("hit a limit for the total number of runnable items", zap.Error(err))
-		return err
+	for _, key := range groupByKeys {
+		query.Selection.KeyValues[key] = queryassociatedaction.ValueQuery{
+			Value:      runningKeyValues[key],
+			Comparison: v1common.Equals,
+			TypeRestrictions: v1common.TypeRestrictions{
+				MinDataType: runningKeyValues[key].Type,
+				MaxDataType: runningKeyValues[key].Type,
+			},
+		}
 	}
 
-	return nil
+	counters, err := mqc.limiterClient.QueryCounters(ctx, query)
+	if err != nil {
+		logger.Error("failed to query the limiter counters", zap.Error(err))
+		return nil, errors.InternalServerError
+	}
+
+	totalCount := int64(0)
+	for _, counter := range counters {
+		totalCount += *counter.Spec.Properties.Counters
+	}
+
+	if totalCount < limit {
+		return nil, nil
+	}
+
+	return &v1willow.BlockingLimit{Limit: limit, Count: totalCount}, nil
 }
 
-func (mqc *memoryQueueChannel) setLimiterEnqueuedValue(ctx context.Context) *errors.ServerError {
-	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "setLimiterEnqueuedValue")
+//	PARAMETERS:
+//	- ctx - context with the logger for the request
+//
+//	RETURNS:
+//	- v1limiter.Counters - the 'enqueued' and 'running' counters the Limiter should have for the channel
+//
+// LimiterCounters reports the Limiter counters for every item the channel currently holds. Every item in the
+// channel counts towards the 'enqueued' counters and any item that is not waiting to be dequeued is 'running'
+func (mqc *memoryQueueChannel) LimiterCounters(ctx context.Context) v1limiter.Counters {
+	_, logger := middleware.GetNamedMiddlewareLogger(ctx, "LimiterCounters")
+
+	mqc.itemsLock.RLock()
+	defer mqc.itemsLock.RUnlock()
 
-	enqueueKeyValues := datatypes.KeyValues{
-		"_willow_queue_name": datatypes.String(mqc.queueName),
-		"_willow_enqueued":   datatypes.String("true"),
+	total := int64(0)
+	onIterate := func(_ datatypes.EncapsulatedValue, _ any) bool {
+		total++
+		return true
 	}
-	for key, value := range mqc.channelKeyValues {
-		enqueueKeyValues[fmt.Sprintf("_willow_%s", key)] = value
+
+	if err := mqc.items.FindGreaterThanOrEqual(datatypes.String(""), v1common.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onIterate); err != nil {
+		logger.Fatal("failed to lookup items", zap.Error(err))
 	}
 
-	err := mqc.limiterClient.SetCounters(ctx, &v1limiter.Counter{
+	running := total - int64(mqc.itemsEnqueued.Len())
+
+	return v1limiter.Counters{
+		limiterCounter(mqc.enqueuedKeyValues(), total),
+		limiterCounter(mqc.runningKeyValues(), running),
+	}
+}
+
+func limiterCounter(keyValues datatypes.KeyValues, counters int64) *v1limiter.Counter {
+	return &v1limiter.Counter{
 		Spec: &v1limiter.CounterSpec{
 			DBDefinition: &v1limiter.CounterDBDefinition{
-				KeyValues: enqueueKeyValues,
+				KeyValues: keyValues,
 			},
 			Properties: &v1limiter.CounteProperties{
-				Counters: helpers.PointerOf[int64](0),
+				Counters: &counters,
 			},
 		},
-	})
+	}
+}
 
-	if err != nil {
-		logger.Warn("failed to remove the enqueued counters values", zap.Error(err))
-		return &errors.ServerError{Message: "Failed to set the counters properly for enqueued channel", StatusCode: http.StatusInternalServerError}
+// enqueuedKeyValues are the Limiter counter's key values for the total 'enqueued' items in the channel
+func (mqc *memoryQueueChannel) enqueuedKeyValues() datatypes.KeyValues {
+	enqueueKeyValues := datatypes.KeyValues{
+		"_willow_queue_name": datatypes.String(mqc.queueName),
+		"_willow_enqueued":   datatypes.String("true"),
+	}
+	for key, value := range mqc.channelKeyValues {
+		enqueueKeyValues[fmt.Sprintf("_willow_%s", key)] = value
 	}
 
-	return nil
+	return enqueueKeyValues
 }
 
-func (mqc *memoryQueueChannel) setLimterRunningValue(ctx context.Context) error {
-	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "setLimiterRunningValue")
-
+// runningKeyValues are the Limiter counter's key values for the total 'running' items in the channel
+func (mqc *memoryQueueChannel) runningKeyValues() datatypes.KeyValues {
 	counterKeyValues := datatypes.KeyValues{
 		"_willow_queue_name": datatypes.String(mqc.queueName),
 		"_willow_running":    datatypes.String("true"),
@@ -873,21 +1540,53 @@ func (mqc *memoryQueueChannel) setLimterRunningValue(ctx context.Context) error
 		counterKeyValues[key] = value
 	}
 
-	counter := &v1limiter.Counter{
-		Spec: &v1limiter.CounterSpec{
-			DBDefinition: &v1limiter.CounterDBDefinition{
-				KeyValues: counterKeyValues,
-			},
-			Properties: &v1limiter.CounteProperties{
-				Counters: helpers.PointerOf[int64](0),
-			},
-		},
+	return counterKeyValues
+}
+
+// limiterUpdateEnqueuedValue is used when an item is enqueued to the channel. This keeps track
+// of the total 'enqueued' items for a queue and rejects when to many items are being added to the queue
+func (mqc *memoryQueueChannel) limiterUpdateEnqueuedValue(ctx context.Context, counterUpdate int64) *errors.ServerError {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "limiterUpdateEnqueuedValue")
+
+	if err := mqc.limiterOutbox.UpdateCounter(ctx, mqc.enqueuedKeyValues(), counterUpdate); err != nil {
+		logger.Warn("hit a limit with the total number of enqued items", zap.Error(err))
+		return &errors.ServerError{Message: "Queue has reached the total number of allowed queue items", StatusCode: http.StatusConflict}
 	}
 
-	if err := mqc.limiterClient.SetCounters(ctx, counter); err != nil {
+	return nil
+}
+
+// limiterReleaseEnqueuedValue is used when an item is removed from the channel. If the Limiter can't be reached,
+// the update is retried in the background
+func (mqc *memoryQueueChannel) limiterReleaseEnqueuedValue(ctx context.Context) {
+	mqc.limiterOutbox.UpdateCounterOrRetry(ctx, mqc.enqueuedKeyValues(), -1)
+}
+
+// limterUpdateRunningValue is used when an item is dequeued channel. This keeps track
+// of the total 'running' items for a queue and rejects when a 3rd paarty rule has reached the limit setup from a user
+func (mqc *memoryQueueChannel) limterUpdateRunningValue(ctx context.Context, counterUpdate int64) error {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "limiterUpdateRunningValue")
+
+	if err := mqc.limiterOutbox.UpdateCounter(ctx, mqc.runningKeyValues(), counterUpdate); err != nil {
 		logger.Warn("hit a limit for the total number of runnable items", zap.Error(err))
-		return &errors.ServerError{Message: "Failed to set the counters properly for running channel", StatusCode: http.StatusInternalServerError}
+		return err
 	}
 
 	return nil
 }
+
+// limiterReleaseRunningValue is used when an item stops processing. If the Limiter can't be reached,
+// the update is retried in the background
+func (mqc *memoryQueueChannel) limiterReleaseRunningValue(ctx context.Context) {
+	mqc.limiterOutbox.UpdateCounterOrRetry(ctx, mqc.runningKeyValues(), -1)
+}
+
+// setLimiterEnqueuedValue removes all 'enqueued' counters when the channel is destroyed
+func (mqc *memoryQueueChannel) setLimiterEnqueuedValue(ctx context.Context) {
+	mqc.limiterOutbox.SetCountersOrRetry(ctx, mqc.enqueuedKeyValues(), 0)
+}
+
+// setLimterRunningValue removes all 'running' counters when the channel is destroyed
+func (mqc *memoryQueueChannel) setLimterRunningValue(ctx context.Context) {
+	mqc.limiterOutbox.SetCountersOrRetry(ctx, mqc.runningKeyValues(), 0)
+}
@@ -0,0 +1,292 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	indexeddeque "github.com/DanLavine/willow/internal/datastructures/indexed_deque"
+
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+)
+
+// enqueuedItem is an item waiting to be dequeued
+type enqueuedItem struct {
+	item *item
+
+	// position in the enqueued order. Positions always increase from the front to the back
+	position int64
+
+	// priority of the bucket the item is saved in
+	priority int64
+}
+
+// enqueuedItems are all the items waiting to be dequeued, in order. The items are also split into a bucket for each
+// base Priority, in the same order. Aging raises all items in a bucket the same way based on how long they have waited,
+// so only the first item in each bucket can be dequeued next. This keeps finding the next item at O(number of priorities)
+// instead of checking every enqueued item.
+//
+// NOTE: this is not thread safe. Callers must hold the channel's itemsLock. An item's priority is only changed while
+// holding the itemsLock, after which UpdatePriority must be called
+type enqueuedItems struct {
+	order   *indexeddeque.IndexedDeque[*enqueuedItem]
+	buckets map[int64]*indexeddeque.IndexedDeque[*enqueuedItem]
+
+	// priorities of all the buckets, from the highest to the lowest
+	priorities []int64
+
+	// positions for the next items added to the front and back
+	frontPosition int64
+	backPosition  int64
+}
+
+func newEnqueuedItems() *enqueuedItems {
+	return &enqueuedItems{
+		order:         indexeddeque.New[*enqueuedItem](),
+		buckets:       map[int64]*indexeddeque.IndexedDeque[*enqueuedItem]{},
+		frontPosition: -1,
+	}
+}
+
+// Len reports the number of enqueued items
+func (enqueued *enqueuedItems) Len() int {
+	return enqueued.order.Len()
+}
+
+//	PARAMETERS:
+//	- id - ID of the item to find
+//
+//	RETURNS:
+//	- *item - enqueued item for the ID
+//	- bool - false if the item is not enqueued
+//
+// Get returns an enqueued item without removing it
+func (enqueued *enqueuedItems) Get(id string) (*item, bool) {
+	entry, ok := enqueued.order.Get(id)
+	if !ok {
+		return nil, false
+	}
+
+	return entry.item, true
+}
+
+//	RETURNS:
+//	- string - ID of the last enqueued item
+//	- *item - last enqueued item
+//	- bool - false if there are no enqueued items
+//
+// Back returns the last enqueued item without removing it
+func (enqueued *enqueuedItems) Back() (string, *item, bool) {
+	id, entry, ok := enqueued.order.Back()
+	if !ok {
+		return "", nil, false
+	}
+
+	return id, entry.item, true
+}
+
+//	PARAMETERS:
+//	- id - unique ID of the item
+//	- queueItem - item to enqueue last
+//
+//	RETURNS:
+//	- bool - false if the item is already enqueued
+//
+// PushBack enqueues an item after all other items
+func (enqueued *enqueuedItems) PushBack(id string, queueItem *item) bool {
+	entry := &enqueuedItem{item: queueItem, position: enqueued.backPosition, priority: queueItem.priority}
+	if !enqueued.order.PushBack(id, entry) {
+		return false
+	}
+
+	enqueued.backPosition++
+	enqueued.addToBucket(id, entry)
+
+	return true
+}
+
+//	PARAMETERS:
+//	- id - unique ID of the item
+//	- queueItem - item to enqueue first
+//
+//	RETURNS:
+//	- bool - false if the item is already enqueued
+//
+// PushFront enqueues an item before all other items
+func (enqueued *enqueuedItems) PushFront(id string, queueItem *item) bool {
+	entry := &enqueuedItem{item: queueItem, position: enqueued.frontPosition, priority: queueItem.priority}
+	if !enqueued.order.PushFront(id, entry) {
+		return false
+	}
+
+	enqueued.frontPosition--
+	enqueued.addToBucket(id, entry)
+
+	return true
+}
+
+//	PARAMETERS:
+//	- id - ID of the item to remove
+//
+//	RETURNS:
+//	- *item - item that was removed
+//	- bool - false if the item is not enqueued
+//
+// Remove takes an item out of the enqueued items, wherever it is
+func (enqueued *enqueuedItems) Remove(id string) (*item, bool) {
+	entry, ok := enqueued.order.Remove(id)
+	if !ok {
+		return nil, false
+	}
+
+	enqueued.removeFromBucket(id, entry)
+
+	return entry.item, true
+}
+
+//	PARAMETERS:
+//	- id - ID of the item to move
+//
+//	RETURNS:
+//	- bool - false if the item is not enqueued
+//
+// MoveToBack moves an enqueued item after all other items
+func (enqueued *enqueuedItems) MoveToBack(id string) bool {
+	entry, ok := enqueued.order.Get(id)
+	if !ok {
+		return false
+	}
+
+	enqueued.order.MoveToBack(id)
+	enqueued.removeFromBucket(id, entry)
+
+	entry.position = enqueued.backPosition
+	enqueued.backPosition++
+	enqueued.addToBucket(id, entry)
+
+	return true
+}
+
+//	PARAMETERS:
+//	- id - ID of the item that had its priority changed
+//
+// UpdatePriority moves an enqueued item to the bucket for the item's current priority
+func (enqueued *enqueuedItems) UpdatePriority(id string) {
+	entry, ok := enqueued.order.Get(id)
+	if !ok || entry.priority == entry.item.priority {
+		return
+	}
+
+	enqueued.removeFromBucket(id, entry)
+	entry.priority = entry.item.priority
+	enqueued.addToBucket(id, entry)
+}
+
+//	PARAMETERS:
+//	- onIterate - called for each enqueued item in order. Return false to stop iterating
+//
+// Iterate over all enqueued items in order. The items must not be modified while iterating
+func (enqueued *enqueuedItems) Iterate(onIterate func(id string, queueItem *item) bool) {
+	enqueued.order.Iterate(func(id string, entry *enqueuedItem) bool {
+		return onIterate(id, entry.item)
+	})
+}
+
+//	PARAMETERS:
+//	- headersFilter - optional headers that an item must have to be dequeued
+//	- priorityAging - optional policy to raise the priority of waiting items
+//	- now - time to calculate how long items have been waiting
+//
+//	RETURNS:
+//	- string - ID of the enqueued item with the highest effective priority that matches the headers filter.
+//	           Items with the same effective priority are chosen in order
+//	- bool - false if no items match
+//
+// Next finds the next enqueued item that can be dequeued
+func (enqueued *enqueuedItems) Next(headersFilter map[string]string, priorityAging *v1willow.PriorityAging, now time.Time) (string, bool) {
+	nextID := ""
+	var next *enqueuedItem
+	var nextPriority int64
+
+	for _, bucketPriority := range enqueued.priorities {
+		// the buckets are ordered, so no items in this or any of the remaining buckets can be dequeued next
+		if next != nil && nextPriority > maxEffectivePriority(bucketPriority, priorityAging) {
+			break
+		}
+
+		// the first item in the bucket that matches the filter has the highest effective priority in the bucket
+		enqueued.buckets[bucketPriority].Iterate(func(id string, entry *enqueuedItem) bool {
+			if !entry.item.MatchHeaders(headersFilter) {
+				return true
+			}
+
+			effectivePriority := entry.item.EffectivePriority(now, priorityAging)
+			if next == nil || effectivePriority > nextPriority || (effectivePriority == nextPriority && entry.position < next.position) {
+				nextID, next, nextPriority = id, entry, effectivePriority
+			}
+
+			return false
+		})
+	}
+
+	return nextID, next != nil
+}
+
+// maxEffectivePriority is the highest priority any item in a bucket can be raised to
+func maxEffectivePriority(priority int64, priorityAging *v1willow.PriorityAging) int64 {
+	if priorityAging == nil {
+		return priority
+	}
+
+	return priority + *priorityAging.MaxBoost
+}
+
+// addToBucket saves the item in the bucket for its priority, in the same order as all the enqueued items
+func (enqueued *enqueuedItems) addToBucket(id string, entry *enqueuedItem) {
+	bucket, ok := enqueued.buckets[entry.priority]
+	if !ok {
+		bucket = indexeddeque.New[*enqueuedItem]()
+		enqueued.buckets[entry.priority] = bucket
+
+		index := sort.Search(len(enqueued.priorities), func(i int) bool { return enqueued.priorities[i] < entry.priority })
+		enqueued.priorities = append(enqueued.priorities, 0)
+		copy(enqueued.priorities[index+1:], enqueued.priorities[index:])
+		enqueued.priorities[index] = entry.priority
+	}
+
+	// items are almost always added to either end
+	if _, back, ok := bucket.Back(); !ok || back.position < entry.position {
+		bucket.PushBack(id, entry)
+		return
+	}
+
+	if _, front, _ := bucket.Front(); front.position > entry.position {
+		bucket.PushFront(id, entry)
+		return
+	}
+
+	// only an item that changed its priority in place can be added to the middle
+	beforeID := ""
+	bucket.Iterate(func(bucketID string, bucketEntry *enqueuedItem) bool {
+		if bucketEntry.position > entry.position {
+			beforeID = bucketID
+			return false
+		}
+
+		return true
+	})
+
+	bucket.InsertBefore(beforeID, id, entry)
+}
+
+// removeFromBucket drops the item from the bucket for its priority. Empty buckets are removed
+func (enqueued *enqueuedItems) removeFromBucket(id string, entry *enqueuedItem) {
+	bucket := enqueued.buckets[entry.priority]
+	bucket.Remove(id)
+
+	if bucket.Len() == 0 {
+		delete(enqueued.buckets, entry.priority)
+
+		index := sort.Search(len(enqueued.priorities), func(i int) bool { return enqueued.priorities[i] <= entry.priority })
+		enqueued.priorities = append(enqueued.priorities[:index], enqueued.priorities[index+1:]...)
+	}
+}
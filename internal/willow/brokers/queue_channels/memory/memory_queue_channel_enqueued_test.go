@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DanLavine/willow/internal/helpers"
+
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+
+	. "github.com/onsi/gomega"
+)
+
+func priorityQueueItem(priority int64, waited time.Duration) *item {
+	queueItem := newItem([]byte(`data`), false, 0, "front", time.Second, 0)
+	queueItem.priority = priority
+	queueItem.waitingSince = time.Now().Add(-waited)
+
+	return queueItem
+}
+
+// enqueuedIDs returns all the enqueued IDs in order
+func enqueuedIDs(enqueued *enqueuedItems) []string {
+	ids := []string{}
+	enqueued.Iterate(func(id string, _ *item) bool {
+		ids = append(ids, id)
+		return true
+	})
+
+	return ids
+}
+
+// bucketIDs returns all the IDs in a priority's bucket in order
+func bucketIDs(enqueued *enqueuedItems, priority int64) []string {
+	ids := []string{}
+	enqueued.buckets[priority].Iterate(func(id string, _ *enqueuedItem) bool {
+		ids = append(ids, id)
+		return true
+	})
+
+	return ids
+}
+
+func Test_enqueuedItems_Next(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It returns false when there are no items", func(t *testing.T) {
+		_, found := newEnqueuedItems().Next(nil, nil, time.Now())
+		g.Expect(found).To(BeFalse())
+	})
+
+	t.Run("It returns items with the same priority in order", func(t *testing.T) {
+		enqueued := newEnqueuedItems()
+		enqueued.PushBack("2", priorityQueueItem(0, 0))
+		enqueued.PushBack("3", priorityQueueItem(0, 0))
+		enqueued.PushFront("1", priorityQueueItem(0, 0))
+
+		nextID, found := enqueued.Next(nil, nil, time.Now())
+		g.Expect(found).To(BeTrue())
+		g.Expect(nextID).To(Equal("1"))
+	})
+
+	t.Run("It returns the item with the highest priority", func(t *testing.T) {
+		enqueued := newEnqueuedItems()
+		enqueued.PushBack("low", priorityQueueItem(-1, 0))
+		enqueued.PushBack("default", priorityQueueItem(0, 0))
+		enqueued.PushBack("high", priorityQueueItem(5, 0))
+		enqueued.PushBack("high 2", priorityQueueItem(5, 0))
+
+		g.Expect(enqueued.priorities).To(Equal([]int64{5, 0, -1}))
+
+		nextID, _ := enqueued.Next(nil, nil, time.Now())
+		g.Expect(nextID).To(Equal("high"))
+	})
+
+	t.Run("It returns the first item that matches the headers filter", func(t *testing.T) {
+		enqueued := newEnqueuedItems()
+		enqueued.PushBack("high", priorityQueueItem(5, 0))
+		matching := priorityQueueItem(0, 0)
+		matching.headers = map[string]string{"type": "match"}
+		enqueued.PushBack("default", priorityQueueItem(0, 0))
+		enqueued.PushBack("matching", matching)
+
+		nextID, found := enqueued.Next(map[string]string{"type": "match"}, nil, time.Now())
+		g.Expect(found).To(BeTrue())
+		g.Expect(nextID).To(Equal("matching"))
+
+		_, found = enqueued.Next(map[string]string{"type": "other"}, nil, time.Now())
+		g.Expect(found).To(BeFalse())
+	})
+
+	t.Run("Context when the priorities are aging", func(t *testing.T) {
+		priorityAging := &v1willow.PriorityAging{Interval: helpers.PointerOf(time.Second), MaxBoost: helpers.PointerOf[int64](10)}
+
+		t.Run("It returns a lower priority item that waited long enough", func(t *testing.T) {
+			enqueued := newEnqueuedItems()
+			enqueued.PushBack("waiting", priorityQueueItem(0, 5*time.Second))
+			enqueued.PushBack("high", priorityQueueItem(3, 0))
+
+			nextID, _ := enqueued.Next(nil, priorityAging, time.Now())
+			g.Expect(nextID).To(Equal("waiting"))
+		})
+
+		t.Run("It returns items with the same effective priority in order", func(t *testing.T) {
+			enqueued := newEnqueuedItems()
+			enqueued.PushBack("high", priorityQueueItem(3, 0))
+			enqueued.PushFront("waiting", priorityQueueItem(0, 3*time.Second+500*time.Millisecond))
+
+			nextID, _ := enqueued.Next(nil, priorityAging, time.Now())
+			g.Expect(nextID).To(Equal("waiting"))
+		})
+
+		t.Run("It does not return a lower priority item that can't be raised enough", func(t *testing.T) {
+			enqueued := newEnqueuedItems()
+			enqueued.PushBack("waiting", priorityQueueItem(0, time.Hour))
+			enqueued.PushBack("high", priorityQueueItem(20, 0))
+
+			nextID, _ := enqueued.Next(nil, priorityAging, time.Now())
+			g.Expect(nextID).To(Equal("high"))
+		})
+	})
+}
+
+func Test_enqueuedItems_Buckets(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It removes a bucket once it is empty", func(t *testing.T) {
+		enqueued := newEnqueuedItems()
+		enqueued.PushBack("1", priorityQueueItem(5, 0))
+		enqueued.PushBack("2", priorityQueueItem(0, 0))
+
+		removed, ok := enqueued.Remove("1")
+		g.Expect(ok).To(BeTrue())
+		g.Expect(removed.priority).To(Equal(int64(5)))
+
+		g.Expect(enqueued.Len()).To(Equal(1))
+		g.Expect(enqueued.priorities).To(Equal([]int64{0}))
+		g.Expect(enqueued.buckets).To(HaveLen(1))
+	})
+
+	t.Run("It keeps the bucket in order when moving an item to the back", func(t *testing.T) {
+		enqueued := newEnqueuedItems()
+		enqueued.PushBack("1", priorityQueueItem(0, 0))
+		enqueued.PushBack("2", priorityQueueItem(0, 0))
+		enqueued.PushBack("3", priorityQueueItem(0, 0))
+
+		g.Expect(enqueued.MoveToBack("1")).To(BeTrue())
+		g.Expect(enqueuedIDs(enqueued)).To(Equal([]string{"2", "3", "1"}))
+		g.Expect(bucketIDs(enqueued, 0)).To(Equal([]string{"2", "3", "1"}))
+	})
+
+	t.Run("It moves an item to the bucket for its new priority in order", func(t *testing.T) {
+		enqueued := newEnqueuedItems()
+		changed := priorityQueueItem(0, 0)
+		enqueued.PushBack("1", priorityQueueItem(5, 0))
+		enqueued.PushBack("2", changed)
+		enqueued.PushBack("3", priorityQueueItem(5, 0))
+		enqueued.PushFront("0", priorityQueueItem(5, 0))
+
+		changed.priority = 5
+		enqueued.UpdatePriority("2")
+
+		g.Expect(enqueuedIDs(enqueued)).To(Equal([]string{"0", "1", "2", "3"}))
+		g.Expect(bucketIDs(enqueued, 5)).To(Equal([]string{"0", "1", "2", "3"}))
+		g.Expect(enqueued.priorities).To(Equal([]int64{5}))
+	})
+}
+
+// Benchmark finding and requeueing the next item when a channel has a million items enqueued with priority aging
+func Benchmark_enqueuedItems_Next_PriorityAging(b *testing.B) {
+	const items = 1_000_000
+	priorityAging := &v1willow.PriorityAging{Interval: helpers.PointerOf(time.Second), MaxBoost: helpers.PointerOf[int64](10)}
+
+	enqueued := newEnqueuedItems()
+	for i := 0; i < items; i++ {
+		enqueued.PushBack(fmt.Sprintf("%d", i), priorityQueueItem(int64(i%5), 0))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		nextID, found := enqueued.Next(nil, priorityAging, time.Now())
+		if !found {
+			b.Fatal("failed to find the next item")
+		}
+
+		nextItem, _ := enqueued.Remove(nextID)
+		enqueued.PushBack(nextID, nextItem)
+	}
+}
@@ -5,24 +5,44 @@ import (
 	"time"
 
 	"github.com/DanLavine/willow/internal/heartbeater"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
 )
 
 type item struct {
 	// lock is used to guard all the child objects
 	lock             *sync.RWMutex
 	data             []byte
+	headers          map[string]string
 	updateable       bool
 	retryCount       uint64
 	maxRetryAttempts uint64
 	retryPosition    string
 	heartbeatTimeout time.Duration
+	maxRunDuration   time.Duration
+	idempotencyKey   string
+	dedupKey         string
+	priority         int64
+
+	// waitingSince is when the item was last enqueued. Used to raise the item's effective priority
+	waitingSince time.Time
 
 	// heartbeater is used to setup and manage the heartbeat process
 	heartbeatLock    *sync.RWMutex
-	heartbeatProcess heartbeater.Heartbeater
+	heartbeatProcess *heartbeater.Lease
+
+	// canceled is set when a request to stop processing the item was received. Guarded by the heartbeatLock
+	canceled bool
+
+	// progress is the last progress reported by the client processing the item. Guarded by the heartbeatLock
+	progress *v1willow.ItemProgress
+
+	// attempts records each time the item was dequeued. Guarded by the heartbeatLock
+	attempts []*v1willow.ItemAttempt
 }
 
-func newItem(data []byte, updateable bool, maxRetryAttempts uint64, retryPosition string, heartbeatTimeout time.Duration) *item {
+func newItem(data []byte, updateable bool, maxRetryAttempts uint64, retryPosition string, heartbeatTimeout, maxRunDuration time.Duration) *item {
 	item := &item{
 		lock:             new(sync.RWMutex),
 		data:             data,
@@ -31,14 +51,23 @@ func newItem(data []byte, updateable bool, maxRetryAttempts uint64, retryPositio
 		maxRetryAttempts: maxRetryAttempts,
 		retryPosition:    retryPosition,
 		heartbeatTimeout: heartbeatTimeout,
+		maxRunDuration:   maxRunDuration,
+		waitingSince:     time.Now(),
 		heartbeatLock:    new(sync.RWMutex),
 	}
 
 	return item
 }
 
-// onTimeout needs to eventually call queue_channels_client.deleteChannel() callback
-func (item *item) CreateHeartbeater(onShutdown, onTimeout func()) heartbeater.Heartbeater {
+//	PARAMETERS:
+//	- heartbeats - shared heartbeat manager that tracks the item's timeout
+//	- onTimeout - callback when the item times out. Needs to eventually call queue_channels_client.deleteChannel() callback
+//
+//	RETURNS:
+//	- error - error if the heartbeat manager has stopped
+//
+// CreateHeartbeater sets up the item's lease. The timeout is not tracked until StartHeartbeater is called
+func (item *item) CreateHeartbeater(heartbeats *heartbeater.Manager, onTimeout func()) error {
 	item.heartbeatLock.Lock()
 	defer item.heartbeatLock.Unlock()
 
@@ -46,16 +75,16 @@ func (item *item) CreateHeartbeater(onShutdown, onTimeout func()) heartbeater.He
 		panic("heartbeat process already running")
 	}
 
-	heartbeatProcess, err := heartbeater.New(item.heartbeatTimeout, onShutdown, onTimeout)
+	heartbeatProcess, err := heartbeats.NewLease(item.heartbeatTimeout, item.maxRunDuration, onTimeout)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	item.heartbeatProcess = heartbeatProcess
-	return heartbeatProcess
+	return nil
 }
 
-// UnsetHeartbeater needs to be called when adding the heartbeater to the async task manager fails
+// UnsetHeartbeater needs to be called when the heartbeater already timed out
 func (item *item) UnsetHeartbeater() {
 	item.heartbeatLock.Lock()
 	defer item.heartbeatLock.Unlock()
@@ -79,7 +108,7 @@ func (item *item) StopHeartbeater() bool {
 	defer item.heartbeatLock.Unlock()
 
 	if item.heartbeatProcess != nil {
-		stopped := item.heartbeatProcess.Stop()
+		stopped := item.heartbeatProcess.Cancel()
 		item.heartbeatProcess = nil
 		return stopped
 	}
@@ -87,6 +116,14 @@ func (item *item) StopHeartbeater() bool {
 	return false
 }
 
+// Processing reports if the item has been dequeued and has a heartbeat process that has not stopped yet
+func (item *item) Processing() bool {
+	item.heartbeatLock.RLock()
+	defer item.heartbeatLock.RUnlock()
+
+	return item.heartbeatProcess != nil
+}
+
 func (item *item) Heartbeat() bool {
 	item.heartbeatLock.Lock()
 	defer item.heartbeatLock.Unlock()
@@ -97,3 +134,176 @@ func (item *item) Heartbeat() bool {
 
 	return false
 }
+
+//	RETURNS:
+//	- bool - TRUE iff the item is currently processing and was marked as canceled
+//
+// Cancel marks a processing item to be stopped. The client is informed through the next heartbeat response
+func (item *item) Cancel() bool {
+	item.heartbeatLock.Lock()
+	defer item.heartbeatLock.Unlock()
+
+	if item.heartbeatProcess != nil {
+		item.canceled = true
+		return true
+	}
+
+	return false
+}
+
+// Canceled reports if a cancel request was received for the item
+func (item *item) Canceled() bool {
+	item.heartbeatLock.RLock()
+	defer item.heartbeatLock.RUnlock()
+
+	return item.canceled
+}
+
+// SetProgress records the last progress reported for the item. Setting nil clears any previous progress
+func (item *item) SetProgress(progress *v1willow.ItemProgress) {
+	item.heartbeatLock.Lock()
+	defer item.heartbeatLock.Unlock()
+
+	item.progress = progress
+}
+
+// StartAttempt records that the item is being dequeued by a client
+func (item *item) StartAttempt() {
+	item.heartbeatLock.Lock()
+	defer item.heartbeatLock.Unlock()
+
+	item.attempts = append(item.attempts, &v1willow.ItemAttempt{StartTime: time.Now()})
+}
+
+// DropAttempt removes the last attempt if it never finished. Used when the client never received the dequeued item
+func (item *item) DropAttempt() {
+	item.heartbeatLock.Lock()
+	defer item.heartbeatLock.Unlock()
+
+	if len(item.attempts) > 0 && item.attempts[len(item.attempts)-1].EndTime == nil {
+		item.attempts = item.attempts[:len(item.attempts)-1]
+	}
+}
+
+//	PARAMETERS:
+//	- result - how the attempt finished. One of the v1willow.ItemAttempt* constants
+//	- reason - optional reason the attempt failed, as reported by the client
+//
+//	RETURNS:
+//	- time.Time - time the attempt finished
+//
+// EndAttempt records the result for the last attempt that is still processing
+func (item *item) EndAttempt(result, reason string) time.Time {
+	item.heartbeatLock.Lock()
+	defer item.heartbeatLock.Unlock()
+
+	endTime := time.Now()
+	if len(item.attempts) > 0 && item.attempts[len(item.attempts)-1].EndTime == nil {
+		item.attempts[len(item.attempts)-1].EndTime = &endTime
+		item.attempts[len(item.attempts)-1].Result = result
+		item.attempts[len(item.attempts)-1].Reason = reason
+	}
+
+	return endTime
+}
+
+//	PARAMETERS:
+//	- headersFilter - headers the item must have with the same values
+//
+//	RETURNS:
+//	- bool - true iff the item has all the headers in the filter
+//
+// MatchHeaders returns true if the item has all the headers in the filter with the same values
+func (item *item) MatchHeaders(headersFilter map[string]string) bool {
+	item.lock.RLock()
+	defer item.lock.RUnlock()
+
+	for key, value := range headersFilter {
+		if headerValue, ok := item.headers[key]; !ok || headerValue != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+//	PARAMETERS:
+//	- now - time to calculate how long the item has been waiting
+//	- priorityAging - optional policy to raise the priority of waiting items
+//
+//	RETURNS:
+//	- int64 - the item's priority including any boost for how long it has been waiting
+//
+// EffectivePriority returns the priority used to order the item while it is enqueued
+func (item *item) EffectivePriority(now time.Time, priorityAging *v1willow.PriorityAging) int64 {
+	item.lock.RLock()
+	defer item.lock.RUnlock()
+
+	return priorityAging.EffectivePriority(item.priority, now.Sub(item.waitingSince))
+}
+
+//	PARAMETERS:
+//	- queueName - name of the queue the item belongs to
+//	- itemID - ID of the item in the channel
+//	- channelKeyValues - KeyValues that define the channel the item belongs to
+//
+//	RETURNS:
+//	- *v1willow.Item - api representation of the item that can be used for inspection
+//
+// Item creates a copy of the item's current details that is safe to return to a client
+func (item *item) Item(queueName, itemID string, channelKeyValues datatypes.KeyValues) *v1willow.Item {
+	item.lock.RLock()
+	defer item.lock.RUnlock()
+	item.heartbeatLock.RLock()
+	defer item.heartbeatLock.RUnlock()
+
+	updateable := item.updateable
+	maxRetryAttempts := item.maxRetryAttempts
+	retryPosition := item.retryPosition
+	heartbeatTimeout := item.heartbeatTimeout
+	maxRunDuration := item.maxRunDuration
+	priority := item.priority
+
+	var idempotencyKey *string
+	if item.idempotencyKey != "" {
+		idempotencyKey = &item.idempotencyKey
+	}
+
+	var dedupKey *string
+	if item.dedupKey != "" {
+		dedupKey = &item.dedupKey
+	}
+
+	var attempts []*v1willow.ItemAttempt
+	for _, attempt := range item.attempts {
+		attemptCopy := *attempt
+		attempts = append(attempts, &attemptCopy)
+	}
+
+	return &v1willow.Item{
+		Spec: &v1willow.ItemSpec{
+			DBDefinition: &v1willow.ItemDBDefinition{
+				KeyValues: channelKeyValues,
+			},
+			Properties: &v1willow.ItemProperties{
+				Data:            item.data,
+				Headers:         item.headers,
+				Updateable:      &updateable,
+				RetryAttempts:   &maxRetryAttempts,
+				RetryPosition:   &retryPosition,
+				TimeoutDuration: &heartbeatTimeout,
+				MaxRunDuration:  &maxRunDuration,
+				IdempotencyKey:  idempotencyKey,
+				DedupKey:        dedupKey,
+				Priority:        &priority,
+			},
+		},
+		State: &v1willow.ItemState{
+			ID:         itemID,
+			QueueName:  queueName,
+			Processing: item.heartbeatProcess != nil,
+			Progress:   item.progress,
+			Attempts:   attempts,
+		},
+	}
+}
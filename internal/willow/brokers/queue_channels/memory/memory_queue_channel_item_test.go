@@ -1,11 +1,15 @@
 package memory
 
 import (
-	"context"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/DanLavine/willow/internal/helpers"
+	"github.com/DanLavine/willow/testhelpers"
+
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+
 	. "github.com/onsi/gomega"
 )
 
@@ -13,30 +17,25 @@ func Test_item_CreateHeartbeater(t *testing.T) {
 	g := NewGomegaWithT(t)
 
 	t.Run("It can create a new heartbeater process if one does not yet exist", func(t *testing.T) {
-		item := newItem([]byte(`data`), true, 3, "front", time.Second)
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
 
-		heartbeater := item.CreateHeartbeater(func() {}, func() {})
-		g.Expect(heartbeater).ToNot(BeNil())
-		g.Expect(item.heartbeatProcess).To(Equal(heartbeater))
+		g.Expect(item.CreateHeartbeater(testhelpers.NewRunningHeartbeats(t), func() {})).ToNot(HaveOccurred())
+		g.Expect(item.heartbeatProcess).ToNot(BeNil())
 	})
 
 	t.Run("It panics if the heartbeater is already set", func(t *testing.T) {
-		item := newItem([]byte(`data`), true, 3, "front", time.Second)
-
-		heartbeater := item.CreateHeartbeater(func() {}, func() {})
-		g.Expect(heartbeater).ToNot(BeNil())
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
+		heartbeats := testhelpers.NewRunningHeartbeats(t)
 
-		g.Expect(func() { item.CreateHeartbeater(func() {}, func() {}) }).To(Panic())
+		g.Expect(item.CreateHeartbeater(heartbeats, func() {})).ToNot(HaveOccurred())
+		g.Expect(func() { _ = item.CreateHeartbeater(heartbeats, func() {}) }).To(Panic())
 	})
 
-	t.Run("It panics if onTimeout is nil", func(t *testing.T) {
-		item := newItem([]byte(`data`), true, 3, "front", time.Second)
-		g.Expect(func() { item.CreateHeartbeater(func() {}, nil) }).To(Panic())
-	})
+	t.Run("It returns an error if onTimeout is nil", func(t *testing.T) {
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
 
-	t.Run("It accepts a nil onShutdown callback", func(t *testing.T) {
-		item := newItem([]byte(`data`), true, 3, "front", time.Second)
-		g.Expect(func() { item.CreateHeartbeater(nil, func() {}) }).ToNot(Panic())
+		g.Expect(item.CreateHeartbeater(testhelpers.NewRunningHeartbeats(t), nil)).To(HaveOccurred())
+		g.Expect(item.heartbeatProcess).To(BeNil())
 	})
 }
 
@@ -44,18 +43,17 @@ func Test_item_UnsetHeartbeater(t *testing.T) {
 	g := NewGomegaWithT(t)
 
 	t.Run("It performs a no-op if the heartbeater is not set", func(t *testing.T) {
-		item := newItem([]byte(`data`), true, 3, "front", time.Second)
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
 
 		item.UnsetHeartbeater()
 		g.Expect(item.heartbeatProcess).To(BeNil())
 	})
 
 	t.Run("It unsets a set heartbeater", func(t *testing.T) {
-		item := newItem([]byte(`data`), true, 3, "front", time.Second)
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
 
-		heartbeater := item.CreateHeartbeater(func() {}, func() {})
-		g.Expect(heartbeater).ToNot(BeNil())
-		g.Expect(item.heartbeatProcess).To(Equal(heartbeater))
+		g.Expect(item.CreateHeartbeater(testhelpers.NewRunningHeartbeats(t), func() {})).ToNot(HaveOccurred())
+		g.Expect(item.heartbeatProcess).ToNot(BeNil())
 
 		item.UnsetHeartbeater()
 		g.Expect(item.heartbeatProcess).To(BeNil())
@@ -66,41 +64,25 @@ func Test_item_StartHeartbeater(t *testing.T) {
 	g := NewGomegaWithT(t)
 
 	t.Run("It returns false if there is no heartbeater process", func(t *testing.T) {
-		item := newItem([]byte(`data`), true, 3, "front", time.Second)
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
 
 		started := item.StartHeartbeater()
 		g.Expect(started).To(BeFalse())
 	})
 
 	t.Run("It can start a heartbeater process", func(t *testing.T) {
-		item := newItem([]byte(`data`), true, 3, "front", time.Second)
-
-		heartbeater := item.CreateHeartbeater(func() {}, func() {})
-		g.Expect(heartbeater).ToNot(BeNil())
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
 
-		// run the heartbeater like an async task manager
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-		go func() {
-			_ = heartbeater.Execute(ctx)
-		}()
+		g.Expect(item.CreateHeartbeater(testhelpers.NewRunningHeartbeats(t), func() {})).ToNot(HaveOccurred())
 
 		started := item.StartHeartbeater()
 		g.Expect(started).To(BeTrue())
 	})
 
 	t.Run("It rerturns false for each of the N+ calls to Start", func(t *testing.T) {
-		item := newItem([]byte(`data`), true, 3, "front", time.Second)
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
 
-		heartbeater := item.CreateHeartbeater(func() {}, func() {})
-		g.Expect(heartbeater).ToNot(BeNil())
-
-		// run the heartbeater like an async task manager
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-		go func() {
-			_ = heartbeater.Execute(ctx)
-		}()
+		g.Expect(item.CreateHeartbeater(testhelpers.NewRunningHeartbeats(t), func() {})).ToNot(HaveOccurred())
 
 		g.Expect(item.StartHeartbeater()).To(BeTrue())
 
@@ -116,24 +98,16 @@ func Test_item_StopHeartbeater(t *testing.T) {
 	g := NewGomegaWithT(t)
 
 	t.Run("It returns false if there is no heartbeater process", func(t *testing.T) {
-		item := newItem([]byte(`data`), true, 3, "front", time.Second)
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
 
 		stopped := item.StopHeartbeater()
 		g.Expect(stopped).To(BeFalse())
 	})
 
 	t.Run("It can stop a running heartbeater process", func(t *testing.T) {
-		item := newItem([]byte(`data`), true, 3, "front", time.Second)
-
-		heartbeater := item.CreateHeartbeater(func() {}, func() {})
-		g.Expect(heartbeater).ToNot(BeNil())
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
 
-		// run the heartbeater like an async task manager
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-		go func() {
-			_ = heartbeater.Execute(ctx)
-		}()
+		g.Expect(item.CreateHeartbeater(testhelpers.NewRunningHeartbeats(t), func() {})).ToNot(HaveOccurred())
 
 		started := item.StartHeartbeater()
 		g.Expect(started).To(BeTrue())
@@ -143,17 +117,9 @@ func Test_item_StopHeartbeater(t *testing.T) {
 	})
 
 	t.Run("It rerturns false for each of the N+ calls to Stop", func(t *testing.T) {
-		item := newItem([]byte(`data`), true, 3, "front", time.Second)
-
-		heartbeater := item.CreateHeartbeater(func() {}, func() {})
-		g.Expect(heartbeater).ToNot(BeNil())
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
 
-		// run the heartbeater like an async task manager
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-		go func() {
-			_ = heartbeater.Execute(ctx)
-		}()
+		g.Expect(item.CreateHeartbeater(testhelpers.NewRunningHeartbeats(t), func() {})).ToNot(HaveOccurred())
 
 		g.Expect(item.StartHeartbeater()).To(BeTrue())
 
@@ -171,24 +137,16 @@ func Test_item_Heartbeater(t *testing.T) {
 	g := NewGomegaWithT(t)
 
 	t.Run("It returns false if there is no heartbeater process", func(t *testing.T) {
-		item := newItem([]byte(`data`), true, 3, "front", time.Second)
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
 
 		heartbeated := item.Heartbeat()
 		g.Expect(heartbeated).To(BeFalse())
 	})
 
 	t.Run("It prevets a heartbeat process from timing out", func(t *testing.T) {
-		item := newItem([]byte(`data`), true, 3, "front", time.Second)
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
 
-		heartbeater := item.CreateHeartbeater(func() {}, func() {})
-		g.Expect(heartbeater).ToNot(BeNil())
-
-		// run the heartbeater like an async task manager
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-		go func() {
-			_ = heartbeater.Execute(ctx)
-		}()
+		g.Expect(item.CreateHeartbeater(testhelpers.NewRunningHeartbeats(t), func() {})).ToNot(HaveOccurred())
 
 		started := item.StartHeartbeater()
 		g.Expect(started).To(BeTrue())
@@ -203,18 +161,10 @@ func Test_item_Heartbeater(t *testing.T) {
 	})
 
 	t.Run("It allows the heartbeat to timeout if not processed in time", func(t *testing.T) {
-		item := newItem([]byte(`data`), true, 3, "front", time.Second)
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
 
 		timedOut := new(atomic.Bool)
-		heartbeater := item.CreateHeartbeater(func() {}, func() { timedOut.Store(true) })
-		g.Expect(heartbeater).ToNot(BeNil())
-
-		// run the heartbeater like an async task manager
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-		go func() {
-			_ = heartbeater.Execute(ctx)
-		}()
+		g.Expect(item.CreateHeartbeater(testhelpers.NewRunningHeartbeats(t), func() { timedOut.Store(true) })).ToNot(HaveOccurred())
 
 		started := item.StartHeartbeater()
 		g.Expect(started).To(BeTrue())
@@ -226,3 +176,29 @@ func Test_item_Heartbeater(t *testing.T) {
 		g.Expect(item.StopHeartbeater()).To(BeFalse())
 	})
 }
+
+func Test_item_EffectivePriority(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It returns the base priority when there is no PriorityAging policy", func(t *testing.T) {
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
+		item.priority = 4
+
+		g.Expect(item.EffectivePriority(time.Now().Add(time.Hour), nil)).To(Equal(int64(4)))
+	})
+
+	t.Run("It raises the priority by 1 for every Interval waited", func(t *testing.T) {
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
+		item.priority = -5
+
+		priorityAging := &v1willow.PriorityAging{Interval: helpers.PointerOf(time.Minute), MaxBoost: helpers.PointerOf[int64](10)}
+		g.Expect(item.EffectivePriority(item.waitingSince.Add(3*time.Minute+time.Second), priorityAging)).To(Equal(int64(-2)))
+	})
+
+	t.Run("It does not raise the priority by more than the MaxBoost", func(t *testing.T) {
+		item := newItem([]byte(`data`), true, 3, "front", time.Second, 0)
+
+		priorityAging := &v1willow.PriorityAging{Interval: helpers.PointerOf(time.Minute), MaxBoost: helpers.PointerOf[int64](10)}
+		g.Expect(item.EffectivePriority(item.waitingSince.Add(time.Hour), priorityAging)).To(Equal(int64(10)))
+	})
+}
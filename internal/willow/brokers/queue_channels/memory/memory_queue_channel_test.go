@@ -3,19 +3,26 @@ package memory
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sync"
 	"testing"
 	"time"
 
 	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
 
 	"github.com/DanLavine/willow/internal/helpers"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/fairshare"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/outbox"
 	fakelimiterclient "github.com/DanLavine/willow/pkg/clients/limiter_client/limiterclientfakes"
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+	v1 "github.com/DanLavine/willow/pkg/models/api/common/v1"
 	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
 	querymatchaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_match_action"
 	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
 	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
 	"github.com/DanLavine/willow/testhelpers"
+	"github.com/DanLavine/willow/testhelpers/testmodels"
 
 	"github.com/DanLavine/willow/pkg/models/datatypes"
 
@@ -54,7 +61,7 @@ func Test_memoryQueueChannel_Delete(t *testing.T) {
 		defer mockController.Finish()
 
 		// create queue channel
-		memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
 		// execute like the task manager
 		go func() {
@@ -71,7 +78,7 @@ func Test_memoryQueueChannel_Delete(t *testing.T) {
 		defer mockController.Finish()
 
 		// create queue channel
-		memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
 		// execute like the task manager
 		ctx, cancel := context.WithCancel(context.Background())
@@ -102,7 +109,7 @@ func Test_memoryQueueChannel_Delete(t *testing.T) {
 		}
 		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-		err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+		_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		successfulDelte := memeoryQueueChannel.Delete()
@@ -122,7 +129,7 @@ func Test_memoryQueueChannel_Enqueue(t *testing.T) {
 		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).Times(1)
 
 		// create queue channel
-		memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
 		// create and enqueue the item
 		enqueueItem := &v1willow.Item{
@@ -143,7 +150,7 @@ func Test_memoryQueueChannel_Enqueue(t *testing.T) {
 		}
 		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-		err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+		_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
 		g.Expect(err).ToNot(HaveOccurred())
 	})
 
@@ -155,7 +162,7 @@ func Test_memoryQueueChannel_Enqueue(t *testing.T) {
 		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).Times(1)
 
 		// create queue channel
-		memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
 		// create and enqueue the item
 		enqueueItem := &v1willow.Item{
@@ -176,7 +183,7 @@ func Test_memoryQueueChannel_Enqueue(t *testing.T) {
 		}
 		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-		err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+		_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		// update the first item in the queue
@@ -198,11 +205,11 @@ func Test_memoryQueueChannel_Enqueue(t *testing.T) {
 		}
 		g.Expect(enqueueItem2.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-		err = memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem2)
+		_, err = memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem2)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		// check the available items len
-		g.Expect(len(memeoryQueueChannel.itemIDsEnqueued)).To(Equal(1))
+		g.Expect(memeoryQueueChannel.itemsEnqueued.Len()).To(Equal(1))
 	})
 
 	t.Run("It appends the last item in the queue if it is not yet processing and not updateable", func(t *testing.T) {
@@ -213,7 +220,7 @@ func Test_memoryQueueChannel_Enqueue(t *testing.T) {
 		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).Times(2)
 
 		// create queue channel
-		memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
 		// create and enqueue the item
 		enqueueItem := &v1willow.Item{
@@ -234,7 +241,7 @@ func Test_memoryQueueChannel_Enqueue(t *testing.T) {
 		}
 		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-		err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+		_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		// update the first item in the queue
@@ -256,11 +263,11 @@ func Test_memoryQueueChannel_Enqueue(t *testing.T) {
 		}
 		g.Expect(enqueueItem2.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-		err = memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem2)
+		_, err = memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem2)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		// check the available items len
-		g.Expect(len(memeoryQueueChannel.itemIDsEnqueued)).To(Equal(2))
+		g.Expect(memeoryQueueChannel.itemsEnqueued.Len()).To(Equal(2))
 	})
 
 	t.Run("Context when the limits are already reached", func(t *testing.T) {
@@ -272,7 +279,7 @@ func Test_memoryQueueChannel_Enqueue(t *testing.T) {
 			fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return fmt.Errorf("failed to update counter") }).Times(1)
 
 			// create queue channel
-			memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+			memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
 			// create and enqueue the item
 			enqueueItem := &v1willow.Item{
@@ -293,7 +300,7 @@ func Test_memoryQueueChannel_Enqueue(t *testing.T) {
 			}
 			g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-			err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+			_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
 			g.Expect(err).To(HaveOccurred())
 			g.Expect(err.Error()).To(ContainSubstring("Queue has reached the total number of allowed queue items"))
 		})
@@ -313,7 +320,7 @@ func Test_memoryQueueChannel_Enqueue(t *testing.T) {
 			}).Times(1)
 
 			// create queue channel
-			memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+			memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
 			// create and enqueue the item
 			enqueueItem := &v1willow.Item{
@@ -334,7 +341,7 @@ func Test_memoryQueueChannel_Enqueue(t *testing.T) {
 			}
 			g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-			err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+			_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
 			g.Expect(err).ToNot(HaveOccurred())
 
 			// update the first item in the queue
@@ -356,787 +363,707 @@ func Test_memoryQueueChannel_Enqueue(t *testing.T) {
 			}
 			g.Expect(enqueueItem2.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-			err = memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem2)
+			_, err = memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem2)
 			g.Expect(err).ToNot(HaveOccurred())
 		})
 	})
 }
 
-func Test_memoryQueueChannel_Dequeue(t *testing.T) {
+func Test_memoryQueueChannel_Enqueue_DedupKey(t *testing.T) {
 	g := NewGomegaWithT(t)
 
-	t.Run("It closes the dequeue chan when Execute stops processing", func(t *testing.T) {
-		// set limiter client to pass
-		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 0)
-		defer mockController.Finish()
-
-		// create queue channel
-		memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+	dedupItem := func(data string, dedupKey string, moveToBack bool) *v1willow.Item {
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{
+						"one": datatypes.Int(1),
+					},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(data),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](0),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+					DedupKey:        helpers.PointerOf(dedupKey),
+				},
+			},
+		}
+		if moveToBack {
+			enqueueItem.Spec.Properties.DedupMoveToBack = helpers.PointerOf(true)
+		}
+		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-		// execute like the task manager
-		ctx, cancel := context.WithCancel(context.Background())
-		go func() {
-			_ = memeoryQueueChannel.Execute(ctx)
-		}()
+		return enqueueItem
+	}
 
-		// grab the dequeu channel
-		dequeueChan := memeoryQueueChannel.Dequeue()
-		g.Consistently(dequeueChan).ShouldNot(Receive())
+	itemData := func(memeoryQueueChannel *memoryQueueChannel) []string {
+		data := []string{}
+		memeoryQueueChannel.itemsEnqueued.Iterate(func(itemID string, _ *item) bool {
+			items := memeoryQueueChannel.Items(testhelpers.NewContextWithMiddlewareSetup(), &itemID)
+			g.Expect(len(items)).To(Equal(1))
+			data = append(data, string(items[0].Spec.Properties.Data))
+			return true
+		})
 
-		// close the deueChan by stopping taskmanager
-		cancel()
-		g.Eventually(dequeueChan).Should(BeClosed())
-	})
+		return data
+	}
 
-	t.Run("It closes the deueue chan and stops Executing when ForceDelete is called", func(t *testing.T) {
-		// set limiter client to pass
-		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 0)
+	t.Run("It replaces the enqueued item with the same key in place", func(t *testing.T) {
+		mockController, fakeLimiterClient := fakeLimiterClient(t)
 		defer mockController.Finish()
 
-		// create queue channel
-		memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		// only the 2 new items update the limiter
+		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).Times(2)
 
-		// execute like the task manager
-		go func() {
-			_ = memeoryQueueChannel.Execute(context.Background())
-		}()
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
-		// grab the dequeu channel
-		dequeueChan := memeoryQueueChannel.Dequeue()
-		g.Consistently(dequeueChan).ShouldNot(Receive())
+		itemState, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), dedupItem("pr-1 commit 1", "pr-1", false))
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), dedupItem("pr-2 commit 1", "pr-2", false))
+		g.Expect(err).ToNot(HaveOccurred())
 
-		// close the deueChan by stopping taskmanager
-		memeoryQueueChannel.ForceDelete(testhelpers.NewContextWithMiddlewareSetup())
-		g.Eventually(dequeueChan).Should(BeClosed())
+		replacedState, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), dedupItem("pr-1 commit 2", "pr-1", false))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(replacedState.ID).To(Equal(itemState.ID))
+
+		g.Expect(itemData(memeoryQueueChannel)).To(Equal([]string{"pr-1 commit 2", "pr-2 commit 1"}))
 	})
 
-	t.Run("Context when the dequeue callback function succeeds", func(t *testing.T) {
-		t.Run("Context successful dequeues", func(t *testing.T) {
-			t.Run("It can dequeue only a single item untill success is called", func(t *testing.T) {
-				// set limiter client to pass
-				mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 7) // 5 for enqueue, 2 for success
-				defer mockController.Finish()
+	t.Run("It can move the replaced item to the back", func(t *testing.T) {
+		mockController, fakeLimiterClient := fakeLimiterClient(t)
+		defer mockController.Finish()
 
-				// create queue channel
-				memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).Times(2)
 
-				// execute like the task manager
-				ctx, cancel := context.WithCancel(context.Background())
-				defer cancel()
-				go func() {
-					_ = memeoryQueueChannel.Execute(ctx)
-				}()
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
-				// create and enqueue multiple items
-				for i := 1; i <= 5; i++ {
-					enqueueItem := &v1willow.Item{
-						Spec: &v1willow.ItemSpec{
-							DBDefinition: &v1willow.ItemDBDefinition{
-								KeyValues: datatypes.KeyValues{
-									"one": datatypes.Int(1),
-								},
-							},
-							Properties: &v1willow.ItemProperties{
-								Data:            []byte(fmt.Sprintf("data %d", i)),
-								Updateable:      helpers.PointerOf(false),
-								RetryAttempts:   helpers.PointerOf[uint64](uint64(i)),
-								RetryPosition:   helpers.PointerOf("front"),
-								TimeoutDuration: helpers.PointerOf(time.Duration(i) * time.Second),
-							},
-						},
-					}
-					g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+		_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), dedupItem("pr-1 commit 1", "pr-1", false))
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), dedupItem("pr-2 commit 1", "pr-2", false))
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), dedupItem("pr-1 commit 2", "pr-1", true))
+		g.Expect(err).ToNot(HaveOccurred())
 
-					err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
-					g.Expect(err).ToNot(HaveOccurred())
-				}
+		g.Expect(itemData(memeoryQueueChannel)).To(Equal([]string{"pr-2 commit 1", "pr-1 commit 2"}))
+	})
 
-				// grab the first dequeu channel
-				var dequeueItem *v1willow.Item
-				var success func()
-				dequeueChan := memeoryQueueChannel.Dequeue()
-				select {
-				case dequeueFunc := <-dequeueChan:
-					dequeueItem, success, _ = dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
-					g.Expect(dequeueItem).ToNot(BeNil())
+	t.Run("It enqueues a new item when the item with the same key is processing", func(t *testing.T) {
+		mockController, fakeLimiterClient := fakeLimiterClient(t)
+		defer mockController.Finish()
 
-					g.Expect(dequeueItem.Spec.Properties.Data).To(Equal([]byte(`data 1`)))
-					g.Expect(dequeueItem.State.ID).ToNot(Equal(""))
-					g.Expect(dequeueItem.Spec.DBDefinition.KeyValues).To(Equal(defaultKeyValues(g)))
-					g.Expect(*dequeueItem.Spec.Properties.TimeoutDuration).To(Equal(time.Second))
-				case <-time.After(time.Second):
-					g.Fail("failed to dequeue item")
-				}
+		// 2 for enqueue, 1 for dequeue
+		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).Times(3)
 
-				// ensure that we cannot dequeue another item
-				g.Consistently(dequeueChan).ShouldNot(Receive())
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
-				// call success and get the 2nd item
-				success()
-				select {
-				case dequeueFunc := <-dequeueChan:
-					dequeueItem, success, _ = dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
-					defer success()
-					g.Expect(dequeueItem).ToNot(BeNil())
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_ = memeoryQueueChannel.Execute(ctx)
+		}()
 
-					g.Expect(dequeueItem.Spec.Properties.Data).To(Equal([]byte(`data 2`)))
-					g.Expect(dequeueItem.State.ID).ToNot(Equal(""))
-					g.Expect(dequeueItem.Spec.DBDefinition.KeyValues).To(Equal(defaultKeyValues(g)))
-					g.Expect(*dequeueItem.Spec.Properties.TimeoutDuration).To(Equal(2 * time.Second))
-				case <-time.After(time.Second):
-					g.Fail("failed to dequeue item")
-				}
-			})
-		})
+		itemState, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), dedupItem("pr-1 commit 1", "pr-1", false))
+		g.Expect(err).ToNot(HaveOccurred())
 
-		t.Run("Context fail dequeues", func(t *testing.T) {
-			t.Run("It dequeues the same item multiple times if fail is called", func(t *testing.T) {
-				// set limiter client to pass
-				mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 9) // 5 for enqueue, 2 for dequeue(), 2 times for fail()
-				defer mockController.Finish()
+		// dequeue the item
+		select {
+		case dequeueFunc := <-memeoryQueueChannel.Dequeue():
+			dequeueItem, success, _ := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+			g.Expect(dequeueItem.State.ID).To(Equal(itemState.ID))
+			g.Expect(*dequeueItem.Spec.Properties.DedupKey).To(Equal("pr-1"))
+			success()
+		case <-time.After(time.Second):
+			g.Fail("failed to dequeue item")
+		}
 
-				// create queue channel
-				memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		newState, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), dedupItem("pr-1 commit 2", "pr-1", false))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(newState.ID).ToNot(Equal(itemState.ID))
+		g.Expect(itemData(memeoryQueueChannel)).To(Equal([]string{"pr-1 commit 2"}))
+	})
+}
 
-				// execute like the task manager
-				ctx, cancel := context.WithCancel(context.Background())
-				defer cancel()
-				go func() {
-					_ = memeoryQueueChannel.Execute(ctx)
-				}()
+func Test_memoryQueueChannel_Dequeue_HeadersFilter(t *testing.T) {
+	g := NewGomegaWithT(t)
 
-				// create and enqueue multiple items
-				for i := 1; i <= 5; i++ {
-					enqueueItem := &v1willow.Item{
-						Spec: &v1willow.ItemSpec{
-							DBDefinition: &v1willow.ItemDBDefinition{
-								KeyValues: datatypes.KeyValues{
-									"one": datatypes.Int(1),
-								},
-							},
-							Properties: &v1willow.ItemProperties{
-								Data:            []byte(fmt.Sprintf("data %d", i)),
-								Updateable:      helpers.PointerOf(false),
-								RetryAttempts:   helpers.PointerOf[uint64](uint64(i)),
-								RetryPosition:   helpers.PointerOf("front"),
-								TimeoutDuration: helpers.PointerOf(time.Duration(i) * time.Second),
-							},
-						},
-					}
-					g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+	headersItem := func(data string, headers map[string]string) *v1willow.Item {
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{
+						"one": datatypes.Int(1),
+					},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(data),
+					Headers:         headers,
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](0),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-					err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
-					g.Expect(err).ToNot(HaveOccurred())
-				}
+		return enqueueItem
+	}
 
-				// grab the first dequeu channel
-				var dequeueItem *v1willow.Item
-				var fail func()
-				dequeueChan := memeoryQueueChannel.Dequeue()
-				select {
-				case dequeueFunc := <-dequeueChan:
-					dequeueItem, _, fail = dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
-					g.Expect(dequeueItem).ToNot(BeNil())
+	t.Run("It dequeues the first item that matches the headers filter", func(t *testing.T) {
+		mockController, fakeLimiterClient := fakeLimiterClient(t)
+		defer mockController.Finish()
 
-					g.Expect(dequeueItem.Spec.Properties.Data).To(Equal([]byte(`data 1`)))
-					g.Expect(dequeueItem.State.ID).ToNot(Equal(""))
-					g.Expect(dequeueItem.Spec.DBDefinition.KeyValues).To(Equal(defaultKeyValues(g)))
-					g.Expect(*dequeueItem.Spec.Properties.TimeoutDuration).To(Equal(time.Second))
-				case <-time.After(time.Second):
-					g.Fail("failed to dequeue item")
-				}
+		// 2 for enqueue, 1 for dequeue
+		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).Times(3)
 
-				// ensure that we cannot dequeue another item
-				g.Consistently(dequeueChan).ShouldNot(Receive())
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
-				// call success and get the 2nd item
-				fail()
-				select {
-				case dequeueFunc := <-dequeueChan:
-					dequeueItem, _, fail = dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
-					defer fail()
-					g.Expect(dequeueItem).ToNot(BeNil())
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_ = memeoryQueueChannel.Execute(ctx)
+		}()
 
-					g.Expect(dequeueItem.Spec.Properties.Data).To(Equal([]byte(`data 1`)))
-					g.Expect(dequeueItem.State.ID).ToNot(Equal(""))
-					g.Expect(dequeueItem.Spec.DBDefinition.KeyValues).To(Equal(defaultKeyValues(g)))
-					g.Expect(*dequeueItem.Spec.Properties.TimeoutDuration).To(Equal(time.Second))
-				case <-time.After(time.Second):
-					g.Fail("failed to dequeue item")
-				}
-			})
-		})
+		_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), headersItem("version 1", map[string]string{"schema": "v1"}))
+		g.Expect(err).ToNot(HaveOccurred())
+		itemState, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), headersItem("version 2", map[string]string{"schema": "v2", "encoding": "json"}))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		select {
+		case dequeueFunc := <-memeoryQueueChannel.Dequeue():
+			dequeueItem, success, _ := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), map[string]string{"schema": "v2"})
+			g.Expect(dequeueItem).ToNot(BeNil())
+			g.Expect(dequeueItem.State.ID).To(Equal(itemState.ID))
+			g.Expect(dequeueItem.Spec.Properties.Data).To(Equal([]byte(`version 2`)))
+			g.Expect(dequeueItem.Spec.Properties.Headers).To(Equal(map[string]string{"schema": "v2", "encoding": "json"}))
+			success()
+		case <-time.After(time.Second):
+			g.Fail("failed to dequeue item")
+		}
+
+		g.Expect(memeoryQueueChannel.itemsEnqueued.Len()).To(Equal(1))
 	})
 
-	t.Run("Context when the dequeue callback function fails", func(t *testing.T) {
-		t.Run("It stops the limit checks if Execute context is canceled", func(t *testing.T) {
-			// set limiter client to pass
+	t.Run("Context when no items match the headers filter", func(t *testing.T) {
+		t.Run("It leaves the items enqueued for other clients", func(t *testing.T) {
 			mockController, fakeLimiterClient := fakeLimiterClient(t)
 			defer mockController.Finish()
 
-			count := 0
-			fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
-				if count == 0 {
-					// allow for a single enqueue
-					count++
-					return nil
-				}
+			// 1 for enqueue, 1 for the unfiltered dequeue
+			fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).Times(2)
 
-				return fmt.Errorf("error, limit has been reached")
-			}).Times(2)
-
-			ruleCount := 0
-			fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
-				if ruleCount == 0 {
-					ruleCount++
-
-					return v1limiter.Rules{
-						&v1limiter.Rule{
-							Spec: &v1limiter.RuleSpec{
-								DBDefinition: &v1limiter.RuleDBDefinition{
-									GroupByKeyValues: datatypes.KeyValues{
-										"one": datatypes.Any(),
-									},
-								},
-								Properties: &v1limiter.RuleProperties{
-									Limit: helpers.PointerOf[int64](0),
-								},
-							},
-							State: &v1limiter.RuleState{
-								ID: "test",
-							},
-						},
-					}, nil
-				}
-
-				return nil, nil
-			}).Times(1)
-
-			fakeLimiterClient.EXPECT().MatchOverrides(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *querymatchaction.MatchActionQuery) (v1limiter.Overrides, error) {
-				return nil, nil
-			}).Times(1)
-
-			// create queue channel
-			memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+			memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
-			// execute like the task manager
-			doneExecuting := make(chan struct{})
 			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
 			go func() {
-				defer close(doneExecuting)
 				_ = memeoryQueueChannel.Execute(ctx)
 			}()
 
-			// create and enqueue an item
-			enqueueItem := &v1willow.Item{
-				Spec: &v1willow.ItemSpec{
-					DBDefinition: &v1willow.ItemDBDefinition{
-						KeyValues: datatypes.KeyValues{
-							"one": datatypes.Int(1),
-						},
-					},
-					Properties: &v1willow.ItemProperties{
-						Data:            []byte(`data`),
-						Updateable:      helpers.PointerOf(false),
-						RetryAttempts:   helpers.PointerOf[uint64](1),
-						RetryPosition:   helpers.PointerOf("front"),
-						TimeoutDuration: helpers.PointerOf(time.Second),
-					},
-				},
-			}
-			g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
-
-			err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+			itemState, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), headersItem("version 1", map[string]string{"schema": "v1"}))
 			g.Expect(err).ToNot(HaveOccurred())
 
-			// grab the first dequeu channel
-			dequeueChan := memeoryQueueChannel.Dequeue()
 			select {
-			case dequeueFunc := <-dequeueChan:
-				dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
+			case dequeueFunc := <-memeoryQueueChannel.Dequeue():
+				dequeueItem, success, failure := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), map[string]string{"schema": "v2"})
 				g.Expect(dequeueItem).To(BeNil())
 				g.Expect(success).To(BeNil())
-				g.Expect(fail).To(BeNil())
+				g.Expect(failure).To(BeNil())
+			case <-time.After(time.Second):
+				g.Fail("failed to read the dequeue function")
+			}
 
+			select {
+			case dequeueFunc := <-memeoryQueueChannel.Dequeue():
+				dequeueItem, success, _ := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+				g.Expect(dequeueItem).ToNot(BeNil())
+				g.Expect(dequeueItem.State.ID).To(Equal(itemState.ID))
+				success()
 			case <-time.After(time.Second):
 				g.Fail("failed to dequeue item")
 			}
-
-			cancel()
-			g.Eventually(doneExecuting).Should(BeClosed())
 		})
+	})
+}
 
-		t.Run("It stops the limit checks if ForceDelete is called", func(t *testing.T) {
-			// set limiter client to pass
-			mockController, fakeLimiterClient := fakeLimiterClient(t)
-			defer mockController.Finish()
+func Test_memoryQueueChannel_Dequeue_FairShare(t *testing.T) {
+	g := NewGomegaWithT(t)
 
-			fakeLimiterClient.EXPECT().SetCounters(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
+	enqueueItem := &v1willow.Item{
+		Spec: &v1willow.ItemSpec{
+			DBDefinition: &v1willow.ItemDBDefinition{
+				KeyValues: defaultKeyValues(g),
+			},
+			Properties: &v1willow.ItemProperties{
+				Data:            []byte(`hello world`),
+				Updateable:      helpers.PointerOf(false),
+				RetryAttempts:   helpers.PointerOf[uint64](0),
+				RetryPosition:   helpers.PointerOf("front"),
+				TimeoutDuration: helpers.PointerOf(time.Second),
+			},
+		},
+	}
+	g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-			count := 0
-			fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
-				if count == 0 {
-					// allow for a single enqueue
-					count++
-					return nil
-				}
+	dequeue := func(memeoryQueueChannel *memoryQueueChannel) *v1willow.Item {
+		select {
+		case dequeueFunc := <-memeoryQueueChannel.Dequeue():
+			dequeueItem, success, _ := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+			if success != nil {
+				success()
+			}
+			return dequeueItem
+		case <-time.After(time.Second):
+			g.Fail("failed to read the dequeue function")
+		}
 
-				return fmt.Errorf("error, limit has been reached")
-			}).Times(2)
+		return nil
+	}
 
-			ruleCount := 0
-			fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
-				if ruleCount == 0 {
-					ruleCount++
+	t.Run("It refuses to dequeue when another channel has a larger share of the round", func(t *testing.T) {
+		// 3 for enqueue, 2 for dequeue
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 5)
+		defer mockController.Finish()
 
-					return v1limiter.Rules{
-						&v1limiter.Rule{
-							Spec: &v1limiter.RuleSpec{
-								DBDefinition: &v1limiter.RuleDBDefinition{
-									GroupByKeyValues: datatypes.KeyValues{
-										"one": datatypes.Any(),
-									},
-								},
-								Properties: &v1limiter.RuleProperties{
-									Limit: helpers.PointerOf[int64](0),
-								},
-							},
-							State: &v1limiter.RuleState{
-								ID: "test",
-							},
+		channelWeight := &v1willow.ChannelWeight{
+			ChannelQuery: &queryassociatedaction.AssociatedActionQuery{
+				Selection: &queryassociatedaction.Selection{
+					KeyValues: queryassociatedaction.SelectionKeyValues{
+						"one": {
+							Value:            datatypes.Int(1),
+							Comparison:       v1.Equals,
+							TypeRestrictions: testmodels.NoTypeRestrictions(g),
 						},
-					}, nil
-				}
+					},
+				},
+			},
+			Weight: helpers.PointerOf[int64](3),
+		}
+		g.Expect(channelWeight.Validate()).ToNot(HaveOccurred())
+		scheduler := fairshare.New([]*v1willow.ChannelWeight{channelWeight})
 
-				return nil, nil
-			}).Times(1)
+		heavyChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), scheduler, nil)
+		lightChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", datatypes.KeyValues{"two": datatypes.Int(2)}, scheduler, nil)
 
-			fakeLimiterClient.EXPECT().MatchOverrides(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *querymatchaction.MatchActionQuery) (v1limiter.Overrides, error) {
-				return nil, nil
-			}).Times(1)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { _ = heavyChannel.Execute(ctx) }()
+		go func() { _ = lightChannel.Execute(ctx) }()
 
-			// create queue channel
-			memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		_, err := heavyChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+		g.Expect(err).ToNot(HaveOccurred())
+		for i := 0; i < 2; i++ {
+			_, err = lightChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
 
-			// execute like the task manager
-			doneExecuting := make(chan struct{})
-			go func() {
-				defer close(doneExecuting)
-				_ = memeoryQueueChannel.Execute(context.Background())
-			}()
+		// starts a new round
+		g.Expect(dequeue(lightChannel)).ToNot(BeNil())
+		g.Expect(lightChannel.FairShare()).To(Equal(fairshare.State{Weight: 1, Deficit: 0}))
+		g.Expect(heavyChannel.FairShare()).To(Equal(fairshare.State{Weight: 3, Deficit: 3}))
 
-			// create and enqueue an item
-			enqueueItem := &v1willow.Item{
-				Spec: &v1willow.ItemSpec{
-					DBDefinition: &v1willow.ItemDBDefinition{
-						KeyValues: datatypes.KeyValues{
-							"one": datatypes.Int(1),
-						},
-					},
-					Properties: &v1willow.ItemProperties{
-						Data:            []byte(`data`),
-						Updateable:      helpers.PointerOf(false),
-						RetryAttempts:   helpers.PointerOf[uint64](1),
-						RetryPosition:   helpers.PointerOf("front"),
-						TimeoutDuration: helpers.PointerOf(time.Second),
-					},
-				},
-			}
-			g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+		// heavy channel still has its share of the round
+		g.Expect(dequeue(lightChannel)).To(BeNil())
+		g.Expect(lightChannel.itemsEnqueued.Len()).To(Equal(1))
 
-			err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
-			g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(dequeue(heavyChannel)).ToNot(BeNil())
+		g.Expect(heavyChannel.FairShare()).To(Equal(fairshare.State{Weight: 3, Deficit: 2}))
+	})
+}
 
-			// grab the first dequeu channel
-			dequeueChan := memeoryQueueChannel.Dequeue()
-			select {
-			case dequeueFunc := <-dequeueChan:
-				dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
-				g.Expect(dequeueItem).To(BeNil())
-				g.Expect(success).To(BeNil())
-				g.Expect(fail).To(BeNil())
-			case <-time.After(time.Second):
-				g.Fail("failed to dequeue item")
-			}
+func Test_memoryQueueChannel_Dequeue_Priority(t *testing.T) {
+	g := NewGomegaWithT(t)
 
-			memeoryQueueChannel.ForceDelete(testhelpers.NewContextWithMiddlewareSetup())
-			g.Eventually(doneExecuting).Should(BeClosed())
-		})
+	priorityItem := func(data string, priority int64) *v1willow.Item {
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: defaultKeyValues(g),
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(data),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](0),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+					Priority:        helpers.PointerOf(priority),
+				},
+			},
+		}
+		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-		t.Run("Context when checking to know when the limit is no longer an issue", func(t *testing.T) {
-			t.Run("It stops when there are no rules", func(t *testing.T) {
-				// set limiter client to pass
-				mockController, fakeLimiterClient := fakeLimiterClient(t)
-				defer mockController.Finish()
+		return enqueueItem
+	}
 
-				count := 0
-				fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
-					if count == 1 {
-						count++
-						return fmt.Errorf("error, limit has been reached")
-					}
+	dequeueData := func(memeoryQueueChannel *memoryQueueChannel) string {
+		select {
+		case dequeueFunc := <-memeoryQueueChannel.Dequeue():
+			dequeueItem, success, _ := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+			g.Expect(dequeueItem).ToNot(BeNil())
+			success()
 
-					count++
-					return nil
-				}).Times(3)
+			return string(dequeueItem.Spec.Properties.Data)
+		case <-time.After(time.Second):
+			g.Fail("failed to dequeue item")
+		}
 
-				ruleCount := 0
-				fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
-					if ruleCount == 0 {
-						ruleCount++
+		return ""
+	}
 
-						return v1limiter.Rules{
-							&v1limiter.Rule{
-								Spec: &v1limiter.RuleSpec{
-									DBDefinition: &v1limiter.RuleDBDefinition{
-										GroupByKeyValues: datatypes.KeyValues{
-											"one": datatypes.Any(),
-										},
-									},
-									Properties: &v1limiter.RuleProperties{
-										Limit: helpers.PointerOf[int64](0),
-									},
-								},
-								State: &v1limiter.RuleState{
-									ID: "test",
-								},
-							},
-						}, nil
-					}
+	t.Run("It dequeues items with a higher priority first", func(t *testing.T) {
+		// 3 for enqueue, 3 for dequeue
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 6)
+		defer mockController.Finish()
 
-					return nil, nil
-				}).Times(2)
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
-				fakeLimiterClient.EXPECT().MatchOverrides(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *querymatchaction.MatchActionQuery) (v1limiter.Overrides, error) {
-					return nil, nil
-				}).Times(1)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_ = memeoryQueueChannel.Execute(ctx)
+		}()
 
-				// create queue channel
-				memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		for data, priority := range map[string]int64{"low": -1, "default": 0, "high": 5} {
+			_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), priorityItem(data, priority))
+			g.Expect(err).ToNot(HaveOccurred())
+		}
 
-				// execute like the task manager
-				doneExecuting := make(chan struct{})
-				ctx, cancel := context.WithCancel(context.Background())
-				go func() {
-					defer close(doneExecuting)
-					_ = memeoryQueueChannel.Execute(ctx)
-				}()
+		g.Expect(dequeueData(memeoryQueueChannel)).To(Equal("high"))
+		g.Expect(dequeueData(memeoryQueueChannel)).To(Equal("default"))
+		g.Expect(dequeueData(memeoryQueueChannel)).To(Equal("low"))
+	})
 
-				// create and enqueue an item
-				enqueueItem := &v1willow.Item{
-					Spec: &v1willow.ItemSpec{
-						DBDefinition: &v1willow.ItemDBDefinition{
-							KeyValues: datatypes.KeyValues{
-								"one": datatypes.Int(1),
-							},
-						},
-						Properties: &v1willow.ItemProperties{
-							Data:            []byte(`data`),
-							Updateable:      helpers.PointerOf(false),
-							RetryAttempts:   helpers.PointerOf[uint64](1),
-							RetryPosition:   helpers.PointerOf("front"),
-							TimeoutDuration: helpers.PointerOf(time.Second),
-						},
-					},
-				}
-				g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+	t.Run("It dequeues items in order again once the items with a priority are gone", func(t *testing.T) {
+		// 3 for enqueue, 2 for dequeue
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 5)
+		defer mockController.Finish()
 
-				err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
-				g.Expect(err).ToNot(HaveOccurred())
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
-				// grab the first dequeu channel
-				// first call should return nil to setup the channel is blocked
-				dequeueChan := memeoryQueueChannel.Dequeue()
-				select {
-				case dequeueFunc := <-dequeueChan:
-					dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
-					g.Expect(dequeueItem).To(BeNil())
-					g.Expect(success).To(BeNil())
-					g.Expect(fail).To(BeNil())
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_ = memeoryQueueChannel.Execute(ctx)
+		}()
 
-				case <-time.After(time.Second):
-					g.Fail("failed to dequeue item")
+		_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), priorityItem("high", 5))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(dequeueData(memeoryQueueChannel)).To(Equal("high"))
+
+		for _, data := range []string{"first", "second"} {
+			_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), priorityItem(data, 0))
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		g.Expect(dequeueData(memeoryQueueChannel)).To(Equal("first"))
+
+		// only the bucket for the default priority is left
+		memeoryQueueChannel.itemsLock.RLock()
+		defer memeoryQueueChannel.itemsLock.RUnlock()
+		g.Expect(memeoryQueueChannel.itemsEnqueued.priorities).To(Equal([]int64{0}))
+	})
+
+	t.Run("Context when the queue has a PriorityAging policy", func(t *testing.T) {
+		t.Run("It raises the priority of items that have been waiting", func(t *testing.T) {
+			// 2 for enqueue, 1 for dequeue
+			mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 3)
+			defer mockController.Finish()
+
+			scheduler := fairshare.New(nil)
+			scheduler.SetPriorityAging(&v1willow.PriorityAging{Interval: helpers.PointerOf(10 * time.Millisecond), MaxBoost: helpers.PointerOf[int64](100)})
+			memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), scheduler, nil)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				_ = memeoryQueueChannel.Execute(ctx)
+			}()
+
+			_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), priorityItem("nightly", 0))
+			g.Expect(err).ToNot(HaveOccurred())
+			time.Sleep(100 * time.Millisecond)
+			_, err = memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), priorityItem("urgent", 3))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			// both the base and effective priorities can be inspected
+			for _, item := range memeoryQueueChannel.Items(testhelpers.NewContextWithMiddlewareSetup(), nil) {
+				switch string(item.Spec.Properties.Data) {
+				case "nightly":
+					g.Expect(*item.Spec.Properties.Priority).To(Equal(int64(0)))
+					g.Expect(*item.State.EffectivePriority).To(BeNumerically(">=", 10))
+				default:
+					g.Expect(*item.Spec.Properties.Priority).To(Equal(int64(3)))
+					g.Expect(*item.State.EffectivePriority).To(BeNumerically("<", 10))
 				}
+			}
 
-				// second call should return an item as the limiter client now passes
-				select {
-				case dequeueFunc := <-dequeueChan:
-					dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
-					g.Expect(dequeueItem).ToNot(BeNil())
-					g.Expect(success).ToNot(BeNil())
-					g.Expect(fail).ToNot(BeNil())
+			g.Expect(dequeueData(memeoryQueueChannel)).To(Equal("nightly"))
+		})
 
-					success()
-				case <-time.After(6 * time.Second):
-					g.Fail("failed to dequeue item")
+		t.Run("It caps the raised priority at the MaxBoost", func(t *testing.T) {
+			// 2 for enqueue, 1 for dequeue
+			mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 3)
+			defer mockController.Finish()
+
+			scheduler := fairshare.New(nil)
+			scheduler.SetPriorityAging(&v1willow.PriorityAging{Interval: helpers.PointerOf(10 * time.Millisecond), MaxBoost: helpers.PointerOf[int64](2)})
+			memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), scheduler, nil)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				_ = memeoryQueueChannel.Execute(ctx)
+			}()
+
+			_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), priorityItem("nightly", 0))
+			g.Expect(err).ToNot(HaveOccurred())
+			time.Sleep(100 * time.Millisecond)
+			_, err = memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), priorityItem("urgent", 3))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			items := memeoryQueueChannel.Items(testhelpers.NewContextWithMiddlewareSetup(), nil)
+			g.Expect(len(items)).To(Equal(2))
+			for _, item := range items {
+				if string(item.Spec.Properties.Data) == "nightly" {
+					g.Expect(*item.State.EffectivePriority).To(Equal(int64(2)))
 				}
+			}
 
-				cancel()
-				g.Eventually(doneExecuting).Should(BeClosed())
-			})
+			g.Expect(dequeueData(memeoryQueueChannel)).To(Equal("urgent"))
+		})
+	})
+}
 
-			t.Run("It stops when all Rules are unlimited", func(t *testing.T) {
-				// set limiter client to pass
-				mockController, fakeLimiterClient := fakeLimiterClient(t)
-				defer mockController.Finish()
+func Test_memoryQueueChannel_Dequeue(t *testing.T) {
+	g := NewGomegaWithT(t)
 
-				count := 0
-				fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
-					if count == 0 {
-						// allow for a single enqueue
-						count++
-						return nil
-					}
+	t.Run("It closes the dequeue chan when Execute stops processing", func(t *testing.T) {
+		// set limiter client to pass
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 0)
+		defer mockController.Finish()
 
-					return fmt.Errorf("error, limit has been reached")
-				}).Times(2)
+		// create queue channel
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
-				fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
-					return v1limiter.Rules{
-						&v1limiter.Rule{
-							Spec: &v1limiter.RuleSpec{
-								DBDefinition: &v1limiter.RuleDBDefinition{
-									GroupByKeyValues: datatypes.KeyValues{
-										"one": datatypes.Any(),
-									},
-								},
-								Properties: &v1limiter.RuleProperties{
-									Limit: helpers.PointerOf[int64](-1),
-								},
-							},
-							State: &v1limiter.RuleState{
-								ID: "test1",
-							},
-						},
-						&v1limiter.Rule{
-							Spec: &v1limiter.RuleSpec{
-								DBDefinition: &v1limiter.RuleDBDefinition{
-									GroupByKeyValues: datatypes.KeyValues{
-										"one": datatypes.Any(),
-										"two": datatypes.Any(),
-									},
-								},
-								Properties: &v1limiter.RuleProperties{
-									Limit: helpers.PointerOf[int64](-1),
-								},
-							},
-							State: &v1limiter.RuleState{
-								ID: "test2",
-							},
-						},
-					}, nil
-				}).Times(1)
+		// execute like the task manager
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			_ = memeoryQueueChannel.Execute(ctx)
+		}()
 
-				fakeLimiterClient.EXPECT().MatchOverrides(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *querymatchaction.MatchActionQuery) (v1limiter.Overrides, error) {
-					return nil, nil
-				}).Times(2)
+		// grab the dequeu channel
+		dequeueChan := memeoryQueueChannel.Dequeue()
+		g.Consistently(dequeueChan).ShouldNot(Receive())
+
+		// close the deueChan by stopping taskmanager
+		cancel()
+		g.Eventually(dequeueChan).Should(BeClosed())
+	})
+
+	t.Run("It closes the deueue chan and stops Executing when ForceDelete is called", func(t *testing.T) {
+		// set limiter client to pass
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 0)
+		defer mockController.Finish()
+
+		// create queue channel
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		// execute like the task manager
+		go func() {
+			_ = memeoryQueueChannel.Execute(context.Background())
+		}()
+
+		// grab the dequeu channel
+		dequeueChan := memeoryQueueChannel.Dequeue()
+		g.Consistently(dequeueChan).ShouldNot(Receive())
+
+		// close the deueChan by stopping taskmanager
+		memeoryQueueChannel.ForceDelete(testhelpers.NewContextWithMiddlewareSetup())
+		g.Eventually(dequeueChan).Should(BeClosed())
+	})
+
+	t.Run("Context when the dequeue callback function succeeds", func(t *testing.T) {
+		t.Run("Context successful dequeues", func(t *testing.T) {
+			t.Run("It can dequeue only a single item untill success is called", func(t *testing.T) {
+				// set limiter client to pass
+				mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 7) // 5 for enqueue, 2 for success
+				defer mockController.Finish()
 
 				// create queue channel
-				memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+				memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
 				// execute like the task manager
-				doneExecuting := make(chan struct{})
 				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
 				go func() {
-					defer close(doneExecuting)
 					_ = memeoryQueueChannel.Execute(ctx)
 				}()
 
-				// create and enqueue an item
-				enqueueItem := &v1willow.Item{
-					Spec: &v1willow.ItemSpec{
-						DBDefinition: &v1willow.ItemDBDefinition{
-							KeyValues: datatypes.KeyValues{
-								"one": datatypes.Int(1),
+				// create and enqueue multiple items
+				for i := 1; i <= 5; i++ {
+					enqueueItem := &v1willow.Item{
+						Spec: &v1willow.ItemSpec{
+							DBDefinition: &v1willow.ItemDBDefinition{
+								KeyValues: datatypes.KeyValues{
+									"one": datatypes.Int(1),
+								},
+							},
+							Properties: &v1willow.ItemProperties{
+								Data:            []byte(fmt.Sprintf("data %d", i)),
+								Updateable:      helpers.PointerOf(false),
+								RetryAttempts:   helpers.PointerOf[uint64](uint64(i)),
+								RetryPosition:   helpers.PointerOf("front"),
+								TimeoutDuration: helpers.PointerOf(time.Duration(i) * time.Second),
 							},
 						},
-						Properties: &v1willow.ItemProperties{
-							Data:            []byte(`data`),
-							Updateable:      helpers.PointerOf(false),
-							RetryAttempts:   helpers.PointerOf[uint64](1),
-							RetryPosition:   helpers.PointerOf("front"),
-							TimeoutDuration: helpers.PointerOf(time.Second),
-						},
-					},
-				}
-				g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+					}
+					g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-				err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
-				g.Expect(err).ToNot(HaveOccurred())
+					_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+					g.Expect(err).ToNot(HaveOccurred())
+				}
 
 				// grab the first dequeu channel
+				var dequeueItem *v1willow.Item
+				var success func()
 				dequeueChan := memeoryQueueChannel.Dequeue()
 				select {
 				case dequeueFunc := <-dequeueChan:
-					dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
-					g.Expect(dequeueItem).To(BeNil())
-					g.Expect(success).To(BeNil())
-					g.Expect(fail).To(BeNil())
+					dequeueItem, success, _ = dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+					g.Expect(dequeueItem).ToNot(BeNil())
 
+					g.Expect(dequeueItem.Spec.Properties.Data).To(Equal([]byte(`data 1`)))
+					g.Expect(dequeueItem.State.ID).ToNot(Equal(""))
+					g.Expect(dequeueItem.Spec.DBDefinition.KeyValues).To(Equal(defaultKeyValues(g)))
+					g.Expect(*dequeueItem.Spec.Properties.TimeoutDuration).To(Equal(time.Second))
 				case <-time.After(time.Second):
 					g.Fail("failed to dequeue item")
 				}
 
-				cancel()
-				g.Eventually(doneExecuting).Should(BeClosed())
+				// ensure that we cannot dequeue another item
+				g.Consistently(dequeueChan).ShouldNot(Receive())
+
+				// call success and get the 2nd item
+				success()
+				select {
+				case dequeueFunc := <-dequeueChan:
+					dequeueItem, success, _ = dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+					defer success()
+					g.Expect(dequeueItem).ToNot(BeNil())
+
+					g.Expect(dequeueItem.Spec.Properties.Data).To(Equal([]byte(`data 2`)))
+					g.Expect(dequeueItem.State.ID).ToNot(Equal(""))
+					g.Expect(dequeueItem.Spec.DBDefinition.KeyValues).To(Equal(defaultKeyValues(g)))
+					g.Expect(*dequeueItem.Spec.Properties.TimeoutDuration).To(Equal(2 * time.Second))
+				case <-time.After(time.Second):
+					g.Fail("failed to dequeue item")
+				}
 			})
+		})
 
-			t.Run("It stops when all Rule's Overrides are unlimited", func(t *testing.T) {
+		t.Run("Context fail dequeues", func(t *testing.T) {
+			t.Run("It dequeues the same item multiple times if fail is called", func(t *testing.T) {
 				// set limiter client to pass
-				mockController, fakeLimiterClient := fakeLimiterClient(t)
+				mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 9) // 5 for enqueue, 2 for dequeue(), 2 times for fail()
 				defer mockController.Finish()
 
-				count := 0
-				fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
-					if count == 0 {
-						// allow for a single enqueue
-						count++
-						return nil
-					}
-
-					return fmt.Errorf("error, limit has been reached")
-				}).Times(2)
-
-				fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
-					return v1limiter.Rules{
-						&v1limiter.Rule{
-							Spec: &v1limiter.RuleSpec{
-								DBDefinition: &v1limiter.RuleDBDefinition{
-									GroupByKeyValues: datatypes.KeyValues{
-										"one": datatypes.Any(),
-									},
-								},
-								Properties: &v1limiter.RuleProperties{
-									Limit: helpers.PointerOf[int64](1),
-								},
-							},
-							State: &v1limiter.RuleState{
-								ID: "test1",
-							},
-						},
-						&v1limiter.Rule{
-							Spec: &v1limiter.RuleSpec{
-								DBDefinition: &v1limiter.RuleDBDefinition{
-									GroupByKeyValues: datatypes.KeyValues{
-										"one": datatypes.Any(),
-										"two": datatypes.Any(),
-									},
-								},
-								Properties: &v1limiter.RuleProperties{
-									Limit: helpers.PointerOf[int64](0),
-								},
-							},
-							State: &v1limiter.RuleState{
-								ID: "test2",
-							},
-						},
-					}, nil
-				}).Times(1)
-
-				overrideCounter := 0
-				fakeLimiterClient.EXPECT().MatchOverrides(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *querymatchaction.MatchActionQuery) (v1limiter.Overrides, error) {
-					if overrideCounter == 0 {
-						overrideCounter++
-						return v1limiter.Overrides{
-							&v1limiter.Override{
-								Spec: &v1limiter.OverrideSpec{
-									DBDefinition: &v1limiter.OverrideDBDefinition{
-										GroupByKeyValues: datatypes.KeyValues{
-											"one": datatypes.Int(1),
-										},
-									},
-									Properties: &v1limiter.OverrideProperties{
-										Limit: helpers.PointerOf[int64](-1),
-									},
-								},
-							},
-						}, nil
-					}
-
-					return v1limiter.Overrides{
-						&v1limiter.Override{
-							Spec: &v1limiter.OverrideSpec{
-								DBDefinition: &v1limiter.OverrideDBDefinition{
-									GroupByKeyValues: datatypes.KeyValues{
-										"one": datatypes.Int(1),
-										"two": datatypes.Int(2),
-									},
-								},
-								Properties: &v1limiter.OverrideProperties{
-									Limit: helpers.PointerOf[int64](-1),
-								},
-							},
-						},
-					}, nil
-				}).Times(2)
-
 				// create queue channel
-				memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+				memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
 				// execute like the task manager
-				doneExecuting := make(chan struct{})
 				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
 				go func() {
-					defer close(doneExecuting)
 					_ = memeoryQueueChannel.Execute(ctx)
 				}()
 
-				// create and enqueue an item
-				enqueueItem := &v1willow.Item{
-					Spec: &v1willow.ItemSpec{
-						DBDefinition: &v1willow.ItemDBDefinition{
-							KeyValues: datatypes.KeyValues{
-								"one": datatypes.Int(1),
+				// create and enqueue multiple items
+				for i := 1; i <= 5; i++ {
+					enqueueItem := &v1willow.Item{
+						Spec: &v1willow.ItemSpec{
+							DBDefinition: &v1willow.ItemDBDefinition{
+								KeyValues: datatypes.KeyValues{
+									"one": datatypes.Int(1),
+								},
+							},
+							Properties: &v1willow.ItemProperties{
+								Data:            []byte(fmt.Sprintf("data %d", i)),
+								Updateable:      helpers.PointerOf(false),
+								RetryAttempts:   helpers.PointerOf[uint64](uint64(i)),
+								RetryPosition:   helpers.PointerOf("front"),
+								TimeoutDuration: helpers.PointerOf(time.Duration(i) * time.Second),
 							},
 						},
-						Properties: &v1willow.ItemProperties{
-							Data:            []byte(`data`),
-							Updateable:      helpers.PointerOf(false),
-							RetryAttempts:   helpers.PointerOf[uint64](1),
-							RetryPosition:   helpers.PointerOf("front"),
-							TimeoutDuration: helpers.PointerOf(time.Second),
-						},
-					},
-				}
-				g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+					}
+					g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-				err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
-				g.Expect(err).ToNot(HaveOccurred())
+					_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+					g.Expect(err).ToNot(HaveOccurred())
+				}
 
 				// grab the first dequeu channel
-
+				var dequeueItem *v1willow.Item
+				var fail func()
 				dequeueChan := memeoryQueueChannel.Dequeue()
 				select {
 				case dequeueFunc := <-dequeueChan:
-					dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
-					g.Expect(dequeueItem).To(BeNil())
-					g.Expect(success).To(BeNil())
-					g.Expect(fail).To(BeNil())
+					dequeueItem, _, fail = dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+					g.Expect(dequeueItem).ToNot(BeNil())
 
+					g.Expect(dequeueItem.Spec.Properties.Data).To(Equal([]byte(`data 1`)))
+					g.Expect(dequeueItem.State.ID).ToNot(Equal(""))
+					g.Expect(dequeueItem.Spec.DBDefinition.KeyValues).To(Equal(defaultKeyValues(g)))
+					g.Expect(*dequeueItem.Spec.Properties.TimeoutDuration).To(Equal(time.Second))
 				case <-time.After(time.Second):
 					g.Fail("failed to dequeue item")
 				}
 
-				cancel()
-				g.Eventually(doneExecuting).Should(BeClosed())
+				// ensure that we cannot dequeue another item
+				g.Consistently(dequeueChan).ShouldNot(Receive())
+
+				// call success and get the 2nd item
+				fail()
+				select {
+				case dequeueFunc := <-dequeueChan:
+					dequeueItem, _, fail = dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+					defer fail()
+					g.Expect(dequeueItem).ToNot(BeNil())
+
+					g.Expect(dequeueItem.Spec.Properties.Data).To(Equal([]byte(`data 1`)))
+					g.Expect(dequeueItem.State.ID).ToNot(Equal(""))
+					g.Expect(dequeueItem.Spec.DBDefinition.KeyValues).To(Equal(defaultKeyValues(g)))
+					g.Expect(*dequeueItem.Spec.Properties.TimeoutDuration).To(Equal(time.Second))
+				case <-time.After(time.Second):
+					g.Fail("failed to dequeue item")
+				}
 			})
+		})
+	})
 
-			t.Run("It stops when the rule is less than the counters", func(t *testing.T) {
-				mockController, fakeLimiterClient := fakeLimiterClient(t)
-				defer mockController.Finish()
+	t.Run("Context when the dequeue callback function fails", func(t *testing.T) {
+		t.Run("It stops the limit checks if Execute context is canceled", func(t *testing.T) {
+			// set limiter client to pass
+			mockController, fakeLimiterClient := fakeLimiterClient(t)
+			defer mockController.Finish()
 
-				count := 0
-				fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
-					if count == 0 {
-						// allow for a single enqueue
-						count++
-						return nil
-					}
+			count := 0
+			fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
+				if count == 0 {
+					// allow for a single enqueue
+					count++
+					return nil
+				}
 
-					return fmt.Errorf("error, limit has been reached")
-				}).Times(2)
+				return fmt.Errorf("error, limit has been reached")
+			}).Times(2)
+
+			ruleCount := 0
+			fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
+				if ruleCount == 0 {
+					ruleCount++
 
-				fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
 					return v1limiter.Rules{
 						&v1limiter.Rule{
 							Spec: &v1limiter.RuleSpec{
@@ -1146,78 +1073,228 @@ func Test_memoryQueueChannel_Dequeue(t *testing.T) {
 									},
 								},
 								Properties: &v1limiter.RuleProperties{
-									Limit: helpers.PointerOf[int64](8),
-								},
-							},
-							State: &v1limiter.RuleState{
-								ID: "test1",
-							},
-						},
-						&v1limiter.Rule{
-							Spec: &v1limiter.RuleSpec{
-								DBDefinition: &v1limiter.RuleDBDefinition{
-									GroupByKeyValues: datatypes.KeyValues{
-										"one": datatypes.Any(),
-										"two": datatypes.Any(),
-									},
-								},
-								Properties: &v1limiter.RuleProperties{
-									Limit: helpers.PointerOf[int64](12),
+									Limit: helpers.PointerOf[int64](0),
 								},
 							},
 							State: &v1limiter.RuleState{
-								ID: "test2",
+								ID: "test",
 							},
 						},
 					}, nil
-				}).Times(1)
+				}
 
-				fakeLimiterClient.EXPECT().MatchOverrides(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *querymatchaction.MatchActionQuery) (v1limiter.Overrides, error) {
-					return nil, nil
-				}).Times(2)
+				return nil, nil
+			}).Times(1)
 
-				fakeLimiterClient.EXPECT().QueryCounters(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *queryassociatedaction.AssociatedActionQuery) (v1limiter.Counters, error) {
-					return v1limiter.Counters{
-						&v1limiter.Counter{
-							Spec: &v1limiter.CounterSpec{
-								DBDefinition: &v1limiter.CounterDBDefinition{
-									KeyValues: datatypes.KeyValues{
-										"one":  datatypes.Int(1),
-										"zone": datatypes.String("west"),
-									},
-								},
-								Properties: &v1limiter.CounteProperties{
-									Counters: helpers.PointerOf[int64](3),
-								},
-							},
-						},
-						&v1limiter.Counter{
-							Spec: &v1limiter.CounterSpec{
-								DBDefinition: &v1limiter.CounterDBDefinition{
-									KeyValues: datatypes.KeyValues{
-										"one":  datatypes.Int(1),
-										"two":  datatypes.Int(2),
-										"zone": datatypes.String("west"),
-									},
-								},
-								Properties: &v1limiter.CounteProperties{
-									Counters: helpers.PointerOf[int64](4),
-								},
-							},
-						},
-					}, nil
-				}).Times(2) // called for each rule
+			fakeLimiterClient.EXPECT().MatchOverrides(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *querymatchaction.MatchActionQuery) (v1limiter.Overrides, error) {
+				return nil, nil
+			}).Times(1)
 
-				// create queue channel
-				memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+			// create queue channel
+			memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
-				// execute like the task manager
-				doneExecuting := make(chan struct{})
-				ctx, cancel := context.WithCancel(context.Background())
-				go func() {
-					defer close(doneExecuting)
-					_ = memeoryQueueChannel.Execute(ctx)
-				}()
+			// execute like the task manager
+			doneExecuting := make(chan struct{})
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				defer close(doneExecuting)
+				_ = memeoryQueueChannel.Execute(ctx)
+			}()
+
+			// create and enqueue an item
+			enqueueItem := &v1willow.Item{
+				Spec: &v1willow.ItemSpec{
+					DBDefinition: &v1willow.ItemDBDefinition{
+						KeyValues: datatypes.KeyValues{
+							"one": datatypes.Int(1),
+						},
+					},
+					Properties: &v1willow.ItemProperties{
+						Data:            []byte(`data`),
+						Updateable:      helpers.PointerOf(false),
+						RetryAttempts:   helpers.PointerOf[uint64](1),
+						RetryPosition:   helpers.PointerOf("front"),
+						TimeoutDuration: helpers.PointerOf(time.Second),
+					},
+				},
+			}
+			g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+			_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			// grab the first dequeu channel
+			dequeueChan := memeoryQueueChannel.Dequeue()
+			select {
+			case dequeueFunc := <-dequeueChan:
+				dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+				g.Expect(dequeueItem).To(BeNil())
+				g.Expect(success).To(BeNil())
+				g.Expect(fail).To(BeNil())
+
+			case <-time.After(time.Second):
+				g.Fail("failed to dequeue item")
+			}
+
+			cancel()
+			g.Eventually(doneExecuting).Should(BeClosed())
+		})
+
+		t.Run("It stops the limit checks if ForceDelete is called", func(t *testing.T) {
+			// set limiter client to pass
+			mockController, fakeLimiterClient := fakeLimiterClient(t)
+			defer mockController.Finish()
+
+			fakeLimiterClient.EXPECT().SetCounters(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
+
+			count := 0
+			fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
+				if count == 0 {
+					// allow for a single enqueue
+					count++
+					return nil
+				}
+
+				return fmt.Errorf("error, limit has been reached")
+			}).Times(2)
+
+			ruleCount := 0
+			fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
+				if ruleCount == 0 {
+					ruleCount++
+
+					return v1limiter.Rules{
+						&v1limiter.Rule{
+							Spec: &v1limiter.RuleSpec{
+								DBDefinition: &v1limiter.RuleDBDefinition{
+									GroupByKeyValues: datatypes.KeyValues{
+										"one": datatypes.Any(),
+									},
+								},
+								Properties: &v1limiter.RuleProperties{
+									Limit: helpers.PointerOf[int64](0),
+								},
+							},
+							State: &v1limiter.RuleState{
+								ID: "test",
+							},
+						},
+					}, nil
+				}
+
+				return nil, nil
+			}).Times(1)
+
+			fakeLimiterClient.EXPECT().MatchOverrides(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *querymatchaction.MatchActionQuery) (v1limiter.Overrides, error) {
+				return nil, nil
+			}).Times(1)
+
+			// create queue channel
+			memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+			// execute like the task manager
+			doneExecuting := make(chan struct{})
+			go func() {
+				defer close(doneExecuting)
+				_ = memeoryQueueChannel.Execute(context.Background())
+			}()
+
+			// create and enqueue an item
+			enqueueItem := &v1willow.Item{
+				Spec: &v1willow.ItemSpec{
+					DBDefinition: &v1willow.ItemDBDefinition{
+						KeyValues: datatypes.KeyValues{
+							"one": datatypes.Int(1),
+						},
+					},
+					Properties: &v1willow.ItemProperties{
+						Data:            []byte(`data`),
+						Updateable:      helpers.PointerOf(false),
+						RetryAttempts:   helpers.PointerOf[uint64](1),
+						RetryPosition:   helpers.PointerOf("front"),
+						TimeoutDuration: helpers.PointerOf(time.Second),
+					},
+				},
+			}
+			g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+			_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			// grab the first dequeu channel
+			dequeueChan := memeoryQueueChannel.Dequeue()
+			select {
+			case dequeueFunc := <-dequeueChan:
+				dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+				g.Expect(dequeueItem).To(BeNil())
+				g.Expect(success).To(BeNil())
+				g.Expect(fail).To(BeNil())
+			case <-time.After(time.Second):
+				g.Fail("failed to dequeue item")
+			}
+
+			memeoryQueueChannel.ForceDelete(testhelpers.NewContextWithMiddlewareSetup())
+			g.Eventually(doneExecuting).Should(BeClosed())
+		})
+
+		t.Run("Context when checking to know when the limit is no longer an issue", func(t *testing.T) {
+			t.Run("It stops when there are no rules", func(t *testing.T) {
+				// set limiter client to pass
+				mockController, fakeLimiterClient := fakeLimiterClient(t)
+				defer mockController.Finish()
+
+				count := 0
+				fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
+					if count == 1 {
+						count++
+						return fmt.Errorf("error, limit has been reached")
+					}
+
+					count++
+					return nil
+				}).Times(3)
+
+				ruleCount := 0
+				fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
+					if ruleCount == 0 {
+						ruleCount++
+
+						return v1limiter.Rules{
+							&v1limiter.Rule{
+								Spec: &v1limiter.RuleSpec{
+									DBDefinition: &v1limiter.RuleDBDefinition{
+										GroupByKeyValues: datatypes.KeyValues{
+											"one": datatypes.Any(),
+										},
+									},
+									Properties: &v1limiter.RuleProperties{
+										Limit: helpers.PointerOf[int64](0),
+									},
+								},
+								State: &v1limiter.RuleState{
+									ID: "test",
+								},
+							},
+						}, nil
+					}
+
+					return nil, nil
+				}).Times(2)
+
+				fakeLimiterClient.EXPECT().MatchOverrides(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *querymatchaction.MatchActionQuery) (v1limiter.Overrides, error) {
+					return nil, nil
+				}).Times(1)
+
+				// create queue channel
+				memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+				// execute like the task manager
+				doneExecuting := make(chan struct{})
+				ctx, cancel := context.WithCancel(context.Background())
+				go func() {
+					defer close(doneExecuting)
+					_ = memeoryQueueChannel.Execute(ctx)
+				}()
 
 				// create and enqueue an item
 				enqueueItem := &v1willow.Item{
@@ -1238,15 +1315,15 @@ func Test_memoryQueueChannel_Dequeue(t *testing.T) {
 				}
 				g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-				err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+				_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
 				g.Expect(err).ToNot(HaveOccurred())
 
 				// grab the first dequeu channel
-
+				// first call should return nil to setup the channel is blocked
 				dequeueChan := memeoryQueueChannel.Dequeue()
 				select {
 				case dequeueFunc := <-dequeueChan:
-					dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
+					dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
 					g.Expect(dequeueItem).To(BeNil())
 					g.Expect(success).To(BeNil())
 					g.Expect(fail).To(BeNil())
@@ -1255,11 +1332,24 @@ func Test_memoryQueueChannel_Dequeue(t *testing.T) {
 					g.Fail("failed to dequeue item")
 				}
 
+				// second call should return an item as the limiter client now passes
+				select {
+				case dequeueFunc := <-dequeueChan:
+					dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+					g.Expect(dequeueItem).ToNot(BeNil())
+					g.Expect(success).ToNot(BeNil())
+					g.Expect(fail).ToNot(BeNil())
+
+					success()
+				case <-time.After(6 * time.Second):
+					g.Fail("failed to dequeue item")
+				}
+
 				cancel()
 				g.Eventually(doneExecuting).Should(BeClosed())
 			})
 
-			t.Run("It stops when all Rule's Overrides are less than the counters", func(t *testing.T) {
+			t.Run("It stops when all Rules are unlimited", func(t *testing.T) {
 				// set limiter client to pass
 				mockController, fakeLimiterClient := fakeLimiterClient(t)
 				defer mockController.Finish()
@@ -1285,7 +1375,7 @@ func Test_memoryQueueChannel_Dequeue(t *testing.T) {
 									},
 								},
 								Properties: &v1limiter.RuleProperties{
-									Limit: helpers.PointerOf[int64](1),
+									Limit: helpers.PointerOf[int64](-1),
 								},
 							},
 							State: &v1limiter.RuleState{
@@ -1301,7 +1391,7 @@ func Test_memoryQueueChannel_Dequeue(t *testing.T) {
 									},
 								},
 								Properties: &v1limiter.RuleProperties{
-									Limit: helpers.PointerOf[int64](0),
+									Limit: helpers.PointerOf[int64](-1),
 								},
 							},
 							State: &v1limiter.RuleState{
@@ -1311,58 +1401,255 @@ func Test_memoryQueueChannel_Dequeue(t *testing.T) {
 					}, nil
 				}).Times(1)
 
-				overrideCounter := 0
 				fakeLimiterClient.EXPECT().MatchOverrides(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *querymatchaction.MatchActionQuery) (v1limiter.Overrides, error) {
-					if overrideCounter == 0 {
-						overrideCounter++
+					return nil, nil
+				}).Times(2)
 
-						return v1limiter.Overrides{
-							&v1limiter.Override{
-								Spec: &v1limiter.OverrideSpec{
-									DBDefinition: &v1limiter.OverrideDBDefinition{
-										GroupByKeyValues: datatypes.KeyValues{
-											"one": datatypes.Int(1),
-										},
-									},
-									Properties: &v1limiter.OverrideProperties{
-										Limit: helpers.PointerOf[int64](8),
-									},
-								},
-							},
-						}, nil
-					}
+				// create queue channel
+				memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
-					return v1limiter.Overrides{
-						&v1limiter.Override{
-							Spec: &v1limiter.OverrideSpec{
-								DBDefinition: &v1limiter.OverrideDBDefinition{
-									GroupByKeyValues: datatypes.KeyValues{
-										"one": datatypes.Int(1),
-										"two": datatypes.Int(2),
-									},
-								},
-								Properties: &v1limiter.OverrideProperties{
-									Limit: helpers.PointerOf[int64](12),
-								},
-							},
-						},
-						&v1limiter.Override{
-							Spec: &v1limiter.OverrideSpec{
-								DBDefinition: &v1limiter.OverrideDBDefinition{
-									GroupByKeyValues: datatypes.KeyValues{
-										"one":  datatypes.Int(1),
-										"two":  datatypes.Int(2),
-										"zone": datatypes.String("west"),
+				// execute like the task manager
+				doneExecuting := make(chan struct{})
+				ctx, cancel := context.WithCancel(context.Background())
+				go func() {
+					defer close(doneExecuting)
+					_ = memeoryQueueChannel.Execute(ctx)
+				}()
+
+				// create and enqueue an item
+				enqueueItem := &v1willow.Item{
+					Spec: &v1willow.ItemSpec{
+						DBDefinition: &v1willow.ItemDBDefinition{
+							KeyValues: datatypes.KeyValues{
+								"one": datatypes.Int(1),
+							},
+						},
+						Properties: &v1willow.ItemProperties{
+							Data:            []byte(`data`),
+							Updateable:      helpers.PointerOf(false),
+							RetryAttempts:   helpers.PointerOf[uint64](1),
+							RetryPosition:   helpers.PointerOf("front"),
+							TimeoutDuration: helpers.PointerOf(time.Second),
+						},
+					},
+				}
+				g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+				_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+				g.Expect(err).ToNot(HaveOccurred())
+
+				// grab the first dequeu channel
+				dequeueChan := memeoryQueueChannel.Dequeue()
+				select {
+				case dequeueFunc := <-dequeueChan:
+					dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+					g.Expect(dequeueItem).To(BeNil())
+					g.Expect(success).To(BeNil())
+					g.Expect(fail).To(BeNil())
+
+				case <-time.After(time.Second):
+					g.Fail("failed to dequeue item")
+				}
+
+				cancel()
+				g.Eventually(doneExecuting).Should(BeClosed())
+			})
+
+			t.Run("It stops when all Rule's Overrides are unlimited", func(t *testing.T) {
+				// set limiter client to pass
+				mockController, fakeLimiterClient := fakeLimiterClient(t)
+				defer mockController.Finish()
+
+				count := 0
+				fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
+					if count == 0 {
+						// allow for a single enqueue
+						count++
+						return nil
+					}
+
+					return fmt.Errorf("error, limit has been reached")
+				}).Times(2)
+
+				fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
+					return v1limiter.Rules{
+						&v1limiter.Rule{
+							Spec: &v1limiter.RuleSpec{
+								DBDefinition: &v1limiter.RuleDBDefinition{
+									GroupByKeyValues: datatypes.KeyValues{
+										"one": datatypes.Any(),
+									},
+								},
+								Properties: &v1limiter.RuleProperties{
+									Limit: helpers.PointerOf[int64](1),
+								},
+							},
+							State: &v1limiter.RuleState{
+								ID: "test1",
+							},
+						},
+						&v1limiter.Rule{
+							Spec: &v1limiter.RuleSpec{
+								DBDefinition: &v1limiter.RuleDBDefinition{
+									GroupByKeyValues: datatypes.KeyValues{
+										"one": datatypes.Any(),
+										"two": datatypes.Any(),
+									},
+								},
+								Properties: &v1limiter.RuleProperties{
+									Limit: helpers.PointerOf[int64](0),
+								},
+							},
+							State: &v1limiter.RuleState{
+								ID: "test2",
+							},
+						},
+					}, nil
+				}).Times(1)
+
+				overrideCounter := 0
+				fakeLimiterClient.EXPECT().MatchOverrides(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *querymatchaction.MatchActionQuery) (v1limiter.Overrides, error) {
+					if overrideCounter == 0 {
+						overrideCounter++
+						return v1limiter.Overrides{
+							&v1limiter.Override{
+								Spec: &v1limiter.OverrideSpec{
+									DBDefinition: &v1limiter.OverrideDBDefinition{
+										GroupByKeyValues: datatypes.KeyValues{
+											"one": datatypes.Int(1),
+										},
+									},
+									Properties: &v1limiter.OverrideProperties{
+										Limit: helpers.PointerOf[int64](-1),
+									},
+								},
+							},
+						}, nil
+					}
+
+					return v1limiter.Overrides{
+						&v1limiter.Override{
+							Spec: &v1limiter.OverrideSpec{
+								DBDefinition: &v1limiter.OverrideDBDefinition{
+									GroupByKeyValues: datatypes.KeyValues{
+										"one": datatypes.Int(1),
+										"two": datatypes.Int(2),
 									},
 								},
 								Properties: &v1limiter.OverrideProperties{
-									Limit: helpers.PointerOf[int64](9),
+									Limit: helpers.PointerOf[int64](-1),
 								},
 							},
 						},
 					}, nil
 				}).Times(2)
 
+				// create queue channel
+				memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+				// execute like the task manager
+				doneExecuting := make(chan struct{})
+				ctx, cancel := context.WithCancel(context.Background())
+				go func() {
+					defer close(doneExecuting)
+					_ = memeoryQueueChannel.Execute(ctx)
+				}()
+
+				// create and enqueue an item
+				enqueueItem := &v1willow.Item{
+					Spec: &v1willow.ItemSpec{
+						DBDefinition: &v1willow.ItemDBDefinition{
+							KeyValues: datatypes.KeyValues{
+								"one": datatypes.Int(1),
+							},
+						},
+						Properties: &v1willow.ItemProperties{
+							Data:            []byte(`data`),
+							Updateable:      helpers.PointerOf(false),
+							RetryAttempts:   helpers.PointerOf[uint64](1),
+							RetryPosition:   helpers.PointerOf("front"),
+							TimeoutDuration: helpers.PointerOf(time.Second),
+						},
+					},
+				}
+				g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+				_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+				g.Expect(err).ToNot(HaveOccurred())
+
+				// grab the first dequeu channel
+
+				dequeueChan := memeoryQueueChannel.Dequeue()
+				select {
+				case dequeueFunc := <-dequeueChan:
+					dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+					g.Expect(dequeueItem).To(BeNil())
+					g.Expect(success).To(BeNil())
+					g.Expect(fail).To(BeNil())
+
+				case <-time.After(time.Second):
+					g.Fail("failed to dequeue item")
+				}
+
+				cancel()
+				g.Eventually(doneExecuting).Should(BeClosed())
+			})
+
+			t.Run("It stops when the rule is less than the counters", func(t *testing.T) {
+				mockController, fakeLimiterClient := fakeLimiterClient(t)
+				defer mockController.Finish()
+
+				count := 0
+				fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
+					if count == 0 {
+						// allow for a single enqueue
+						count++
+						return nil
+					}
+
+					return fmt.Errorf("error, limit has been reached")
+				}).Times(2)
+
+				fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
+					return v1limiter.Rules{
+						&v1limiter.Rule{
+							Spec: &v1limiter.RuleSpec{
+								DBDefinition: &v1limiter.RuleDBDefinition{
+									GroupByKeyValues: datatypes.KeyValues{
+										"one": datatypes.Any(),
+									},
+								},
+								Properties: &v1limiter.RuleProperties{
+									Limit: helpers.PointerOf[int64](8),
+								},
+							},
+							State: &v1limiter.RuleState{
+								ID: "test1",
+							},
+						},
+						&v1limiter.Rule{
+							Spec: &v1limiter.RuleSpec{
+								DBDefinition: &v1limiter.RuleDBDefinition{
+									GroupByKeyValues: datatypes.KeyValues{
+										"one": datatypes.Any(),
+										"two": datatypes.Any(),
+									},
+								},
+								Properties: &v1limiter.RuleProperties{
+									Limit: helpers.PointerOf[int64](12),
+								},
+							},
+							State: &v1limiter.RuleState{
+								ID: "test2",
+							},
+						},
+					}, nil
+				}).Times(1)
+
+				fakeLimiterClient.EXPECT().MatchOverrides(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *querymatchaction.MatchActionQuery) (v1limiter.Overrides, error) {
+					return nil, nil
+				}).Times(2)
+
 				fakeLimiterClient.EXPECT().QueryCounters(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *queryassociatedaction.AssociatedActionQuery) (v1limiter.Counters, error) {
 					return v1limiter.Counters{
 						&v1limiter.Counter{
@@ -1393,10 +1680,10 @@ func Test_memoryQueueChannel_Dequeue(t *testing.T) {
 							},
 						},
 					}, nil
-				}).Times(3) // called for each override
+				}).Times(2) // called for each rule
 
 				// create queue channel
-				memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+				memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
 				// execute like the task manager
 				doneExecuting := make(chan struct{})
@@ -1425,7 +1712,7 @@ func Test_memoryQueueChannel_Dequeue(t *testing.T) {
 				}
 				g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-				err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+				_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
 				g.Expect(err).ToNot(HaveOccurred())
 
 				// grab the first dequeu channel
@@ -1433,7 +1720,7 @@ func Test_memoryQueueChannel_Dequeue(t *testing.T) {
 				dequeueChan := memeoryQueueChannel.Dequeue()
 				select {
 				case dequeueFunc := <-dequeueChan:
-					dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
+					dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
 					g.Expect(dequeueItem).To(BeNil())
 					g.Expect(success).To(BeNil())
 					g.Expect(fail).To(BeNil())
@@ -1445,228 +1732,1420 @@ func Test_memoryQueueChannel_Dequeue(t *testing.T) {
 				cancel()
 				g.Eventually(doneExecuting).Should(BeClosed())
 			})
-		})
+
+			t.Run("It stops when all Rule's Overrides are less than the counters", func(t *testing.T) {
+				// set limiter client to pass
+				mockController, fakeLimiterClient := fakeLimiterClient(t)
+				defer mockController.Finish()
+
+				count := 0
+				fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
+					if count == 0 {
+						// allow for a single enqueue
+						count++
+						return nil
+					}
+
+					return fmt.Errorf("error, limit has been reached")
+				}).Times(2)
+
+				fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
+					return v1limiter.Rules{
+						&v1limiter.Rule{
+							Spec: &v1limiter.RuleSpec{
+								DBDefinition: &v1limiter.RuleDBDefinition{
+									GroupByKeyValues: datatypes.KeyValues{
+										"one": datatypes.Any(),
+									},
+								},
+								Properties: &v1limiter.RuleProperties{
+									Limit: helpers.PointerOf[int64](1),
+								},
+							},
+							State: &v1limiter.RuleState{
+								ID: "test1",
+							},
+						},
+						&v1limiter.Rule{
+							Spec: &v1limiter.RuleSpec{
+								DBDefinition: &v1limiter.RuleDBDefinition{
+									GroupByKeyValues: datatypes.KeyValues{
+										"one": datatypes.Any(),
+										"two": datatypes.Any(),
+									},
+								},
+								Properties: &v1limiter.RuleProperties{
+									Limit: helpers.PointerOf[int64](0),
+								},
+							},
+							State: &v1limiter.RuleState{
+								ID: "test2",
+							},
+						},
+					}, nil
+				}).Times(1)
+
+				overrideCounter := 0
+				fakeLimiterClient.EXPECT().MatchOverrides(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *querymatchaction.MatchActionQuery) (v1limiter.Overrides, error) {
+					if overrideCounter == 0 {
+						overrideCounter++
+
+						return v1limiter.Overrides{
+							&v1limiter.Override{
+								Spec: &v1limiter.OverrideSpec{
+									DBDefinition: &v1limiter.OverrideDBDefinition{
+										GroupByKeyValues: datatypes.KeyValues{
+											"one": datatypes.Int(1),
+										},
+									},
+									Properties: &v1limiter.OverrideProperties{
+										Limit: helpers.PointerOf[int64](8),
+									},
+								},
+							},
+						}, nil
+					}
+
+					return v1limiter.Overrides{
+						&v1limiter.Override{
+							Spec: &v1limiter.OverrideSpec{
+								DBDefinition: &v1limiter.OverrideDBDefinition{
+									GroupByKeyValues: datatypes.KeyValues{
+										"one": datatypes.Int(1),
+										"two": datatypes.Int(2),
+									},
+								},
+								Properties: &v1limiter.OverrideProperties{
+									Limit: helpers.PointerOf[int64](12),
+								},
+							},
+						},
+						&v1limiter.Override{
+							Spec: &v1limiter.OverrideSpec{
+								DBDefinition: &v1limiter.OverrideDBDefinition{
+									GroupByKeyValues: datatypes.KeyValues{
+										"one":  datatypes.Int(1),
+										"two":  datatypes.Int(2),
+										"zone": datatypes.String("west"),
+									},
+								},
+								Properties: &v1limiter.OverrideProperties{
+									Limit: helpers.PointerOf[int64](9),
+								},
+							},
+						},
+					}, nil
+				}).Times(2)
+
+				fakeLimiterClient.EXPECT().QueryCounters(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *queryassociatedaction.AssociatedActionQuery) (v1limiter.Counters, error) {
+					return v1limiter.Counters{
+						&v1limiter.Counter{
+							Spec: &v1limiter.CounterSpec{
+								DBDefinition: &v1limiter.CounterDBDefinition{
+									KeyValues: datatypes.KeyValues{
+										"one":  datatypes.Int(1),
+										"zone": datatypes.String("west"),
+									},
+								},
+								Properties: &v1limiter.CounteProperties{
+									Counters: helpers.PointerOf[int64](3),
+								},
+							},
+						},
+						&v1limiter.Counter{
+							Spec: &v1limiter.CounterSpec{
+								DBDefinition: &v1limiter.CounterDBDefinition{
+									KeyValues: datatypes.KeyValues{
+										"one":  datatypes.Int(1),
+										"two":  datatypes.Int(2),
+										"zone": datatypes.String("west"),
+									},
+								},
+								Properties: &v1limiter.CounteProperties{
+									Counters: helpers.PointerOf[int64](4),
+								},
+							},
+						},
+					}, nil
+				}).Times(3) // called for each override
+
+				// create queue channel
+				memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+				// execute like the task manager
+				doneExecuting := make(chan struct{})
+				ctx, cancel := context.WithCancel(context.Background())
+				go func() {
+					defer close(doneExecuting)
+					_ = memeoryQueueChannel.Execute(ctx)
+				}()
+
+				// create and enqueue an item
+				enqueueItem := &v1willow.Item{
+					Spec: &v1willow.ItemSpec{
+						DBDefinition: &v1willow.ItemDBDefinition{
+							KeyValues: datatypes.KeyValues{
+								"one": datatypes.Int(1),
+							},
+						},
+						Properties: &v1willow.ItemProperties{
+							Data:            []byte(`data`),
+							Updateable:      helpers.PointerOf(false),
+							RetryAttempts:   helpers.PointerOf[uint64](1),
+							RetryPosition:   helpers.PointerOf("front"),
+							TimeoutDuration: helpers.PointerOf(time.Second),
+						},
+					},
+				}
+				g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+				_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+				g.Expect(err).ToNot(HaveOccurred())
+
+				// grab the first dequeu channel
+
+				dequeueChan := memeoryQueueChannel.Dequeue()
+				select {
+				case dequeueFunc := <-dequeueChan:
+					dequeueItem, success, fail := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+					g.Expect(dequeueItem).To(BeNil())
+					g.Expect(success).To(BeNil())
+					g.Expect(fail).To(BeNil())
+
+				case <-time.After(time.Second):
+					g.Fail("failed to dequeue item")
+				}
+
+				cancel()
+				g.Eventually(doneExecuting).Should(BeClosed())
+			})
+		})
+	})
+}
+
+func Test_memoryQueueChannel_ACK(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	enqueue := func(g *GomegaWithT, memeoryQueueChannel *memoryQueueChannel, updateable bool, retryAttempts int, retryPosition string, enqueueCount int) func() {
+		return func() {
+			for i := 0; i < enqueueCount; i++ {
+				// create and enqueue an items
+				enqueueItem := &v1willow.Item{
+					Spec: &v1willow.ItemSpec{
+						DBDefinition: &v1willow.ItemDBDefinition{
+							KeyValues: datatypes.KeyValues{
+								"one": datatypes.Int(1),
+							},
+						},
+						Properties: &v1willow.ItemProperties{
+							Data:            []byte(fmt.Sprintf("data %d", i)),
+							Updateable:      helpers.PointerOf(updateable),
+							RetryAttempts:   helpers.PointerOf[uint64](uint64(retryAttempts)),
+							RetryPosition:   helpers.PointerOf(retryPosition),
+							TimeoutDuration: helpers.PointerOf(time.Second),
+						},
+					},
+				}
+				g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+				_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		}
+	}
+
+	enqueueAndDequeue := func(g *GomegaWithT, memeoryQueueChannel *memoryQueueChannel, enqueue func()) *v1willow.Item {
+		enqueue()
+
+		// grab the first item
+		var dequeueItem *v1willow.Item
+		var success func()
+
+		dequeueChan := memeoryQueueChannel.Dequeue()
+		select {
+		case dequeueFunc := <-dequeueChan:
+			dequeueItem, success, _ = dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+			g.Expect(dequeueItem).ToNot(BeNil())
+
+			// call success
+			success()
+		case <-time.After(time.Second):
+			g.Fail("failed to dequeue item")
+		}
+
+		return dequeueItem
+	}
+
+	t.Run("It returns an error if the tree is empty or item id cannot be found", func(t *testing.T) {
+		mockController, fakeLimiterClient := fakeLimiterClient(t)
+		defer mockController.Finish()
+
+		// create queue channel
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		ack := &v1willow.ACK{
+			ItemID:    "item not found",
+			KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+			Passed:    true,
+		}
+		g.Expect(ack.Validate()).ToNot(HaveOccurred())
+
+		delete, _, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ack)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("failed to find processing item by id"))
+		g.Expect(delete).To(BeTrue())
+	})
+
+	t.Run("Context when the ack success is true", func(t *testing.T) {
+		t.Run("Context when there is only 1 item in the queue", func(t *testing.T) {
+			t.Run("It removes the item without re-queuing and signals to destroy the channel", func(t *testing.T) {
+				mockController, fakeLimiterClient := fakeLimiterClient(t)
+				defer mockController.Finish()
+
+				// create queue channel
+				memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+				// 1 for enqueue, 1 for dequeue(). 2 for ack
+				fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
+					return nil
+				}).Times(4)
+
+				// execute like the task manager
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				go func() {
+					_ = memeoryQueueChannel.Execute(ctx)
+				}()
+
+				// deqeue an item successfully
+				dequeueItem := enqueueAndDequeue(g, memeoryQueueChannel, enqueue(g, memeoryQueueChannel, true, 2, "front", 1))
+
+				// ack the dequeued item
+				ack := &v1willow.ACK{
+					ItemID:    dequeueItem.State.ID,
+					KeyValues: dequeueItem.Spec.DBDefinition.KeyValues,
+					Passed:    true,
+				}
+				g.Expect(ack.Validate()).ToNot(HaveOccurred())
+
+				destroyChannel, _, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ack)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(destroyChannel).To(BeTrue())
+
+				// ensure nothing else is dequeued
+				dequeueChan := memeoryQueueChannel.Dequeue()
+				g.Consistently(dequeueChan).ShouldNot(Receive())
+			})
+
+			t.Run("It retries releasing the counters in the background when the Limiter fails", func(t *testing.T) {
+				mockController, fakeLimiterClient := fakeLimiterClient(t)
+				defer mockController.Finish()
+
+				// create queue channel
+				limiterOutbox := outbox.New(zap.NewNop(), fakeLimiterClient)
+				memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, limiterOutbox, func() {}, "test", defaultKeyValues(g), nil, nil)
+
+				// 1 for enqueue, 1 for dequeue(). 2 failures for ack
+				fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, counter *v1limiter.Counter) error {
+					if *counter.Spec.Properties.Counters < 0 {
+						return fmt.Errorf("limiter is unavailable")
+					}
+
+					return nil
+				}).Times(4)
+
+				// execute like the task manager
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				go func() {
+					_ = memeoryQueueChannel.Execute(ctx)
+				}()
+
+				// deqeue an item successfully
+				dequeueItem := enqueueAndDequeue(g, memeoryQueueChannel, enqueue(g, memeoryQueueChannel, true, 2, "front", 1))
+
+				// ack the dequeued item
+				ack := &v1willow.ACK{
+					ItemID:    dequeueItem.State.ID,
+					KeyValues: dequeueItem.Spec.DBDefinition.KeyValues,
+					Passed:    true,
+				}
+				g.Expect(ack.Validate()).ToNot(HaveOccurred())
+
+				destroyChannel, _, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ack)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(destroyChannel).To(BeTrue())
+
+				// the running and enqueued counters are waiting to be retried
+				g.Expect(limiterOutbox.InSync()).To(BeFalse())
+				g.Expect(limiterOutbox.State().PendingUpdates).To(Equal(int64(2)))
+			})
+		})
+
+		t.Run("Context when there are multiple items in the queue", func(t *testing.T) {
+			t.Run("It removes the item without re-queuing and does not signal to destroy the channel", func(t *testing.T) {
+				mockController, fakeLimiterClient := fakeLimiterClient(t)
+				defer mockController.Finish()
+
+				// create queue channel
+				memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+				// 2 for enqueue, 1 for dequeue(). 2 for ack
+				fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
+					return nil
+				}).Times(5)
+
+				// execute like the task manager
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				go func() {
+					_ = memeoryQueueChannel.Execute(ctx)
+				}()
+
+				// deqeue an item successfully
+				dequeueItem := enqueueAndDequeue(g, memeoryQueueChannel, enqueue(g, memeoryQueueChannel, false, 2, "front", 2))
+
+				// ack the dequeued item
+				ack := &v1willow.ACK{
+					ItemID:    dequeueItem.State.ID,
+					KeyValues: dequeueItem.Spec.DBDefinition.KeyValues,
+					Passed:    true,
+				}
+				g.Expect(ack.Validate()).ToNot(HaveOccurred())
+
+				destroyChannel, _, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ack)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(destroyChannel).To(BeFalse())
+			})
+		})
+
+		t.Run("It returns the completed item with the result and attempt history", func(t *testing.T) {
+			mockController, fakeLimiterClient := fakeLimiterClient(t)
+			defer mockController.Finish()
+
+			// create queue channel
+			memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+			// 1 for enqueue, 2 for dequeue(), 1 for the failed ack, 2 for the passed ack
+			fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
+				return nil
+			}).Times(6)
+
+			// execute like the task manager
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				_ = memeoryQueueChannel.Execute(ctx)
+			}()
+
+			// fail the first attempt
+			dequeueItem := enqueueAndDequeue(g, memeoryQueueChannel, enqueue(g, memeoryQueueChannel, false, 1, "front", 1))
+			ackFalse := &v1willow.ACK{
+				ItemID:    dequeueItem.State.ID,
+				KeyValues: dequeueItem.Spec.DBDefinition.KeyValues,
+				Passed:    false,
+				Reason:    "bad input",
+			}
+			g.Expect(ackFalse.Validate()).ToNot(HaveOccurred())
+
+			_, completedItem, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ackFalse)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(completedItem).To(BeNil())
+
+			// pass the second attempt
+			dequeueItem = enqueueAndDequeue(g, memeoryQueueChannel, func() {})
+			ackTrue := &v1willow.ACK{
+				ItemID:    dequeueItem.State.ID,
+				KeyValues: dequeueItem.Spec.DBDefinition.KeyValues,
+				Passed:    true,
+				Result:    []byte(`built`),
+			}
+			g.Expect(ackTrue.Validate()).ToNot(HaveOccurred())
+
+			_, completedItem, err = memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ackTrue)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(completedItem).ToNot(BeNil())
+			g.Expect(completedItem.State.ID).To(Equal(dequeueItem.State.ID))
+			g.Expect(completedItem.State.Processing).To(BeFalse())
+			g.Expect(completedItem.Spec.Properties.Data).To(Equal([]byte(`data 0`)))
+			g.Expect(completedItem.State.Completed).ToNot(BeNil())
+			g.Expect(completedItem.State.Completed.Result).To(Equal([]byte(`built`)))
+			g.Expect(completedItem.State.Attempts).To(HaveLen(2))
+			g.Expect(completedItem.State.Attempts[0].Result).To(Equal(v1willow.ItemAttemptFailed))
+			g.Expect(completedItem.State.Attempts[0].Reason).To(Equal("bad input"))
+			g.Expect(completedItem.State.Attempts[1].Result).To(Equal(v1willow.ItemAttemptPassed))
+			g.Expect(completedItem.State.Attempts[1].Reason).To(BeEmpty())
+			g.Expect(*completedItem.State.Attempts[1].EndTime).To(Equal(completedItem.State.Completed.CompletedTime))
+		})
+	})
+
+	t.Run("Context when the ack success is false", func(t *testing.T) {
+		t.Run("It re-queues the item when under the requeue limit", func(t *testing.T) {
+			mockController, fakeLimiterClient := fakeLimiterClient(t)
+			defer mockController.Finish()
+
+			// create queue channel
+			memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+			// 1 for enqueue, 2 for dequeue(), 1 for failHeartbeat(), 2 for ack
+			fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
+				return nil
+			}).Times(6)
+
+			// execute like the task manager
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				_ = memeoryQueueChannel.Execute(ctx)
+			}()
+
+			// deqeue an item successfully
+			dequeueItem := enqueueAndDequeue(g, memeoryQueueChannel, enqueue(g, memeoryQueueChannel, true, 1, "front", 1))
+
+			// ack the dequeued item
+			ackFalse := &v1willow.ACK{
+				ItemID:    dequeueItem.State.ID,
+				KeyValues: dequeueItem.Spec.DBDefinition.KeyValues,
+				Passed:    false,
+			}
+			g.Expect(ackFalse.Validate()).ToNot(HaveOccurred())
+
+			destroyChannel, _, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ackFalse)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(destroyChannel).To(BeFalse())
+
+			// ensure we can dequeue the same item again
+			dequeueChan := memeoryQueueChannel.Dequeue()
+			select {
+			case dequeueFunc := <-dequeueChan:
+				var success func()
+				dequeueItem, success, _ = dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+				g.Expect(dequeueItem).ToNot(BeNil())
+
+				// call success for dequeuing the item
+				success()
+			case <-time.After(time.Second):
+				g.Fail("failed to dequeue item")
+			}
+
+			// 2nd call to ack can remove the item since there are no more retry attempts
+			ackTrue := &v1willow.ACK{
+				ItemID:    dequeueItem.State.ID,
+				KeyValues: dequeueItem.Spec.DBDefinition.KeyValues,
+				Passed:    false,
+			}
+			g.Expect(ackTrue.Validate()).ToNot(HaveOccurred())
+
+			destroyChannel, _, err = memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ackTrue)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(destroyChannel).To(BeTrue())
+
+			// ensure we can not dequeue the same item again
+			dequeueChan = memeoryQueueChannel.Dequeue()
+			g.Consistently(dequeueChan).ShouldNot(Receive())
+		})
+	})
+}
+
+func Test_memoryQueueChannel_Cancel(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	enqueueAndDequeue := func(g *GomegaWithT, memeoryQueueChannel *memoryQueueChannel) *v1willow.Item {
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{
+						"one": datatypes.Int(1),
+					},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(`data`),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](3),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+		_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		var dequeueItem *v1willow.Item
+		select {
+		case dequeueFunc := <-memeoryQueueChannel.Dequeue():
+			var success func()
+			dequeueItem, success, _ = dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+			g.Expect(dequeueItem).ToNot(BeNil())
+
+			success()
+		case <-time.After(time.Second):
+			g.Fail("failed to dequeue item")
+		}
+
+		return dequeueItem
+	}
+
+	t.Run("It returns an error if the item id cannot be found", func(t *testing.T) {
+		mockController, fakeLimiterClient := fakeLimiterClient(t)
+		defer mockController.Finish()
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		cancel := &v1willow.Cancel{
+			ItemID:    "item not found",
+			KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+		}
+		g.Expect(cancel.Validate()).ToNot(HaveOccurred())
+
+		err := memeoryQueueChannel.Cancel(testhelpers.NewContextWithMiddlewareSetup(), cancel)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("failed to find processing item by id"))
+	})
+
+	t.Run("It returns an error if the item is not processing", func(t *testing.T) {
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 1)
+		defer mockController.Finish()
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(`data`),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](3),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+		itemState, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		cancel := &v1willow.Cancel{
+			ItemID:    itemState.ID,
+			KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+		}
+		g.Expect(cancel.Validate()).ToNot(HaveOccurred())
+
+		cancelErr := memeoryQueueChannel.Cancel(testhelpers.NewContextWithMiddlewareSetup(), cancel)
+		g.Expect(cancelErr).To(HaveOccurred())
+		g.Expect(cancelErr.Error()).To(ContainSubstring("failed to find processing item by id"))
+	})
+
+	t.Run("It informs the heartbeat response and removes the item on a failed ACK without retrying", func(t *testing.T) {
+		// 1 for enqueue, 1 for dequeue(), 2 for ack
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 4)
+		defer mockController.Finish()
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		// execute like the task manager
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_ = memeoryQueueChannel.Execute(ctx)
+		}()
+
+		dequeueItem := enqueueAndDequeue(g, memeoryQueueChannel)
+
+		heartbeat := &v1willow.Heartbeat{
+			ItemID:    dequeueItem.State.ID,
+			KeyValues: dequeueItem.Spec.DBDefinition.KeyValues,
+		}
+		g.Expect(heartbeat.Validate()).ToNot(HaveOccurred())
+
+		// not canceled yet
+		heartbeatResponse, err := memeoryQueueChannel.Heartbeat(testhelpers.NewContextWithMiddlewareSetup(), heartbeat)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(heartbeatResponse.Cancel).To(BeFalse())
+
+		// cancel the item
+		cancelRequest := &v1willow.Cancel{
+			ItemID:    dequeueItem.State.ID,
+			KeyValues: dequeueItem.Spec.DBDefinition.KeyValues,
+		}
+		g.Expect(cancelRequest.Validate()).ToNot(HaveOccurred())
+		g.Expect(memeoryQueueChannel.Cancel(testhelpers.NewContextWithMiddlewareSetup(), cancelRequest)).To(BeNil())
+
+		// heartbeat now reports the cancel
+		heartbeatResponse, err = memeoryQueueChannel.Heartbeat(testhelpers.NewContextWithMiddlewareSetup(), heartbeat)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(heartbeatResponse.Cancel).To(BeTrue())
+
+		// failed ack removes the item even though there are retry attempts remaining
+		ack := &v1willow.ACK{
+			ItemID:    dequeueItem.State.ID,
+			KeyValues: dequeueItem.Spec.DBDefinition.KeyValues,
+			Passed:    false,
+		}
+		g.Expect(ack.Validate()).ToNot(HaveOccurred())
+
+		destroyChannel, _, ackErr := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ack)
+		g.Expect(ackErr).ToNot(HaveOccurred())
+		g.Expect(destroyChannel).To(BeTrue())
+		g.Expect(memeoryQueueChannel.items.Empty()).To(BeTrue())
+		g.Expect(memeoryQueueChannel.itemsEnqueued.Len()).To(Equal(0))
+	})
+}
+
+func Test_memoryQueueChannel_DeleteItems(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	enqueue := func(g *GomegaWithT, memeoryQueueChannel *memoryQueueChannel, headers map[string]string) *v1willow.ItemState {
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(`data`),
+					Headers:         headers,
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](3),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+		itemState, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return itemState
+	}
+
+	itemDelete := func(g *GomegaWithT, headers map[string]string) *v1willow.ItemDelete {
+		itemDelete := &v1willow.ItemDelete{
+			Headers:   headers,
+			KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+		}
+		g.Expect(itemDelete.Validate()).ToNot(HaveOccurred())
+
+		return itemDelete
+	}
+
+	t.Run("It returns an error if no items have the headers", func(t *testing.T) {
+		// 1 for enqueue
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 1)
+		defer mockController.Finish()
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+		enqueue(g, memeoryQueueChannel, map[string]string{"id": "1"})
+
+		destroyChannel, err := memeoryQueueChannel.DeleteItems(testhelpers.NewContextWithMiddlewareSetup(), itemDelete(g, map[string]string{"id": "2"}))
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.StatusCode).To(Equal(http.StatusNotFound))
+		g.Expect(destroyChannel).To(BeFalse())
+		g.Expect(memeoryQueueChannel.itemsEnqueued.Len()).To(Equal(1))
+	})
+
+	t.Run("It deletes the enqueued items with the headers", func(t *testing.T) {
+		// 2 for enqueue, 1 for the delete
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 3)
+		defer mockController.Finish()
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+		enqueue(g, memeoryQueueChannel, map[string]string{"id": "1"})
+		keptItem := enqueue(g, memeoryQueueChannel, map[string]string{"id": "2"})
+
+		destroyChannel, err := memeoryQueueChannel.DeleteItems(testhelpers.NewContextWithMiddlewareSetup(), itemDelete(g, map[string]string{"id": "1"}))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(destroyChannel).To(BeFalse())
+		g.Expect(enqueuedIDs(memeoryQueueChannel.itemsEnqueued)).To(Equal([]string{keptItem.ID}))
+	})
+
+	t.Run("It does not offer a dequeue for an enqueued item that was deleted", func(t *testing.T) {
+		// 1 for enqueue, 1 for the delete, 2 for the dequeue that finds nothing
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 4)
+		defer mockController.Finish()
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_ = memeoryQueueChannel.Execute(ctx)
+		}()
+
+		enqueue(g, memeoryQueueChannel, map[string]string{"id": "1"})
+
+		destroyChannel, err := memeoryQueueChannel.DeleteItems(testhelpers.NewContextWithMiddlewareSetup(), itemDelete(g, map[string]string{"id": "1"}))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(destroyChannel).To(BeTrue())
+
+		// the notification for the deleted item finds nothing
+		select {
+		case dequeueFunc := <-memeoryQueueChannel.Dequeue():
+			dequeueItem, _, _ := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+			g.Expect(dequeueItem).To(BeNil())
+		case <-time.After(time.Second):
+			g.Fail("failed to receive the dequeue for the deleted item")
+		}
+
+		g.Consistently(memeoryQueueChannel.Dequeue(), 100*time.Millisecond).ShouldNot(Receive())
+	})
+
+	t.Run("It deletes processing items with the headers", func(t *testing.T) {
+		// 1 for enqueue, 1 for dequeue(), 2 for the delete
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 4)
+		defer mockController.Finish()
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_ = memeoryQueueChannel.Execute(ctx)
+		}()
+
+		itemState := enqueue(g, memeoryQueueChannel, map[string]string{"id": "1"})
+
+		select {
+		case dequeueFunc := <-memeoryQueueChannel.Dequeue():
+			dequeueItem, success, _ := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+			g.Expect(dequeueItem).ToNot(BeNil())
+			success()
+		case <-time.After(time.Second):
+			g.Fail("failed to dequeue item")
+		}
+
+		destroyChannel, err := memeoryQueueChannel.DeleteItems(testhelpers.NewContextWithMiddlewareSetup(), itemDelete(g, map[string]string{"id": "1"}))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(destroyChannel).To(BeTrue())
+		g.Expect(memeoryQueueChannel.items.Empty()).To(BeTrue())
+
+		// the client processing the item can no longer heartbeat
+		heartbeat := &v1willow.Heartbeat{
+			ItemID:    itemState.ID,
+			KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+		}
+		g.Expect(heartbeat.Validate()).ToNot(HaveOccurred())
+
+		_, heartbeatErr := memeoryQueueChannel.Heartbeat(testhelpers.NewContextWithMiddlewareSetup(), heartbeat)
+		g.Expect(heartbeatErr).To(HaveOccurred())
+		g.Expect(heartbeatErr.Error()).To(ContainSubstring("failed to find processing item by id"))
+	})
+}
+
+func Test_memoryQueueChannel_Items(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	enqueue := func(g *GomegaWithT, memeoryQueueChannel *memoryQueueChannel, data string) string {
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(data),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](1),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+		itemState, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return itemState.ID
+	}
+
+	t.Run("It returns an empty list when there are no items", func(t *testing.T) {
+		mockController, fakeLimiterClient := fakeLimiterClient(t)
+		defer mockController.Finish()
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		g.Expect(memeoryQueueChannel.Items(testhelpers.NewContextWithMiddlewareSetup(), nil)).To(BeEmpty())
+		g.Expect(memeoryQueueChannel.Items(testhelpers.NewContextWithMiddlewareSetup(), helpers.PointerOf("not found"))).To(BeEmpty())
+	})
+
+	t.Run("It returns all enqueued items", func(t *testing.T) {
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 2)
+		defer mockController.Finish()
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		firstID := enqueue(g, memeoryQueueChannel, "first")
+		secondID := enqueue(g, memeoryQueueChannel, "second")
+
+		items := memeoryQueueChannel.Items(testhelpers.NewContextWithMiddlewareSetup(), nil)
+		g.Expect(items.Validate()).ToNot(HaveOccurred())
+		g.Expect(len(items)).To(Equal(2))
+
+		itemIDs := []string{items[0].State.ID, items[1].State.ID}
+		g.Expect(itemIDs).To(ConsistOf(firstID, secondID))
+		g.Expect(items[0].State.Processing).To(BeFalse())
+		g.Expect(items[1].State.Processing).To(BeFalse())
+	})
+
+	t.Run("It can return a single item by ID", func(t *testing.T) {
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 2)
+		defer mockController.Finish()
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		_ = enqueue(g, memeoryQueueChannel, "first")
+		secondID := enqueue(g, memeoryQueueChannel, "second")
+
+		items := memeoryQueueChannel.Items(testhelpers.NewContextWithMiddlewareSetup(), &secondID)
+		g.Expect(len(items)).To(Equal(1))
+		g.Expect(items[0].State.ID).To(Equal(secondID))
+		g.Expect(items[0].Spec.Properties.Data).To(Equal([]byte(`second`)))
+		g.Expect(items[0].Spec.DBDefinition.KeyValues).To(Equal(defaultKeyValues(g)))
+	})
+
+	t.Run("Context when an item is processing", func(t *testing.T) {
+		t.Run("It returns the last progress reported through a heartbeat", func(t *testing.T) {
+			// 1 for enqueue, 1 for dequeue()
+			mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 2)
+			defer mockController.Finish()
+
+			memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+			// execute like the task manager
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				_ = memeoryQueueChannel.Execute(ctx)
+			}()
+
+			itemID := enqueue(g, memeoryQueueChannel, "first")
+
+			select {
+			case dequeueFunc := <-memeoryQueueChannel.Dequeue():
+				dequeueItem, success, _ := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+				g.Expect(dequeueItem).ToNot(BeNil())
+				success()
+			case <-time.After(time.Second):
+				g.Fail("failed to dequeue item")
+			}
+
+			// no progress reported yet
+			items := memeoryQueueChannel.Items(testhelpers.NewContextWithMiddlewareSetup(), &itemID)
+			g.Expect(len(items)).To(Equal(1))
+			g.Expect(items[0].State.Processing).To(BeTrue())
+			g.Expect(items[0].State.Progress).To(BeNil())
+
+			// heartbeat with progress
+			heartbeat := &v1willow.Heartbeat{
+				ItemID:    itemID,
+				KeyValues: defaultKeyValues(g),
+				Progress: &v1willow.ItemProgress{
+					Percent:   helpers.PointerOf(50.0),
+					Status:    helpers.PointerOf("compiling"),
+					KeyValues: datatypes.KeyValues{"step": datatypes.String("build")},
+				},
+			}
+			g.Expect(heartbeat.Validate()).ToNot(HaveOccurred())
+
+			_, err := memeoryQueueChannel.Heartbeat(testhelpers.NewContextWithMiddlewareSetup(), heartbeat)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			items = memeoryQueueChannel.Items(testhelpers.NewContextWithMiddlewareSetup(), &itemID)
+			g.Expect(len(items)).To(Equal(1))
+			g.Expect(items[0].State.Progress).To(Equal(heartbeat.Progress))
+
+			// heartbeats without progress keep the last reported progress
+			heartbeat.Progress = nil
+			_, err = memeoryQueueChannel.Heartbeat(testhelpers.NewContextWithMiddlewareSetup(), heartbeat)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			items = memeoryQueueChannel.Items(testhelpers.NewContextWithMiddlewareSetup(), &itemID)
+			g.Expect(len(items)).To(Equal(1))
+			g.Expect(*items[0].State.Progress.Status).To(Equal("compiling"))
+		})
+	})
+}
+
+func Test_memoryQueueChannel_Diagnose(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	enqueue := func(g *GomegaWithT, memeoryQueueChannel *memoryQueueChannel) {
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(`data`),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](1),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+		_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	rule := func(id string, limit int64, groupByKeys ...string) *v1limiter.Rule {
+		groupByKeyValues := datatypes.KeyValues{}
+		for _, key := range groupByKeys {
+			groupByKeyValues[key] = datatypes.Any()
+		}
+
+		return &v1limiter.Rule{
+			Spec: &v1limiter.RuleSpec{
+				DBDefinition: &v1limiter.RuleDBDefinition{GroupByKeyValues: groupByKeyValues},
+				Properties:   &v1limiter.RuleProperties{Limit: helpers.PointerOf(limit)},
+			},
+			State: &v1limiter.RuleState{ID: id},
+		}
+	}
+
+	counters := func(count int64) v1limiter.Counters {
+		return v1limiter.Counters{
+			&v1limiter.Counter{
+				Spec: &v1limiter.CounterSpec{
+					DBDefinition: &v1limiter.CounterDBDefinition{
+						KeyValues: datatypes.KeyValues{"one": datatypes.Int(1), "_willow_running": datatypes.String("true")},
+					},
+					Properties: &v1limiter.CounteProperties{Counters: helpers.PointerOf(count)},
+				},
+			},
+		}
+	}
+
+	t.Run("It reports the number of enqueued items when nothing is blocking the channel", func(t *testing.T) {
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 1)
+		defer mockController.Finish()
+
+		fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, match *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
+			// ensure the running key values are matched against
+			g.Expect(match.KeyValues["_willow_queue_name"].Value).To(Equal(datatypes.String("test")))
+			g.Expect(match.KeyValues["_willow_running"].Value).To(Equal(datatypes.String("true")))
+			g.Expect(match.KeyValues["one"].Value).To(Equal(datatypes.Int(1)))
+
+			return nil, nil
+		}).Times(1)
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+		enqueue(g, memeoryQueueChannel)
+
+		channelDiagnosis, err := memeoryQueueChannel.Diagnose(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(channelDiagnosis.Validate()).ToNot(HaveOccurred())
+		g.Expect(channelDiagnosis.KeyValues).To(Equal(defaultKeyValues(g)))
+		g.Expect(channelDiagnosis.EnqueuedItems).To(Equal(int64(1)))
+		g.Expect(channelDiagnosis.ProcessingItems).To(Equal(int64(0)))
+		g.Expect(channelDiagnosis.BlockingLimits).To(BeEmpty())
+	})
+
+	t.Run("It reports the rules that are at their limit", func(t *testing.T) {
+		mockController, fakeLimiterClient := fakeLimiterClient(t)
+		defer mockController.Finish()
+
+		fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
+			return v1limiter.Rules{rule("unlimited", -1, "one"), rule("under", 5, "one"), rule("reached", 3, "one", "_willow_running")}, nil
+		}).Times(1)
+
+		fakeLimiterClient.EXPECT().MatchOverrides(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *querymatchaction.MatchActionQuery) (v1limiter.Overrides, error) {
+			return nil, nil
+		}).Times(3)
+
+		fakeLimiterClient.EXPECT().QueryCounters(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, query *queryassociatedaction.AssociatedActionQuery) (v1limiter.Counters, error) {
+			// counters are grouped by the rule's keys with the running values
+			g.Expect(query.Selection.KeyValues["one"].Value).To(Equal(datatypes.Int(1)))
+			return counters(3), nil
+		}).Times(2)
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		channelDiagnosis, err := memeoryQueueChannel.Diagnose(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(channelDiagnosis.EnqueuedItems).To(Equal(int64(0)))
+		g.Expect(channelDiagnosis.BlockingLimits).To(Equal(v1willow.BlockingLimits{
+			{RuleID: "reached", Limit: 3, Count: 3},
+		}))
+	})
+
+	t.Run("It reports the overrides that are at their limit instead of the rule", func(t *testing.T) {
+		mockController, fakeLimiterClient := fakeLimiterClient(t)
+		defer mockController.Finish()
+
+		fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
+			return v1limiter.Rules{rule("rule", -1, "one")}, nil
+		}).Times(1)
+
+		fakeLimiterClient.EXPECT().MatchOverrides(gomock.Any(), "rule", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *querymatchaction.MatchActionQuery) (v1limiter.Overrides, error) {
+			return v1limiter.Overrides{
+				&v1limiter.Override{
+					Spec: &v1limiter.OverrideSpec{
+						DBDefinition: &v1limiter.OverrideDBDefinition{GroupByKeyValues: datatypes.KeyValues{"one": datatypes.Int(1)}},
+						Properties:   &v1limiter.OverrideProperties{Limit: helpers.PointerOf[int64](0)},
+					},
+					State: &v1limiter.OverrideState{ID: "override"},
+				},
+			}, nil
+		}).Times(1)
+
+		fakeLimiterClient.EXPECT().QueryCounters(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *queryassociatedaction.AssociatedActionQuery) (v1limiter.Counters, error) {
+			return nil, nil
+		}).Times(1)
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		channelDiagnosis, err := memeoryQueueChannel.Diagnose(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(channelDiagnosis.BlockingLimits).To(Equal(v1willow.BlockingLimits{
+			{RuleID: "rule", OverrideID: "override", Limit: 0, Count: 0},
+		}))
+	})
+
+	t.Run("It returns an error when the Limiter cannot be reached", func(t *testing.T) {
+		mockController, fakeLimiterClient := fakeLimiterClient(t)
+		defer mockController.Finish()
+
+		fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
+			return nil, fmt.Errorf("failed to connect")
+		}).Times(1)
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		channelDiagnosis, err := memeoryQueueChannel.Diagnose(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(err).To(Equal(errors.InternalServerError))
+		g.Expect(channelDiagnosis).To(BeNil())
+	})
+
+	t.Run("It can diagnose the channel while items are dequeued and time out", func(t *testing.T) {
+		mockController, fakeLimiterClient := fakeLimiterClient(t)
+		defer mockController.Finish()
+
+		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
+		fakeLimiterClient.EXPECT().SetCounters(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
+		fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *querymatchaction.MatchActionQuery) (v1limiter.Rules, error) {
+			return nil, nil
+		}).AnyTimes()
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_ = memeoryQueueChannel.Execute(ctx)
+		}()
+
+		for i := 0; i < 10; i++ {
+			enqueueItem := &v1willow.Item{
+				Spec: &v1willow.ItemSpec{
+					DBDefinition: &v1willow.ItemDBDefinition{
+						KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+					},
+					Properties: &v1willow.ItemProperties{
+						Data:            []byte(fmt.Sprintf("%d", i)),
+						Updateable:      helpers.PointerOf(false),
+						RetryAttempts:   helpers.PointerOf[uint64](2),
+						RetryPosition:   helpers.PointerOf("back"),
+						TimeoutDuration: helpers.PointerOf(10 * time.Millisecond),
+					},
+				},
+			}
+			g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+			_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		// dequeue the items without heartbeating, so they all time out and are retried
+		dequeueCtx, dequeueCancel := context.WithCancel(context.Background())
+		dequeueDone := make(chan struct{})
+		go func() {
+			defer close(dequeueDone)
+
+			for {
+				select {
+				case <-dequeueCtx.Done():
+					return
+				case dequeueFunc := <-memeoryQueueChannel.Dequeue():
+					if dequeueItem, success, _ := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil); dequeueItem != nil {
+						success()
+					}
+				}
+			}
+		}()
+
+		for i := 0; i < 100; i++ {
+			channelDiagnosis, err := memeoryQueueChannel.Diagnose(testhelpers.NewContextWithMiddlewareSetup())
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(channelDiagnosis.ProcessingItems).To(BeNumerically("<=", 10))
+			time.Sleep(time.Millisecond)
+		}
+
+		dequeueCancel()
+		g.Eventually(dequeueDone).Should(BeClosed())
+	})
+}
+
+func Test_memoryQueueChannel_Export(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	enqueue := func(g *GomegaWithT, memeoryQueueChannel *memoryQueueChannel, data string) string {
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(data),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](1),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+		itemState, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return itemState.ID
+	}
+
+	t.Run("It exports no items for an empty channel", func(t *testing.T) {
+		mockController, fakeLimiterClient := fakeLimiterClient(t)
+		defer mockController.Finish()
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		exportedChannel := memeoryQueueChannel.Export(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(exportedChannel.KeyValues).To(Equal(defaultKeyValues(g)))
+		g.Expect(exportedChannel.Items).To(BeEmpty())
+	})
+
+	t.Run("It exports all enqueued items in the order they will be dequeued", func(t *testing.T) {
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 3)
+		defer mockController.Finish()
+
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+
+		firstID := enqueue(g, memeoryQueueChannel, "first")
+		secondID := enqueue(g, memeoryQueueChannel, "second")
+		thirdID := enqueue(g, memeoryQueueChannel, "third")
+
+		exportedChannel := memeoryQueueChannel.Export(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(exportedChannel.Validate()).ToNot(HaveOccurred())
+		g.Expect(len(exportedChannel.Items)).To(Equal(3))
+
+		g.Expect(exportedChannel.Items[0].ID).To(Equal(firstID))
+		g.Expect(exportedChannel.Items[0].Properties.Data).To(Equal([]byte("first")))
+		g.Expect(exportedChannel.Items[1].ID).To(Equal(secondID))
+		g.Expect(exportedChannel.Items[1].Properties.Data).To(Equal([]byte("second")))
+		g.Expect(exportedChannel.Items[2].ID).To(Equal(thirdID))
+		g.Expect(exportedChannel.Items[2].Properties.Data).To(Equal([]byte("third")))
 	})
 }
 
-func Test_memoryQueueChannel_ACK(t *testing.T) {
+func Test_memoryQueueChannel_Import(t *testing.T) {
 	g := NewGomegaWithT(t)
 
-	enqueue := func(g *GomegaWithT, memeoryQueueChannel *memoryQueueChannel, updateable bool, retryAttempts int, retryPosition string, enqueueCount int) func() {
-		return func() {
-			for i := 0; i < enqueueCount; i++ {
-				// create and enqueue an items
-				enqueueItem := &v1willow.Item{
-					Spec: &v1willow.ItemSpec{
-						DBDefinition: &v1willow.ItemDBDefinition{
-							KeyValues: datatypes.KeyValues{
-								"one": datatypes.Int(1),
-							},
-						},
-						Properties: &v1willow.ItemProperties{
-							Data:            []byte(fmt.Sprintf("data %d", i)),
-							Updateable:      helpers.PointerOf(updateable),
-							RetryAttempts:   helpers.PointerOf[uint64](uint64(retryAttempts)),
-							RetryPosition:   helpers.PointerOf(retryPosition),
-							TimeoutDuration: helpers.PointerOf(time.Second),
-						},
-					},
-				}
-				g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
-
-				err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
-				g.Expect(err).ToNot(HaveOccurred())
-			}
+	exportedItem := func(id, data string, retryCount uint64) *v1willow.ExportedItem {
+		return &v1willow.ExportedItem{
+			ID: id,
+			Properties: &v1willow.ItemProperties{
+				Data:            []byte(data),
+				Updateable:      helpers.PointerOf(false),
+				RetryAttempts:   helpers.PointerOf[uint64](3),
+				RetryPosition:   helpers.PointerOf("front"),
+				TimeoutDuration: helpers.PointerOf(time.Second),
+			},
+			RetryCount: retryCount,
 		}
 	}
 
-	enqueueAndDequeue := func(g *GomegaWithT, memeoryQueueChannel *memoryQueueChannel, enqueue func()) *v1willow.Item {
-		enqueue()
-
-		// grab the first item
-		var dequeueItem *v1willow.Item
-		var success func()
-
-		dequeueChan := memeoryQueueChannel.Dequeue()
-		select {
-		case dequeueFunc := <-dequeueChan:
-			dequeueItem, success, _ = dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
-			g.Expect(dequeueItem).ToNot(BeNil())
+	t.Run("It enqueues the items with their original IDs and retry counts", func(t *testing.T) {
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 2)
+		defer mockController.Finish()
 
-			// call success
-			success()
-		case <-time.After(time.Second):
-			g.Fail("failed to dequeue item")
-		}
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
-		return dequeueItem
-	}
+		err := memeoryQueueChannel.Import(testhelpers.NewContextWithMiddlewareSetup(), []*v1willow.ExportedItem{exportedItem("first", "1", 2), exportedItem("second", "2", 0)})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(memeoryQueueChannel.enqueuedCount()).To(Equal(2))
+
+		exportedChannel := memeoryQueueChannel.Export(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(len(exportedChannel.Items)).To(Equal(2))
+		g.Expect(exportedChannel.Items[0].ID).To(Equal("first"))
+		g.Expect(exportedChannel.Items[0].Properties.Data).To(Equal([]byte("1")))
+		g.Expect(exportedChannel.Items[0].RetryCount).To(Equal(uint64(2)))
+		g.Expect(exportedChannel.Items[1].ID).To(Equal("second"))
+		g.Expect(exportedChannel.Items[1].RetryCount).To(Equal(uint64(0)))
+	})
 
-	t.Run("It returns an error if the tree is empty or item id cannot be found", func(t *testing.T) {
+	t.Run("It returns a conflict and releases the enqueued counter when the item already exists", func(t *testing.T) {
 		mockController, fakeLimiterClient := fakeLimiterClient(t)
 		defer mockController.Finish()
 
-		// create queue channel
-		memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		counts := []int64{}
+		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, counter *v1limiter.Counter) error {
+			counts = append(counts, *counter.Spec.Properties.Counters)
+			return nil
+		}).Times(3)
 
-		ack := &v1willow.ACK{
-			ItemID:    "item not found",
-			KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
-			Passed:    true,
-		}
-		g.Expect(ack.Validate()).ToNot(HaveOccurred())
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
+		g.Expect(memeoryQueueChannel.Import(testhelpers.NewContextWithMiddlewareSetup(), []*v1willow.ExportedItem{exportedItem("first", "1", 0)})).ToNot(HaveOccurred())
 
-		delete, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ack)
+		err := memeoryQueueChannel.Import(testhelpers.NewContextWithMiddlewareSetup(), []*v1willow.ExportedItem{exportedItem("first", "1", 0)})
 		g.Expect(err).To(HaveOccurred())
-		g.Expect(err.Error()).To(ContainSubstring("failed to find processing item by id"))
-		g.Expect(delete).To(BeTrue())
+		g.Expect(err.StatusCode).To(Equal(http.StatusConflict))
+		g.Expect(counts).To(Equal([]int64{1, 1, -1}))
+		g.Expect(memeoryQueueChannel.enqueuedCount()).To(Equal(1))
 	})
+}
 
-	t.Run("Context when the ack success is true", func(t *testing.T) {
-		t.Run("Context when there is only 1 item in the queue", func(t *testing.T) {
-			t.Run("It removes the item without re-queuing and signals to destroy the channel", func(t *testing.T) {
-				mockController, fakeLimiterClient := fakeLimiterClient(t)
-				defer mockController.Finish()
-
-				// create queue channel
-				memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
-
-				// 1 for enqueue, 1 for dequeue(). 2 for ack
-				fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
-					return nil
-				}).Times(4)
-
-				// execute like the task manager
-				ctx, cancel := context.WithCancel(context.Background())
-				defer cancel()
-				go func() {
-					_ = memeoryQueueChannel.Execute(ctx)
-				}()
+func Test_memoryQueueChannel_LimiterCounters(t *testing.T) {
+	g := NewGomegaWithT(t)
 
-				// deqeue an item successfully
-				dequeueItem := enqueueAndDequeue(g, memeoryQueueChannel, enqueue(g, memeoryQueueChannel, true, 2, "front", 1))
+	enqueue := func(g *GomegaWithT, memeoryQueueChannel *memoryQueueChannel) {
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(`data`),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](1),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-				// ack the dequeued item
-				ack := &v1willow.ACK{
-					ItemID:    dequeueItem.State.ID,
-					KeyValues: dequeueItem.Spec.DBDefinition.KeyValues,
-					Passed:    true,
-				}
-				g.Expect(ack.Validate()).ToNot(HaveOccurred())
+		_, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+		g.Expect(err).ToNot(HaveOccurred())
+	}
 
-				destroyChannel, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ack)
-				g.Expect(err).ToNot(HaveOccurred())
-				g.Expect(destroyChannel).To(BeTrue())
+	t.Run("It reports empty counters for an empty channel", func(t *testing.T) {
+		mockController, fakeLimiterClient := fakeLimiterClient(t)
+		defer mockController.Finish()
 
-				// ensure nothing else is dequeued
-				dequeueChan := memeoryQueueChannel.Dequeue()
-				g.Consistently(dequeueChan).ShouldNot(Receive())
-			})
-		})
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
-		t.Run("Context when there are multiple items in the queue", func(t *testing.T) {
-			t.Run("It removes the item without re-queuing and does not signal to destroy the channel", func(t *testing.T) {
-				mockController, fakeLimiterClient := fakeLimiterClient(t)
-				defer mockController.Finish()
+		counters := memeoryQueueChannel.LimiterCounters(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(len(counters)).To(Equal(2))
+		g.Expect(counters[0].Spec.DBDefinition.KeyValues).To(Equal(memeoryQueueChannel.enqueuedKeyValues()))
+		g.Expect(*counters[0].Spec.Properties.Counters).To(Equal(int64(0)))
+		g.Expect(counters[1].Spec.DBDefinition.KeyValues).To(Equal(memeoryQueueChannel.runningKeyValues()))
+		g.Expect(*counters[1].Spec.Properties.Counters).To(Equal(int64(0)))
+	})
 
-				// create queue channel
-				memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+	t.Run("It counts every item as enqueued and any processing items as running", func(t *testing.T) {
+		// 3 for enqueue, 1 for dequeue
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 4)
+		defer mockController.Finish()
 
-				// 2 for enqueue, 1 for dequeue(). 2 for ack
-				fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
-					return nil
-				}).Times(5)
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
-				// execute like the task manager
-				ctx, cancel := context.WithCancel(context.Background())
-				defer cancel()
-				go func() {
-					_ = memeoryQueueChannel.Execute(ctx)
-				}()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_ = memeoryQueueChannel.Execute(ctx)
+		}()
 
-				// deqeue an item successfully
-				dequeueItem := enqueueAndDequeue(g, memeoryQueueChannel, enqueue(g, memeoryQueueChannel, false, 2, "front", 2))
+		enqueue(g, memeoryQueueChannel)
+		enqueue(g, memeoryQueueChannel)
+		enqueue(g, memeoryQueueChannel)
 
-				// ack the dequeued item
-				ack := &v1willow.ACK{
-					ItemID:    dequeueItem.State.ID,
-					KeyValues: dequeueItem.Spec.DBDefinition.KeyValues,
-					Passed:    true,
-				}
-				g.Expect(ack.Validate()).ToNot(HaveOccurred())
+		select {
+		case dequeueFunc := <-memeoryQueueChannel.Dequeue():
+			_, success, _ := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+			success()
+		case <-time.After(time.Second):
+			g.Fail("failed to dequeue item")
+		}
 
-				destroyChannel, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ack)
-				g.Expect(err).ToNot(HaveOccurred())
-				g.Expect(destroyChannel).To(BeFalse())
-			})
-		})
+		counters := memeoryQueueChannel.LimiterCounters(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(len(counters)).To(Equal(2))
+		g.Expect(*counters[0].Spec.Properties.Counters).To(Equal(int64(3)))
+		g.Expect(*counters[1].Spec.Properties.Counters).To(Equal(int64(1)))
 	})
+}
 
-	t.Run("Context when the ack success is false", func(t *testing.T) {
-		t.Run("It re-queues the item when under the requeue limit", func(t *testing.T) {
-			mockController, fakeLimiterClient := fakeLimiterClient(t)
-			defer mockController.Finish()
-
-			// create queue channel
-			memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+func Test_memoryQueueChannel_MaxRunDuration(t *testing.T) {
+	g := NewGomegaWithT(t)
 
-			// 1 for enqueue, 2 for dequeue(), 1 for failHeartbeat(), 2 for ack
-			fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error {
-				return nil
-			}).Times(6)
+	t.Run("It fails the item and rejects heartbeats once the max run duration is reached", func(t *testing.T) {
+		// 1 for enqueue, 1 for dequeue(), 2 for failing the item without retries
+		mockController, fakeLimiterClient := setupSuccessFakeLimiter(t, 4)
+		defer mockController.Finish()
 
-			// execute like the task manager
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
-			go func() {
-				_ = memeoryQueueChannel.Execute(ctx)
-			}()
+		deleted := make(chan struct{})
+		deleteOnce := new(sync.Once)
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() { deleteOnce.Do(func() { close(deleted) }) }, "test", defaultKeyValues(g), nil, nil)
 
-			// deqeue an item successfully
-			dequeueItem := enqueueAndDequeue(g, memeoryQueueChannel, enqueue(g, memeoryQueueChannel, true, 1, "front", 1))
+		// execute like the task manager
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_ = memeoryQueueChannel.Execute(ctx)
+		}()
 
-			// ack the dequeued item
-			ackFalse := &v1willow.ACK{
-				ItemID:    dequeueItem.State.ID,
-				KeyValues: dequeueItem.Spec.DBDefinition.KeyValues,
-				Passed:    false,
-			}
-			g.Expect(ackFalse.Validate()).ToNot(HaveOccurred())
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(`data`),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](0),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(200 * time.Millisecond),
+					MaxRunDuration:  helpers.PointerOf(500 * time.Millisecond),
+				},
+			},
+		}
+		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-			destroyChannel, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ackFalse)
-			g.Expect(err).ToNot(HaveOccurred())
-			g.Expect(destroyChannel).To(BeFalse())
+		itemState, err := memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)
+		g.Expect(err).ToNot(HaveOccurred())
 
-			// ensure we can dequeue the same item again
-			dequeueChan := memeoryQueueChannel.Dequeue()
-			select {
-			case dequeueFunc := <-dequeueChan:
-				var success func()
-				dequeueItem, success, _ = dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
-				g.Expect(dequeueItem).ToNot(BeNil())
+		select {
+		case dequeueFunc := <-memeoryQueueChannel.Dequeue():
+			dequeueItem, success, _ := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
+			g.Expect(dequeueItem).ToNot(BeNil())
+			g.Expect(*dequeueItem.Spec.Properties.MaxRunDuration).To(Equal(500 * time.Millisecond))
+			success()
+		case <-time.After(time.Second):
+			g.Fail("failed to dequeue item")
+		}
 
-				// call success for dequeuing the item
-				success()
-			case <-time.After(time.Second):
-				g.Fail("failed to dequeue item")
-			}
+		heartbeat := &v1willow.Heartbeat{
+			ItemID:    itemState.ID,
+			KeyValues: defaultKeyValues(g),
+		}
+		g.Expect(heartbeat.Validate()).ToNot(HaveOccurred())
 
-			// 2nd call to ack can remove the item since there are no more retry attempts
-			ackTrue := &v1willow.ACK{
-				ItemID:    dequeueItem.State.ID,
-				KeyValues: dequeueItem.Spec.DBDefinition.KeyValues,
-				Passed:    false,
-			}
-			g.Expect(ackTrue.Validate()).ToNot(HaveOccurred())
+		// keep heartbeating faster than the timeout until the heartbeats are rejected
+		g.Eventually(func() *errors.ServerError {
+			_, heartbeatErr := memeoryQueueChannel.Heartbeat(testhelpers.NewContextWithMiddlewareSetup(), heartbeat)
+			return heartbeatErr
+		}, 2*time.Second, 50*time.Millisecond).ShouldNot(BeNil())
 
-			destroyChannel, err = memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ackTrue)
-			g.Expect(err).ToNot(HaveOccurred())
-			g.Expect(destroyChannel).To(BeTrue())
+		g.Eventually(deleted).Should(BeClosed())
+		g.Expect(memeoryQueueChannel.items.Empty()).To(BeTrue())
 
-			// ensure we can not dequeue the same item again
-			dequeueChan = memeoryQueueChannel.Dequeue()
-			g.Consistently(dequeueChan).ShouldNot(Receive())
-		})
+		_, heartbeatErr := memeoryQueueChannel.Heartbeat(testhelpers.NewContextWithMiddlewareSetup(), heartbeat)
+		g.Expect(heartbeatErr).To(HaveOccurred())
+		g.Expect(heartbeatErr.Error()).To(ContainSubstring("failed to find processing item by id"))
 	})
 }
 
@@ -1681,7 +3160,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
 
 		// create queue channel
-		memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
 		// execute like the task manager
 		ctx, cancel := context.WithCancel(context.Background())
@@ -1724,7 +3203,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 					},
 				}
 				g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
-				g.Expect(memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)).ToNot(HaveOccurred())
+				g.Expect(memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)).ToNot(BeNil())
 			}(i)
 		}
 
@@ -1739,7 +3218,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
 
 		// create queue channel
-		memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
 		// execute like the task manager
 		ctx, cancel := context.WithCancel(context.Background())
@@ -1782,7 +3261,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 					},
 				}
 				g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
-				g.Expect(memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)).ToNot(HaveOccurred())
+				g.Expect(memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)).ToNot(BeNil())
 			}(i)
 		}
 		wg.Wait()
@@ -1797,7 +3276,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 				// ensure we can dequeue the same item again
 				select {
 				case dequeueFunc := <-dequeueChan:
-					dequeueItem, success, _ := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
+					dequeueItem, success, _ := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
 					g.Expect(dequeueItem).ToNot(BeNil())
 
 					// call success for dequeuing the item and trigger another item to process
@@ -1815,7 +3294,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 						}
 						g.Expect(ackTrue.Validate()).ToNot(HaveOccurred())
 
-						_, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ackTrue)
+						_, _, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ackTrue)
 						g.Expect(err).ToNot(HaveOccurred())
 					}()
 				case <-time.After(5 * time.Second):
@@ -1835,7 +3314,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
 
 		// create queue channel
-		memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
 		// execute like the task manager
 		ctx, cancel := context.WithCancel(context.Background())
@@ -1878,7 +3357,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 					},
 				}
 				g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
-				g.Expect(memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)).ToNot(HaveOccurred())
+				g.Expect(memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)).ToNot(BeNil())
 			}(i)
 		}
 		wg.Wait()
@@ -1893,7 +3372,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 				// ensure we can dequeue the same item again
 				select {
 				case dequeueFunc := <-dequeueChan:
-					dequeueItem, success, failed := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
+					dequeueItem, success, failed := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
 					g.Expect(dequeueItem).ToNot(BeNil())
 
 					// call success for dequeuing the item and trigger another item to process
@@ -1914,7 +3393,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 							}
 							g.Expect(ackTrue.Validate()).ToNot(HaveOccurred())
 
-							_, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ackTrue)
+							_, _, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ackTrue)
 							g.Expect(err).ToNot(HaveOccurred())
 						}()
 					}
@@ -1936,7 +3415,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
 
 		// create queue channel
-		memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
 		// execute like the task manager
 		ctx, cancel := context.WithCancel(context.Background())
@@ -1974,7 +3453,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 					},
 				}
 				g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
-				g.Expect(memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)).ToNot(HaveOccurred())
+				g.Expect(memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)).ToNot(BeNil())
 			}(i)
 		}
 		wg.Wait()
@@ -1989,7 +3468,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 				// ensure we can dequeue the same item again
 				select {
 				case dequeueFunc := <-dequeueChan:
-					dequeueItem, success, failed := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
+					dequeueItem, success, failed := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
 					g.Expect(dequeueItem).ToNot(BeNil())
 
 					// call success for dequeuing the item and trigger another item to process
@@ -2013,7 +3492,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 							}
 							g.Expect(ackTrue.Validate()).ToNot(HaveOccurred())
 
-							_, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ackTrue)
+							_, _, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ackTrue)
 							g.Expect(err).ToNot(HaveOccurred())
 						}()
 					}
@@ -2035,7 +3514,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
 
 		// create queue channel
-		memeoryQueueChannel := New(fakeLimiterClient, func() {}, "test", defaultKeyValues(g))
+		memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", defaultKeyValues(g), nil, nil)
 
 		// execute like the task manager
 		ctx, cancel := context.WithCancel(context.Background())
@@ -2079,7 +3558,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 					},
 				}
 				g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
-				g.Expect(memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)).ToNot(HaveOccurred())
+				g.Expect(memeoryQueueChannel.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), enqueueItem)).ToNot(BeNil())
 			}(i)
 
 			wg.Add(1)
@@ -2089,7 +3568,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 				// ensure we can dequeue the same item again
 				select {
 				case dequeueFunc := <-dequeueChan:
-					dequeueItem, success, failed := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup())
+					dequeueItem, success, failed := dequeueFunc(testhelpers.NewContextWithMiddlewareSetup(), nil)
 					g.Expect(dequeueItem).ToNot(BeNil())
 
 					// call success for dequeuing the item and trigger another item to process
@@ -2113,7 +3592,7 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 							}
 							g.Expect(ackTrue.Validate()).ToNot(HaveOccurred())
 
-							_, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ackTrue)
+							_, _, err := memeoryQueueChannel.ACK(testhelpers.NewContextWithMiddlewareSetup(), ackTrue)
 							g.Expect(err).ToNot(HaveOccurred())
 						}()
 					}
@@ -2126,3 +3605,52 @@ func Test_memoryQueueChannel_async(t *testing.T) {
 		wg.Wait()
 	})
 }
+
+// Benchmark replacing a dedup item and moving it to the back when a channel has a million items enqueued
+func Benchmark_memoryQueueChannel_Enqueue_DedupMoveToBack(b *testing.B) {
+	const items = 1_000_000
+	g := NewGomegaWithT(b)
+
+	mockController := gomock.NewController(b)
+	defer mockController.Finish()
+	fakeLimiterClient := fakelimiterclient.NewMockLimiterClient(mockController)
+	fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
+
+	memeoryQueueChannel := New(testhelpers.NewRunningHeartbeats(b), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient), func() {}, "test", datatypes.KeyValues{"one": datatypes.Int(1)}, nil, nil)
+
+	dedupItem := func(dedupKey string) *v1willow.Item {
+		return &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(dedupKey),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](0),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+					DedupKey:        helpers.PointerOf(dedupKey),
+					DedupMoveToBack: helpers.PointerOf(true),
+				},
+			},
+		}
+	}
+
+	ctx := testhelpers.NewContextWithMiddlewareSetup()
+	enqueueItems := make([]*v1willow.Item, items)
+	for i := 0; i < items; i++ {
+		enqueueItems[i] = dedupItem(fmt.Sprintf("%d", i))
+		_, err := memeoryQueueChannel.Enqueue(ctx, enqueueItems[i])
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// always replace the item at the front, which was the worst case when searching the enqueued items
+		if _, err := memeoryQueueChannel.Enqueue(ctx, enqueueItems[i%items]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package mirror
+
+import "time"
+
+// SetRetryInterval changes how often the Mirror retries for the tests. The returned func restores the original
+func SetRetryInterval(interval time.Duration) func() {
+	original := retryInterval
+	retryInterval = interval
+
+	return func() { retryInterval = original }
+}
+
+// SetMaxOperations changes how many operations can wait to be sent for the tests. The returned func restores the original
+func SetMaxOperations(operations int) func() {
+	original := maxOperations
+	maxOperations = operations
+
+	return func() { maxOperations = original }
+}
+
+// MarkCaughtUp records operations as if the mirror already caught up, without sending anything
+func (m *Mirror) MarkCaughtUp() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.catchingUp = false
+}
@@ -0,0 +1,517 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DanLavine/willow/internal/helpers"
+	"github.com/DanLavine/willow/pkg/clients"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+	"go.uber.org/zap"
+
+	willowclient "github.com/DanLavine/willow/pkg/clients/willow_client"
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+)
+
+var (
+	// retryInterval is how long to wait before trying to send operations to the mirror again after a failure
+	retryInterval = time.Second
+
+	// maxOperations is the number of operations that can wait to be sent before the mirror drops them and catches up instead
+	maxOperations = 10_000
+)
+
+type operationType int
+
+const (
+	operationEnqueue operationType = iota
+	operationRemove
+	operationDeleteChannel
+)
+
+type operation struct {
+	operationType operationType
+
+	// channel the operation is for
+	keyValues datatypes.KeyValues
+
+	// ID of the item in the source queue. Not set when deleting a channel
+	itemID string
+
+	// item that was enqueued or updated. Only set for enqueue operations
+	item *v1willow.Item
+
+	// when the operation was recorded, to report how long the mirror is behind
+	recorded time.Time
+}
+
+// Mirror streams every change to a queue's items to the same queue on another Willow service through
+// its HTTP api. Operations are sent in order by a single Execute loop. When an operation fails to send,
+// the Mirror switches to catching up. Once the other service is available again, it is brought up to
+// date with all the items currently in the queue instead of replaying each operation.
+type Mirror struct {
+	logger *zap.Logger
+
+	queueName string
+
+	// template for the client's certificates, each mirror provides its own URL
+	clientConfig clients.Config
+
+	// snapshot reports all items currently in the source queue
+	snapshot func(ctx context.Context) v1willow.Items
+
+	// notify the Execute loop that there are operations to send
+	notify chan struct{}
+
+	stop     chan struct{}
+	stopOnce *sync.Once
+
+	lock *sync.Mutex
+
+	// configuration for the queue to create on the mirror. Nil when the queue is not mirrored
+	properties *v1willow.QueueProperties
+	client     willowclient.WillowServiceClient
+
+	// operations waiting to be sent to the mirror. generation changes whenever the operations are dropped,
+	// so the Execute loop never removes operations recorded after a reset
+	operations []*operation
+	generation uint64
+
+	// catchingUp is set when the mirror needs all items sent again, from the first failure until the catch
+	// up succeeds. Operations are only recorded while the catch up is running, since any operations before
+	// that are included in the snapshot
+	catchingUp     bool
+	catchUpRunning bool
+	behindSince    time.Time
+	lastError      string
+}
+
+//	PARAMETERS:
+//	- logger - logger for any errors encountered in the background
+//	- queueName - name of the source queue
+//	- clientConfig - certificates used to connect to any mirrors. The URL is ignored
+//	- snapshot - callback to report all items currently in the source queue
+//
+//	RETURNS:
+//	- *Mirror - mirror for a single queue. Nothing is sent until SetProperties is called with a Mirror
+//
+// New creates a Mirror for a single queue
+func New(logger *zap.Logger, queueName string, clientConfig *clients.Config, snapshot func(ctx context.Context) v1willow.Items) *Mirror {
+	mirror := &Mirror{
+		logger:    logger.Named("mirror").With(zap.String("queue_name", queueName)),
+		queueName: queueName,
+		snapshot:  snapshot,
+		notify:    make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		stopOnce:  new(sync.Once),
+		lock:      new(sync.Mutex),
+	}
+
+	if clientConfig != nil {
+		mirror.clientConfig = clients.Config{
+			CAFile:        clientConfig.CAFile,
+			ClientKeyFile: clientConfig.ClientKeyFile,
+			ClientCRTFile: clientConfig.ClientCRTFile,
+		}
+	}
+
+	return mirror
+}
+
+//	PARAMETERS:
+//	- properties - the source queue's properties. When properties.Mirror is nil, the queue stops being mirrored
+//
+//	RETURNS:
+//	- error - error setting up the client for the mirror
+//
+// SetProperties configures where to mirror the queue. Changing the mirror always catches up the new mirror
+// with all the items currently in the queue
+func (m *Mirror) SetProperties(properties *v1willow.QueueProperties) error {
+	if m == nil {
+		return nil
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if properties == nil || properties.Mirror == nil {
+		m.properties = nil
+		m.client = nil
+		m.operations = nil
+		m.generation++
+		m.catchingUp = false
+		m.catchUpRunning = false
+		m.behindSince = time.Time{}
+		m.lastError = ""
+		return nil
+	}
+
+	// nothing to do when the mirror did not change
+	if m.properties != nil && *m.properties.Mirror.URL == *properties.Mirror.URL && m.targetQueueName(m.properties) == m.targetQueueName(properties) {
+		m.properties = properties
+		return nil
+	}
+
+	clientConfig := m.clientConfig
+	clientConfig.URL = *properties.Mirror.URL
+
+	client, err := willowclient.NewWillowClient(&clientConfig)
+	if err != nil {
+		return err
+	}
+
+	m.properties = properties
+	m.client = client
+	m.lastError = ""
+	m.behindSince = time.Time{}
+	m.reset()
+	m.wake()
+
+	return nil
+}
+
+// targetQueueName returns the name of the queue on the mirror
+func (m *Mirror) targetQueueName(properties *v1willow.QueueProperties) string {
+	if properties.Mirror.QueueName != nil {
+		return *properties.Mirror.QueueName
+	}
+
+	return m.queueName
+}
+
+// reset drops any waiting operations and starts catching up the mirror. Must hold the lock
+func (m *Mirror) reset() {
+	m.operations = nil
+	m.generation++
+	m.catchingUp = true
+	m.catchUpRunning = false
+
+	if m.behindSince.IsZero() {
+		m.behindSince = time.Now()
+	}
+}
+
+// wake the Execute loop without blocking
+func (m *Mirror) wake() {
+	select {
+	case m.notify <- struct{}{}:
+	default:
+	}
+}
+
+// record an operation to send to the mirror
+func (m *Mirror) record(op *operation) {
+	if m == nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// nothing to record when not mirroring or when waiting to catch up, since all the items will be sent anyways
+	if m.properties == nil || (m.catchingUp && !m.catchUpRunning) {
+		return
+	}
+
+	if len(m.operations) >= maxOperations {
+		m.logger.Warn("too many operations waiting to be sent, catching up the mirror instead", zap.Int("operations", len(m.operations)))
+		m.reset()
+		m.wake()
+		return
+	}
+
+	op.recorded = time.Now()
+	m.operations = append(m.operations, op)
+	m.wake()
+}
+
+//	PARAMETERS:
+//	- keyValues - key values of the item's channel
+//	- itemID - ID of the item in the source queue
+//	- enqueueItem - item that was enqueued or that updated the existing item
+//
+// Enqueued records an item that was enqueued or updated in the source queue
+func (m *Mirror) Enqueued(keyValues datatypes.KeyValues, itemID string, enqueueItem *v1willow.Item) {
+	m.record(&operation{operationType: operationEnqueue, keyValues: keyValues, itemID: itemID, item: enqueueItem})
+}
+
+//	PARAMETERS:
+//	- keyValues - key values of the item's channel
+//	- itemID - ID of the item in the source queue
+//
+// Removed records an item that was acked, dead lettered after running out of retry attempts or dropped from
+// the source queue
+func (m *Mirror) Removed(keyValues datatypes.KeyValues, itemID string) {
+	m.record(&operation{operationType: operationRemove, keyValues: keyValues, itemID: itemID})
+}
+
+//	PARAMETERS:
+//	- keyValues - key values of the channel
+//
+// ChannelDeleted records a channel that was deleted along with all its items
+func (m *Mirror) ChannelDeleted(keyValues datatypes.KeyValues) {
+	m.record(&operation{operationType: operationDeleteChannel, keyValues: keyValues})
+}
+
+//	RETURNS:
+//	- *v1willow.QueueMirrorState - current lag of the mirror. Nil when the queue is not mirrored
+//
+// State reports how far behind the mirror is
+func (m *Mirror) State() *v1willow.QueueMirrorState {
+	if m == nil {
+		return nil
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.properties == nil {
+		return nil
+	}
+
+	state := &v1willow.QueueMirrorState{
+		CatchingUp: m.catchingUp,
+		Lag:        int64(len(m.operations)),
+		LastError:  m.lastError,
+	}
+
+	switch {
+	case m.catchingUp:
+		state.LagDuration = time.Since(m.behindSince)
+	case len(m.operations) != 0:
+		state.LagDuration = time.Since(m.operations[0].recorded)
+	}
+
+	return state
+}
+
+// Stop sending operations to the mirror. Used when the source queue is deleted
+func (m *Mirror) Stop() {
+	if m == nil {
+		return
+	}
+
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+}
+
+// Execute sends all operations to the mirror until the server shuts down or the Mirror is stopped
+func (m *Mirror) Execute(ctx context.Context) error {
+	ticker := time.NewTicker(retryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-m.stop:
+			return nil
+		case <-m.notify:
+		case <-ticker.C:
+		}
+
+		m.sync(ctx)
+	}
+}
+
+// sync sends everything that is waiting to the mirror. On any errors, the mirror starts catching up and is
+// retried on the next tick
+func (m *Mirror) sync(ctx context.Context) {
+	// 1. catch up the mirror if required
+	m.lock.Lock()
+	client, properties, catchingUp, generation := m.client, m.properties, m.catchingUp, m.generation
+	if catchingUp {
+		// operations recorded from here on are sent after catching up
+		m.catchUpRunning = true
+	}
+	m.lock.Unlock()
+
+	if client == nil {
+		return
+	}
+
+	if catchingUp {
+		err := m.catchUp(ctx, client, properties)
+
+		m.lock.Lock()
+		switch {
+		case m.generation != generation:
+			// the mirror changed while catching up, so start over
+		case err != nil:
+			m.failed(err)
+		default:
+			m.catchingUp = false
+			m.catchUpRunning = false
+			m.behindSince = time.Time{}
+			m.lastError = ""
+		}
+		m.lock.Unlock()
+
+		if err != nil || !m.caughtUp(generation) {
+			return
+		}
+	}
+
+	// 2. send all the waiting operations in order
+	for {
+		m.lock.Lock()
+		if m.generation != generation || len(m.operations) == 0 {
+			m.lock.Unlock()
+			return
+		}
+		op := m.operations[0]
+		m.lock.Unlock()
+
+		err := m.send(ctx, client, m.targetQueueName(properties), op)
+
+		m.lock.Lock()
+		if m.generation == generation {
+			if err != nil {
+				m.failed(err)
+			} else {
+				m.operations = m.operations[1:]
+			}
+		}
+		m.lock.Unlock()
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// caughtUp reports if the mirror did not change since the generation and is not catching up
+func (m *Mirror) caughtUp(generation uint64) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.generation == generation && !m.catchingUp
+}
+
+// failed records an error sending to the mirror and starts catching up. Must hold the lock
+func (m *Mirror) failed(err error) {
+	m.logger.Warn("failed to send operations to the mirror, catching up once it is available", zap.Error(err))
+
+	m.lastError = err.Error()
+	m.reset()
+}
+
+// catchUp ensures the queue exists on the mirror and that the mirror has exactly the items currently in the queue
+func (m *Mirror) catchUp(ctx context.Context, client willowclient.WillowServiceClient, properties *v1willow.QueueProperties) error {
+	targetQueueName := m.targetQueueName(properties)
+
+	// 1. ensure the queue exists on the mirror
+	if _, err := client.GetQueue(ctx, targetQueueName, &queryassociatedaction.AssociatedActionQuery{}); err != nil {
+		targetProperties := *properties
+		targetProperties.Mirror = nil
+
+		if err := client.CreateQueue(ctx, &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{Name: helpers.PointerOf(targetQueueName)},
+				Properties:   &targetProperties,
+			},
+		}); err != nil {
+			return fmt.Errorf("failed to create queue '%s' on the mirror: %w", targetQueueName, err)
+		}
+	}
+
+	// 2. send all the items currently in the queue. Completed items that are retained are already removed on the mirror
+	itemIDs := map[string]struct{}{}
+	for _, item := range m.snapshot(ctx) {
+		if item.State.Completed != nil {
+			continue
+		}
+
+		itemIDs[item.State.ID] = struct{}{}
+		if _, err := client.EnqueueQueueItem(ctx, targetQueueName, mirrorItem(item.Spec.DBDefinition.KeyValues, item.State.ID, item)); err != nil {
+			return fmt.Errorf("failed to enqueue item '%s' on the mirror: %w", item.State.ID, err)
+		}
+	}
+
+	// 3. remove any items from the mirror that are no longer in the queue
+	mirrorItems, err := client.QueryQueueItems(ctx, targetQueueName, &v1willow.ItemQuery{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}})
+	if err != nil {
+		return fmt.Errorf("failed to query items on the mirror: %w", err)
+	}
+
+	for _, mirrorItem := range mirrorItems {
+		itemID, ok := mirrorItem.Spec.Properties.Headers[v1willow.MirrorItemIDHeader]
+		if !ok {
+			continue
+		}
+
+		if _, ok := itemIDs[itemID]; ok {
+			continue
+		}
+
+		if err := m.remove(ctx, client, targetQueueName, mirrorItem.Spec.DBDefinition.KeyValues, itemID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// send a single operation to the mirror
+func (m *Mirror) send(ctx context.Context, client willowclient.WillowServiceClient, targetQueueName string, op *operation) error {
+	switch op.operationType {
+	case operationEnqueue:
+		if _, err := client.EnqueueQueueItem(ctx, targetQueueName, mirrorItem(op.keyValues, op.itemID, op.item)); err != nil {
+			return fmt.Errorf("failed to enqueue item '%s' on the mirror: %w", op.itemID, err)
+		}
+	case operationRemove:
+		return m.remove(ctx, client, targetQueueName, op.keyValues, op.itemID)
+	case operationDeleteChannel:
+		if err := client.DeleteQueueChannel(ctx, targetQueueName, op.keyValues); err != nil {
+			return fmt.Errorf("failed to delete channel on the mirror: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// remove an item from the mirror by deleting the item with the source item's ID header. This removes the item
+// whether it is enqueued or processing on the mirror. Items that are already gone are left alone
+func (m *Mirror) remove(ctx context.Context, client willowclient.WillowServiceClient, targetQueueName string, keyValues datatypes.KeyValues, itemID string) error {
+	err := client.DeleteQueueItems(ctx, targetQueueName, &v1willow.ItemDelete{
+		Headers:   map[string]string{v1willow.MirrorItemIDHeader: itemID},
+		KeyValues: keyValues,
+	})
+	if err != nil && !errors.Is(err, willowclient.ErrItemsNotFound) {
+		return fmt.Errorf("failed to delete item '%s' on the mirror: %w", itemID, err)
+	}
+
+	return nil
+}
+
+// mirrorItem converts an item in the source queue to the item enqueued on the mirror. Each item on the mirror
+// uses a DedupKey based on the source item's ID, so updates and catching up replace the mirror's item
+func mirrorItem(keyValues datatypes.KeyValues, itemID string, item *v1willow.Item) *v1willow.Item {
+	properties := *item.Spec.Properties
+
+	headers := map[string]string{}
+	for key, value := range properties.Headers {
+		headers[key] = value
+	}
+	headers[v1willow.MirrorItemIDHeader] = itemID
+
+	properties.Headers = headers
+	properties.Updateable = helpers.PointerOf(false)
+	properties.IdempotencyKey = nil
+	properties.DedupKey = helpers.PointerOf(fmt.Sprintf("%s:%s", v1willow.MirrorItemIDHeader, itemID))
+	properties.DedupMoveToBack = nil
+
+	return &v1willow.Item{
+		Spec: &v1willow.ItemSpec{
+			DBDefinition: &v1willow.ItemDBDefinition{
+				KeyValues: keyValues,
+			},
+			Properties: &properties,
+		},
+	}
+}
@@ -0,0 +1,281 @@
+package mirror_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DanLavine/willow/internal/helpers"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/mirror"
+	"github.com/DanLavine/willow/pkg/clients"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+	"github.com/DanLavine/willow/testhelpers/testwillow"
+	"go.uber.org/zap"
+
+	. "github.com/onsi/gomega"
+
+	willowclient "github.com/DanLavine/willow/pkg/clients/willow_client"
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+)
+
+func mirroredProperties(url string) *v1willow.QueueProperties {
+	return &v1willow.QueueProperties{
+		MaxItems: helpers.PointerOf[int64](5),
+		Mirror: &v1willow.QueueMirror{
+			URL: helpers.PointerOf(url),
+		},
+	}
+}
+
+func noItems(_ context.Context) v1willow.Items {
+	return v1willow.Items{}
+}
+
+func sourceItem(itemID string, keyValues datatypes.KeyValues) *v1willow.Item {
+	return &v1willow.Item{
+		Spec: &v1willow.ItemSpec{
+			DBDefinition: &v1willow.ItemDBDefinition{
+				KeyValues: keyValues,
+			},
+			Properties: &v1willow.ItemProperties{
+				Data:            []byte(itemID),
+				Updateable:      helpers.PointerOf(true),
+				RetryAttempts:   helpers.PointerOf[uint64](0),
+				RetryPosition:   helpers.PointerOf("front"),
+				TimeoutDuration: helpers.PointerOf(time.Second),
+			},
+		},
+		State: &v1willow.ItemState{
+			ID: itemID,
+		},
+	}
+}
+
+// mirroredItemIDs reports the source item IDs for every item on the mirror
+func mirroredItemIDs(client *willowclient.WillowClient, queueName string) func() []string {
+	return func() []string {
+		items, err := client.QueryQueueItems(context.Background(), queueName, &v1willow.ItemQuery{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}})
+		if err != nil {
+			return nil
+		}
+
+		itemIDs := []string{}
+		for _, item := range items {
+			itemIDs = append(itemIDs, item.Spec.Properties.Headers[v1willow.MirrorItemIDHeader])
+		}
+
+		return itemIDs
+	}
+}
+
+func Test_Mirror_State(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It reports nothing when the queue is not mirrored", func(t *testing.T) {
+		queueMirror := mirror.New(zap.NewNop(), "test", nil, noItems)
+		queueMirror.Enqueued(datatypes.KeyValues{"one": datatypes.Int(1)}, "item id", &v1willow.Item{})
+
+		g.Expect(queueMirror.State()).To(BeNil())
+	})
+
+	t.Run("It reports nothing for a nil mirror", func(t *testing.T) {
+		var queueMirror *mirror.Mirror
+		queueMirror.Enqueued(datatypes.KeyValues{"one": datatypes.Int(1)}, "item id", &v1willow.Item{})
+
+		g.Expect(queueMirror.State()).To(BeNil())
+	})
+
+	t.Run("It starts catching up when a mirror is set", func(t *testing.T) {
+		queueMirror := mirror.New(zap.NewNop(), "test", nil, noItems)
+		g.Expect(queueMirror.SetProperties(mirroredProperties("http://127.0.0.1:8080"))).ToNot(HaveOccurred())
+
+		// operations are included in the catch up
+		queueMirror.Enqueued(datatypes.KeyValues{"one": datatypes.Int(1)}, "item id", &v1willow.Item{})
+
+		state := queueMirror.State()
+		g.Expect(state).ToNot(BeNil())
+		g.Expect(state.CatchingUp).To(BeTrue())
+		g.Expect(state.Lag).To(Equal(int64(0)))
+	})
+
+	t.Run("It records operations once caught up", func(t *testing.T) {
+		queueMirror := mirror.New(zap.NewNop(), "test", nil, noItems)
+		g.Expect(queueMirror.SetProperties(mirroredProperties("http://127.0.0.1:8080"))).ToNot(HaveOccurred())
+		queueMirror.MarkCaughtUp()
+
+		queueMirror.Enqueued(datatypes.KeyValues{"one": datatypes.Int(1)}, "item id", &v1willow.Item{})
+		queueMirror.Removed(datatypes.KeyValues{"one": datatypes.Int(1)}, "item id")
+		queueMirror.ChannelDeleted(datatypes.KeyValues{"one": datatypes.Int(1)})
+
+		state := queueMirror.State()
+		g.Expect(state.CatchingUp).To(BeFalse())
+		g.Expect(state.Lag).To(Equal(int64(3)))
+		g.Expect(state.LagDuration).To(BeNumerically(">", 0))
+	})
+
+	t.Run("It catches up instead of recording too many operations", func(t *testing.T) {
+		defer mirror.SetMaxOperations(2)()
+
+		queueMirror := mirror.New(zap.NewNop(), "test", nil, noItems)
+		g.Expect(queueMirror.SetProperties(mirroredProperties("http://127.0.0.1:8080"))).ToNot(HaveOccurred())
+		queueMirror.MarkCaughtUp()
+
+		for i := 0; i < 3; i++ {
+			queueMirror.Removed(datatypes.KeyValues{"one": datatypes.Int(1)}, "item id")
+		}
+
+		state := queueMirror.State()
+		g.Expect(state.CatchingUp).To(BeTrue())
+		g.Expect(state.Lag).To(Equal(int64(0)))
+	})
+
+	t.Run("It stops reporting when the mirror is removed", func(t *testing.T) {
+		queueMirror := mirror.New(zap.NewNop(), "test", nil, noItems)
+		g.Expect(queueMirror.SetProperties(mirroredProperties("http://127.0.0.1:8080"))).ToNot(HaveOccurred())
+		g.Expect(queueMirror.SetProperties(&v1willow.QueueProperties{MaxItems: helpers.PointerOf[int64](5)})).ToNot(HaveOccurred())
+
+		g.Expect(queueMirror.State()).To(BeNil())
+	})
+}
+
+func Test_Mirror_Execute(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It records the error when the mirror is unavailable", func(t *testing.T) {
+		defer mirror.SetRetryInterval(10 * time.Millisecond)()
+
+		queueMirror := mirror.New(zap.NewNop(), "test", nil, noItems)
+		g.Expect(queueMirror.SetProperties(mirroredProperties("http://127.0.0.1:1"))).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = queueMirror.Execute(ctx)
+		}()
+
+		g.Eventually(func() string { return queueMirror.State().LastError }).ShouldNot(BeEmpty())
+		g.Expect(queueMirror.State().CatchingUp).To(BeTrue())
+
+		cancel()
+		g.Eventually(done).Should(BeClosed())
+	})
+
+	t.Run("It stops when the queue is deleted", func(t *testing.T) {
+		queueMirror := mirror.New(zap.NewNop(), "test", nil, noItems)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = queueMirror.Execute(context.Background())
+		}()
+
+		queueMirror.Stop()
+		queueMirror.Stop()
+		g.Eventually(done).Should(BeClosed())
+	})
+
+	t.Run("It sends all changes to another Willow service", func(t *testing.T) {
+		defer mirror.SetRetryInterval(10 * time.Millisecond)()
+
+		server := testwillow.Start(t, g)
+		client, err := willowclient.NewWillowClient(&clients.Config{URL: server.URL})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		channelOne := datatypes.KeyValues{"one": datatypes.Int(1)}
+		channelTwo := datatypes.KeyValues{"two": datatypes.Int(2)}
+		snapshot := func(_ context.Context) v1willow.Items {
+			return v1willow.Items{sourceItem("item 1", channelOne)}
+		}
+
+		properties := mirroredProperties(server.URL)
+		properties.Mirror.QueueName = helpers.PointerOf("mirrored")
+
+		queueMirror := mirror.New(zap.NewNop(), "test", nil, snapshot)
+		g.Expect(queueMirror.SetProperties(properties)).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = queueMirror.Execute(ctx)
+		}()
+		defer func() {
+			cancel()
+			g.Eventually(done).Should(BeClosed())
+		}()
+
+		// catching up creates the queue and sends all the items already in the source queue
+		g.Eventually(mirroredItemIDs(client, "mirrored")).Should(ConsistOf("item 1"))
+		g.Eventually(func() bool { return queueMirror.State().CatchingUp }).Should(BeFalse())
+
+		// operations are then sent in order
+		queueMirror.Enqueued(channelTwo, "item 2", sourceItem("item 2", channelTwo))
+		g.Eventually(mirroredItemIDs(client, "mirrored")).Should(ConsistOf("item 1", "item 2"))
+
+		queueMirror.Removed(channelOne, "item 1")
+		g.Eventually(mirroredItemIDs(client, "mirrored")).Should(ConsistOf("item 2"))
+
+		queueMirror.ChannelDeleted(channelTwo)
+		g.Eventually(mirroredItemIDs(client, "mirrored")).Should(BeEmpty())
+
+		// each operation is only dropped once the mirror responds
+		g.Eventually(func() int64 { return queueMirror.State().Lag }).Should(Equal(int64(0)))
+
+		state := queueMirror.State()
+		g.Expect(state.CatchingUp).To(BeFalse())
+		g.Expect(state.LastError).To(BeEmpty())
+	})
+
+	t.Run("It deletes items that are processing on the other Willow service", func(t *testing.T) {
+		defer mirror.SetRetryInterval(10 * time.Millisecond)()
+
+		server := testwillow.Start(t, g)
+		client, err := willowclient.NewWillowClient(&clients.Config{URL: server.URL})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		channelOne := datatypes.KeyValues{"one": datatypes.Int(1)}
+
+		properties := mirroredProperties(server.URL)
+		properties.Mirror.QueueName = helpers.PointerOf("mirrored")
+
+		queueMirror := mirror.New(zap.NewNop(), "test", nil, noItems)
+		g.Expect(queueMirror.SetProperties(properties)).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = queueMirror.Execute(ctx)
+		}()
+		defer func() {
+			cancel()
+			g.Eventually(done).Should(BeClosed())
+		}()
+
+		g.Eventually(func() bool { return queueMirror.State().CatchingUp }).Should(BeFalse())
+
+		queueMirror.Enqueued(channelOne, "item 1", sourceItem("item 1", channelOne))
+		g.Eventually(mirroredItemIDs(client, "mirrored")).Should(ConsistOf("item 1"))
+
+		// a client on the other Willow service is processing the item
+		dequeueCtx, dequeueCancel := context.WithTimeout(context.Background(), time.Second)
+		defer dequeueCancel()
+		processingItem, err := client.DequeueQueueItem(dequeueCtx, "mirrored", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// removing the item deletes it, so the client can no longer complete it
+		queueMirror.Removed(channelOne, "item 1")
+		g.Eventually(mirroredItemIDs(client, "mirrored")).Should(BeEmpty())
+		g.Expect(processingItem.ACK(context.Background(), true)).To(HaveOccurred())
+
+		// removing an item that is not on the mirror is not an error
+		queueMirror.Removed(channelOne, "item 2")
+		g.Eventually(func() int64 { return queueMirror.State().Lag }).Should(Equal(int64(0)))
+
+		state := queueMirror.State()
+		g.Expect(state.CatchingUp).To(BeFalse())
+		g.Expect(state.LastError).To(BeEmpty())
+	})
+}
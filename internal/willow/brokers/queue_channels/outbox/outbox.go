@@ -0,0 +1,384 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+	"go.uber.org/zap"
+
+	limiterclient "github.com/DanLavine/willow/pkg/clients/limiter_client"
+	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+)
+
+var (
+	// minBackoff is how long to wait before the first retry of a failed update
+	minBackoff = 100 * time.Millisecond
+
+	// maxBackoff is the longest time to wait between retries of a failed update
+	maxBackoff = 30 * time.Second
+)
+
+// update is a pending change to the Limiter's counters for a single set of key values
+type update struct {
+	keyValues datatypes.KeyValues
+
+	// when set, counters is the value to set the counters to. Otherwise counters is the amount to update them by
+	set      bool
+	counters int64
+
+	// version changes whenever the update is coalesced, so a retry only removes what it sent
+	version uint64
+
+	// when the update first failed, to report how long the Limiter has been out of sync
+	queued time.Time
+
+	attempts    int
+	nextAttempt time.Time
+}
+
+// coalesce combines another update for the same key values. Counters that are set can never be negative
+func (u *update) coalesce(set bool, counters int64) {
+	switch {
+	case set:
+		u.set = true
+		u.counters = counters
+	case u.set:
+		u.counters += counters
+		if u.counters < 0 {
+			u.counters = 0
+		}
+	default:
+		u.counters += counters
+	}
+
+	u.version++
+}
+
+// Outbox records changes to the Limiter's counters that must not be dropped, such as releasing the counters
+// for items that are removed. When the Limiter cannot be reached, the change is kept in memory and retried with
+// a backoff by a single Execute loop. Changes for the same key values are coalesced into one update, so the
+// outbox never grows past the number of counters Willow manages.
+type Outbox struct {
+	logger        *zap.Logger
+	limiterClient limiterclient.LimiterClient
+
+	// notify the Execute loop that there are updates to send
+	notify chan struct{}
+
+	lock *sync.Mutex
+
+	// encoded key values -> pending update
+	pending map[string]*update
+
+	retries   uint64
+	lastError string
+}
+
+//	PARAMETERS:
+//	- logger - logger for any errors encountered in the background
+//	- limiterClient - client to update the Limiter's counters
+//
+//	RETURNS:
+//	- *Outbox - outbox shared by all queue channels
+//
+// New creates an Outbox for the Limiter's counters
+func New(logger *zap.Logger, limiterClient limiterclient.LimiterClient) *Outbox {
+	return &Outbox{
+		logger:        logger.Named("limiter_outbox"),
+		limiterClient: limiterClient,
+		notify:        make(chan struct{}, 1),
+		lock:          new(sync.Mutex),
+		pending:       map[string]*update{},
+	}
+}
+
+// Key encodes the key values so updates and samples for the same counter can be matched. Maps are always encoded
+// with sorted keys
+func Key(keyValues datatypes.KeyValues) string {
+	encoded, err := json.Marshal(keyValues)
+	if err != nil {
+		panic(err)
+	}
+
+	return string(encoded)
+}
+
+func counter(keyValues datatypes.KeyValues, counters int64) *v1limiter.Counter {
+	return &v1limiter.Counter{
+		Spec: &v1limiter.CounterSpec{
+			DBDefinition: &v1limiter.CounterDBDefinition{
+				KeyValues: keyValues,
+			},
+			Properties: &v1limiter.CounteProperties{
+				Counters: &counters,
+			},
+		},
+	}
+}
+
+func (o *Outbox) wake() {
+	select {
+	case o.notify <- struct{}{}:
+	default:
+	}
+}
+
+//	PARAMETERS:
+//	- ctx - context for the request to the Limiter
+//	- keyValues - key values of the counter to update
+//	- counters - amount to update the counter by
+//
+//	RETURNS:
+//	- error - error from the Limiter, including when a limit is reached
+//
+// UpdateCounter updates a counter on the Limiter right away and is used when the caller needs to know if a limit
+// was reached. Nothing is retried on a failure. When the counter is waiting to be set, the update is also applied
+// to the value that will be set
+func (o *Outbox) UpdateCounter(ctx context.Context, keyValues datatypes.KeyValues, counters int64) error {
+	if err := o.limiterClient.UpdateCounter(ctx, counter(keyValues, counters)); err != nil {
+		return err
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if pendingUpdate, ok := o.pending[Key(keyValues)]; ok && pendingUpdate.set {
+		pendingUpdate.coalesce(false, counters)
+	}
+
+	return nil
+}
+
+//	PARAMETERS:
+//	- ctx - context for the request to the Limiter
+//	- keyValues - key values of the counter to update
+//	- counters - amount to update the counter by
+//
+// UpdateCounterOrRetry updates a counter on the Limiter and must only be used for updates that can not reach a
+// limit, such as releasing a counter. When there are already updates waiting for the same key values, this is
+// coalesced with them. Otherwise the update is sent right away and retried in the background on a failure
+func (o *Outbox) UpdateCounterOrRetry(ctx context.Context, keyValues datatypes.KeyValues, counters int64) {
+	o.sendOrRetry(ctx, keyValues, false, counters)
+}
+
+//	PARAMETERS:
+//	- ctx - context for the request to the Limiter
+//	- keyValues - key values of the counters to set
+//	- counters - value to set the counters to
+//
+// SetCountersOrRetry sets the counters on the Limiter. When there are already updates waiting for the same key
+// values, they are replaced. Otherwise the counters are set right away and retried in the background on a failure
+func (o *Outbox) SetCountersOrRetry(ctx context.Context, keyValues datatypes.KeyValues, counters int64) {
+	o.sendOrRetry(ctx, keyValues, true, counters)
+}
+
+func (o *Outbox) sendOrRetry(ctx context.Context, keyValues datatypes.KeyValues, set bool, counters int64) {
+	updateKey := Key(keyValues)
+
+	// coalesce with any updates that are already waiting, so they are applied in order
+	o.lock.Lock()
+	if pendingUpdate, ok := o.pending[updateKey]; ok {
+		pendingUpdate.coalesce(set, counters)
+		o.lock.Unlock()
+		return
+	}
+	o.lock.Unlock()
+
+	err := o.send(ctx, keyValues, set, counters)
+	if err == nil {
+		return
+	}
+
+	o.logger.Warn("failed to update the Limiter counters, retrying in the background", zap.Any("key_values", keyValues), zap.Error(err))
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.lastError = err.Error()
+
+	if pendingUpdate, ok := o.pending[updateKey]; ok {
+		// another update failed at the same time
+		pendingUpdate.coalesce(set, counters)
+	} else {
+		o.pending[updateKey] = &update{
+			keyValues:   keyValues,
+			set:         set,
+			counters:    counters,
+			queued:      time.Now(),
+			attempts:    1,
+			nextAttempt: time.Now().Add(backoff(1)),
+		}
+	}
+
+	o.wake()
+}
+
+func (o *Outbox) send(ctx context.Context, keyValues datatypes.KeyValues, set bool, counters int64) error {
+	if set {
+		return o.limiterClient.SetCounters(ctx, counter(keyValues, counters))
+	}
+
+	return o.limiterClient.UpdateCounter(ctx, counter(keyValues, counters))
+}
+
+// backoff doubles the time between each attempt, up to the maxBackoff
+func backoff(attempts int) time.Duration {
+	wait := minBackoff
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > maxBackoff {
+		return maxBackoff
+	}
+
+	return wait
+}
+
+//	RETURNS:
+//	- bool - true when there are no updates waiting to be retried
+//
+// InSync reports if all counter updates have been recorded on the Limiter
+func (o *Outbox) InSync() bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	return len(o.pending) == 0
+}
+
+//	RETURNS:
+//	- *v1willow.LimiterOutboxState - pending updates and retries for the Limiter's counters
+//
+// State reports how far the Limiter's counters are out of sync with Willow
+func (o *Outbox) State() *v1willow.LimiterOutboxState {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	state := &v1willow.LimiterOutboxState{
+		PendingUpdates: int64(len(o.pending)),
+		Retries:        o.retries,
+		LastError:      o.lastError,
+	}
+
+	for _, pendingUpdate := range o.pending {
+		if outOfSync := time.Since(pendingUpdate.queued); outOfSync > state.OutOfSyncDuration {
+			state.OutOfSyncDuration = outOfSync
+		}
+	}
+
+	return state
+}
+
+// Handler for the GoAsync manager to retry any failed updates until the server is shutdown
+func (o *Outbox) Execute(ctx context.Context) error {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+
+	for {
+		if wait, ok := o.retry(ctx); ok {
+			timer.Reset(wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			if pending := o.State().PendingUpdates; pending != 0 {
+				o.logger.Error("shutting down with Limiter counter updates that were never sent", zap.Int64("pending_updates", pending))
+			}
+
+			return nil
+		case <-o.notify:
+		case <-timer.C:
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+}
+
+//	RETURNS:
+//	- time.Duration - how long to wait for the next update to retry
+//	- bool - false when there are no more updates waiting
+//
+// retry sends all updates that are due
+func (o *Outbox) retry(ctx context.Context) (time.Duration, bool) {
+	now := time.Now()
+
+	o.lock.Lock()
+	due := map[string]update{}
+	for updateKey, pendingUpdate := range o.pending {
+		if !pendingUpdate.nextAttempt.After(now) {
+			due[updateKey] = *pendingUpdate
+		}
+	}
+	o.lock.Unlock()
+
+	for updateKey, sent := range due {
+		err := o.send(ctx, sent.keyValues, sent.set, sent.counters)
+
+		o.lock.Lock()
+		pendingUpdate := o.pending[updateKey]
+		o.retries++
+
+		switch {
+		case err != nil:
+			o.lastError = err.Error()
+			pendingUpdate.attempts++
+			pendingUpdate.nextAttempt = time.Now().Add(backoff(pendingUpdate.attempts))
+		case pendingUpdate.version == sent.version:
+			delete(o.pending, updateKey)
+		case !pendingUpdate.set:
+			// keep any updates that were coalesced while sending
+			pendingUpdate.counters -= sent.counters
+			pendingUpdate.attempts = 0
+			pendingUpdate.nextAttempt = time.Now()
+
+			if pendingUpdate.counters == 0 {
+				delete(o.pending, updateKey)
+			}
+		default:
+			// the set value changed while sending, so set the counters again
+			pendingUpdate.attempts = 0
+			pendingUpdate.nextAttempt = time.Now()
+		}
+		o.lock.Unlock()
+
+		if err != nil {
+			o.logger.Warn("failed to retry the Limiter counter update", zap.Any("key_values", sent.keyValues), zap.Error(err))
+		}
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if len(o.pending) == 0 {
+		if len(due) != 0 {
+			o.lastError = ""
+			o.logger.Info("Limiter counters are back in sync")
+		}
+
+		return 0, false
+	}
+
+	var next time.Time
+	for _, pendingUpdate := range o.pending {
+		if next.IsZero() || pendingUpdate.nextAttempt.Before(next) {
+			next = pendingUpdate.nextAttempt
+		}
+	}
+
+	if wait := time.Until(next); wait > 0 {
+		return wait, true
+	}
+
+	return 0, true
+}
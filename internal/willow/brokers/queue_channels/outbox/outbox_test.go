@@ -0,0 +1,238 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/DanLavine/willow/pkg/clients/limiter_client/limiterclientfakes"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+
+	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
+
+	. "github.com/onsi/gomega"
+)
+
+func keyValues() datatypes.KeyValues {
+	return datatypes.KeyValues{
+		"_willow_queue_name": datatypes.String("test"),
+		"_willow_running":    datatypes.String("true"),
+	}
+}
+
+// limiter records all counter requests and fails them until available is set
+type limiter struct {
+	lock      *sync.Mutex
+	available bool
+	updates   []int64
+	sets      []int64
+}
+
+func newLimiter(t *testing.T) (*limiter, *limiterclientfakes.MockLimiterClient) {
+	mockController := gomock.NewController(t)
+	t.Cleanup(mockController.Finish)
+
+	fakeLimiter := &limiter{lock: new(sync.Mutex)}
+	mockClient := limiterclientfakes.NewMockLimiterClient(mockController)
+
+	mockClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, counter *v1limiter.Counter) error {
+		fakeLimiter.lock.Lock()
+		defer fakeLimiter.lock.Unlock()
+
+		if !fakeLimiter.available {
+			return fmt.Errorf("limiter is unavailable")
+		}
+
+		fakeLimiter.updates = append(fakeLimiter.updates, *counter.Spec.Properties.Counters)
+		return nil
+	}).AnyTimes()
+
+	mockClient.EXPECT().SetCounters(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, counter *v1limiter.Counter) error {
+		fakeLimiter.lock.Lock()
+		defer fakeLimiter.lock.Unlock()
+
+		if !fakeLimiter.available {
+			return fmt.Errorf("limiter is unavailable")
+		}
+
+		fakeLimiter.sets = append(fakeLimiter.sets, *counter.Spec.Properties.Counters)
+		return nil
+	}).AnyTimes()
+
+	return fakeLimiter, mockClient
+}
+
+func (l *limiter) setAvailable(available bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.available = available
+}
+
+func (l *limiter) requests() ([]int64, []int64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return append([]int64{}, l.updates...), append([]int64{}, l.sets...)
+}
+
+func runOutbox(t *testing.T, outbox *Outbox) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = outbox.Execute(ctx)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func Test_Outbox_UpdateCounter(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It returns the Limiter's error without retrying", func(t *testing.T) {
+		fakeLimiter, mockClient := newLimiter(t)
+		outbox := New(zap.NewNop(), mockClient)
+
+		g.Expect(outbox.UpdateCounter(context.Background(), keyValues(), 1)).To(HaveOccurred())
+		g.Expect(outbox.InSync()).To(BeTrue())
+
+		fakeLimiter.setAvailable(true)
+		g.Expect(outbox.UpdateCounter(context.Background(), keyValues(), 1)).ToNot(HaveOccurred())
+
+		updates, _ := fakeLimiter.requests()
+		g.Expect(updates).To(Equal([]int64{1}))
+	})
+
+	t.Run("It adds the update to counters that are waiting to be set", func(t *testing.T) {
+		fakeLimiter, mockClient := newLimiter(t)
+		outbox := New(zap.NewNop(), mockClient)
+
+		outbox.SetCountersOrRetry(context.Background(), keyValues(), 0)
+		g.Expect(outbox.InSync()).To(BeFalse())
+
+		fakeLimiter.setAvailable(true)
+		g.Expect(outbox.UpdateCounter(context.Background(), keyValues(), 1)).ToNot(HaveOccurred())
+
+		runOutbox(t, outbox)
+		g.Eventually(outbox.InSync).Should(BeTrue())
+
+		_, sets := fakeLimiter.requests()
+		g.Expect(sets).To(Equal([]int64{1}))
+	})
+}
+
+func Test_Outbox_OrRetry(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	setup := func() {
+		originalMinBackoff := minBackoff
+		t.Cleanup(func() { minBackoff = originalMinBackoff })
+		minBackoff = time.Millisecond
+	}
+
+	t.Run("It sends the update right away when the Limiter is available", func(t *testing.T) {
+		fakeLimiter, mockClient := newLimiter(t)
+		fakeLimiter.setAvailable(true)
+		outbox := New(zap.NewNop(), mockClient)
+
+		outbox.UpdateCounterOrRetry(context.Background(), keyValues(), -1)
+		outbox.SetCountersOrRetry(context.Background(), keyValues(), 0)
+
+		g.Expect(outbox.InSync()).To(BeTrue())
+
+		updates, sets := fakeLimiter.requests()
+		g.Expect(updates).To(Equal([]int64{-1}))
+		g.Expect(sets).To(Equal([]int64{0}))
+	})
+
+	t.Run("It reports the updates that are out of sync", func(t *testing.T) {
+		_, mockClient := newLimiter(t)
+		outbox := New(zap.NewNop(), mockClient)
+
+		outbox.UpdateCounterOrRetry(context.Background(), keyValues(), -1)
+		outbox.UpdateCounterOrRetry(context.Background(), datatypes.KeyValues{"other": datatypes.Int(1)}, -1)
+
+		state := outbox.State()
+		g.Expect(outbox.InSync()).To(BeFalse())
+		g.Expect(state.PendingUpdates).To(Equal(int64(2)))
+		g.Expect(state.OutOfSyncDuration).To(BeNumerically(">", 0))
+		g.Expect(state.LastError).To(ContainSubstring("limiter is unavailable"))
+	})
+
+	t.Run("It retries the update once the Limiter is available", func(t *testing.T) {
+		setup()
+
+		fakeLimiter, mockClient := newLimiter(t)
+		outbox := New(zap.NewNop(), mockClient)
+		runOutbox(t, outbox)
+
+		outbox.UpdateCounterOrRetry(context.Background(), keyValues(), -1)
+		g.Consistently(outbox.InSync, 50*time.Millisecond).Should(BeFalse())
+
+		fakeLimiter.setAvailable(true)
+		g.Eventually(outbox.InSync).Should(BeTrue())
+		g.Expect(outbox.State().Retries).To(BeNumerically(">", 1))
+		g.Expect(outbox.State().LastError).To(BeEmpty())
+
+		updates, _ := fakeLimiter.requests()
+		g.Expect(updates).To(Equal([]int64{-1}))
+	})
+
+	t.Run("It coalesces updates for the same key values", func(t *testing.T) {
+		setup()
+
+		fakeLimiter, mockClient := newLimiter(t)
+		outbox := New(zap.NewNop(), mockClient)
+
+		outbox.UpdateCounterOrRetry(context.Background(), keyValues(), -1)
+		outbox.UpdateCounterOrRetry(context.Background(), keyValues(), -1)
+		outbox.UpdateCounterOrRetry(context.Background(), keyValues(), -1)
+		g.Expect(outbox.State().PendingUpdates).To(Equal(int64(1)))
+
+		fakeLimiter.setAvailable(true)
+		runOutbox(t, outbox)
+		g.Eventually(outbox.InSync).Should(BeTrue())
+
+		updates, _ := fakeLimiter.requests()
+		g.Expect(updates).To(Equal([]int64{-3}))
+	})
+
+	t.Run("It replaces pending updates when the counters are set", func(t *testing.T) {
+		setup()
+
+		fakeLimiter, mockClient := newLimiter(t)
+		outbox := New(zap.NewNop(), mockClient)
+
+		outbox.UpdateCounterOrRetry(context.Background(), keyValues(), -1)
+		outbox.SetCountersOrRetry(context.Background(), keyValues(), 0)
+		outbox.UpdateCounterOrRetry(context.Background(), keyValues(), -1)
+
+		fakeLimiter.setAvailable(true)
+		runOutbox(t, outbox)
+		g.Eventually(outbox.InSync).Should(BeTrue())
+
+		updates, sets := fakeLimiter.requests()
+		g.Expect(updates).To(BeEmpty())
+		g.Expect(sets).To(Equal([]int64{0}))
+	})
+}
+
+func Test_backoff(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It doubles the wait up to the max backoff", func(t *testing.T) {
+		g.Expect(backoff(1)).To(Equal(minBackoff))
+		g.Expect(backoff(2)).To(Equal(2 * minBackoff))
+		g.Expect(backoff(3)).To(Equal(4 * minBackoff))
+		g.Expect(backoff(1_000)).To(Equal(maxBackoff))
+	})
+}
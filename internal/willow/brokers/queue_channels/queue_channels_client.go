@@ -8,6 +8,7 @@ import (
 
 	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
 
+	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
 	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
 )
 
@@ -17,12 +18,32 @@ type QueueChannelsClient interface {
 
 	// channel operations
 	Channels(ctx context.Context, queueName string, channelQuery *queryassociatedaction.AssociatedActionQuery) v1willow.Channels
-	EnqueueQueueItem(ctx context.Context, queueName string, enqueueItem *v1willow.Item) *errors.ServerError
+	DiagnoseChannels(ctx context.Context, queueName string, channelQuery *queryassociatedaction.AssociatedActionQuery) (v1willow.ChannelDiagnoses, *errors.ServerError)
+	EnqueueQueueItem(ctx context.Context, queueName string, enqueueItem *v1willow.Item) (*v1willow.ItemState, *errors.ServerError)
 	DequeueQueueItem(ctx context.Context, queueName string, dequeueQuery *queryassociatedaction.AssociatedActionQuery) (*v1willow.Item, func(), func(), *errors.ServerError)
+	DequeueQueueItems(ctx context.Context, queueQueries map[string]*queryassociatedaction.AssociatedActionQuery, headersFilter map[string]string) (*v1willow.Item, func(), func(), *errors.ServerError)
+	StartDestroyingQueue(queueName string)
+	StopDestroyingQueue(queueName string)
 	DestroyChannelsForQueue(ctx context.Context, queueName string) *errors.ServerError
 	DeleteChannel(ctx context.Context, queueName string, channelKeyValues datatypes.KeyValues) *errors.ServerError
+	SetChannelWeights(queueName string, channelWeights []*v1willow.ChannelWeight)
+	SetPriorityAging(queueName string, priorityAging *v1willow.PriorityAging)
+
+	// export operations
+	ExportChannels(ctx context.Context, queueName string) []*v1willow.ExportedChannel
+	ImportChannel(ctx context.Context, queueName string, exportedChannel *v1willow.ExportedChannel) *errors.ServerError
+
+	// limiter operations
+	LimiterCounters(ctx context.Context, queueName string) v1limiter.Counters
+
+	// mirror operations
+	SetMirror(ctx context.Context, queueName string, properties *v1willow.QueueProperties) *errors.ServerError
+	MirrorState(queueName string) *v1willow.QueueMirrorState
 
 	// item operations
-	ACK(ctx context.Context, queueName string, ack *v1willow.ACK) *errors.ServerError
-	Heartbeat(ctx context.Context, queueName string, heartbeat *v1willow.Heartbeat) *errors.ServerError
+	ACK(ctx context.Context, queueName string, ack *v1willow.ACK) (*v1willow.Item, *errors.ServerError)
+	Heartbeat(ctx context.Context, queueName string, heartbeat *v1willow.Heartbeat) (*v1willow.HeartbeatResponse, *errors.ServerError)
+	Cancel(ctx context.Context, queueName string, cancel *v1willow.Cancel) *errors.ServerError
+	DeleteItems(ctx context.Context, queueName string, itemDelete *v1willow.ItemDelete) *errors.ServerError
+	Items(ctx context.Context, queueName string, itemQuery *v1willow.ItemQuery) v1willow.Items
 }
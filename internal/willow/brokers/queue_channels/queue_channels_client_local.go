@@ -2,12 +2,16 @@ package queuechannels
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/DanLavine/channelops"
 	"github.com/DanLavine/goasync"
+	"github.com/DanLavine/willow/pkg/clients"
 	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+	v1common "github.com/DanLavine/willow/pkg/models/api/common/v1"
 	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
 	"github.com/DanLavine/willow/pkg/models/datatypes"
 	"go.uber.org/zap"
@@ -15,18 +19,26 @@ import (
 	"github.com/DanLavine/willow/internal/middleware"
 	"github.com/DanLavine/willow/internal/reporting"
 	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/constructor"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/fairshare"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/mirror"
 
 	btreeonetomany "github.com/DanLavine/willow/internal/datastructures/btree_one_to_many"
+	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
 	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
 )
 
-type clientWaiting struct {
-	// queue the client is waiting on
-	queueName string
-	// query is used to match any channels against to see if they can provide values for the client
-	query *queryassociatedaction.AssociatedActionQuery
-	// channelOPS is the collection of channels attempting to be read
-	channelOPS channelops.RepeatableMergeReadChannelOperator[func(ctx context.Context) (*v1willow.Item, func(), func())]
+// headersFilterBackoff is how long a client filtering by headers waits before reading from the channels again
+// when a channel had no items that matched the filter
+var headersFilterBackoff = 100 * time.Millisecond
+
+// errorQueueDeleted is returned to any clients waiting to dequeue from a queue that was deleted
+func errorQueueDeleted(queueName string) *errors.ServerError {
+	return &errors.ServerError{Message: fmt.Sprintf("Queue '%s' was deleted while waiting to dequeue an item", queueName), StatusCode: http.StatusGone}
+}
+
+// errorChannelDeleted is returned to any clients waiting to dequeue from a channel that was deleted
+func errorChannelDeleted(queueName string) *errors.ServerError {
+	return &errors.ServerError{Message: fmt.Sprintf("Channel in queue '%s' was deleted while waiting to dequeue an item", queueName), StatusCode: http.StatusGone}
 }
 
 type queueChannelsClientLocal struct {
@@ -45,10 +57,30 @@ type queueChannelsClientLocal struct {
 
 	// client wating resources
 	clientsWaitingLock *sync.RWMutex
-	clientsWaiting     []clientWaiting
+	clientsWaiting     *clientsWaitingIndex
+
+	// queues that are being destroyed and the number of destroy calls in progress. Any clients that try to dequeue from these are rejected
+	destroyingQueues map[string]int
+
+	// fair share schedulers for each queue's channels
+	schedulersLock *sync.Mutex
+	schedulers     map[string]*fairshare.Scheduler
+
+	// mirrors that stream each queue's items to another Willow service
+	mirrorClientConfig *clients.Config
+	mirrorsLock        *sync.Mutex
+	mirrors            map[string]*mirror.Mirror
 }
 
-func NewLocalQueueChannelsClient(queueChannelsConstructor constructor.QueueChannelsConstrutor) *queueChannelsClientLocal {
+//	PARAMETERS:
+//	- queueChannelsConstructor - constructor to create each channel
+//	- mirrorClientConfig - optional certificates used to connect to any queue mirrors
+//
+//	RETURNS:
+//	- *queueChannelsClientLocal - client to manage all channels for every queue
+//
+// NewLocalQueueChannelsClient creates the client that manages all queue channels in memory
+func NewLocalQueueChannelsClient(queueChannelsConstructor constructor.QueueChannelsConstrutor, mirrorClientConfig *clients.Config) *queueChannelsClientLocal {
 	shutdownContext, cancel := context.WithCancel(context.Background())
 
 	return &queueChannelsClientLocal{
@@ -58,7 +90,158 @@ func NewLocalQueueChannelsClient(queueChannelsConstructor constructor.QueueChann
 		queueChannelsConstructor: queueChannelsConstructor,
 		queueChannels:            btreeonetomany.NewThreadSafe(),
 		clientsWaitingLock:       new(sync.RWMutex),
-		clientsWaiting:           []clientWaiting{},
+		clientsWaiting:           newClientsWaitingIndex(),
+		destroyingQueues:         map[string]int{},
+		schedulersLock:           new(sync.Mutex),
+		schedulers:               map[string]*fairshare.Scheduler{},
+		mirrorClientConfig:       mirrorClientConfig,
+		mirrorsLock:              new(sync.Mutex),
+		mirrors:                  map[string]*mirror.Mirror{},
+	}
+}
+
+// SetChannelWeights configures the weights used to fairly dequeue items across a queue's channels
+func (qccl *queueChannelsClientLocal) SetChannelWeights(queueName string, channelWeights []*v1willow.ChannelWeight) {
+	qccl.schedulersLock.Lock()
+	defer qccl.schedulersLock.Unlock()
+
+	if scheduler, ok := qccl.schedulers[queueName]; ok {
+		scheduler.SetWeights(channelWeights)
+	} else {
+		qccl.schedulers[queueName] = fairshare.New(channelWeights)
+	}
+}
+
+// SetPriorityAging configures the policy used to raise the priority of items waiting in a queue's channels
+func (qccl *queueChannelsClientLocal) SetPriorityAging(queueName string, priorityAging *v1willow.PriorityAging) {
+	qccl.scheduler(queueName).SetPriorityAging(priorityAging)
+}
+
+// scheduler returns the fair share scheduler for a queue, creating one without any weights if it does not exist
+func (qccl *queueChannelsClientLocal) scheduler(queueName string) *fairshare.Scheduler {
+	qccl.schedulersLock.Lock()
+	defer qccl.schedulersLock.Unlock()
+
+	scheduler, ok := qccl.schedulers[queueName]
+	if !ok {
+		scheduler = fairshare.New(nil)
+		qccl.schedulers[queueName] = scheduler
+	}
+
+	return scheduler
+}
+
+//	PARAMETERS:
+//	- ctx - context with the logger for the request
+//	- queueName - name of the queue to mirror
+//	- properties - the queue's properties. When properties.Mirror is nil, the queue stops being mirrored
+//
+//	RETURNS:
+//	- *errors.ServerError - error setting up the client for the mirror
+//
+// SetMirror configures the Willow service that all of a queue's items are streamed to. A mirror and its
+// background task only exist while the queue has a Mirror configured
+func (qccl *queueChannelsClientLocal) SetMirror(ctx context.Context, queueName string, properties *v1willow.QueueProperties) *errors.ServerError {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "SetMirror")
+
+	if properties == nil || properties.Mirror == nil {
+		qccl.removeMirror(ctx, queueName)
+		return nil
+	}
+
+	qccl.mirrorsLock.Lock()
+	queueMirror, ok := qccl.mirrors[queueName]
+	if !ok {
+		baseLogger := reporting.BaseLogger(logger)
+		snapshot := func(_ context.Context) v1willow.Items {
+			return qccl.Items(reporting.StripedContext(baseLogger), queueName, &v1willow.ItemQuery{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}})
+		}
+
+		queueMirror = mirror.New(baseLogger, queueName, qccl.mirrorClientConfig, snapshot)
+		qccl.mirrors[queueName] = queueMirror
+
+		// on an error the server is shutting down, so there is nothing to send anyways
+		_ = qccl.asyncManager.AddExecuteTask(fmt.Sprintf("mirror-%s", queueName), queueMirror)
+	}
+	qccl.mirrorsLock.Unlock()
+
+	// channels created before the mirror was set start recording their items to it
+	if !ok {
+		qccl.setChannelMirrors(ctx, queueName, queueMirror)
+	}
+
+	if err := queueMirror.SetProperties(properties); err != nil {
+		logger.Error("failed to setup the client for the mirror", zap.Error(err))
+
+		if !ok {
+			qccl.removeMirror(ctx, queueName)
+		}
+
+		return errors.InternalServerError
+	}
+
+	return nil
+}
+
+// removeMirror stops a queue's mirror and removes it from all the queue's channels
+func (qccl *queueChannelsClientLocal) removeMirror(ctx context.Context, queueName string) {
+	qccl.mirrorsLock.Lock()
+	queueMirror, ok := qccl.mirrors[queueName]
+	delete(qccl.mirrors, queueName)
+	qccl.mirrorsLock.Unlock()
+
+	if !ok {
+		return
+	}
+
+	// stop recording any operations before stopping the background task
+	_ = queueMirror.SetProperties(nil)
+	queueMirror.Stop()
+
+	qccl.setChannelMirrors(ctx, queueName, nil)
+}
+
+// setChannelMirrors replaces the mirror for all of a queue's channels
+func (qccl *queueChannelsClientLocal) setChannelMirrors(ctx context.Context, queueName string, queueMirror *mirror.Mirror) {
+	_, logger := middleware.GetNamedMiddlewareLogger(ctx, "setChannelMirrors")
+
+	setMirror := func(oneToManyItem btreeonetomany.OneToManyItem) bool {
+		oneToManyItem.Value().(constructor.QueueChannel).SetMirror(queueMirror)
+		return true
+	}
+
+	if err := qccl.queueChannels.QueryAction(queueName, &queryassociatedaction.AssociatedActionQuery{}, setMirror); err != nil {
+		switch err {
+		case btreeonetomany.ErrorManyIDDestroying:
+			logger.Debug("Already destroying the queue's channels")
+		default:
+			logger.Fatal("Failed to query channels", zap.Error(err))
+		}
+	}
+}
+
+// MirrorState reports how far behind a queue's mirror is. Nil when the queue is not mirrored
+func (qccl *queueChannelsClientLocal) MirrorState(queueName string) *v1willow.QueueMirrorState {
+	return qccl.queueMirror(queueName).State()
+}
+
+// queueMirror returns the mirror for a queue. Nil when the queue is not mirrored
+func (qccl *queueChannelsClientLocal) queueMirror(queueName string) *mirror.Mirror {
+	qccl.mirrorsLock.Lock()
+	defer qccl.mirrorsLock.Unlock()
+
+	return qccl.mirrors[queueName]
+}
+
+// syncChannelMirror ensures a newly created channel has the queue's current mirror. A mirror set while the
+// channel was being created could have missed the channel, since it was not yet saved
+func (qccl *queueChannelsClientLocal) syncChannelMirror(queueName string, queueChannel constructor.QueueChannel, createdMirror *mirror.Mirror) {
+	if queueChannel == nil {
+		return
+	}
+
+	if queueMirror := qccl.queueMirror(queueName); queueMirror != createdMirror {
+		queueChannel.SetMirror(queueMirror)
 	}
 }
 
@@ -83,6 +266,41 @@ func (qccl *queueChannelsClientLocal) Execute(ctx context.Context) error {
 	return nil
 }
 
+//	PARAMETERS:
+//	- queueName - name of the queue that is about to be destroyed
+//
+// StartDestroyingQueue cancels all clients waiting to dequeue from the queue and rejects any new clients until
+// StopDestroyingQueue is called. Clients waiting on multiple queues are only canceled once all their queues are
+// destroyed. This must be called before destroying the queue, since waiting clients block the queue from being destroyed
+func (qccl *queueChannelsClientLocal) StartDestroyingQueue(queueName string) {
+	qccl.clientsWaitingLock.Lock()
+	defer qccl.clientsWaitingLock.Unlock()
+
+	qccl.destroyingQueues[queueName]++
+
+	qccl.clientsWaiting.forEach(queueName, func(clientWaiting *clientWaiting) {
+		clientWaiting.deletedQueues[queueName] = struct{}{}
+		if len(clientWaiting.deletedQueues) == len(clientWaiting.queueQueries) {
+			clientWaiting.cancel(errorQueueDeleted(queueName))
+		}
+	})
+}
+
+//	PARAMETERS:
+//	- queueName - name of the queue that finished destroying
+//
+// StopDestroyingQueue allows clients to dequeue from a queue by the same name again
+func (qccl *queueChannelsClientLocal) StopDestroyingQueue(queueName string) {
+	qccl.clientsWaitingLock.Lock()
+	defer qccl.clientsWaitingLock.Unlock()
+
+	if qccl.destroyingQueues[queueName] <= 1 {
+		delete(qccl.destroyingQueues, queueName)
+	} else {
+		qccl.destroyingQueues[queueName]--
+	}
+}
+
 func (qccl *queueChannelsClientLocal) DestroyChannelsForQueue(ctx context.Context, queueName string) *errors.ServerError {
 	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "DestroyChannelsForQueue")
 
@@ -103,6 +321,15 @@ func (qccl *queueChannelsClientLocal) DestroyChannelsForQueue(ctx context.Contex
 		}
 	}
 
+	qccl.schedulersLock.Lock()
+	delete(qccl.schedulers, queueName)
+	qccl.schedulersLock.Unlock()
+
+	qccl.mirrorsLock.Lock()
+	qccl.mirrors[queueName].Stop()
+	delete(qccl.mirrors, queueName)
+	qccl.mirrorsLock.Unlock()
+
 	return nil
 }
 
@@ -114,6 +341,10 @@ func (qccl *queueChannelsClientLocal) DeleteChannel(ctx context.Context, queueNa
 		queueChannel := oneToManyItem.Value().(constructor.QueueChannel)
 		queueChannel.ForceDelete(ctx)
 
+		// the channel's items are removed from any mirror as well. Record this while the channel is still held, so
+		// a channel recreated by a concurrent enqueue is always mirrored after the delete
+		qccl.queueMirror(queueName).ChannelDeleted(channelKeyValues)
+
 		return true
 	}
 
@@ -127,9 +358,49 @@ func (qccl *queueChannelsClientLocal) DeleteChannel(ctx context.Context, queueNa
 		}
 	}
 
+	qccl.cancelClientsWaitingOnChannel(queueName, channelKeyValues)
+
 	return nil
 }
 
+// cancelClientsWaitingOnChannel cancels any clients waiting to dequeue from only the deleted channel. Clients with queries that
+// can match other channels keep waiting
+func (qccl *queueChannelsClientLocal) cancelClientsWaitingOnChannel(queueName string, channelKeyValues datatypes.KeyValues) {
+	qccl.clientsWaitingLock.Lock()
+	defer qccl.clientsWaitingLock.Unlock()
+
+	qccl.clientsWaiting.forEachCandidate(queueName, channelKeyValues, func(clientWaiting *clientWaiting) {
+		if len(clientWaiting.queueQueries) == 1 && matchesOnlyChannel(clientWaiting.queueQueries[queueName], channelKeyValues) {
+			clientWaiting.cancel(errorChannelDeleted(queueName))
+		}
+	})
+}
+
+// matchesOnlyChannel reports if the query can only ever match a channel with the exact key values
+func matchesOnlyChannel(query *queryassociatedaction.AssociatedActionQuery, channelKeyValues datatypes.KeyValues) bool {
+	if query == nil || query.Selection == nil || len(query.Or) != 0 || len(query.And) != 0 || len(query.Selection.IDs) != 0 {
+		return false
+	}
+
+	// any channel with more key values could also match
+	if query.Selection.MaxNumberOfKeyValues == nil || *query.Selection.MaxNumberOfKeyValues != len(channelKeyValues) {
+		return false
+	}
+
+	if len(query.Selection.KeyValues) != len(channelKeyValues) {
+		return false
+	}
+
+	for key, valueQuery := range query.Selection.KeyValues {
+		value, ok := channelKeyValues[key]
+		if !ok || valueQuery.Comparison != v1common.Equals || valueQuery.Value != value {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (qccl *queueChannelsClientLocal) attemptDeleteChannel(logger *zap.Logger, queueName string, channelKeyValues datatypes.KeyValues) {
 	logger = logger.Named("attemptDeleteChannel").With(zap.String("queue_name", queueName), zap.Any("channel_key_values", channelKeyValues))
 
@@ -149,9 +420,12 @@ func (qccl *queueChannelsClientLocal) attemptDeleteChannel(logger *zap.Logger, q
 	}
 }
 
-func (qccl *queueChannelsClientLocal) EnqueueQueueItem(ctx context.Context, queueName string, enqueueItem *v1willow.Item) *errors.ServerError {
+func (qccl *queueChannelsClientLocal) EnqueueQueueItem(ctx context.Context, queueName string, enqueueItem *v1willow.Item) (*v1willow.ItemState, *errors.ServerError) {
 	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "EnqueueQueueItem")
+	var itemState *v1willow.ItemState
 	var enqueueError *errors.ServerError
+	var createdChannel constructor.QueueChannel
+	var createdMirror *mirror.Mirror
 
 	//create a new channel to enqueue items to
 	bTreeOneToManyOnCreate := func() any {
@@ -159,13 +433,16 @@ func (qccl *queueChannelsClientLocal) EnqueueQueueItem(ctx context.Context, queu
 			// on a timeout we can attempt to delete the channel
 			qccl.attemptDeleteChannel(reporting.BaseLogger(logger), queueName, enqueueItem.Spec.DBDefinition.KeyValues)
 		}
-		queueChannel := qccl.queueChannelsConstructor.New(destroyCallback, queueName, enqueueItem.Spec.DBDefinition.KeyValues)
-		enqueueError = queueChannel.Enqueue(ctx, enqueueItem)
+		createdMirror = qccl.queueMirror(queueName)
+		queueChannel := qccl.queueChannelsConstructor.New(destroyCallback, queueName, enqueueItem.Spec.DBDefinition.KeyValues, qccl.scheduler(queueName), createdMirror)
+		itemState, enqueueError = queueChannel.Enqueue(ctx, enqueueItem)
 
 		// break early because we failed to enqueue the item and return nil because nothing was saved
 		if enqueueError != nil {
+			_ = queueChannel.Delete()
 			return nil
 		}
+		createdChannel = queueChannel
 
 		// when a new channel is created, need to add it to the async task manager. if there is an error, that means the
 		// server is shutting down so don't add it to any waiting clients.
@@ -180,7 +457,7 @@ func (qccl *queueChannelsClientLocal) EnqueueQueueItem(ctx context.Context, queu
 	// enqueue an item to an already existing channel
 	bTreeOneToManyOnFind := func(item btreeonetomany.OneToManyItem) {
 		queueChannel := item.Value().(constructor.QueueChannel)
-		enqueueError = queueChannel.Enqueue(ctx, enqueueItem)
+		itemState, enqueueError = queueChannel.Enqueue(ctx, enqueueItem)
 	}
 
 	if _, err := qccl.queueChannels.CreateOrFind(queueName, enqueueItem.Spec.DBDefinition.KeyValues, bTreeOneToManyOnCreate, bTreeOneToManyOnFind); err != nil {
@@ -189,11 +466,12 @@ func (qccl *queueChannelsClientLocal) EnqueueQueueItem(ctx context.Context, queu
 		// This shouldn't happen as the 'Queue' should ensure these don't process once it starts destroying
 		default:
 			logger.Error("failed to create or find the queue channels", zap.Error(err))
-			return errors.InternalServerError
+			return nil, errors.InternalServerError
 		}
 	}
+	qccl.syncChannelMirror(queueName, createdChannel, createdMirror)
 
-	return enqueueError
+	return itemState, enqueueError
 }
 
 //	PARAMETERS:
@@ -211,7 +489,32 @@ func (qccl *queueChannelsClientLocal) EnqueueQueueItem(ctx context.Context, queu
 // Dequeue an item from the queue. This is a blocking operation until an item is found that matches the query. This will also start a heartbeating
 // operation for any succeffully dequeued items
 func (qccl *queueChannelsClientLocal) DequeueQueueItem(ctx context.Context, queueName string, dequeueQuery *queryassociatedaction.AssociatedActionQuery) (*v1willow.Item, func(), func(), *errors.ServerError) {
-	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "DequeueQueueItem")
+	ctx, _ = middleware.GetNamedMiddlewareLogger(ctx, "DequeueQueueItem")
+
+	return qccl.dequeueQueueItems(ctx, map[string]*queryassociatedaction.AssociatedActionQuery{queueName: dequeueQuery}, nil)
+}
+
+//	PARAMETERS:
+//	- cancelContext - context that can be canceled to stop processing this function
+//	- queueQueries - names of the queues to find items from and the query to match any channels for in each queue
+//	- headersFilter - optional headers that an item must have to be dequeued
+//
+//	RETURNS
+//	- *v1willow.DequeueQueueItem - item dequeued that can be returned to the client who made the original request. The item's
+//	                               state includes the queue name it was dequeued from
+//	- func() - success callback that must be called when the dequeueItem is sent back to the client
+//	- func() - failure callback that must be called when the dequeueItem fails to send back to the original client
+//	- *errors.ServerError - any unexpected errors during the dequeue process
+//
+// Dequeue an item from any of the queues. This is a blocking operation until an item is found that matches one of the queue's queries
+func (qccl *queueChannelsClientLocal) DequeueQueueItems(ctx context.Context, queueQueries map[string]*queryassociatedaction.AssociatedActionQuery, headersFilter map[string]string) (*v1willow.Item, func(), func(), *errors.ServerError) {
+	ctx, _ = middleware.GetNamedMiddlewareLogger(ctx, "DequeueQueueItems")
+
+	return qccl.dequeueQueueItems(ctx, queueQueries, headersFilter)
+}
+
+func (qccl *queueChannelsClientLocal) dequeueQueueItems(ctx context.Context, queueQueries map[string]*queryassociatedaction.AssociatedActionQuery, headersFilter map[string]string) (*v1willow.Item, func(), func(), *errors.ServerError) {
+	logger := middleware.GetMiddlewareLogger(ctx)
 
 	// var of the return values
 	var dequeueItem *v1willow.Item
@@ -221,9 +524,15 @@ func (qccl *queueChannelsClientLocal) DequeueQueueItem(ctx context.Context, queu
 	// setup our client so that any possible channels created after these calls are automatically added.
 	// this is important to do before we traverse the queues so we don't miss any duplicate channels.
 	// Duplicate channels added the the channelops will be dropped
-	channelOperations, reader := channelops.NewRepeatableMergeRead[func(ctx context.Context) (*v1willow.Item, func(), func())](false, ctx, qccl.shutdownCtx)
-	qccl.addClientWaiting(queueName, dequeueQuery, channelOperations)
-	defer qccl.removeClientWaiting(channelOperations)
+	waitingCtx, cancelWaiting := context.WithCancelCause(ctx)
+	defer cancelWaiting(nil)
+
+	channelOperations, reader := channelops.NewRepeatableMergeRead[func(ctx context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func())](false, waitingCtx, qccl.shutdownCtx)
+	clientWaiting, err := qccl.addClientWaiting(queueQueries, channelOperations, cancelWaiting)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer qccl.removeClientWaiting(clientWaiting)
 
 	// in the background go through all the channels and add them to the channel operator. This will break if the reader finds a valid item to read
 	go func() {
@@ -239,13 +548,16 @@ func (qccl *queueChannelsClientLocal) DequeueQueueItem(ctx context.Context, queu
 			return true
 		}
 
-		if err := qccl.queueChannels.QueryAction(queueName, dequeueQuery, bTreeOneToManyOnIterate); err != nil {
-			switch err {
-			//case btreeonetomany.ErrorOneIDDestroying
-			// This shouldn't happen as the 'Queue' should ensure these don't process once it starts destroying
-			default:
-				logger.Error("failed to create or find the queue channels", zap.Error(err))
-				panic(err)
+		for queueName, dequeueQuery := range queueQueries {
+			if err := qccl.queueChannels.QueryAction(queueName, dequeueQuery, bTreeOneToManyOnIterate); err != nil {
+				switch err {
+				case btreeonetomany.ErrorOneIDDestroying:
+					// can happen when dequeuing from multiple queues since the 'Queue' does not guard each one
+					logger.Debug("skipping queue that is destroying", zap.String("queue_name", queueName))
+				default:
+					logger.Error("failed to create or find the queue channels", zap.Error(err))
+					panic(err)
+				}
 			}
 		}
 	}()
@@ -254,7 +566,7 @@ func (qccl *queueChannelsClientLocal) DequeueQueueItem(ctx context.Context, queu
 	defer logger.Debug("found available item")
 
 	for repeatableReader := range reader {
-		dequeueItem, successCallback, failureCallback = repeatableReader.Value(ctx)
+		dequeueItem, successCallback, failureCallback = repeatableReader.Value(ctx, headersFilter)
 		if dequeueItem != nil {
 			// pulled something from the queue
 			repeatableReader.Stop()
@@ -262,10 +574,24 @@ func (qccl *queueChannelsClientLocal) DequeueQueueItem(ctx context.Context, queu
 			return dequeueItem, successCallback, failureCallback, nil
 		}
 
+		// when filtering by headers, the channel can have items this client skips. Back off so other
+		// clients waiting on the same channel can dequeue them
+		if len(headersFilter) != 0 {
+			select {
+			case <-time.After(headersFilterBackoff):
+			case <-waitingCtx.Done():
+			}
+		}
+
 		// continue to dequeue a valid item
 		repeatableReader.Continue()
 	}
 
+	// reader was closed because the queues or channels being waited on were deleted
+	if deletedErr, ok := context.Cause(waitingCtx).(*errors.ServerError); ok {
+		return nil, nil, nil, deletedErr
+	}
+
 	// reader was closed. Must have been canceled by the client or server shutdown
 	select {
 	case <-ctx.Done():
@@ -278,15 +604,16 @@ func (qccl *queueChannelsClientLocal) DequeueQueueItem(ctx context.Context, queu
 }
 
 // ACK the item in a channel
-func (qccl *queueChannelsClientLocal) ACK(ctx context.Context, queueName string, ack *v1willow.ACK) *errors.ServerError {
+func (qccl *queueChannelsClientLocal) ACK(ctx context.Context, queueName string, ack *v1willow.ACK) (*v1willow.Item, *errors.ServerError) {
 	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "ACK")
 
 	ackErr := &errors.ServerError{Message: "Failed to find channel by key values ", StatusCode: http.StatusNotFound}
 
 	tryDelete := false
+	var completedItem *v1willow.Item
 	performAck := func(oneToManyItem btreeonetomany.OneToManyItem) bool {
 		queueChannel := oneToManyItem.Value().(constructor.QueueChannel)
-		tryDelete, ackErr = queueChannel.ACK(ctx, ack)
+		tryDelete, completedItem, ackErr = queueChannel.ACK(ctx, ack)
 
 		return false
 	}
@@ -314,18 +641,19 @@ func (qccl *queueChannelsClientLocal) ACK(ctx context.Context, queueName string,
 		}
 	}
 
-	return ackErr
+	return completedItem, ackErr
 }
 
 // Heartbeat an item that has been pulled from the queue
-func (qccl *queueChannelsClientLocal) Heartbeat(ctx context.Context, queueName string, heartbeat *v1willow.Heartbeat) *errors.ServerError {
+func (qccl *queueChannelsClientLocal) Heartbeat(ctx context.Context, queueName string, heartbeat *v1willow.Heartbeat) (*v1willow.HeartbeatResponse, *errors.ServerError) {
 	ctx, _ = middleware.GetNamedMiddlewareLogger(ctx, "Heartbeat")
 
+	var heartbeatResponse *v1willow.HeartbeatResponse
 	heartbeatErr := &errors.ServerError{Message: "Failed to find channel for item by key values", StatusCode: http.StatusNotFound}
 
 	performHeartbeat := func(oneToManyItem btreeonetomany.OneToManyItem) bool {
 		queueChannel := oneToManyItem.Value().(constructor.QueueChannel)
-		heartbeatErr = queueChannel.Heartbeat(ctx, heartbeat)
+		heartbeatResponse, heartbeatErr = queueChannel.Heartbeat(ctx, heartbeat)
 
 		return false
 	}
@@ -336,43 +664,113 @@ func (qccl *queueChannelsClientLocal) Heartbeat(ctx context.Context, queueName s
 		panic(err)
 	}
 
-	return heartbeatErr
+	return heartbeatResponse, heartbeatErr
 }
 
-// on dequeue, we add a client waiting to capture any newly created channels
-func (qccl *queueChannelsClientLocal) addClientWaiting(queueName string, channelQuery *queryassociatedaction.AssociatedActionQuery, channelOps *channelops.RepeatableMergeReadChannelOps[func(ctx context.Context) (*v1willow.Item, func(), func())]) {
+// Cancel an item that has been pulled from the queue
+func (qccl *queueChannelsClientLocal) Cancel(ctx context.Context, queueName string, cancel *v1willow.Cancel) *errors.ServerError {
+	ctx, _ = middleware.GetNamedMiddlewareLogger(ctx, "Cancel")
+
+	cancelErr := &errors.ServerError{Message: "Failed to find channel for item by key values", StatusCode: http.StatusNotFound}
+
+	performCancel := func(oneToManyItem btreeonetomany.OneToManyItem) bool {
+		queueChannel := oneToManyItem.Value().(constructor.QueueChannel)
+		cancelErr = queueChannel.Cancel(ctx, cancel)
+
+		return false
+	}
+
+	// cancel the item in the queue channel
+	if err := qccl.queueChannels.QueryAction(queueName, queryassociatedaction.KeyValuesToExactAssociatedActionQuery(cancel.KeyValues), performCancel); err != nil {
+		panic(err)
+	}
+
+	return cancelErr
+}
+
+// DeleteItems removes all items with the headers from a channel. The channel is deleted if it is then empty
+func (qccl *queueChannelsClientLocal) DeleteItems(ctx context.Context, queueName string, itemDelete *v1willow.ItemDelete) *errors.ServerError {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "DeleteItems")
+
+	deleteErr := &errors.ServerError{Message: "Failed to find channel for items by key values", StatusCode: http.StatusNotFound}
+
+	tryDelete := false
+	performDelete := func(oneToManyItem btreeonetomany.OneToManyItem) bool {
+		queueChannel := oneToManyItem.Value().(constructor.QueueChannel)
+		tryDelete, deleteErr = queueChannel.DeleteItems(ctx, itemDelete)
+
+		return false
+	}
+
+	// delete the items in the queue channel
+	if err := qccl.queueChannels.QueryAction(queueName, queryassociatedaction.KeyValuesToExactAssociatedActionQuery(itemDelete.KeyValues), performDelete); err != nil {
+		panic(err)
+	}
+
+	// if there are no more items, attempt to delete the channel
+	if tryDelete {
+		deleteChannel := func(oneToManyItem btreeonetomany.OneToManyItem) bool {
+			queueChannel := oneToManyItem.Value().(constructor.QueueChannel)
+			return queueChannel.Delete()
+		}
+
+		if err := qccl.queueChannels.DeleteOneOfManyByKeyValues(queueName, itemDelete.KeyValues, deleteChannel); err != nil {
+			switch err {
+			case btreeonetomany.ErrorManyIDDestroying:
+				logger.Debug("Not deleting the channel after deleting items as queue is being deleted")
+			default:
+				logger.Fatal("Failed to delete channel after deleting items", zap.Error(err))
+			}
+		}
+	}
+
+	return deleteErr
+}
+
+// on dequeue, we add a client waiting to capture any newly created channels. If all the queues are already being destroyed,
+// the client is rejected
+func (qccl *queueChannelsClientLocal) addClientWaiting(queueQueries map[string]*queryassociatedaction.AssociatedActionQuery, channelOps *channelops.RepeatableMergeReadChannelOps[func(ctx context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func())], cancel context.CancelCauseFunc) (*clientWaiting, *errors.ServerError) {
 	qccl.clientsWaitingLock.Lock()
 	defer qccl.clientsWaitingLock.Unlock()
 
-	qccl.clientsWaiting = append(qccl.clientsWaiting, clientWaiting{queueName: queueName, query: channelQuery, channelOPS: channelOps})
+	newClientWaiting := &clientWaiting{queueQueries: queueQueries, channelOPS: channelOps, deletedQueues: map[string]struct{}{}, cancel: cancel}
+	for queueName := range queueQueries {
+		if _, ok := qccl.destroyingQueues[queueName]; ok {
+			newClientWaiting.deletedQueues[queueName] = struct{}{}
+
+			if len(newClientWaiting.deletedQueues) == len(queueQueries) {
+				return nil, errorQueueDeleted(queueName)
+			}
+		}
+	}
+
+	qccl.clientsWaiting.add(newClientWaiting)
+	return newClientWaiting, nil
 }
 
 // when a client finishes dequeue, it removes itself from the clients waiting to process an item
-func (qccl *queueChannelsClientLocal) removeClientWaiting(channelOps *channelops.RepeatableMergeReadChannelOps[func(ctx context.Context) (*v1willow.Item, func(), func())]) {
+func (qccl *queueChannelsClientLocal) removeClientWaiting(clientWaiting *clientWaiting) {
 	qccl.clientsWaitingLock.Lock()
 	defer qccl.clientsWaitingLock.Unlock()
 
-	for index, clientWaiting := range qccl.clientsWaiting {
-		if clientWaiting.channelOPS == channelOps {
-			qccl.clientsWaiting[index] = qccl.clientsWaiting[len(qccl.clientsWaiting)-1]
-			qccl.clientsWaiting = qccl.clientsWaiting[:len(qccl.clientsWaiting)-1]
-			return
-		}
-	}
+	qccl.clientsWaiting.remove(clientWaiting)
 }
 
 // when a new channel is created, check any clients currently waiting that might be interested in the channel
-func (qccl *queueChannelsClientLocal) updateClientsWaiting(queueName string, channelTags datatypes.KeyValues, channel <-chan func(ctx context.Context) (*v1willow.Item, func(), func())) {
+func (qccl *queueChannelsClientLocal) updateClientsWaiting(queueName string, channelTags datatypes.KeyValues, channel <-chan func(ctx context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func())) {
 	qccl.clientsWaitingLock.Lock()
 	defer qccl.clientsWaitingLock.Unlock()
 
-	for _, clientWaiting := range qccl.clientsWaiting {
-		if clientWaiting.queueName == queueName {
-			if clientWaiting.query.MatchTags(channelTags) {
-				clientWaiting.channelOPS.MergeOrToOne(channel)
-			}
+	// only the clients indexed by one of the channel's key values, or that could not be indexed, can match the channel
+	qccl.clientsWaiting.forEachCandidate(queueName, channelTags, func(clientWaiting *clientWaiting) {
+		if _, deleted := clientWaiting.deletedQueues[queueName]; deleted {
+			return
 		}
-	}
+
+		if clientWaiting.queueQueries[queueName].MatchTags(channelTags) {
+			clientWaiting.channelOPS.MergeOrToOne(channel)
+		}
+	})
 }
 
 // read operration for the channel
@@ -381,6 +779,8 @@ func (qccl *queueChannelsClientLocal) Channels(ctx context.Context, queueName st
 	channels := v1willow.Channels{}
 
 	queryChannels := func(oneToManyItem btreeonetomany.OneToManyItem) bool {
+		fairShare := oneToManyItem.Value().(constructor.QueueChannel).FairShare()
+
 		channels = append(channels, &v1willow.Channel{
 			Spec: &v1willow.ChannelSpec{
 				DBDefinition: &v1willow.ChannelDBDefinition{
@@ -391,6 +791,8 @@ func (qccl *queueChannelsClientLocal) Channels(ctx context.Context, queueName st
 				// #TODO: have these be actual values
 				EnqueuedItems:   -1,
 				ProcessingItems: -1,
+				Weight:          fairShare.Weight,
+				Deficit:         fairShare.Deficit,
 			},
 		})
 
@@ -408,3 +810,182 @@ func (qccl *queueChannelsClientLocal) Channels(ctx context.Context, queueName st
 
 	return channels
 }
+
+//	PARAMETERS:
+//	- queueName - name of the queue the channels belong to
+//	- query - query for the channels a dequeue request would match
+//
+//	RETURNS:
+//	- v1willow.ChannelDiagnoses - diagnosis for each matching channel. Empty when no channels match the query
+//	- *errors.ServerError - error communicating with the Limiter
+//
+// DiagnoseChannels explains why a dequeue request for the query is not receiving any items
+func (qccl *queueChannelsClientLocal) DiagnoseChannels(ctx context.Context, queueName string, query *queryassociatedaction.AssociatedActionQuery) (v1willow.ChannelDiagnoses, *errors.ServerError) {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "DiagnoseChannels")
+
+	// collect the channels first, so the Limiter is not called while holding the channels
+	queueChannels := []constructor.QueueChannel{}
+	queryChannels := func(oneToManyItem btreeonetomany.OneToManyItem) bool {
+		queueChannels = append(queueChannels, oneToManyItem.Value().(constructor.QueueChannel))
+		return true
+	}
+
+	if err := qccl.queueChannels.QueryAction(queueName, query, queryChannels); err != nil {
+		switch err {
+		case btreeonetomany.ErrorManyIDDestroying:
+			logger.Debug("Already destroying the queue's channels")
+		default:
+			logger.Fatal("Failed to query channels", zap.Error(err))
+		}
+	}
+
+	channelDiagnoses := v1willow.ChannelDiagnoses{}
+	for _, queueChannel := range queueChannels {
+		channelDiagnosis, err := queueChannel.Diagnose(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		channelDiagnoses = append(channelDiagnoses, channelDiagnosis)
+	}
+
+	return channelDiagnoses, nil
+}
+
+// read operation for the items in all channels that match the query
+func (qccl *queueChannelsClientLocal) Items(ctx context.Context, queueName string, itemQuery *v1willow.ItemQuery) v1willow.Items {
+	_, logger := middleware.GetNamedMiddlewareLogger(ctx, "Items")
+	items := v1willow.Items{}
+
+	queryItems := func(oneToManyItem btreeonetomany.OneToManyItem) bool {
+		queueChannel := oneToManyItem.Value().(constructor.QueueChannel)
+		items = append(items, queueChannel.Items(ctx, itemQuery.ItemID)...)
+
+		return true
+	}
+
+	if err := qccl.queueChannels.QueryAction(queueName, itemQuery.ChannelQuery, queryItems); err != nil {
+		switch err {
+		case btreeonetomany.ErrorManyIDDestroying:
+			logger.Debug("Already destroying the queue's channels")
+		default:
+			logger.Fatal("Failed to query items", zap.Error(err))
+		}
+	}
+
+	return items
+}
+
+// ExportChannels reports all items for every channel in a queue, in the order they will be dequeued
+func (qccl *queueChannelsClientLocal) ExportChannels(ctx context.Context, queueName string) []*v1willow.ExportedChannel {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "ExportChannels")
+	exportedChannels := []*v1willow.ExportedChannel{}
+
+	exportChannel := func(oneToManyItem btreeonetomany.OneToManyItem) bool {
+		queueChannel := oneToManyItem.Value().(constructor.QueueChannel)
+		if exportedChannel := queueChannel.Export(ctx); len(exportedChannel.Items) != 0 {
+			exportedChannels = append(exportedChannels, exportedChannel)
+		}
+
+		return true
+	}
+
+	if err := qccl.queueChannels.QueryAction(queueName, &queryassociatedaction.AssociatedActionQuery{}, exportChannel); err != nil {
+		switch err {
+		case btreeonetomany.ErrorManyIDDestroying:
+			logger.Debug("Already destroying the queue's channels")
+		default:
+			logger.Fatal("Failed to export channels", zap.Error(err))
+		}
+	}
+
+	return exportedChannels
+}
+
+//	PARAMETERS:
+//	- ctx - context with the logger for the request
+//	- queueName - name of the queue to report the counters for
+//
+//	RETURNS:
+//	- v1limiter.Counters - the counters the Limiter should have for every channel in the queue
+//
+// LimiterCounters reports the 'enqueued' and 'running' counters for all of a queue's channels
+func (qccl *queueChannelsClientLocal) LimiterCounters(ctx context.Context, queueName string) v1limiter.Counters {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "LimiterCounters")
+	counters := v1limiter.Counters{}
+
+	channelCounters := func(oneToManyItem btreeonetomany.OneToManyItem) bool {
+		queueChannel := oneToManyItem.Value().(constructor.QueueChannel)
+		counters = append(counters, queueChannel.LimiterCounters(ctx)...)
+
+		return true
+	}
+
+	if err := qccl.queueChannels.QueryAction(queueName, &queryassociatedaction.AssociatedActionQuery{}, channelCounters); err != nil {
+		switch err {
+		case btreeonetomany.ErrorManyIDDestroying:
+			logger.Debug("Already destroying the queue's channels")
+		default:
+			logger.Fatal("Failed to query channels", zap.Error(err))
+		}
+	}
+
+	return counters
+}
+
+//	PARAMETERS:
+//	- ctx - context with the logger for the request
+//	- queueName - name of the queue to import the channel to
+//	- exportedChannel - channel and all its items to enqueue
+//
+//	RETURNS:
+//	- *errors.ServerError - error if any items already exist or the queue's limits are reached
+//
+// ImportChannel enqueues all items from an exported channel, keeping their original IDs and retry counts
+func (qccl *queueChannelsClientLocal) ImportChannel(ctx context.Context, queueName string, exportedChannel *v1willow.ExportedChannel) *errors.ServerError {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "ImportChannel")
+	var importError *errors.ServerError
+	var createdChannel constructor.QueueChannel
+	var createdMirror *mirror.Mirror
+
+	if len(exportedChannel.Items) == 0 {
+		return nil
+	}
+
+	// create a new channel to import the items to
+	bTreeOneToManyOnCreate := func() any {
+		destroyCallback := func() {
+			// on a timeout we can attempt to delete the channel
+			qccl.attemptDeleteChannel(reporting.BaseLogger(logger), queueName, exportedChannel.KeyValues)
+		}
+		createdMirror = qccl.queueMirror(queueName)
+		queueChannel := qccl.queueChannelsConstructor.New(destroyCallback, queueName, exportedChannel.KeyValues, qccl.scheduler(queueName), createdMirror)
+
+		// break early when nothing was saved. Any items imported before an error are kept, since they are
+		// already counted on the Limiter
+		if importError = queueChannel.Import(ctx, exportedChannel.Items); importError != nil && queueChannel.Delete() {
+			return nil
+		}
+
+		createdChannel = queueChannel
+		if err := qccl.asyncManager.AddExecuteTask(queueName, queueChannel); err == nil {
+			qccl.updateClientsWaiting(queueName, exportedChannel.KeyValues, queueChannel.Dequeue())
+		}
+
+		return queueChannel
+	}
+
+	// import the items to an already existing channel
+	bTreeOneToManyOnFind := func(item btreeonetomany.OneToManyItem) {
+		queueChannel := item.Value().(constructor.QueueChannel)
+		importError = queueChannel.Import(ctx, exportedChannel.Items)
+	}
+
+	if _, err := qccl.queueChannels.CreateOrFind(queueName, exportedChannel.KeyValues, bTreeOneToManyOnCreate, bTreeOneToManyOnFind); err != nil {
+		logger.Error("failed to create or find the queue channel", zap.Error(err))
+		return errors.InternalServerError
+	}
+	qccl.syncChannelMirror(queueName, createdChannel, createdMirror)
+
+	return importError
+}
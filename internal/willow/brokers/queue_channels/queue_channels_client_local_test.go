@@ -3,6 +3,7 @@ package queuechannels
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
@@ -15,6 +16,9 @@ import (
 	"github.com/DanLavine/willow/internal/middleware"
 	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/constructor"
 	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/constructor/constructorfakes"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/fairshare"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/mirror"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/outbox"
 	"github.com/DanLavine/willow/pkg/clients/limiter_client/limiterclientfakes"
 	"github.com/DanLavine/willow/pkg/models/api/common/errors"
 	v1 "github.com/DanLavine/willow/pkg/models/api/common/v1"
@@ -60,7 +64,7 @@ func setupConstuctor(t *testing.T, g *GomegaWithT) (*gomock.Controller, construc
 	fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
 	fakeLimiterClient.EXPECT().SetCounters(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
 
-	constructor, err := constructor.NewQueueChannelConstructor("memory", fakeLimiterClient)
+	constructor, err := constructor.NewQueueChannelConstructor("memory", testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient))
 	g.Expect(err).ToNot(HaveOccurred())
 
 	return mockController, constructor
@@ -77,19 +81,23 @@ func Test_queueChannelsClientLocal_EnqueueQueueItem(t *testing.T) {
 
 			// setup fake queue channel
 			mockQueueChannel := constructorfakes.NewMockQueueChannel(mockController)
-			mockQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *v1willow.Item) *errors.ServerError { return nil }).Times(1)
-			mockQueueChannel.EXPECT().Dequeue().DoAndReturn(func() (channel <-chan func(logger context.Context) (*v1willow.Item, func(), func())) {
+			mockQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *v1willow.Item) (*v1willow.ItemState, *errors.ServerError) {
+				return &v1willow.ItemState{ID: "item id"}, nil
+			}).Times(1)
+			mockQueueChannel.EXPECT().Dequeue().DoAndReturn(func() (channel <-chan func(logger context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func())) {
 				return nil
 			}).Times(1)
 
 			// setup fake constructor
 			mockConstructor := constructorfakes.NewMockQueueChannelsConstrutor(mockController)
-			mockConstructor.EXPECT().New(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ func(), _ string, _ datatypes.KeyValues) constructor.QueueChannel { return mockQueueChannel }).Times(1)
+			mockConstructor.EXPECT().New(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ func(), _ string, _ datatypes.KeyValues, _ *fairshare.Scheduler, _ *mirror.Mirror) constructor.QueueChannel {
+				return mockQueueChannel
+			}).Times(1)
 
 			// setup queue channel client local
-			queueChannelClentLocal := NewLocalQueueChannelsClient(mockConstructor)
+			queueChannelClentLocal := NewLocalQueueChannelsClient(mockConstructor, nil)
 
-			err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", defaultEnqueueItem(g))
+			_, err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", defaultEnqueueItem(g))
 			g.Expect(err).ToNot(HaveOccurred())
 		})
 
@@ -100,17 +108,21 @@ func Test_queueChannelsClientLocal_EnqueueQueueItem(t *testing.T) {
 
 			// setup fake queue channel
 			mockQueueChannel := constructorfakes.NewMockQueueChannel(mockController)
-			mockQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *v1willow.Item) *errors.ServerError { return nil }).Times(5)
-			mockQueueChannel.EXPECT().Dequeue().DoAndReturn(func() (channel <-chan func(logger context.Context) (*v1willow.Item, func(), func())) {
+			mockQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *v1willow.Item) (*v1willow.ItemState, *errors.ServerError) {
+				return &v1willow.ItemState{ID: "item id"}, nil
+			}).Times(5)
+			mockQueueChannel.EXPECT().Dequeue().DoAndReturn(func() (channel <-chan func(logger context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func())) {
 				return nil
 			}).Times(5)
 
 			// setup fake constructor
 			mockConstructor := constructorfakes.NewMockQueueChannelsConstrutor(mockController)
-			mockConstructor.EXPECT().New(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ func(), _ string, _ datatypes.KeyValues) constructor.QueueChannel { return mockQueueChannel }).Times(5)
+			mockConstructor.EXPECT().New(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ func(), _ string, _ datatypes.KeyValues, _ *fairshare.Scheduler, _ *mirror.Mirror) constructor.QueueChannel {
+				return mockQueueChannel
+			}).Times(5)
 
 			// setup queue channel client local
-			queueChannelClentLocal := NewLocalQueueChannelsClient(mockConstructor)
+			queueChannelClentLocal := NewLocalQueueChannelsClient(mockConstructor, nil)
 
 			g.Expect(queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name 1", defaultEnqueueItem(g)))
 			g.Expect(queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name 2", defaultEnqueueItem(g)))
@@ -126,17 +138,21 @@ func Test_queueChannelsClientLocal_EnqueueQueueItem(t *testing.T) {
 
 			// setup fake queue channel
 			mockQueueChannel := constructorfakes.NewMockQueueChannel(mockController)
-			mockQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *v1willow.Item) *errors.ServerError { return nil }).Times(5)
-			mockQueueChannel.EXPECT().Dequeue().DoAndReturn(func() (channel <-chan func(logger context.Context) (*v1willow.Item, func(), func())) {
+			mockQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *v1willow.Item) (*v1willow.ItemState, *errors.ServerError) {
+				return &v1willow.ItemState{ID: "item id"}, nil
+			}).Times(5)
+			mockQueueChannel.EXPECT().Dequeue().DoAndReturn(func() (channel <-chan func(logger context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func())) {
 				return nil
 			}).Times(5)
 
 			// setup fake constructor
 			mockConstructor := constructorfakes.NewMockQueueChannelsConstrutor(mockController)
-			mockConstructor.EXPECT().New(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ func(), _ string, _ datatypes.KeyValues) constructor.QueueChannel { return mockQueueChannel }).Times(5)
+			mockConstructor.EXPECT().New(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ func(), _ string, _ datatypes.KeyValues, _ *fairshare.Scheduler, _ *mirror.Mirror) constructor.QueueChannel {
+				return mockQueueChannel
+			}).Times(5)
 
 			// setup queue channel client local
-			queueChannelClentLocal := NewLocalQueueChannelsClient(mockConstructor)
+			queueChannelClentLocal := NewLocalQueueChannelsClient(mockConstructor, nil)
 
 			for i := 0; i < 5; i++ {
 				enqueuItem := &v1willow.Item{
@@ -167,18 +183,21 @@ func Test_queueChannelsClientLocal_EnqueueQueueItem(t *testing.T) {
 
 			// setup fake queue channel
 			mockQueueChannel := constructorfakes.NewMockQueueChannel(mockController)
-			mockQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *v1willow.Item) *errors.ServerError {
-				return &errors.ServerError{Message: "failed to enqueue item"}
+			mockQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *v1willow.Item) (*v1willow.ItemState, *errors.ServerError) {
+				return nil, &errors.ServerError{Message: "failed to enqueue item"}
 			}).Times(1)
+			mockQueueChannel.EXPECT().Delete().Return(true).Times(1)
 
 			// setup fake constructor
 			mockConstructor := constructorfakes.NewMockQueueChannelsConstrutor(mockController)
-			mockConstructor.EXPECT().New(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ func(), _ string, _ datatypes.KeyValues) constructor.QueueChannel { return mockQueueChannel }).Times(1)
+			mockConstructor.EXPECT().New(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ func(), _ string, _ datatypes.KeyValues, _ *fairshare.Scheduler, _ *mirror.Mirror) constructor.QueueChannel {
+				return mockQueueChannel
+			}).Times(1)
 
 			// setup queue channel client local
-			queueChannelClentLocal := NewLocalQueueChannelsClient(mockConstructor)
+			queueChannelClentLocal := NewLocalQueueChannelsClient(mockConstructor, nil)
 
-			err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", defaultEnqueueItem(g))
+			_, err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", defaultEnqueueItem(g))
 			g.Expect(err).To(HaveOccurred())
 			g.Expect(err.Error()).To(ContainSubstring("failed to enqueue item"))
 		})
@@ -192,17 +211,21 @@ func Test_queueChannelsClientLocal_EnqueueQueueItem(t *testing.T) {
 
 			// setup fake queue channel
 			mockQueueChannel := constructorfakes.NewMockQueueChannel(mockController)
-			mockQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *v1willow.Item) *errors.ServerError { return nil }).Times(5)
-			mockQueueChannel.EXPECT().Dequeue().DoAndReturn(func() (channel <-chan func(logger context.Context) (*v1willow.Item, func(), func())) {
+			mockQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *v1willow.Item) (*v1willow.ItemState, *errors.ServerError) {
+				return &v1willow.ItemState{ID: "item id"}, nil
+			}).Times(5)
+			mockQueueChannel.EXPECT().Dequeue().DoAndReturn(func() (channel <-chan func(logger context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func())) {
 				return nil
 			}).Times(1)
 
 			// setup fake constructor
 			mockConstructor := constructorfakes.NewMockQueueChannelsConstrutor(mockController)
-			mockConstructor.EXPECT().New(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ func(), _ string, _ datatypes.KeyValues) constructor.QueueChannel { return mockQueueChannel }).Times(1)
+			mockConstructor.EXPECT().New(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ func(), _ string, _ datatypes.KeyValues, _ *fairshare.Scheduler, _ *mirror.Mirror) constructor.QueueChannel {
+				return mockQueueChannel
+			}).Times(1)
 
 			// setup queue channel client local
-			queueChannelClentLocal := NewLocalQueueChannelsClient(mockConstructor)
+			queueChannelClentLocal := NewLocalQueueChannelsClient(mockConstructor, nil)
 
 			g.Expect(queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", defaultEnqueueItem(g)))
 			g.Expect(queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", defaultEnqueueItem(g)))
@@ -223,7 +246,7 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 			mockController, constructor := setupConstuctor(t, g)
 			defer mockController.Finish()
 
-			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor)
+			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
 
 			query := &queryassociatedaction.AssociatedActionQuery{
 				Selection: &queryassociatedaction.Selection{
@@ -270,7 +293,7 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 			mockController, constructor := setupConstuctor(t, g)
 			defer mockController.Finish()
 
-			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor)
+			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
 
 			query := &queryassociatedaction.AssociatedActionQuery{
 				Selection: &queryassociatedaction.Selection{
@@ -308,11 +331,115 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 			g.Expect(dequeueErr.Error()).To(Equal("Client closed"))
 		})
 
+		t.Run("It unblocks the request when the queue is destroyed", func(t *testing.T) {
+			mockController, constructor := setupConstuctor(t, g)
+			defer mockController.Finish()
+
+			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
+
+			done := make(chan struct{})
+			var dequeueItem *v1willow.Item
+			var dequeueErr *errors.ServerError
+			go func() {
+				defer close(done)
+				dequeueItem, _, _, dequeueErr = queueChannelClentLocal.DequeueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+			}()
+
+			g.Eventually(func() int {
+				queueChannelClentLocal.clientsWaitingLock.RLock()
+				defer queueChannelClentLocal.clientsWaitingLock.RUnlock()
+				return queueChannelClentLocal.clientsWaiting.len()
+			}).Should(Equal(1))
+
+			queueChannelClentLocal.StartDestroyingQueue("test queue")
+			defer queueChannelClentLocal.StopDestroyingQueue("test queue")
+
+			g.Eventually(done).Should(BeClosed())
+			g.Expect(dequeueItem).To(BeNil())
+			g.Expect(dequeueErr).To(HaveOccurred())
+			g.Expect(dequeueErr.StatusCode).To(Equal(http.StatusGone))
+			g.Expect(dequeueErr.Message).To(Equal("Queue 'test queue' was deleted while waiting to dequeue an item"))
+		})
+
+		t.Run("It rejects new requests while the queue is destroying", func(t *testing.T) {
+			mockController, constructor := setupConstuctor(t, g)
+			defer mockController.Finish()
+
+			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
+			queueChannelClentLocal.StartDestroyingQueue("test queue")
+
+			dequeueItem, _, _, dequeueErr := queueChannelClentLocal.DequeueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "test queue", &queryassociatedaction.AssociatedActionQuery{})
+			g.Expect(dequeueItem).To(BeNil())
+			g.Expect(dequeueErr).To(HaveOccurred())
+			g.Expect(dequeueErr.StatusCode).To(Equal(http.StatusGone))
+
+			// once destroyed, the queue can be waited on again
+			queueChannelClentLocal.StopDestroyingQueue("test queue")
+
+			ctx, cancel := context.WithCancel(testhelpers.NewContextWithMiddlewareSetup())
+			cancel()
+
+			_, _, _, dequeueErr = queueChannelClentLocal.DequeueQueueItem(ctx, "test queue", &queryassociatedaction.AssociatedActionQuery{})
+			g.Expect(dequeueErr).To(HaveOccurred())
+			g.Expect(dequeueErr.Error()).To(Equal("Client closed"))
+		})
+
+		t.Run("It unblocks the request when the only channel it can match is deleted", func(t *testing.T) {
+			mockController, constructor := setupConstuctor(t, g)
+			defer mockController.Finish()
+
+			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
+			channelKeyValues := datatypes.KeyValues{"one": datatypes.Int(1)}
+
+			done := make(chan struct{})
+			var dequeueErr *errors.ServerError
+			go func() {
+				defer close(done)
+				_, _, _, dequeueErr = queueChannelClentLocal.DequeueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "test queue", queryassociatedaction.KeyValuesToExactAssociatedActionQuery(channelKeyValues))
+			}()
+
+			g.Consistently(done).ShouldNot(BeClosed())
+
+			// deleting another channel does nothing
+			g.Expect(queueChannelClentLocal.DeleteChannel(testhelpers.NewContextWithMiddlewareSetup(), "test queue", datatypes.KeyValues{"two": datatypes.Int(2)})).ToNot(HaveOccurred())
+			g.Consistently(done).ShouldNot(BeClosed())
+
+			g.Expect(queueChannelClentLocal.DeleteChannel(testhelpers.NewContextWithMiddlewareSetup(), "test queue", channelKeyValues)).ToNot(HaveOccurred())
+			g.Eventually(done).Should(BeClosed())
+			g.Expect(dequeueErr).To(HaveOccurred())
+			g.Expect(dequeueErr.StatusCode).To(Equal(http.StatusGone))
+			g.Expect(dequeueErr.Message).To(Equal("Channel in queue 'test queue' was deleted while waiting to dequeue an item"))
+		})
+
+		t.Run("It keeps waiting when a deleted channel is not the only one it can match", func(t *testing.T) {
+			mockController, constructor := setupConstuctor(t, g)
+			defer mockController.Finish()
+
+			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
+
+			ctx, cancel := context.WithCancel(testhelpers.NewContextWithMiddlewareSetup())
+			done := make(chan struct{})
+			var dequeueErr *errors.ServerError
+			go func() {
+				defer close(done)
+				_, _, _, dequeueErr = queueChannelClentLocal.DequeueQueueItem(ctx, "test queue", &queryassociatedaction.AssociatedActionQuery{})
+			}()
+
+			g.Consistently(done).ShouldNot(BeClosed())
+
+			g.Expect(queueChannelClentLocal.DeleteChannel(testhelpers.NewContextWithMiddlewareSetup(), "test queue", datatypes.KeyValues{"one": datatypes.Int(1)})).ToNot(HaveOccurred())
+			g.Consistently(done).ShouldNot(BeClosed())
+
+			cancel()
+			g.Eventually(done).Should(BeClosed())
+			g.Expect(dequeueErr.Error()).To(Equal("Client closed"))
+		})
+
 		t.Run("It can dequeue an newly Enqueued item", func(t *testing.T) {
 			mockController, constructor := setupConstuctor(t, g)
 			defer mockController.Finish()
 
-			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor)
+			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
 			// run the server in async mode
 			executeCtx, executeCancel := context.WithCancel(testhelpers.NewContextWithMiddlewareSetup())
 			defer executeCancel()
@@ -375,7 +502,7 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 			mockController, constructor := setupConstuctor(t, g)
 			defer mockController.Finish()
 
-			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor)
+			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
 			// run the server in async mode
 			executeCtx, executeCancel := context.WithCancel(context.Background())
 			defer executeCancel()
@@ -472,7 +599,7 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 			mockController, constructor := setupConstuctor(t, g)
 			defer mockController.Finish()
 
-			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor)
+			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
 			// run the server in async mode
 			executeCtx, executeCancel := context.WithCancel(context.Background())
 			defer executeCancel()
@@ -511,7 +638,7 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 			mockController, constructor := setupConstuctor(t, g)
 			defer mockController.Finish()
 
-			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor)
+			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
 			// run the server in async mode
 			executeCtx, executeCancel := context.WithCancel(context.Background())
 			defer executeCancel()
@@ -582,7 +709,7 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 			fakeQueueChannel := constructorfakes.NewMockQueueChannel(mockController)
 
 			fakeConstructor := constructorfakes.NewMockQueueChannelsConstrutor(mockController)
-			fakeConstructor.EXPECT().New(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ func(), _ string, _ datatypes.KeyValues) constructor.QueueChannel {
+			fakeConstructor.EXPECT().New(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ func(), _ string, _ datatypes.KeyValues, _ *fairshare.Scheduler, _ *mirror.Mirror) constructor.QueueChannel {
 				return fakeQueueChannel
 			}).AnyTimes()
 
@@ -595,11 +722,13 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 
 			// setup the channel
 			count := 0
-			dequeueChanOne := make(chan func(logger context.Context) (*v1willow.Item, func(), func()))
-			dequeueChanTwo := make(chan func(logger context.Context) (*v1willow.Item, func(), func()))
+			dequeueChanOne := make(chan func(logger context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func()))
+			dequeueChanTwo := make(chan func(logger context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func()))
 
-			fakeQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *v1willow.Item) *errors.ServerError { return nil }).AnyTimes()
-			fakeQueueChannel.EXPECT().Dequeue().DoAndReturn(func() <-chan (func(logger context.Context) (*v1willow.Item, func(), func())) {
+			fakeQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *v1willow.Item) (*v1willow.ItemState, *errors.ServerError) {
+				return &v1willow.ItemState{ID: "item id"}, nil
+			}).AnyTimes()
+			fakeQueueChannel.EXPECT().Dequeue().DoAndReturn(func() <-chan (func(logger context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func())) {
 				// IMPORTANT TO HAVE THIS BE 2. the enqueue calls dequeue 1 time each to update any clients currently waiting
 				if count <= 2 {
 					count++
@@ -612,10 +741,12 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 			// setup the response for the dequeue chan
 			go func() {
 				// first reponse is all nil (mimic the limiter blocking a request)
-				dequeueChanOne <- func(logger context.Context) (*v1willow.Item, func(), func()) { return nil, nil, nil }
+				dequeueChanOne <- func(logger context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func()) {
+					return nil, nil, nil
+				}
 
 				// second response over same channel now should pass
-				dequeueChanTwo <- func(logger context.Context) (*v1willow.Item, func(), func()) {
+				dequeueChanTwo <- func(logger context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func()) {
 					return &v1willow.Item{
 						Spec: &v1willow.ItemSpec{
 							DBDefinition: &v1willow.ItemDBDefinition{
@@ -639,7 +770,7 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 				}
 			}()
 
-			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor)
+			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
 
 			// enqueue our fake chan twice
 			g.Expect(queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "test queue", &v1willow.Item{
@@ -657,7 +788,7 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 						TimeoutDuration: helpers.PointerOf(time.Second),
 					},
 				},
-			})).ToNot(HaveOccurred())
+			})).ToNot(BeNil())
 
 			g.Expect(queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "test queue", &v1willow.Item{
 				Spec: &v1willow.ItemSpec{
@@ -675,7 +806,7 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 						TimeoutDuration: helpers.PointerOf(time.Second),
 					},
 				},
-			})).ToNot(HaveOccurred())
+			})).ToNot(BeNil())
 
 			// run the server in async mode
 			executeCtx, executeCancel := context.WithCancel(context.Background())
@@ -728,9 +859,11 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 			defer mockController.Finish()
 
 			// setup the channel
-			dequeueChan := make(chan func(logger context.Context) (*v1willow.Item, func(), func()))
-			fakeQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *v1willow.Item) *errors.ServerError { return nil }).Times(1)
-			fakeQueueChannel.EXPECT().Dequeue().DoAndReturn(func() <-chan (func(logger context.Context) (*v1willow.Item, func(), func())) {
+			dequeueChan := make(chan func(logger context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func()))
+			fakeQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *v1willow.Item) (*v1willow.ItemState, *errors.ServerError) {
+				return &v1willow.ItemState{ID: "item id"}, nil
+			}).Times(1)
+			fakeQueueChannel.EXPECT().Dequeue().DoAndReturn(func() <-chan (func(logger context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func())) {
 				return dequeueChan
 			}).AnyTimes()
 			fakeQueueChannel.EXPECT().Execute(gomock.Any()).DoAndReturn(func(ctx context.Context) error { return nil }).Times(1)
@@ -738,11 +871,13 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 			// setup the response for the dequeue chan
 			go func() {
 				// first reponse is all nil (mimic the limiter blocking a request)
-				empty := func(logger context.Context) (*v1willow.Item, func(), func()) { return nil, nil, nil }
+				empty := func(logger context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func()) {
+					return nil, nil, nil
+				}
 				dequeueChan <- empty
 
 				// second response over same channel now should pass
-				item := func(logger context.Context) (*v1willow.Item, func(), func()) {
+				item := func(logger context.Context, headersFilter map[string]string) (*v1willow.Item, func(), func()) {
 					return &v1willow.Item{
 						Spec: &v1willow.ItemSpec{
 							DBDefinition: &v1willow.ItemDBDefinition{
@@ -766,7 +901,7 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 				dequeueChan <- item
 			}()
 
-			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor)
+			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
 
 			// enqueue our fake chan
 			g.Expect(queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "test queue", &v1willow.Item{
@@ -784,7 +919,7 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 						TimeoutDuration: helpers.PointerOf(time.Second),
 					},
 				},
-			})).ToNot(HaveOccurred())
+			})).ToNot(BeNil())
 
 			// run the server in async mode
 			executeCtx, executeCancel := context.WithCancel(context.Background())
@@ -834,6 +969,127 @@ func Test_queueChannelsClientLocal_DequeueQueueItem(t *testing.T) {
 	})
 }
 
+func Test_queueChannelsClientLocal_DequeueQueueItems(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	query := func() *queryassociatedaction.AssociatedActionQuery {
+		query := &queryassociatedaction.AssociatedActionQuery{
+			Selection: &queryassociatedaction.Selection{
+				KeyValues: queryassociatedaction.SelectionKeyValues{
+					"one": {
+						Value:            datatypes.Any(),
+						Comparison:       v1.Equals,
+						TypeRestrictions: testmodels.NoTypeRestrictions(g),
+					},
+				},
+			},
+		}
+		g.Expect(query.Validate()).ToNot(HaveOccurred())
+
+		return query
+	}
+
+	t.Run("It immediately returns an item from any of the queues", func(t *testing.T) {
+		mockController, constructor := setupConstuctor(t, g)
+		defer mockController.Finish()
+
+		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
+		// run the server in async mode
+		executeCtx, executeCancel := context.WithCancel(context.Background())
+		defer executeCancel()
+		go func() {
+			_ = queueChannelClentLocal.Execute(executeCtx)
+		}()
+
+		// enqueue an item to the second queue only
+		g.Expect(queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue 2", defaultEnqueueItem(g)))
+
+		queueQueries := map[string]*queryassociatedaction.AssociatedActionQuery{"queue 1": query(), "queue 2": query()}
+		dequeueItem, success, failure, dequeueErr := queueChannelClentLocal.DequeueQueueItems(testhelpers.NewContextWithMiddlewareSetup(), queueQueries, nil)
+		g.Expect(dequeueErr).To(BeNil())
+		g.Expect(dequeueItem).ToNot(BeNil())
+		g.Expect(dequeueItem.State.QueueName).To(Equal("queue 2"))
+		g.Expect(dequeueItem.Spec.Properties.Data).To(Equal([]byte(`item to queue`)))
+		g.Expect(success).ToNot(BeNil())
+		g.Expect(failure).ToNot(BeNil())
+
+		success()
+	})
+
+	t.Run("It dequeues a newly enqueued item from any of the queues", func(t *testing.T) {
+		mockController, constructor := setupConstuctor(t, g)
+		defer mockController.Finish()
+
+		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
+		// run the server in async mode
+		executeCtx, executeCancel := context.WithCancel(testhelpers.NewContextWithMiddlewareSetup())
+		defer executeCancel()
+		go func() {
+			_ = queueChannelClentLocal.Execute(executeCtx)
+		}()
+
+		testZapCore, testLogs := observer.New(zap.DebugLevel)
+		testContext := context.WithValue(context.Background(), middleware.LoggerCtxKey, zap.New(testZapCore))
+
+		// run dequeue and wait till it has checked all current channels
+		done := make(chan struct{})
+		var dequeueItem *v1willow.Item
+		var success func()
+		var dequeueErr *errors.ServerError
+		go func() {
+			defer close(done)
+			queueQueries := map[string]*queryassociatedaction.AssociatedActionQuery{"queue 1": query(), "queue 2": query()}
+			dequeueItem, success, _, dequeueErr = queueChannelClentLocal.DequeueQueueItems(testContext, queueQueries, nil)
+		}()
+		g.Eventually(func() string {
+			if testLogs.Len() == 0 {
+				return ""
+			}
+			return testLogs.All()[testLogs.Len()-1].Message
+		}).Should(ContainSubstring("waiting for available item"))
+
+		// enqueue items to a queue that is not part of the request and one that is
+		g.Expect(queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue 3", defaultEnqueueItem(g)))
+		g.Consistently(done).ShouldNot(BeClosed())
+		g.Expect(queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue 1", defaultEnqueueItem(g)))
+
+		g.Eventually(done).Should(BeClosed())
+		g.Expect(dequeueErr).To(BeNil())
+		g.Expect(dequeueItem).ToNot(BeNil())
+		g.Expect(dequeueItem.State.QueueName).To(Equal("queue 1"))
+
+		success()
+	})
+
+	t.Run("It unblocks the request once all the queues are destroyed", func(t *testing.T) {
+		mockController, constructor := setupConstuctor(t, g)
+		defer mockController.Finish()
+
+		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
+
+		done := make(chan struct{})
+		var dequeueErr *errors.ServerError
+		go func() {
+			defer close(done)
+			queueQueries := map[string]*queryassociatedaction.AssociatedActionQuery{"queue 1": query(), "queue 2": query()}
+			_, _, _, dequeueErr = queueChannelClentLocal.DequeueQueueItems(testhelpers.NewContextWithMiddlewareSetup(), queueQueries, nil)
+		}()
+
+		g.Consistently(done).ShouldNot(BeClosed())
+
+		queueChannelClentLocal.StartDestroyingQueue("queue 1")
+		defer queueChannelClentLocal.StopDestroyingQueue("queue 1")
+		g.Consistently(done).ShouldNot(BeClosed())
+
+		queueChannelClentLocal.StartDestroyingQueue("queue 2")
+		defer queueChannelClentLocal.StopDestroyingQueue("queue 2")
+
+		g.Eventually(done).Should(BeClosed())
+		g.Expect(dequeueErr).To(HaveOccurred())
+		g.Expect(dequeueErr.StatusCode).To(Equal(http.StatusGone))
+	})
+}
+
 func Test_queueChannelsClientLocal_ACK(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -845,7 +1101,7 @@ func Test_queueChannelsClientLocal_ACK(t *testing.T) {
 		fakeLimiterClient := limiterclientfakes.NewMockLimiterClient(mockController)
 		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
 
-		constructor, err := constructor.NewQueueChannelConstructor("memory", fakeLimiterClient)
+		constructor, err := constructor.NewQueueChannelConstructor("memory", testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient))
 		g.Expect(err).ToNot(HaveOccurred())
 
 		return mockController, constructor
@@ -864,9 +1120,9 @@ func Test_queueChannelsClientLocal_ACK(t *testing.T) {
 		}
 		g.Expect(ack.Validate()).ToNot(HaveOccurred())
 
-		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor)
+		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
 
-		err := queueChannelClentLocal.ACK(testhelpers.NewContextWithMiddlewareSetup(), "not found", ack)
+		_, err := queueChannelClentLocal.ACK(testhelpers.NewContextWithMiddlewareSetup(), "not found", ack)
 		g.Expect(err).To(HaveOccurred())
 		g.Expect(err.Error()).To(ContainSubstring("Failed to find channel by key values"))
 	})
@@ -874,7 +1130,7 @@ func Test_queueChannelsClientLocal_ACK(t *testing.T) {
 	t.Run("Context when an item exists to be acked", func(t *testing.T) {
 		setupQueueChannelClient := func(g *GomegaWithT) (*gomock.Controller, *queueChannelsClientLocal) {
 			mockController, constructor := setupConstuctor(g)
-			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor)
+			queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
 
 			return mockController, queueChannelClentLocal
 		}
@@ -898,7 +1154,7 @@ func Test_queueChannelsClientLocal_ACK(t *testing.T) {
 			}
 			g.Expect(enqueuItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-			err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", enqueuItem)
+			_, err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", enqueuItem)
 			g.Expect(err).ToNot(HaveOccurred())
 		}
 
@@ -953,7 +1209,7 @@ func Test_queueChannelsClientLocal_ACK(t *testing.T) {
 			}
 			g.Expect(ack.Validate()).ToNot(HaveOccurred())
 
-			err := queueChannelClentLocal.ACK(testhelpers.NewContextWithMiddlewareSetup(), "queue name", ack)
+			_, err := queueChannelClentLocal.ACK(testhelpers.NewContextWithMiddlewareSetup(), "queue name", ack)
 			g.Expect(err).ToNot(HaveOccurred())
 
 			// ensure the channel is eventually deleted.
@@ -995,7 +1251,7 @@ func Test_queueChannelsClientLocal_ACK(t *testing.T) {
 			}
 			g.Expect(ack.Validate()).ToNot(HaveOccurred())
 
-			err := queueChannelClentLocal.ACK(testhelpers.NewContextWithMiddlewareSetup(), "queue name", ack)
+			_, err := queueChannelClentLocal.ACK(testhelpers.NewContextWithMiddlewareSetup(), "queue name", ack)
 			g.Expect(err).ToNot(HaveOccurred())
 
 			// ensure the channel is not deleted
@@ -1038,7 +1294,7 @@ func Test_queueChannelsClientLocal_ACK(t *testing.T) {
 			}
 			g.Expect(ack.Validate()).ToNot(HaveOccurred())
 
-			err := queueChannelClentLocal.ACK(testhelpers.NewContextWithMiddlewareSetup(), "queue name", ack)
+			_, err := queueChannelClentLocal.ACK(testhelpers.NewContextWithMiddlewareSetup(), "queue name", ack)
 			g.Expect(err).ToNot(HaveOccurred())
 
 			// ensure the channel is not deleted
@@ -1061,7 +1317,7 @@ func Test_queueChannelsClientLocal_ACK(t *testing.T) {
 			}
 			g.Expect(ack.Validate()).ToNot(HaveOccurred())
 
-			err = queueChannelClentLocal.ACK(testhelpers.NewContextWithMiddlewareSetup(), "queue name", ack2)
+			_, err = queueChannelClentLocal.ACK(testhelpers.NewContextWithMiddlewareSetup(), "queue name", ack2)
 			g.Expect(err).ToNot(HaveOccurred())
 
 			// ensure the channel is eventually deleted
@@ -1091,7 +1347,7 @@ func Test_queueChannelsClientLocal_Heartbeat(t *testing.T) {
 		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
 		fakeLimiterClient.EXPECT().SetCounters(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
 
-		constructor, err := constructor.NewQueueChannelConstructor("memory", fakeLimiterClient)
+		constructor, err := constructor.NewQueueChannelConstructor("memory", testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient))
 		g.Expect(err).ToNot(HaveOccurred())
 
 		return mockController, constructor
@@ -1101,7 +1357,7 @@ func Test_queueChannelsClientLocal_Heartbeat(t *testing.T) {
 		mockController, constructor := setupConstuctor(g)
 
 		// setup queue channel client local
-		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor)
+		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
 
 		return mockController, queueChannelClentLocal
 	}
@@ -1125,7 +1381,7 @@ func Test_queueChannelsClientLocal_Heartbeat(t *testing.T) {
 		}
 		g.Expect(enqueuItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-		err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", enqueuItem)
+		_, err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", enqueuItem)
 		g.Expect(err).ToNot(HaveOccurred())
 	}
 
@@ -1167,9 +1423,9 @@ func Test_queueChannelsClientLocal_Heartbeat(t *testing.T) {
 		}
 		g.Expect(hearbeat.Validate()).ToNot(HaveOccurred())
 
-		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor)
+		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
 
-		err := queueChannelClentLocal.Heartbeat(testhelpers.NewContextWithMiddlewareSetup(), "queue name", hearbeat)
+		_, err := queueChannelClentLocal.Heartbeat(testhelpers.NewContextWithMiddlewareSetup(), "queue name", hearbeat)
 		g.Expect(err).To(HaveOccurred())
 		g.Expect(err.Error()).To(ContainSubstring("Failed to find channel for item by key values"))
 	})
@@ -1199,7 +1455,7 @@ func Test_queueChannelsClientLocal_Heartbeat(t *testing.T) {
 		g.Expect(hearbeat.Validate()).ToNot(HaveOccurred())
 
 		for i := 0; i < 5; i++ {
-			err := queueChannelClentLocal.Heartbeat(testhelpers.NewContextWithMiddlewareSetup(), "queue name", hearbeat)
+			_, err := queueChannelClentLocal.Heartbeat(testhelpers.NewContextWithMiddlewareSetup(), "queue name", hearbeat)
 			g.Expect(err).ToNot(HaveOccurred())
 
 			time.Sleep(300 * time.Millisecond)
@@ -1213,7 +1469,7 @@ func Test_queueChannelsClientLocal_Heartbeat(t *testing.T) {
 		}
 		g.Expect(ack.Validate()).ToNot(HaveOccurred())
 
-		err := queueChannelClentLocal.ACK(testhelpers.NewContextWithMiddlewareSetup(), "queue name", ack)
+		_, err := queueChannelClentLocal.ACK(testhelpers.NewContextWithMiddlewareSetup(), "queue name", ack)
 		g.Expect(err).ToNot(HaveOccurred())
 	})
 
@@ -1254,6 +1510,218 @@ func Test_queueChannelsClientLocal_Heartbeat(t *testing.T) {
 	})
 }
 
+func Test_queueChannelsClientLocal_Cancel(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	setupConstuctor := func(g *GomegaWithT) (*gomock.Controller, constructor.QueueChannelsConstrutor) {
+		// setup fake constructor
+		mockController := gomock.NewController(t)
+
+		// setup the limiter client to always pass
+		fakeLimiterClient := limiterclientfakes.NewMockLimiterClient(mockController)
+		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
+		fakeLimiterClient.EXPECT().SetCounters(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
+
+		constructor, err := constructor.NewQueueChannelConstructor("memory", testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return mockController, constructor
+	}
+
+	setupQueueChannelClient := func(g *GomegaWithT) (*gomock.Controller, *queueChannelsClientLocal) {
+		mockController, constructor := setupConstuctor(g)
+
+		// setup queue channel client local
+		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
+
+		return mockController, queueChannelClentLocal
+	}
+
+	enqueueItem := func(g *GomegaWithT, queueChannelClentLocal *queueChannelsClientLocal) {
+		enqueuItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{
+						"one": datatypes.Int(1),
+					},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(`item to queue`),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](1),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		g.Expect(enqueuItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+		_, err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", enqueuItem)
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	dequeueItem := func(g *GomegaWithT, queueChannelClentLocal *queueChannelsClientLocal) *v1willow.Item {
+		query := &queryassociatedaction.AssociatedActionQuery{} // select all
+		g.Expect(query.Validate()).ToNot(HaveOccurred())
+
+		done := make(chan struct{})
+		var dequeueItem *v1willow.Item
+		var success func()
+		var failure func()
+		var dequeueErr *errors.ServerError
+		go func() {
+			defer close(done)
+			dequeueItem, success, failure, dequeueErr = queueChannelClentLocal.DequeueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", query)
+		}()
+
+		g.Eventually(done, 2*time.Second).Should(BeClosed())
+		g.Expect(dequeueItem).ToNot(BeNil())
+		g.Expect(success).ToNot(BeNil())
+		g.Expect(failure).ToNot(BeNil())
+		g.Expect(dequeueErr).To(BeNil())
+
+		// call successful dequeue
+		success()
+
+		return dequeueItem
+	}
+
+	t.Run("It returns an error if the channel cannot be found", func(t *testing.T) {
+		mockController, constructor := setupConstuctor(g)
+		defer mockController.Finish()
+
+		cancel := &v1willow.Cancel{
+			ItemID: "not found",
+			KeyValues: datatypes.KeyValues{
+				"one": datatypes.Int(1),
+			},
+		}
+		g.Expect(cancel.Validate()).ToNot(HaveOccurred())
+
+		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
+
+		err := queueChannelClentLocal.Cancel(testhelpers.NewContextWithMiddlewareSetup(), "queue name", cancel)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("Failed to find channel for item by key values"))
+	})
+
+	t.Run("It reports the cancel on the next heartbeat for a processing item", func(t *testing.T) {
+		mockController, queueChannelClentLocal := setupQueueChannelClient(g)
+		defer mockController.Finish()
+
+		// run the queue channel client async
+		executeCtx, executeCancel := context.WithCancel(context.Background())
+		defer executeCancel()
+		go func() {
+			_ = queueChannelClentLocal.Execute(executeCtx)
+		}()
+
+		// enqueue and dequeue a single item
+		enqueueItem(g, queueChannelClentLocal)
+		item := dequeueItem(g, queueChannelClentLocal)
+
+		// cancel the item
+		cancel := &v1willow.Cancel{
+			ItemID:    item.State.ID,
+			KeyValues: item.Spec.DBDefinition.KeyValues,
+		}
+		g.Expect(cancel.Validate()).ToNot(HaveOccurred())
+
+		err := queueChannelClentLocal.Cancel(testhelpers.NewContextWithMiddlewareSetup(), "queue name", cancel)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// heartbeat the item
+		hearbeat := &v1willow.Heartbeat{
+			ItemID:    item.State.ID,
+			KeyValues: item.Spec.DBDefinition.KeyValues,
+		}
+		g.Expect(hearbeat.Validate()).ToNot(HaveOccurred())
+
+		heartbeatResponse, err := queueChannelClentLocal.Heartbeat(testhelpers.NewContextWithMiddlewareSetup(), "queue name", hearbeat)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(heartbeatResponse.Cancel).To(BeTrue())
+	})
+}
+
+func Test_queueChannelsClientLocal_DeleteItems(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	setupConstuctor := func(g *GomegaWithT) (*gomock.Controller, constructor.QueueChannelsConstrutor) {
+		// setup fake constructor
+		mockController := gomock.NewController(t)
+
+		// setup the limiter client to always pass
+		fakeLimiterClient := limiterclientfakes.NewMockLimiterClient(mockController)
+		fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
+		fakeLimiterClient.EXPECT().SetCounters(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *v1limiter.Counter) error { return nil }).AnyTimes()
+
+		constructor, err := constructor.NewQueueChannelConstructor("memory", testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return mockController, constructor
+	}
+
+	itemDelete := &v1willow.ItemDelete{
+		Headers: map[string]string{"id": "1"},
+		KeyValues: datatypes.KeyValues{
+			"one": datatypes.Int(1),
+		},
+	}
+	g.Expect(itemDelete.Validate()).ToNot(HaveOccurred())
+
+	t.Run("It returns an error if the channel cannot be found", func(t *testing.T) {
+		mockController, constructor := setupConstuctor(g)
+		defer mockController.Finish()
+
+		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
+
+		err := queueChannelClentLocal.DeleteItems(testhelpers.NewContextWithMiddlewareSetup(), "queue name", itemDelete)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("Failed to find channel for items by key values"))
+	})
+
+	t.Run("It deletes the channel once the last item is deleted", func(t *testing.T) {
+		mockController, constructor := setupConstuctor(g)
+		defer mockController.Finish()
+
+		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
+
+		// run the queue channel client async
+		executeCtx, executeCancel := context.WithCancel(context.Background())
+		defer executeCancel()
+		go func() {
+			_ = queueChannelClentLocal.Execute(executeCtx)
+		}()
+
+		enqueuItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{
+						"one": datatypes.Int(1),
+					},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(`item to queue`),
+					Headers:         map[string]string{"id": "1"},
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf[uint64](1),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		g.Expect(enqueuItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+		_, err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", enqueuItem)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		err = queueChannelClentLocal.DeleteItems(testhelpers.NewContextWithMiddlewareSetup(), "queue name", itemDelete)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(queueChannelClentLocal.Channels(testhelpers.NewContextWithMiddlewareSetup(), "queue name", &queryassociatedaction.AssociatedActionQuery{})).To(BeEmpty())
+	})
+}
+
 func Test_queueChannelsClientLocal_DestroyChannelsForQueue(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -1261,7 +1729,7 @@ func Test_queueChannelsClientLocal_DestroyChannelsForQueue(t *testing.T) {
 		mockController, constructor := setupConstuctor(t, g)
 		defer mockController.Finish()
 
-		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor)
+		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
 
 		err := queueChannelClentLocal.DestroyChannelsForQueue(testhelpers.NewContextWithMiddlewareSetup(), "does not matter")
 		g.Expect(err).ToNot(HaveOccurred())
@@ -1271,7 +1739,7 @@ func Test_queueChannelsClientLocal_DestroyChannelsForQueue(t *testing.T) {
 		mockController, constructor := setupConstuctor(t, g)
 		defer mockController.Finish()
 
-		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor)
+		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
 
 		// enqueue a number of items all to 1 queue
 		for i := 0; i < 10; i++ {
@@ -1293,7 +1761,7 @@ func Test_queueChannelsClientLocal_DestroyChannelsForQueue(t *testing.T) {
 			}
 			g.Expect(enqueuItem.ValidateSpecOnly()).ToNot(HaveOccurred())
 
-			err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", enqueuItem)
+			_, err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", enqueuItem)
 			g.Expect(err).ToNot(HaveOccurred())
 		}
 
@@ -1315,7 +1783,7 @@ func Test_queueChannelsClientLocal_DestroyChannelsForQueue(t *testing.T) {
 			},
 		}
 		g.Expect(enqueuItem.ValidateSpecOnly()).ToNot(HaveOccurred())
-		g.Expect(queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name 2", enqueuItem)).ToNot(HaveOccurred())
+		g.Expect(queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name 2", enqueuItem)).ToNot(BeNil())
 
 		err := queueChannelClentLocal.DestroyChannelsForQueue(testhelpers.NewContextWithMiddlewareSetup(), "queue name")
 		g.Expect(err).ToNot(HaveOccurred())
@@ -1335,3 +1803,152 @@ func Test_queueChannelsClientLocal_DestroyChannelsForQueue(t *testing.T) {
 		g.Expect(foundItems).To(Equal(1))
 	})
 }
+
+func Test_queueChannelsClientLocal_SetChannelWeights(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	channelWeights := []*v1willow.ChannelWeight{
+		{
+			ChannelQuery: &queryassociatedaction.AssociatedActionQuery{
+				Selection: &queryassociatedaction.Selection{
+					KeyValues: queryassociatedaction.SelectionKeyValues{
+						"one": {
+							Value:            datatypes.Int(1),
+							Comparison:       v1.Equals,
+							TypeRestrictions: testmodels.NoTypeRestrictions(g),
+						},
+					},
+				},
+			},
+			Weight: helpers.PointerOf[int64](3),
+		},
+	}
+	g.Expect(channelWeights[0].Validate()).ToNot(HaveOccurred())
+
+	t.Run("It reports the weights for any matching channels", func(t *testing.T) {
+		mockController, constructor := setupConstuctor(t, g)
+		defer mockController.Finish()
+
+		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
+		queueChannelClentLocal.SetChannelWeights("queue name", channelWeights)
+
+		_, err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", defaultEnqueueItem(g))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		channels := queueChannelClentLocal.Channels(testhelpers.NewContextWithMiddlewareSetup(), "queue name", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(len(channels)).To(Equal(1))
+		g.Expect(channels[0].State.Weight).To(Equal(int64(3)))
+		g.Expect(channels[0].State.Deficit).To(Equal(int64(0)))
+	})
+
+	t.Run("It updates the weights for existing channels", func(t *testing.T) {
+		mockController, constructor := setupConstuctor(t, g)
+		defer mockController.Finish()
+
+		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
+
+		_, err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", defaultEnqueueItem(g))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		channels := queueChannelClentLocal.Channels(testhelpers.NewContextWithMiddlewareSetup(), "queue name", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(len(channels)).To(Equal(1))
+		g.Expect(channels[0].State.Weight).To(Equal(int64(1)))
+
+		queueChannelClentLocal.SetChannelWeights("queue name", channelWeights)
+
+		channels = queueChannelClentLocal.Channels(testhelpers.NewContextWithMiddlewareSetup(), "queue name", &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(len(channels)).To(Equal(1))
+		g.Expect(channels[0].State.Weight).To(Equal(int64(3)))
+	})
+
+	t.Run("It removes the weights when the queue's channels are destroyed", func(t *testing.T) {
+		mockController, constructor := setupConstuctor(t, g)
+		defer mockController.Finish()
+
+		queueChannelClentLocal := NewLocalQueueChannelsClient(constructor, nil)
+		queueChannelClentLocal.SetChannelWeights("queue name", channelWeights)
+
+		err := queueChannelClentLocal.DestroyChannelsForQueue(testhelpers.NewContextWithMiddlewareSetup(), "queue name")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(queueChannelClentLocal.schedulers).ToNot(HaveKey("queue name"))
+	})
+}
+
+func Test_queueChannelsClientLocal_SetMirror(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	mirroredProperties := &v1willow.QueueProperties{
+		MaxItems: helpers.PointerOf[int64](5),
+		Mirror: &v1willow.QueueMirror{
+			URL: helpers.PointerOf("http://127.0.0.1:8080"),
+		},
+	}
+
+	t.Run("It does not create a mirror when the queue is not mirrored", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockQueueChannel := constructorfakes.NewMockQueueChannel(mockController)
+		mockQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).Return(&v1willow.ItemState{ID: "item id"}, nil).Times(1)
+		mockQueueChannel.EXPECT().Dequeue().Return(nil).Times(1)
+
+		mockConstructor := constructorfakes.NewMockQueueChannelsConstrutor(mockController)
+		mockConstructor.EXPECT().New(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Nil()).Return(mockQueueChannel).Times(1)
+
+		queueChannelClentLocal := NewLocalQueueChannelsClient(mockConstructor, nil)
+		g.Expect(queueChannelClentLocal.SetMirror(testhelpers.NewContextWithMiddlewareSetup(), "queue name", &v1willow.QueueProperties{MaxItems: helpers.PointerOf[int64](5)})).To(BeNil())
+		g.Expect(queueChannelClentLocal.mirrors).To(BeEmpty())
+		g.Expect(queueChannelClentLocal.MirrorState("queue name")).To(BeNil())
+
+		_, err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", defaultEnqueueItem(g))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(queueChannelClentLocal.mirrors).To(BeEmpty())
+	})
+
+	t.Run("It creates channels with the mirror once it is set", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockQueueChannel := constructorfakes.NewMockQueueChannel(mockController)
+		mockQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).Return(&v1willow.ItemState{ID: "item id"}, nil).Times(1)
+		mockQueueChannel.EXPECT().Dequeue().Return(nil).Times(1)
+
+		mockConstructor := constructorfakes.NewMockQueueChannelsConstrutor(mockController)
+		mockConstructor.EXPECT().New(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Not(gomock.Nil())).Return(mockQueueChannel).Times(1)
+
+		queueChannelClentLocal := NewLocalQueueChannelsClient(mockConstructor, nil)
+		g.Expect(queueChannelClentLocal.SetMirror(testhelpers.NewContextWithMiddlewareSetup(), "queue name", mirroredProperties)).To(BeNil())
+		g.Expect(queueChannelClentLocal.mirrors).To(HaveLen(1))
+		g.Expect(queueChannelClentLocal.MirrorState("queue name")).ToNot(BeNil())
+
+		_, err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", defaultEnqueueItem(g))
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+
+	t.Run("It sets and removes the mirror on channels that already exist", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockQueueChannel := constructorfakes.NewMockQueueChannel(mockController)
+		mockQueueChannel.EXPECT().Enqueue(gomock.Any(), gomock.Any()).Return(&v1willow.ItemState{ID: "item id"}, nil).Times(1)
+		mockQueueChannel.EXPECT().Dequeue().Return(nil).Times(1)
+		gomock.InOrder(
+			mockQueueChannel.EXPECT().SetMirror(gomock.Not(gomock.Nil())).Times(1),
+			mockQueueChannel.EXPECT().SetMirror(gomock.Nil()).Times(1),
+		)
+
+		mockConstructor := constructorfakes.NewMockQueueChannelsConstrutor(mockController)
+		mockConstructor.EXPECT().New(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Nil()).Return(mockQueueChannel).Times(1)
+
+		queueChannelClentLocal := NewLocalQueueChannelsClient(mockConstructor, nil)
+		_, err := queueChannelClentLocal.EnqueueQueueItem(testhelpers.NewContextWithMiddlewareSetup(), "queue name", defaultEnqueueItem(g))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(queueChannelClentLocal.SetMirror(testhelpers.NewContextWithMiddlewareSetup(), "queue name", mirroredProperties)).To(BeNil())
+		g.Expect(queueChannelClentLocal.MirrorState("queue name")).ToNot(BeNil())
+
+		g.Expect(queueChannelClentLocal.SetMirror(testhelpers.NewContextWithMiddlewareSetup(), "queue name", &v1willow.QueueProperties{MaxItems: helpers.PointerOf[int64](5)})).To(BeNil())
+		g.Expect(queueChannelClentLocal.mirrors).To(BeEmpty())
+		g.Expect(queueChannelClentLocal.MirrorState("queue name")).To(BeNil())
+	})
+}
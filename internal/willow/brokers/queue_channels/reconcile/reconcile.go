@@ -0,0 +1,236 @@
+package reconcile
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/DanLavine/willow/internal/middleware"
+	"github.com/DanLavine/willow/internal/reporting"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/outbox"
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+	"go.uber.org/zap"
+
+	limiterclient "github.com/DanLavine/willow/pkg/clients/limiter_client"
+	v1common "github.com/DanLavine/willow/pkg/models/api/common/v1"
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+)
+
+// settleInterval is how long to wait between the two samples of the counters. Items that are enqueued, dequeued
+// or acked while sampling change both Willow and the Limiter, so only drift that is the same in both samples is
+// corrected
+var settleInterval = 100 * time.Millisecond
+
+// ExpectedCounters reports the counters the Limiter should have for all items Willow holds
+type ExpectedCounters func(ctx context.Context) (v1limiter.Counters, *errors.ServerError)
+
+// sample of a counter from both Willow and the Limiter
+type sample struct {
+	keyValues    datatypes.KeyValues
+	limiterCount int64
+	willowCount  int64
+}
+
+// Reconciler compares the '_willow_enqueued' and '_willow_running' counters on the Limiter with the items Willow
+// holds and sets any counters that drifted, such as from a crash or updates that were dropped. Counters for
+// channels that no longer exist are removed
+type Reconciler struct {
+	logger *zap.Logger
+
+	limiterClient limiterclient.LimiterClient
+	limiterOutbox *outbox.Outbox
+
+	interval time.Duration
+	expected ExpectedCounters
+
+	// only one reconcile can run at a time
+	runLock *sync.Mutex
+
+	stateLock   *sync.Mutex
+	runs        uint64
+	corrections uint64
+}
+
+//	PARAMETERS:
+//	- logger - logger for the periodic reconciles
+//	- limiterClient - client to query the Limiter's counters
+//	- limiterOutbox - outbox used to set any counters that drifted
+//	- interval - how often to reconcile in the background. When 0, counters are only reconciled on demand
+//	- expected - reports the counters the Limiter should have
+//
+//	RETURNS:
+//	- *Reconciler - reconciler for the Limiter's counters
+//
+// New creates a Reconciler for the Limiter's counters
+func New(logger *zap.Logger, limiterClient limiterclient.LimiterClient, limiterOutbox *outbox.Outbox, interval time.Duration, expected ExpectedCounters) *Reconciler {
+	return &Reconciler{
+		logger:        logger.Named("limiter_reconcile"),
+		limiterClient: limiterClient,
+		limiterOutbox: limiterOutbox,
+		interval:      interval,
+		expected:      expected,
+		runLock:       new(sync.Mutex),
+		stateLock:     new(sync.Mutex),
+	}
+}
+
+// Handler for the GoAsync manager to periodically reconcile the counters until the server is shutdown
+func (r *Reconciler) Execute(ctx context.Context) error {
+	if r.interval == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := r.Reconcile(reporting.StripedContext(r.logger)); err != nil {
+				r.logger.Warn("skipped reconciling the Limiter counters", zap.String("reason", err.Message))
+			}
+		}
+	}
+}
+
+//	PARAMETERS:
+//	- ctx - context with the logger for the request
+//
+//	RETURNS:
+//	- *v1willow.LimiterReconcile - every counter that was corrected
+//	- *errors.ServerError - error if the counters could not be compared
+//
+// Reconcile samples the Limiter's counters twice and sets every counter that drifted from Willow by the same
+// amount in both samples. Nothing is corrected while the outbox is still retrying updates, since the Limiter is
+// expected to be behind
+func (r *Reconciler) Reconcile(ctx context.Context) (*v1willow.LimiterReconcile, *errors.ServerError) {
+	r.runLock.Lock()
+	defer r.runLock.Unlock()
+
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "Reconcile")
+
+	firstSample, err := r.sample(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	time.Sleep(settleInterval)
+
+	secondSample, err := r.sample(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limiterReconcile := &v1willow.LimiterReconcile{}
+	for counterKey, second := range secondSample {
+		if second.limiterCount == second.willowCount {
+			continue
+		}
+
+		// the drift must be the same in both samples, otherwise it is from items that are currently changing
+		first, ok := firstSample[counterKey]
+		if !ok || first.limiterCount-first.willowCount != second.limiterCount-second.willowCount {
+			continue
+		}
+
+		logger.Warn("correcting a Limiter counter that drifted from Willow",
+			zap.Any("key_values", second.keyValues),
+			zap.Int64("limiter_count", second.limiterCount),
+			zap.Int64("willow_count", second.willowCount),
+		)
+
+		r.limiterOutbox.SetCountersOrRetry(ctx, second.keyValues, second.willowCount)
+		limiterReconcile.Corrections = append(limiterReconcile.Corrections, &v1willow.LimiterCorrection{
+			KeyValues:    second.keyValues,
+			LimiterCount: second.limiterCount,
+			WillowCount:  second.willowCount,
+		})
+	}
+
+	r.stateLock.Lock()
+	r.runs++
+	r.corrections += uint64(len(limiterReconcile.Corrections))
+	r.stateLock.Unlock()
+
+	if len(limiterReconcile.Corrections) != 0 {
+		logger.Info("reconciled the Limiter counters", zap.Int("corrections", len(limiterReconcile.Corrections)))
+	}
+
+	return limiterReconcile, nil
+}
+
+// sample reads all of Willow's counters from the Limiter and the counters Willow expects, keyed by the encoded key values
+func (r *Reconciler) sample(ctx context.Context) (map[string]*sample, *errors.ServerError) {
+	if !r.limiterOutbox.InSync() {
+		return nil, &errors.ServerError{Message: "Limiter counters are still being retried, try again once they are in sync", StatusCode: http.StatusConflict}
+	}
+
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "sample")
+	samples := map[string]*sample{}
+
+	for _, counterKey := range []string{"_willow_enqueued", "_willow_running"} {
+		query := &queryassociatedaction.AssociatedActionQuery{
+			Selection: &queryassociatedaction.Selection{
+				KeyValues: queryassociatedaction.SelectionKeyValues{
+					counterKey: queryassociatedaction.ValueQuery{
+						Value:      datatypes.String("true"),
+						Comparison: v1common.Equals,
+						TypeRestrictions: v1common.TypeRestrictions{
+							MinDataType: datatypes.T_string,
+							MaxDataType: datatypes.T_string,
+						},
+					},
+				},
+			},
+		}
+
+		counters, err := r.limiterClient.QueryCounters(ctx, query)
+		if err != nil {
+			logger.Error("failed to query the Limiter counters", zap.Error(err))
+			return nil, errors.InternalServerError
+		}
+
+		for _, counter := range counters {
+			keyValues := counter.Spec.DBDefinition.KeyValues
+			samples[outbox.Key(keyValues)] = &sample{keyValues: keyValues, limiterCount: *counter.Spec.Properties.Counters}
+		}
+	}
+
+	expected, serverErr := r.expected(ctx)
+	if serverErr != nil {
+		return nil, serverErr
+	}
+
+	for _, counter := range expected {
+		keyValues := counter.Spec.DBDefinition.KeyValues
+		counterKey := outbox.Key(keyValues)
+
+		if _, ok := samples[counterKey]; !ok {
+			samples[counterKey] = &sample{keyValues: keyValues}
+		}
+		samples[counterKey].willowCount += *counter.Spec.Properties.Counters
+	}
+
+	return samples, nil
+}
+
+//	RETURNS:
+//	- *v1willow.LimiterReconcileState - number of reconciles and corrections so far
+//
+// State reports the reconciles that have run
+func (r *Reconciler) State() *v1willow.LimiterReconcileState {
+	r.stateLock.Lock()
+	defer r.stateLock.Unlock()
+
+	return &v1willow.LimiterReconcileState{
+		Runs:        r.runs,
+		Corrections: r.corrections,
+	}
+}
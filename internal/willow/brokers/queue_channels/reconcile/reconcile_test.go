@@ -0,0 +1,261 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/outbox"
+	"github.com/DanLavine/willow/pkg/clients/limiter_client/limiterclientfakes"
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+	"github.com/DanLavine/willow/testhelpers"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
+
+	. "github.com/onsi/gomega"
+)
+
+func enqueuedKeyValues(channel string) datatypes.KeyValues {
+	return datatypes.KeyValues{
+		"_willow_queue_name": datatypes.String("test"),
+		"_willow_enqueued":   datatypes.String("true"),
+		"_willow_channel":    datatypes.String(channel),
+	}
+}
+
+func runningKeyValues(channel string) datatypes.KeyValues {
+	return datatypes.KeyValues{
+		"_willow_queue_name": datatypes.String("test"),
+		"_willow_running":    datatypes.String("true"),
+		"channel":            datatypes.String(channel),
+	}
+}
+
+func counter(keyValues datatypes.KeyValues, counters int64) *v1limiter.Counter {
+	return &v1limiter.Counter{
+		Spec: &v1limiter.CounterSpec{
+			DBDefinition: &v1limiter.CounterDBDefinition{KeyValues: keyValues},
+			Properties:   &v1limiter.CounteProperties{Counters: &counters},
+		},
+	}
+}
+
+// limiter serves the counters for each query and records every counter that is set
+type limiter struct {
+	lock *sync.Mutex
+
+	// results for each query of the '_willow_enqueued' and '_willow_running' counters
+	enqueued []v1limiter.Counters
+	running  []v1limiter.Counters
+
+	sets map[string]int64
+}
+
+func newLimiter(t *testing.T) (*limiter, *limiterclientfakes.MockLimiterClient) {
+	mockController := gomock.NewController(t)
+	t.Cleanup(mockController.Finish)
+
+	fakeLimiter := &limiter{lock: new(sync.Mutex), sets: map[string]int64{}}
+	mockClient := limiterclientfakes.NewMockLimiterClient(mockController)
+
+	mockClient.EXPECT().QueryCounters(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, query *queryassociatedaction.AssociatedActionQuery) (v1limiter.Counters, error) {
+		fakeLimiter.lock.Lock()
+		defer fakeLimiter.lock.Unlock()
+
+		results := &fakeLimiter.running
+		if _, ok := query.Selection.KeyValues["_willow_enqueued"]; ok {
+			results = &fakeLimiter.enqueued
+		}
+
+		if len(*results) == 0 {
+			return nil, fmt.Errorf("limiter is unavailable")
+		}
+
+		counters := (*results)[0]
+		if len(*results) > 1 {
+			*results = (*results)[1:]
+		}
+
+		return counters, nil
+	}).AnyTimes()
+
+	mockClient.EXPECT().SetCounters(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, counter *v1limiter.Counter) error {
+		fakeLimiter.lock.Lock()
+		defer fakeLimiter.lock.Unlock()
+
+		fakeLimiter.sets[outbox.Key(counter.Spec.DBDefinition.KeyValues)] = *counter.Spec.Properties.Counters
+		return nil
+	}).AnyTimes()
+
+	return fakeLimiter, mockClient
+}
+
+func expected(counters ...*v1limiter.Counter) ExpectedCounters {
+	return func(_ context.Context) (v1limiter.Counters, *errors.ServerError) {
+		return counters, nil
+	}
+}
+
+func setupSettleInterval(t *testing.T) {
+	originalSettleInterval := settleInterval
+	t.Cleanup(func() { settleInterval = originalSettleInterval })
+	settleInterval = time.Millisecond
+}
+
+func Test_Reconciler_Reconcile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	setupSettleInterval(t)
+
+	t.Run("It does nothing when the Limiter matches Willow", func(t *testing.T) {
+		fakeLimiter, mockClient := newLimiter(t)
+		fakeLimiter.enqueued = []v1limiter.Counters{{counter(enqueuedKeyValues("one"), 2)}}
+		fakeLimiter.running = []v1limiter.Counters{{counter(runningKeyValues("one"), 1)}}
+
+		reconciler := New(zap.NewNop(), mockClient, outbox.New(zap.NewNop(), mockClient), 0, expected(
+			counter(enqueuedKeyValues("one"), 2),
+			counter(runningKeyValues("one"), 1),
+		))
+
+		limiterReconcile, err := reconciler.Reconcile(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(limiterReconcile.Corrections).To(BeEmpty())
+		g.Expect(fakeLimiter.sets).To(BeEmpty())
+		g.Expect(reconciler.State().Runs).To(Equal(uint64(1)))
+	})
+
+	t.Run("It sets the counters that drifted from Willow", func(t *testing.T) {
+		fakeLimiter, mockClient := newLimiter(t)
+		fakeLimiter.enqueued = []v1limiter.Counters{{counter(enqueuedKeyValues("one"), 5)}}
+		fakeLimiter.running = []v1limiter.Counters{{}}
+
+		reconciler := New(zap.NewNop(), mockClient, outbox.New(zap.NewNop(), mockClient), 0, expected(
+			counter(enqueuedKeyValues("one"), 2),
+			counter(runningKeyValues("one"), 1),
+		))
+
+		limiterReconcile, err := reconciler.Reconcile(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(limiterReconcile.Corrections).To(HaveLen(2))
+		g.Expect(limiterReconcile.Validate()).ToNot(HaveOccurred())
+
+		g.Expect(fakeLimiter.sets).To(Equal(map[string]int64{
+			outbox.Key(enqueuedKeyValues("one")): 2,
+			outbox.Key(runningKeyValues("one")):  1,
+		}))
+		g.Expect(reconciler.State().Corrections).To(Equal(uint64(2)))
+	})
+
+	t.Run("It removes counters for channels that no longer exist", func(t *testing.T) {
+		fakeLimiter, mockClient := newLimiter(t)
+		fakeLimiter.enqueued = []v1limiter.Counters{{counter(enqueuedKeyValues("one"), 1), counter(enqueuedKeyValues("deleted"), 3)}}
+		fakeLimiter.running = []v1limiter.Counters{{counter(runningKeyValues("deleted"), 1)}}
+
+		reconciler := New(zap.NewNop(), mockClient, outbox.New(zap.NewNop(), mockClient), 0, expected(
+			counter(enqueuedKeyValues("one"), 1),
+			counter(runningKeyValues("one"), 0),
+		))
+
+		limiterReconcile, err := reconciler.Reconcile(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(limiterReconcile.Corrections).To(HaveLen(2))
+
+		g.Expect(fakeLimiter.sets).To(Equal(map[string]int64{
+			outbox.Key(enqueuedKeyValues("deleted")): 0,
+			outbox.Key(runningKeyValues("deleted")):  0,
+		}))
+	})
+
+	t.Run("It does not correct counters that changed between samples", func(t *testing.T) {
+		fakeLimiter, mockClient := newLimiter(t)
+		fakeLimiter.enqueued = []v1limiter.Counters{
+			{counter(enqueuedKeyValues("one"), 3)},
+			{counter(enqueuedKeyValues("one"), 4)},
+		}
+		fakeLimiter.running = []v1limiter.Counters{{}}
+
+		reconciler := New(zap.NewNop(), mockClient, outbox.New(zap.NewNop(), mockClient), 0, expected(
+			counter(enqueuedKeyValues("one"), 2),
+		))
+
+		limiterReconcile, err := reconciler.Reconcile(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(limiterReconcile.Corrections).To(BeEmpty())
+		g.Expect(fakeLimiter.sets).To(BeEmpty())
+	})
+
+	t.Run("It returns a conflict when the outbox is still retrying updates", func(t *testing.T) {
+		fakeLimiter, mockClient := newLimiter(t)
+		mockClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).Return(fmt.Errorf("limiter is unavailable")).AnyTimes()
+
+		limiterOutbox := outbox.New(zap.NewNop(), mockClient)
+		limiterOutbox.UpdateCounterOrRetry(context.Background(), enqueuedKeyValues("one"), -1)
+
+		reconciler := New(zap.NewNop(), mockClient, limiterOutbox, 0, expected())
+
+		limiterReconcile, err := reconciler.Reconcile(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(limiterReconcile).To(BeNil())
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.StatusCode).To(Equal(http.StatusConflict))
+		g.Expect(fakeLimiter.sets).To(BeEmpty())
+	})
+
+	t.Run("It returns an error when the Limiter can not be queried", func(t *testing.T) {
+		_, mockClient := newLimiter(t)
+		reconciler := New(zap.NewNop(), mockClient, outbox.New(zap.NewNop(), mockClient), 0, expected())
+
+		limiterReconcile, err := reconciler.Reconcile(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(limiterReconcile).To(BeNil())
+		g.Expect(err).To(Equal(errors.InternalServerError))
+		g.Expect(reconciler.State().Runs).To(Equal(uint64(0)))
+	})
+}
+
+func Test_Reconciler_Execute(t *testing.T) {
+	g := NewGomegaWithT(t)
+	setupSettleInterval(t)
+
+	t.Run("It reconciles the counters periodically", func(t *testing.T) {
+		fakeLimiter, mockClient := newLimiter(t)
+		fakeLimiter.enqueued = []v1limiter.Counters{{counter(enqueuedKeyValues("deleted"), 1)}}
+		fakeLimiter.running = []v1limiter.Counters{{}}
+
+		reconciler := New(zap.NewNop(), mockClient, outbox.New(zap.NewNop(), mockClient), 10*time.Millisecond, expected())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = reconciler.Execute(ctx)
+		}()
+
+		g.Eventually(func() uint64 { return reconciler.State().Runs }).Should(BeNumerically(">=", 2))
+		g.Expect(reconciler.State().Corrections).To(BeNumerically(">=", 1))
+
+		cancel()
+		g.Eventually(done).Should(BeClosed())
+	})
+
+	t.Run("It only reconciles on demand when the interval is 0", func(t *testing.T) {
+		_, mockClient := newLimiter(t)
+		reconciler := New(zap.NewNop(), mockClient, outbox.New(zap.NewNop(), mockClient), 0, expected())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = reconciler.Execute(ctx)
+		}()
+
+		g.Consistently(func() uint64 { return reconciler.State().Runs }, 50*time.Millisecond).Should(Equal(uint64(0)))
+
+		cancel()
+		g.Eventually(done).Should(BeClosed())
+	})
+}
@@ -2,7 +2,9 @@ package queues
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/DanLavine/willow/internal/willow/brokers/queues/memory"
 	"github.com/DanLavine/willow/pkg/models/api/common/errors"
@@ -15,6 +17,62 @@ type Queue interface {
 	// Get the configured limit for the queue
 	ConfiguredLimit() int64
 
+	// Get the default max run duration for items in the queue. 0 means there is no limit
+	MaxRunDuration() time.Duration
+
+	// Get how long completed items are retained for. 0 means completed items are not retained
+	CompletedRetention() time.Duration
+
+	// Retain a successfully processed item until the retention period expires
+	RetainCompleted(completedItem *v1willow.Item)
+
+	// Query the completed items that are currently retained
+	CompletedItems(itemQuery *v1willow.ItemQuery) v1willow.Items
+
+	// Get how long idempotency keys are remembered for. 0 means idempotency keys are not accepted
+	IdempotencyWindow() time.Duration
+
+	// Enqueue an item with an IdempotencyKey through the enqueue callback, unless the key was already used. In that
+	// case the existing item's ID and channel KeyValues are returned instead
+	EnqueueIdempotent(enqueueItem *v1willow.Item, enqueue func() (*v1willow.ItemState, *errors.ServerError)) (*v1willow.ItemState, *v1willow.Item, *errors.ServerError)
+
+	// Find the item's ID and channel KeyValues that was enqueued with the IdempotencyKey
+	IdempotentItem(idempotencyKey string) *v1willow.Item
+
+	// Record an imported item's IdempotencyKey, so enqueues with the same key return the imported item
+	RegisterIdempotentItem(enqueueItem *v1willow.Item, itemID string)
+
+	// Get the max size in bytes of an item's Data. 0 means there is no limit
+	MaxPayloadSize() int64
+
+	// Get the JSON Schema that JSON items must satisfy. Nil means there is no schema
+	DataSchema() json.RawMessage
+
+	// Get the weights for the queue's channels. Nil means all channels have the same weight
+	ChannelWeights() []*v1willow.ChannelWeight
+
+	// Get the policy to raise the priority of waiting items. Nil means priorities are never raised
+	PriorityAging() *v1willow.PriorityAging
+
+	// Get the Willow service that all items are streamed to. Nil means the queue is not mirrored
+	Mirror() *v1willow.QueueMirror
+
+	// Validate that an item satisfies the queue's MaxPayloadSize and DataSchema
+	ValidateItem(enqueueItem *v1willow.Item) *errors.ModelError
+
+	// Get how long the queue can be idle before it is deleted. 0 means the queue is never deleted for being idle
+	IdleTTL() time.Duration
+
+	// Record any enqueue or ack activity on the queue
+	RecordActivity()
+
+	// Record a client starting and stopping to wait for an item to dequeue
+	DequeueStarted()
+	DequeueStopped()
+
+	// Check if the queue has had no activity for the IdleTTL
+	Idle(now time.Time) bool
+
 	// Update the queue parameters
 	Update(ctx context.Context, limiterRuleID string, updateRequest *v1willow.QueueProperties) *errors.ServerError
 
@@ -0,0 +1,120 @@
+package queues
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DanLavine/willow/internal/helpers"
+	"github.com/DanLavine/willow/internal/middleware"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+	"go.uber.org/zap"
+
+	limiterclient "github.com/DanLavine/willow/pkg/clients/limiter_client"
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
+)
+
+// willowRuleKeyValues are the group by keys for the Limiter rule that enforces each queue's MaxItems. Each queue
+// has an override on the rule with its own limit
+func willowRuleKeyValues() datatypes.KeyValues {
+	return datatypes.KeyValues{
+		"_willow_queue_name": datatypes.Any(),
+		"_willow_enqueued":   datatypes.Any(),
+	}
+}
+
+//	PARAMETERS:
+//	- ctx - context with the logger for the request
+//	- limiterClient - client to setup the rule on the Limiter
+//
+//	RETURNS:
+//	- string - ID of the rule that all queue overrides are created on
+//	- error - error finding or creating the rule
+//
+// SetupLimiterRule finds the Limiter rule from a previous Willow process or creates it if this is the first
+// start against the Limiter. Willow only keeps queues in memory, so any overrides already on the rule are for
+// queues that no longer exist and are removed
+func SetupLimiterRule(ctx context.Context, limiterClient limiterclient.LimiterClient) (string, error) {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "SetupLimiterRule")
+
+	rule, err := findLimiterRule(ctx, limiterClient)
+	if err != nil {
+		return "", err
+	}
+
+	if rule == nil {
+		rule, err = limiterClient.CreateRule(ctx, &v1limiter.Rule{
+			Spec: &v1limiter.RuleSpec{
+				DBDefinition: &v1limiter.RuleDBDefinition{
+					GroupByKeyValues: willowRuleKeyValues(),
+				},
+				Properties: &v1limiter.RuleProperties{
+					Limit: helpers.PointerOf[int64](0), // by default, all queues have a limit of 0
+				},
+			},
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create the rule: %w", err)
+		}
+
+		logger.Info("created the Limiter rule", zap.String("rule_id", rule.State.ID))
+		return rule.State.ID, nil
+	}
+
+	logger.Info("reusing the Limiter rule from a previous process", zap.String("rule_id", rule.State.ID))
+
+	// the limit could have been changed by hand
+	if *rule.Spec.Properties.Limit != 0 {
+		if err = limiterClient.UpdateRule(ctx, rule.State.ID, &v1limiter.RuleProperties{Limit: helpers.PointerOf[int64](0)}); err != nil {
+			return "", fmt.Errorf("failed to reset the rule's limit: %w", err)
+		}
+	}
+
+	// remove all orphaned overrides. Each is created again when its queue is created
+	overrides, err := limiterClient.QueryOverrides(ctx, rule.State.ID, &queryassociatedaction.AssociatedActionQuery{})
+	if err != nil {
+		return "", fmt.Errorf("failed to query the rule's overrides: %w", err)
+	}
+
+	for _, override := range overrides {
+		if err = limiterClient.DeleteOverride(ctx, rule.State.ID, override.State.ID); err != nil {
+			return "", fmt.Errorf("failed to delete orphaned override '%s': %w", override.State.ID, err)
+		}
+
+		logger.Info("removed an orphaned Limiter override", zap.Any("key_values", override.Spec.DBDefinition.GroupByKeyValues))
+	}
+
+	return rule.State.ID, nil
+}
+
+// findLimiterRule returns the rule with the exact willow group by key values, or nil if it does not exist
+func findLimiterRule(ctx context.Context, limiterClient limiterclient.LimiterClient) (*v1limiter.Rule, error) {
+	rules, err := limiterClient.QueryRules(ctx, &queryassociatedaction.AssociatedActionQuery{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the rules: %w", err)
+	}
+
+	ruleKeyValues := willowRuleKeyValues()
+	for _, rule := range rules {
+		groupByKeyValues := rule.Spec.DBDefinition.GroupByKeyValues
+		if len(groupByKeyValues) != len(ruleKeyValues) {
+			continue
+		}
+
+		matched := true
+		for key, ruleValue := range ruleKeyValues {
+			// rules with the same keys can group by specific values instead of any value
+			value, ok := groupByKeyValues[key]
+			if !ok || value.Type != ruleValue.Type || value.Less(ruleValue) || ruleValue.Less(value) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return rule, nil
+		}
+	}
+
+	return nil, nil
+}
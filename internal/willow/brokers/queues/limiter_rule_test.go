@@ -0,0 +1,169 @@
+package queues
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DanLavine/willow/internal/helpers"
+	"github.com/DanLavine/willow/pkg/clients/limiter_client/limiterclientfakes"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+	"github.com/DanLavine/willow/testhelpers"
+	"go.uber.org/mock/gomock"
+
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
+
+	. "github.com/onsi/gomega"
+)
+
+func limiterRule(id string, limit int64, groupByKeyValues datatypes.KeyValues) *v1limiter.Rule {
+	return &v1limiter.Rule{
+		Spec: &v1limiter.RuleSpec{
+			DBDefinition: &v1limiter.RuleDBDefinition{GroupByKeyValues: groupByKeyValues},
+			Properties:   &v1limiter.RuleProperties{Limit: helpers.PointerOf(limit)},
+		},
+		State: &v1limiter.RuleState{ID: id},
+	}
+}
+
+func limiterOverride(id string, queueName string) *v1limiter.Override {
+	return &v1limiter.Override{
+		Spec: &v1limiter.OverrideSpec{
+			DBDefinition: &v1limiter.OverrideDBDefinition{
+				GroupByKeyValues: datatypes.KeyValues{
+					"_willow_queue_name": datatypes.String(queueName),
+					"_willow_enqueued":   datatypes.String("true"),
+				},
+			},
+			Properties: &v1limiter.OverrideProperties{Limit: helpers.PointerOf[int64](5)},
+		},
+		State: &v1limiter.OverrideState{ID: id},
+	}
+}
+
+func Test_SetupLimiterRule(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It returns an error when the rules cannot be queried", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		fakeLimiterClient := limiterclientfakes.NewMockLimiterClient(mockController)
+		fakeLimiterClient.EXPECT().QueryRules(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("failed to connect")).Times(1)
+
+		ruleID, err := SetupLimiterRule(testhelpers.NewContextWithMiddlewareSetup(), fakeLimiterClient)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("failed to query the rules: failed to connect"))
+		g.Expect(ruleID).To(BeEmpty())
+	})
+
+	t.Run("Context when the rule is missing", func(t *testing.T) {
+		t.Run("It creates the rule with a limit of 0", func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			defer mockController.Finish()
+
+			fakeLimiterClient := limiterclientfakes.NewMockLimiterClient(mockController)
+			fakeLimiterClient.EXPECT().QueryRules(gomock.Any(), gomock.Any()).Return(v1limiter.Rules{}, nil).Times(1)
+			fakeLimiterClient.EXPECT().CreateRule(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, rule *v1limiter.Rule) (*v1limiter.Rule, error) {
+				g.Expect(rule.Spec.DBDefinition.GroupByKeyValues).To(Equal(willowRuleKeyValues()))
+				g.Expect(*rule.Spec.Properties.Limit).To(Equal(int64(0)))
+
+				return limiterRule("created", 0, rule.Spec.DBDefinition.GroupByKeyValues), nil
+			}).Times(1)
+
+			ruleID, err := SetupLimiterRule(testhelpers.NewContextWithMiddlewareSetup(), fakeLimiterClient)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ruleID).To(Equal("created"))
+		})
+
+		t.Run("It creates the rule when another rule only has the same group by keys", func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			defer mockController.Finish()
+
+			sameKeys := datatypes.KeyValues{
+				"_willow_queue_name": datatypes.String("some queue"),
+				"_willow_enqueued":   datatypes.Any(),
+			}
+
+			fakeLimiterClient := limiterclientfakes.NewMockLimiterClient(mockController)
+			fakeLimiterClient.EXPECT().QueryRules(gomock.Any(), gomock.Any()).Return(v1limiter.Rules{limiterRule("user rule", 3, sameKeys)}, nil).Times(1)
+			fakeLimiterClient.EXPECT().CreateRule(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, rule *v1limiter.Rule) (*v1limiter.Rule, error) {
+				return limiterRule("created", 0, rule.Spec.DBDefinition.GroupByKeyValues), nil
+			}).Times(1)
+
+			ruleID, err := SetupLimiterRule(testhelpers.NewContextWithMiddlewareSetup(), fakeLimiterClient)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ruleID).To(Equal("created"))
+		})
+
+		t.Run("It returns an error when the rule cannot be created", func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			defer mockController.Finish()
+
+			fakeLimiterClient := limiterclientfakes.NewMockLimiterClient(mockController)
+			fakeLimiterClient.EXPECT().QueryRules(gomock.Any(), gomock.Any()).Return(v1limiter.Rules{}, nil).Times(1)
+			fakeLimiterClient.EXPECT().CreateRule(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("failed to connect")).Times(1)
+
+			ruleID, err := SetupLimiterRule(testhelpers.NewContextWithMiddlewareSetup(), fakeLimiterClient)
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(err.Error()).To(ContainSubstring("failed to create the rule: failed to connect"))
+			g.Expect(ruleID).To(BeEmpty())
+		})
+	})
+
+	t.Run("Context when the rule already exists", func(t *testing.T) {
+		t.Run("It resets the limit when it is not 0", func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			defer mockController.Finish()
+
+			fakeLimiterClient := limiterclientfakes.NewMockLimiterClient(mockController)
+			fakeLimiterClient.EXPECT().QueryRules(gomock.Any(), gomock.Any()).Return(v1limiter.Rules{limiterRule("existing", 10, willowRuleKeyValues())}, nil).Times(1)
+			fakeLimiterClient.EXPECT().UpdateRule(gomock.Any(), "existing", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, properties *v1limiter.RuleProperties) error {
+				g.Expect(*properties.Limit).To(Equal(int64(0)))
+				return nil
+			}).Times(1)
+			fakeLimiterClient.EXPECT().QueryOverrides(gomock.Any(), "existing", gomock.Any()).Return(v1limiter.Overrides{}, nil).Times(1)
+
+			ruleID, err := SetupLimiterRule(testhelpers.NewContextWithMiddlewareSetup(), fakeLimiterClient)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ruleID).To(Equal("existing"))
+		})
+
+		t.Run("It deletes all the orphaned overrides", func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			defer mockController.Finish()
+
+			fakeLimiterClient := limiterclientfakes.NewMockLimiterClient(mockController)
+			fakeLimiterClient.EXPECT().QueryRules(gomock.Any(), gomock.Any()).Return(v1limiter.Rules{limiterRule("existing", 0, willowRuleKeyValues())}, nil).Times(1)
+			fakeLimiterClient.EXPECT().QueryOverrides(gomock.Any(), "existing", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, query *queryassociatedaction.AssociatedActionQuery) (v1limiter.Overrides, error) {
+				// all overrides on the rule are orphaned
+				g.Expect(query).To(Equal(&queryassociatedaction.AssociatedActionQuery{}))
+				return v1limiter.Overrides{limiterOverride("override 1", "queue 1"), limiterOverride("override 2", "queue 2")}, nil
+			}).Times(1)
+			fakeLimiterClient.EXPECT().DeleteOverride(gomock.Any(), "existing", "override 1").Return(nil).Times(1)
+			fakeLimiterClient.EXPECT().DeleteOverride(gomock.Any(), "existing", "override 2").Return(nil).Times(1)
+
+			ruleID, err := SetupLimiterRule(testhelpers.NewContextWithMiddlewareSetup(), fakeLimiterClient)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ruleID).To(Equal("existing"))
+		})
+
+		t.Run("It stops at the first override that fails to delete", func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			defer mockController.Finish()
+
+			deleteErr := fmt.Errorf("failed to connect")
+
+			fakeLimiterClient := limiterclientfakes.NewMockLimiterClient(mockController)
+			fakeLimiterClient.EXPECT().QueryRules(gomock.Any(), gomock.Any()).Return(v1limiter.Rules{limiterRule("existing", 0, willowRuleKeyValues())}, nil).Times(1)
+			fakeLimiterClient.EXPECT().QueryOverrides(gomock.Any(), "existing", gomock.Any()).Return(v1limiter.Overrides{limiterOverride("override 1", "queue 1"), limiterOverride("override 2", "queue 2")}, nil).Times(1)
+			fakeLimiterClient.EXPECT().DeleteOverride(gomock.Any(), "existing", "override 1").Return(deleteErr).Times(1)
+
+			ruleID, err := SetupLimiterRule(testhelpers.NewContextWithMiddlewareSetup(), fakeLimiterClient)
+			g.Expect(err).To(MatchError(deleteErr))
+			g.Expect(err.Error()).To(ContainSubstring("failed to delete orphaned override 'override 1'"))
+			g.Expect(ruleID).To(BeEmpty())
+		})
+	})
+}
@@ -1,22 +1,37 @@
 package memory
 
 import (
+	"container/list"
 	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/DanLavine/willow/internal/helpers"
 	"github.com/DanLavine/willow/internal/middleware"
 	"github.com/DanLavine/willow/pkg/models/api/common/errors"
 	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
 	"github.com/DanLavine/willow/pkg/models/datatypes"
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/validate"
 	"go.uber.org/zap"
 
+	openapierrors "github.com/go-openapi/errors"
+
 	limiterclient "github.com/DanLavine/willow/pkg/clients/limiter_client"
 	v1common "github.com/DanLavine/willow/pkg/models/api/common/v1"
 	v1 "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
 	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
 )
 
+// maxCompletedItems is the number of completed items a queue can retain. Once reached, the oldest retained items are dropped
+var maxCompletedItems = 10_000
+
 type memoryQueue struct {
 	// use for the limiter:
 	// 1. create a new queue, need to add the overrides
@@ -24,8 +39,56 @@ type memoryQueue struct {
 	limiterClient limiterclient.LimiterClient
 
 	// queue details
-	configuredLimit *atomic.Int64
-	queueName       string
+	configuredLimit    *atomic.Int64
+	maxRunDuration     *atomic.Int64
+	completedRetention *atomic.Int64
+	idempotencyWindow  *atomic.Int64
+	maxPayloadSize     *atomic.Int64
+	idleTTL            *atomic.Int64
+	queueName          string
+
+	// activity used to find idle queues. lastActivity is stored as unix nanoseconds
+	lastActivity    *atomic.Int64
+	dequeuesWaiting *atomic.Int64
+
+	// optional JSON Schema that JSON items must satisfy
+	dataSchemaLock *sync.RWMutex
+	dataSchema     json.RawMessage
+	parsedSchema   *spec.Schema
+
+	// optional weights for the queue's channels and the policy to raise the priority of waiting items
+	schedulingLock *sync.RWMutex
+	channelWeights []*v1willow.ChannelWeight
+	priorityAging  *v1willow.PriorityAging
+
+	// optional Willow service that all items are streamed to
+	mirrorLock *sync.RWMutex
+	mirror     *v1willow.QueueMirror
+
+	// completed items that are retained for querying, keyed by item ID. completedOrder holds the
+	// same items from the oldest to the newest
+	completedLock  *sync.Mutex
+	completedItems map[string]*completedItem
+	completedOrder *list.List
+
+	// items that were enqueued with an idempotency key, keyed by the IdempotencyKey
+	idempotencyLock  *sync.Mutex
+	idempotencyItems map[string]*idempotentItem
+
+	// IdempotencyKeys with an enqueue in progress. Each channel is closed once the enqueue finishes. Guarded by the idempotencyLock
+	idempotencyReservations map[string]chan struct{}
+}
+
+type completedItem struct {
+	item       *v1willow.Item
+	evictTimer *time.Timer
+	element    *list.Element
+}
+
+type idempotentItem struct {
+	// item only records the ID and channel KeyValues of the item that was enqueued
+	item        *v1willow.Item
+	expireTimer *time.Timer
 }
 
 func New(ctx context.Context, queue *v1willow.Queue, limiterRuleID string, limiterClient limiterclient.LimiterClient) (*memoryQueue, *errors.ServerError) {
@@ -34,30 +97,72 @@ func New(ctx context.Context, queue *v1willow.Queue, limiterRuleID string, limit
 	limit := new(atomic.Int64)
 	limit.Store(*queue.Spec.Properties.MaxItems)
 
-	// need to create an override for the willow Rules in the limiter
-	_, err := limiterClient.CreateOverride(ctx, limiterRuleID, &v1.Override{
-		Spec: &v1.OverrideSpec{
-			DBDefinition: &v1.OverrideDBDefinition{
-				GroupByKeyValues: datatypes.KeyValues{
-					"_willow_queue_name": datatypes.String(*queue.Spec.DBDefinition.Name),
-					"_willow_enqueued":   datatypes.String("true"),
-				},
-			},
-			Properties: &v1.OverrideProperties{
-				Limit: queue.Spec.Properties.MaxItems,
-			},
-		},
-	})
+	maxRunDuration := new(atomic.Int64)
+	if queue.Spec.Properties.MaxRunDuration != nil {
+		maxRunDuration.Store(int64(*queue.Spec.Properties.MaxRunDuration))
+	}
+
+	completedRetention := new(atomic.Int64)
+	if queue.Spec.Properties.CompletedRetention != nil {
+		completedRetention.Store(int64(*queue.Spec.Properties.CompletedRetention))
+	}
+
+	idempotencyWindow := new(atomic.Int64)
+	if queue.Spec.Properties.IdempotencyWindow != nil {
+		idempotencyWindow.Store(int64(*queue.Spec.Properties.IdempotencyWindow))
+	}
+
+	maxPayloadSize := new(atomic.Int64)
+	if queue.Spec.Properties.MaxPayloadSize != nil {
+		maxPayloadSize.Store(*queue.Spec.Properties.MaxPayloadSize)
+	}
+
+	idleTTL := new(atomic.Int64)
+	if queue.Spec.Properties.IdleTTL != nil {
+		idleTTL.Store(int64(*queue.Spec.Properties.IdleTTL))
+	}
+
+	lastActivity := new(atomic.Int64)
+	lastActivity.Store(time.Now().UnixNano())
 
+	parsedSchema, err := parseDataSchema(queue.Spec.Properties.DataSchema)
 	if err != nil {
+		logger.Error("Failed to parse the data schema", zap.Error(err))
+		return nil, errors.ServerErrorModelRequestValidation(&errors.ModelError{Field: "DataSchema", Err: err})
+	}
+
+	// need to create an override for the willow Rules in the limiter. An override left behind by a previous
+	// Willow process is adopted instead
+	if err = setOverride(ctx, limiterClient, limiterRuleID, *queue.Spec.DBDefinition.Name, queue.Spec.Properties.MaxItems); err != nil {
 		logger.Error("Failed to create a Limiter override", zap.Error(err))
 		return nil, errors.InternalServerError
 	}
 
 	return &memoryQueue{
-		limiterClient:   limiterClient,
-		configuredLimit: limit,
-		queueName:       *queue.Spec.DBDefinition.Name,
+		limiterClient:           limiterClient,
+		configuredLimit:         limit,
+		maxRunDuration:          maxRunDuration,
+		completedRetention:      completedRetention,
+		idempotencyWindow:       idempotencyWindow,
+		maxPayloadSize:          maxPayloadSize,
+		idleTTL:                 idleTTL,
+		queueName:               *queue.Spec.DBDefinition.Name,
+		lastActivity:            lastActivity,
+		dequeuesWaiting:         new(atomic.Int64),
+		dataSchemaLock:          new(sync.RWMutex),
+		dataSchema:              queue.Spec.Properties.DataSchema,
+		parsedSchema:            parsedSchema,
+		schedulingLock:          new(sync.RWMutex),
+		channelWeights:          queue.Spec.Properties.ChannelWeights,
+		priorityAging:           queue.Spec.Properties.PriorityAging,
+		mirrorLock:              new(sync.RWMutex),
+		mirror:                  queue.Spec.Properties.Mirror,
+		completedLock:           new(sync.Mutex),
+		completedItems:          map[string]*completedItem{},
+		completedOrder:          list.New(),
+		idempotencyLock:         new(sync.Mutex),
+		idempotencyItems:        map[string]*idempotentItem{},
+		idempotencyReservations: map[string]chan struct{}{},
 	}, nil
 }
 
@@ -65,47 +170,430 @@ func (mq *memoryQueue) ConfiguredLimit() int64 {
 	return mq.configuredLimit.Load()
 }
 
-func (mq *memoryQueue) Update(ctx context.Context, limiterRuleID string, updateReq *v1willow.QueueProperties) *errors.ServerError {
-	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "Update")
-	mq.configuredLimit.Store(*updateReq.MaxItems)
+func (mq *memoryQueue) MaxRunDuration() time.Duration {
+	return time.Duration(mq.maxRunDuration.Load())
+}
 
-	// get the original override id
-	overrides, err := mq.limiterClient.QueryOverrides(ctx, limiterRuleID, &queryassociatedaction.AssociatedActionQuery{
-		Selection: &queryassociatedaction.Selection{
-			KeyValues: queryassociatedaction.SelectionKeyValues{
-				"_willow_queue_name": queryassociatedaction.ValueQuery{
-					Value:      datatypes.String(mq.queueName),
-					Comparison: v1common.Equals,
-					TypeRestrictions: v1common.TypeRestrictions{
-						MinDataType: datatypes.T_string,
-						MaxDataType: datatypes.T_string,
-					},
-				},
-				"_willow_enqueued": queryassociatedaction.ValueQuery{
-					Value:      datatypes.String("true"),
-					Comparison: v1common.Equals,
-					TypeRestrictions: v1common.TypeRestrictions{
-						MinDataType: datatypes.T_string,
-						MaxDataType: datatypes.T_string,
-					},
+func (mq *memoryQueue) CompletedRetention() time.Duration {
+	return time.Duration(mq.completedRetention.Load())
+}
+
+// RetainCompleted saves the completed item until the queue's current retention period expires.
+// Changing the retention period does not affect items that are already retained. When the queue
+// already retains maxCompletedItems, the oldest retained item is dropped
+func (mq *memoryQueue) RetainCompleted(item *v1willow.Item) {
+	retention := mq.CompletedRetention()
+	if retention <= 0 {
+		return
+	}
+
+	mq.completedLock.Lock()
+	defer mq.completedLock.Unlock()
+
+	itemID := item.State.ID
+	if retained, ok := mq.completedItems[itemID]; ok {
+		mq.evictCompleted(itemID, retained)
+	}
+
+	for len(mq.completedItems) >= maxCompletedItems {
+		oldest := mq.completedOrder.Front().Value.(*completedItem)
+		mq.evictCompleted(oldest.item.State.ID, oldest)
+	}
+
+	retained := &completedItem{item: item}
+	retained.element = mq.completedOrder.PushBack(retained)
+	retained.evictTimer = time.AfterFunc(retention, func() {
+		mq.completedLock.Lock()
+		defer mq.completedLock.Unlock()
+
+		// ensure the item was not replaced or destroyed already
+		if mq.completedItems[itemID] == retained {
+			mq.evictCompleted(itemID, retained)
+		}
+	})
+
+	mq.completedItems[itemID] = retained
+}
+
+// evictCompleted drops a retained item. Must be called with the completedLock held
+func (mq *memoryQueue) evictCompleted(itemID string, retained *completedItem) {
+	retained.evictTimer.Stop()
+	mq.completedOrder.Remove(retained.element)
+	delete(mq.completedItems, itemID)
+}
+
+// CompletedItems returns all retained items whose channel matches the query, ordered by when they completed
+func (mq *memoryQueue) CompletedItems(itemQuery *v1willow.ItemQuery) v1willow.Items {
+	mq.completedLock.Lock()
+	defer mq.completedLock.Unlock()
+
+	items := v1willow.Items{}
+	for itemID, retained := range mq.completedItems {
+		if itemQuery.ItemID != nil && *itemQuery.ItemID != itemID {
+			continue
+		}
+
+		if itemQuery.ChannelQuery.MatchTags(retained.item.Spec.DBDefinition.KeyValues) {
+			items = append(items, retained.item)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return completedTime(items[i]).Before(completedTime(items[j]))
+	})
+
+	return items
+}
+
+// completedTime reports when a retained item completed. Imported items might not record the time
+func completedTime(item *v1willow.Item) time.Time {
+	if item.State.Completed == nil {
+		return time.Time{}
+	}
+
+	return item.State.Completed.CompletedTime
+}
+
+func (mq *memoryQueue) IdempotencyWindow() time.Duration {
+	return time.Duration(mq.idempotencyWindow.Load())
+}
+
+func (mq *memoryQueue) MaxPayloadSize() int64 {
+	return mq.maxPayloadSize.Load()
+}
+
+func (mq *memoryQueue) IdleTTL() time.Duration {
+	return time.Duration(mq.idleTTL.Load())
+}
+
+// RecordActivity marks the queue as active so it is not deleted for being idle
+func (mq *memoryQueue) RecordActivity() {
+	mq.lastActivity.Store(time.Now().UnixNano())
+}
+
+// DequeueStarted records a client waiting to dequeue an item. The queue is never idle while clients are waiting
+func (mq *memoryQueue) DequeueStarted() {
+	mq.dequeuesWaiting.Add(1)
+	mq.RecordActivity()
+}
+
+// DequeueStopped records that a client is no longer waiting to dequeue an item
+func (mq *memoryQueue) DequeueStopped() {
+	mq.dequeuesWaiting.Add(-1)
+	mq.RecordActivity()
+}
+
+//	PARAMETERS:
+//	- now - time to check the queue's last activity against
+//
+//	RETURNS:
+//	- bool - true if the queue has an IdleTTL, no waiting dequeue clients and no activity for the IdleTTL
+//
+// Idle reports if the queue can be deleted for being idle. The caller is responsible for ensuring there are no items
+func (mq *memoryQueue) Idle(now time.Time) bool {
+	idleTTL := mq.IdleTTL()
+	if idleTTL <= 0 || mq.dequeuesWaiting.Load() > 0 {
+		return false
+	}
+
+	return now.Sub(time.Unix(0, mq.lastActivity.Load())) >= idleTTL
+}
+
+func (mq *memoryQueue) DataSchema() json.RawMessage {
+	mq.dataSchemaLock.RLock()
+	defer mq.dataSchemaLock.RUnlock()
+
+	return mq.dataSchema
+}
+
+//	RETURNS:
+//	- []*v1willow.ChannelWeight - weights for the queue's channels. Nil means all channels have the same weight
+//
+// ChannelWeights returns the weights used to fairly dequeue items across the queue's channels
+func (mq *memoryQueue) ChannelWeights() []*v1willow.ChannelWeight {
+	mq.schedulingLock.RLock()
+	defer mq.schedulingLock.RUnlock()
+
+	return mq.channelWeights
+}
+
+//	RETURNS:
+//	- *v1willow.PriorityAging - policy to raise the priority of waiting items. Nil means priorities are never raised
+//
+// PriorityAging returns the policy used to raise the priority of items waiting in the queue's channels
+func (mq *memoryQueue) PriorityAging() *v1willow.PriorityAging {
+	mq.schedulingLock.RLock()
+	defer mq.schedulingLock.RUnlock()
+
+	return mq.priorityAging
+}
+
+//	RETURNS:
+//	- *v1willow.QueueMirror - Willow service that all items are streamed to. Nil means the queue is not mirrored
+//
+// Mirror returns where the queue's items are mirrored to
+func (mq *memoryQueue) Mirror() *v1willow.QueueMirror {
+	mq.mirrorLock.RLock()
+	defer mq.mirrorLock.RUnlock()
+
+	return mq.mirror
+}
+
+//	PARAMETERS:
+//	- enqueueItem - item to validate before it is enqueued
+//
+//	RETURNS:
+//	- *errors.ModelError - error pointing to the item's field that does not satisfy the queue's rules
+//
+// ValidateItem ensures the item's Data is within the queue's MaxPayloadSize and satisfies the queue's
+// DataSchema when the item's content type is JSON
+func (mq *memoryQueue) ValidateItem(enqueueItem *v1willow.Item) *errors.ModelError {
+	properties := enqueueItem.Spec.Properties
+
+	if maxPayloadSize := mq.MaxPayloadSize(); maxPayloadSize > 0 && int64(len(properties.Data)) > maxPayloadSize {
+		return &errors.ModelError{Field: "Data", Err: fmt.Errorf("is %d bytes which exceeds the queue's MaxPayloadSize of %d bytes", len(properties.Data), maxPayloadSize)}
+	}
+
+	mq.dataSchemaLock.RLock()
+	parsedSchema := mq.parsedSchema
+	mq.dataSchemaLock.RUnlock()
+
+	if parsedSchema == nil || properties.ContentType() != v1willow.ContentTypeJSON {
+		return nil
+	}
+
+	var data any
+	if err := json.Unmarshal(properties.Data, &data); err != nil {
+		return &errors.ModelError{Field: "Data", Err: fmt.Errorf("is not valid JSON: %w", err)}
+	}
+
+	if err := validate.AgainstSchema(parsedSchema, data, strfmt.Default); err != nil {
+		return &errors.ModelError{Field: "Data", Child: schemaModelError(err)}
+	}
+
+	return nil
+}
+
+//	PARAMETERS:
+//	- dataSchema - JSON Schema that was already validated by the QueueProperties
+//
+//	RETURNS:
+//	- *spec.Schema - parsed JSON Schema. Nil if there is no DataSchema
+//	- error - any errors parsing the DataSchema
+//
+// parseDataSchema parses the DataSchema that an item's JSON Data must satisfy
+func parseDataSchema(dataSchema json.RawMessage) (*spec.Schema, error) {
+	if len(dataSchema) == 0 {
+		return nil, nil
+	}
+
+	schema := &spec.Schema{}
+	if err := json.Unmarshal(dataSchema, schema); err != nil {
+		return nil, fmt.Errorf("is not a valid JSON Schema: %w", err)
+	}
+
+	return schema, nil
+}
+
+// schemaModelError converts the first JSON Schema validation error into a ModelError for the failing field
+func schemaModelError(err error) *errors.ModelError {
+	if compositeError, ok := err.(*openapierrors.CompositeError); ok && len(compositeError.Errors) > 0 {
+		err = compositeError.Errors[0]
+	}
+
+	// the validator names fields relative to an empty root, I.E. '.commit'
+	if validationError, ok := err.(*openapierrors.Validation); ok {
+		return &errors.ModelError{
+			Field: strings.TrimPrefix(validationError.Name, "."),
+			Err:   fmt.Errorf("%s", strings.TrimPrefix(validationError.Error(), ".")),
+		}
+	}
+
+	return &errors.ModelError{Err: err}
+}
+
+//	PARAMETERS:
+//	- enqueueItem - item with an IdempotencyKey to enqueue
+//	- enqueue - callback to enqueue the item when the key has not been seen within the idempotency window
+//
+//	RETURNS:
+//	- *v1willow.ItemState - state of the item created by the enqueue callback
+//	- *v1willow.Item - ID and channel KeyValues of the existing item when the key was already used
+//	- *errors.ServerError - error from the enqueue callback
+//
+// EnqueueIdempotent ensures that only one item is enqueued for each IdempotencyKey within the idempotency window.
+// The key is reserved while the item is enqueued, so only requests with the same key wait on the enqueue
+func (mq *memoryQueue) EnqueueIdempotent(enqueueItem *v1willow.Item, enqueue func() (*v1willow.ItemState, *errors.ServerError)) (*v1willow.ItemState, *v1willow.Item, *errors.ServerError) {
+	idempotencyKey := *enqueueItem.Spec.Properties.IdempotencyKey
+
+	// 1. reserve the key, unless it was already used
+	reservation, existingItem := mq.reserveIdempotencyKey(idempotencyKey)
+	if existingItem != nil {
+		return nil, existingItem, nil
+	}
+
+	// 2. enqueue without holding the lock, since this includes a request to the Limiter
+	itemState, err := enqueue()
+
+	// 3. save the key on a success, or release it so another request can enqueue the item
+	mq.idempotencyLock.Lock()
+	defer mq.idempotencyLock.Unlock()
+
+	delete(mq.idempotencyReservations, idempotencyKey)
+	close(reservation)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mq.saveIdempotentItem(idempotencyKey, enqueueItem.Spec.DBDefinition.KeyValues, itemState.ID)
+
+	return itemState, nil, nil
+}
+
+// reserveIdempotencyKey waits for any other enqueue with the same key to finish. It then returns either the item
+// that was already enqueued with the key, or the new reservation that must be closed once the enqueue finishes
+func (mq *memoryQueue) reserveIdempotencyKey(idempotencyKey string) (chan struct{}, *v1willow.Item) {
+	mq.idempotencyLock.Lock()
+	defer mq.idempotencyLock.Unlock()
+
+	for {
+		if existing, ok := mq.idempotencyItems[idempotencyKey]; ok {
+			return nil, existing.item
+		}
+
+		inProgress, ok := mq.idempotencyReservations[idempotencyKey]
+		if !ok {
+			break
+		}
+
+		mq.idempotencyLock.Unlock()
+		<-inProgress
+		mq.idempotencyLock.Lock()
+	}
+
+	reservation := make(chan struct{})
+	mq.idempotencyReservations[idempotencyKey] = reservation
+
+	return reservation, nil
+}
+
+//	PARAMETERS:
+//	- enqueueItem - imported item with an IdempotencyKey
+//	- itemID - ID the item was imported with
+//
+// RegisterIdempotentItem records an imported item's IdempotencyKey, so enqueues with the same key return the imported
+// item for the queue's IdempotencyWindow. When the key is already used, the existing item is kept
+func (mq *memoryQueue) RegisterIdempotentItem(enqueueItem *v1willow.Item, itemID string) {
+	if mq.IdempotencyWindow() <= 0 {
+		return
+	}
+
+	idempotencyKey := *enqueueItem.Spec.Properties.IdempotencyKey
+
+	mq.idempotencyLock.Lock()
+	defer mq.idempotencyLock.Unlock()
+
+	if _, ok := mq.idempotencyItems[idempotencyKey]; ok {
+		return
+	}
+
+	mq.saveIdempotentItem(idempotencyKey, enqueueItem.Spec.DBDefinition.KeyValues, itemID)
+}
+
+// saveIdempotentItem records the item for the key until the idempotency window expires. Must hold the idempotencyLock
+func (mq *memoryQueue) saveIdempotentItem(idempotencyKey string, keyValues datatypes.TypedKeyValues, itemID string) {
+	idempotent := &idempotentItem{
+		item: &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: keyValues,
 				},
 			},
-			MinNumberOfKeyValues: helpers.PointerOf(2),
-			MaxNumberOfKeyValues: helpers.PointerOf(2),
+			State: &v1willow.ItemState{
+				ID:        itemID,
+				QueueName: mq.queueName,
+			},
 		},
+	}
+	idempotent.expireTimer = time.AfterFunc(mq.IdempotencyWindow(), func() {
+		mq.idempotencyLock.Lock()
+		defer mq.idempotencyLock.Unlock()
+
+		// ensure the key was not destroyed already
+		if mq.idempotencyItems[idempotencyKey] == idempotent {
+			delete(mq.idempotencyItems, idempotencyKey)
+		}
 	})
+
+	mq.idempotencyItems[idempotencyKey] = idempotent
+}
+
+// IdempotentItem returns the ID and channel KeyValues of the item enqueued with the IdempotencyKey, or nil
+// if the key has not been used within the idempotency window
+func (mq *memoryQueue) IdempotentItem(idempotencyKey string) *v1willow.Item {
+	mq.idempotencyLock.Lock()
+	defer mq.idempotencyLock.Unlock()
+
+	if existing, ok := mq.idempotencyItems[idempotencyKey]; ok {
+		return existing.item
+	}
+
+	return nil
+}
+
+func (mq *memoryQueue) Update(ctx context.Context, limiterRuleID string, updateReq *v1willow.QueueProperties) *errors.ServerError {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "Update")
+
+	parsedSchema, err := parseDataSchema(updateReq.DataSchema)
 	if err != nil {
-		panic(err)
+		logger.Error("Failed to parse the data schema", zap.Error(err))
+		return errors.ServerErrorModelRequestValidation(&errors.ModelError{Field: "DataSchema", Err: err})
 	}
-	if len(overrides) != 1 {
-		panic(overrides)
+
+	mq.configuredLimit.Store(*updateReq.MaxItems)
+	if updateReq.MaxRunDuration != nil {
+		mq.maxRunDuration.Store(int64(*updateReq.MaxRunDuration))
+	} else {
+		mq.maxRunDuration.Store(0)
+	}
+	if updateReq.CompletedRetention != nil {
+		mq.completedRetention.Store(int64(*updateReq.CompletedRetention))
+	} else {
+		mq.completedRetention.Store(0)
+	}
+	if updateReq.IdempotencyWindow != nil {
+		mq.idempotencyWindow.Store(int64(*updateReq.IdempotencyWindow))
+	} else {
+		mq.idempotencyWindow.Store(0)
+	}
+	if updateReq.IdleTTL != nil {
+		mq.idleTTL.Store(int64(*updateReq.IdleTTL))
+	} else {
+		mq.idleTTL.Store(0)
+	}
+	if updateReq.MaxPayloadSize != nil {
+		mq.maxPayloadSize.Store(*updateReq.MaxPayloadSize)
+	} else {
+		mq.maxPayloadSize.Store(0)
 	}
 
-	// need to create an override for the willow Rules in the limiter
-	err = mq.limiterClient.UpdateOverride(ctx, limiterRuleID, overrides[0].State.ID, &v1.OverrideProperties{
-		Limit: updateReq.MaxItems,
-	})
-	if err != nil {
+	mq.dataSchemaLock.Lock()
+	mq.dataSchema = updateReq.DataSchema
+	mq.parsedSchema = parsedSchema
+	mq.dataSchemaLock.Unlock()
+
+	mq.schedulingLock.Lock()
+	mq.channelWeights = updateReq.ChannelWeights
+	mq.priorityAging = updateReq.PriorityAging
+	mq.schedulingLock.Unlock()
+
+	mq.mirrorLock.Lock()
+	mq.mirror = updateReq.Mirror
+	mq.mirrorLock.Unlock()
+
+	// update the override for the willow Rules in the limiter, recreating it if it was removed
+	if err = setOverride(ctx, mq.limiterClient, limiterRuleID, mq.queueName, updateReq.MaxItems); err != nil {
 		logger.Error("Failed to update the Limiter override", zap.Error(err))
 		return errors.InternalServerError
 	}
@@ -116,11 +604,49 @@ func (mq *memoryQueue) Update(ctx context.Context, limiterRuleID string, updateR
 func (mq *memoryQueue) Destroy(ctx context.Context, limiterRuleID, queueID string) *errors.ServerError {
 	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "Destroy")
 
-	overrides, err := mq.limiterClient.QueryOverrides(ctx, limiterRuleID, &queryassociatedaction.AssociatedActionQuery{
+	// drop all the retained completed items
+	mq.completedLock.Lock()
+	for itemID, retained := range mq.completedItems {
+		mq.evictCompleted(itemID, retained)
+	}
+	mq.completedLock.Unlock()
+
+	// drop all the idempotency keys
+	mq.idempotencyLock.Lock()
+	for idempotencyKey, idempotent := range mq.idempotencyItems {
+		idempotent.expireTimer.Stop()
+		delete(mq.idempotencyItems, idempotencyKey)
+	}
+	mq.idempotencyLock.Unlock()
+
+	override, err := findOverride(ctx, mq.limiterClient, limiterRuleID, mq.queueName)
+	if err != nil {
+		logger.Error("Failed to find the Limiter override", zap.Error(err))
+		return errors.InternalServerError
+	}
+
+	// already removed, such as by a Willow process cleaning up orphaned overrides
+	if override == nil {
+		logger.Warn("Limiter override was already removed")
+		return nil
+	}
+
+	// need to delete the override for the willow Rules in the limiter
+	if err = mq.limiterClient.DeleteOverride(ctx, limiterRuleID, override.State.ID); err != nil {
+		logger.Error("Failed to delete the Limiter override", zap.Error(err))
+		return errors.InternalServerError
+	}
+
+	return nil
+}
+
+// findOverride returns the queue's override for the willow Rule, or nil if it does not exist
+func findOverride(ctx context.Context, limiterClient limiterclient.LimiterClient, limiterRuleID, queueName string) (*v1.Override, error) {
+	overrides, err := limiterClient.QueryOverrides(ctx, limiterRuleID, &queryassociatedaction.AssociatedActionQuery{
 		Selection: &queryassociatedaction.Selection{
 			KeyValues: queryassociatedaction.SelectionKeyValues{
 				"_willow_queue_name": queryassociatedaction.ValueQuery{
-					Value:      datatypes.String(mq.queueName),
+					Value:      datatypes.String(queueName),
 					Comparison: v1common.Equals,
 					TypeRestrictions: v1common.TypeRestrictions{
 						MinDataType: datatypes.T_string,
@@ -136,21 +662,46 @@ func (mq *memoryQueue) Destroy(ctx context.Context, limiterRuleID, queueID strin
 					},
 				},
 			},
+			MinNumberOfKeyValues: helpers.PointerOf(2),
+			MaxNumberOfKeyValues: helpers.PointerOf(2),
 		},
 	})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	if len(overrides) != 1 {
-		panic(overrides)
+
+	// the key values are unique for a rule's overrides, so there can only be one
+	if len(overrides) == 0 {
+		return nil, nil
 	}
 
-	// need to delete the override for the willow Rules in the limiter
-	err = mq.limiterClient.DeleteOverride(ctx, limiterRuleID, overrides[0].State.ID)
+	return overrides[0], nil
+}
+
+// setOverride sets the limit on the queue's override for the willow Rule, creating the override if it does not exist
+func setOverride(ctx context.Context, limiterClient limiterclient.LimiterClient, limiterRuleID, queueName string, limit *int64) error {
+	override, err := findOverride(ctx, limiterClient, limiterRuleID, queueName)
 	if err != nil {
-		logger.Error("Failed to delete the Limiter override", zap.Error(err))
-		return errors.InternalServerError
+		return err
 	}
 
-	return nil
+	if override != nil {
+		return limiterClient.UpdateOverride(ctx, limiterRuleID, override.State.ID, &v1.OverrideProperties{Limit: limit})
+	}
+
+	_, err = limiterClient.CreateOverride(ctx, limiterRuleID, &v1.Override{
+		Spec: &v1.OverrideSpec{
+			DBDefinition: &v1.OverrideDBDefinition{
+				GroupByKeyValues: datatypes.KeyValues{
+					"_willow_queue_name": datatypes.String(queueName),
+					"_willow_enqueued":   datatypes.String("true"),
+				},
+			},
+			Properties: &v1.OverrideProperties{
+				Limit: limit,
+			},
+		},
+	})
+
+	return err
 }
@@ -0,0 +1,280 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DanLavine/willow/internal/helpers"
+	"github.com/DanLavine/willow/pkg/clients/limiter_client/limiterclientfakes"
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+	"github.com/DanLavine/willow/testhelpers"
+	"go.uber.org/mock/gomock"
+
+	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+
+	. "github.com/onsi/gomega"
+)
+
+func setupQueue(t *testing.T, g *WithT, properties *v1willow.QueueProperties) *memoryQueue {
+	mockController := gomock.NewController(t)
+	fakeLimiterClient := limiterclientfakes.NewMockLimiterClient(mockController)
+	fakeLimiterClient.EXPECT().QueryOverrides(gomock.Any(), gomock.Any(), gomock.Any()).Return(v1limiter.Overrides{}, nil).AnyTimes()
+	fakeLimiterClient.EXPECT().CreateOverride(gomock.Any(), gomock.Any(), gomock.Any()).Return(&v1limiter.Override{}, nil).AnyTimes()
+
+	properties.MaxItems = helpers.PointerOf[int64](5)
+	queue, err := New(testhelpers.NewContextWithMiddlewareSetup(), &v1willow.Queue{
+		Spec: &v1willow.QueueSpec{
+			DBDefinition: &v1willow.QueueDBDefinition{Name: helpers.PointerOf("test queue")},
+			Properties:   properties,
+		},
+	}, "willow rule", fakeLimiterClient)
+	g.Expect(err).To(BeNil())
+
+	return queue
+}
+
+func idempotentEnqueueItem(idempotencyKey string) *v1willow.Item {
+	return &v1willow.Item{
+		Spec: &v1willow.ItemSpec{
+			DBDefinition: &v1willow.ItemDBDefinition{
+				KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+			},
+			Properties: &v1willow.ItemProperties{
+				Data:           []byte(`data`),
+				IdempotencyKey: helpers.PointerOf(idempotencyKey),
+			},
+		},
+	}
+}
+
+func Test_memoryQueue_EnqueueIdempotent(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It returns the existing item for a key that was already used", func(t *testing.T) {
+		queue := setupQueue(t, g, &v1willow.QueueProperties{IdempotencyWindow: helpers.PointerOf(time.Hour)})
+
+		itemState, existingItem, err := queue.EnqueueIdempotent(idempotentEnqueueItem("key"), func() (*v1willow.ItemState, *errors.ServerError) {
+			return &v1willow.ItemState{ID: "first"}, nil
+		})
+		g.Expect(err).To(BeNil())
+		g.Expect(existingItem).To(BeNil())
+		g.Expect(itemState.ID).To(Equal("first"))
+
+		itemState, existingItem, err = queue.EnqueueIdempotent(idempotentEnqueueItem("key"), func() (*v1willow.ItemState, *errors.ServerError) {
+			panic("should not enqueue")
+		})
+		g.Expect(err).To(BeNil())
+		g.Expect(itemState).To(BeNil())
+		g.Expect(existingItem.State.ID).To(Equal("first"))
+	})
+
+	t.Run("It waits for an enqueue in progress with the same key", func(t *testing.T) {
+		queue := setupQueue(t, g, &v1willow.QueueProperties{IdempotencyWindow: helpers.PointerOf(time.Hour)})
+
+		enqueueStarted := make(chan struct{})
+		releaseEnqueue := make(chan struct{})
+		go func() {
+			_, _, _ = queue.EnqueueIdempotent(idempotentEnqueueItem("key"), func() (*v1willow.ItemState, *errors.ServerError) {
+				close(enqueueStarted)
+				<-releaseEnqueue
+				return &v1willow.ItemState{ID: "first"}, nil
+			})
+		}()
+		g.Eventually(enqueueStarted).Should(BeClosed())
+
+		existing := make(chan *v1willow.Item)
+		go func() {
+			_, existingItem, _ := queue.EnqueueIdempotent(idempotentEnqueueItem("key"), func() (*v1willow.ItemState, *errors.ServerError) {
+				panic("should not enqueue")
+			})
+			existing <- existingItem
+		}()
+		g.Consistently(existing).ShouldNot(Receive())
+
+		close(releaseEnqueue)
+
+		var existingItem *v1willow.Item
+		g.Eventually(existing).Should(Receive(&existingItem))
+		g.Expect(existingItem.State.ID).To(Equal("first"))
+	})
+
+	t.Run("It does not block enqueues with other keys", func(t *testing.T) {
+		queue := setupQueue(t, g, &v1willow.QueueProperties{IdempotencyWindow: helpers.PointerOf(time.Hour)})
+
+		enqueueStarted := make(chan struct{})
+		releaseEnqueue := make(chan struct{})
+		defer close(releaseEnqueue)
+		go func() {
+			_, _, _ = queue.EnqueueIdempotent(idempotentEnqueueItem("slow key"), func() (*v1willow.ItemState, *errors.ServerError) {
+				close(enqueueStarted)
+				<-releaseEnqueue
+				return &v1willow.ItemState{ID: "slow"}, nil
+			})
+		}()
+		g.Eventually(enqueueStarted).Should(BeClosed())
+
+		itemState, existingItem, err := queue.EnqueueIdempotent(idempotentEnqueueItem("other key"), func() (*v1willow.ItemState, *errors.ServerError) {
+			return &v1willow.ItemState{ID: "other"}, nil
+		})
+		g.Expect(err).To(BeNil())
+		g.Expect(existingItem).To(BeNil())
+		g.Expect(itemState.ID).To(Equal("other"))
+	})
+
+	t.Run("It releases the key when the enqueue fails", func(t *testing.T) {
+		queue := setupQueue(t, g, &v1willow.QueueProperties{IdempotencyWindow: helpers.PointerOf(time.Hour)})
+
+		_, _, err := queue.EnqueueIdempotent(idempotentEnqueueItem("key"), func() (*v1willow.ItemState, *errors.ServerError) {
+			return nil, errors.InternalServerError
+		})
+		g.Expect(err).To(Equal(errors.InternalServerError))
+		g.Expect(queue.IdempotentItem("key")).To(BeNil())
+
+		itemState, existingItem, err := queue.EnqueueIdempotent(idempotentEnqueueItem("key"), func() (*v1willow.ItemState, *errors.ServerError) {
+			return &v1willow.ItemState{ID: "second"}, nil
+		})
+		g.Expect(err).To(BeNil())
+		g.Expect(existingItem).To(BeNil())
+		g.Expect(itemState.ID).To(Equal("second"))
+	})
+}
+
+func completedQueueItem(itemID string, completedTime time.Time) *v1willow.Item {
+	return &v1willow.Item{
+		Spec: &v1willow.ItemSpec{
+			DBDefinition: &v1willow.ItemDBDefinition{
+				KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+			},
+		},
+		State: &v1willow.ItemState{
+			ID:        itemID,
+			Completed: &v1willow.ItemCompleted{CompletedTime: completedTime},
+		},
+	}
+}
+
+func completedItemIDs(items v1willow.Items) []string {
+	itemIDs := []string{}
+	for _, item := range items {
+		itemIDs = append(itemIDs, item.State.ID)
+	}
+
+	return itemIDs
+}
+
+func Test_memoryQueue_RetainCompleted(t *testing.T) {
+	g := NewGomegaWithT(t)
+	allItems := &v1willow.ItemQuery{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}}
+
+	t.Run("It does not retain items when there is no retention", func(t *testing.T) {
+		queue := setupQueue(t, g, &v1willow.QueueProperties{})
+
+		queue.RetainCompleted(completedQueueItem("item", time.Now()))
+		g.Expect(queue.CompletedItems(allItems)).To(BeEmpty())
+	})
+
+	t.Run("It returns the items ordered by when they completed", func(t *testing.T) {
+		queue := setupQueue(t, g, &v1willow.QueueProperties{CompletedRetention: helpers.PointerOf(time.Hour)})
+
+		now := time.Now()
+		queue.RetainCompleted(completedQueueItem("third", now.Add(2*time.Second)))
+		queue.RetainCompleted(completedQueueItem("first", now))
+		queue.RetainCompleted(completedQueueItem("second", now.Add(time.Second)))
+
+		g.Expect(completedItemIDs(queue.CompletedItems(allItems))).To(Equal([]string{"first", "second", "third"}))
+	})
+
+	t.Run("It drops the oldest retained items when reaching the max", func(t *testing.T) {
+		defer setMaxCompletedItems(2)()
+		queue := setupQueue(t, g, &v1willow.QueueProperties{CompletedRetention: helpers.PointerOf(time.Hour)})
+
+		now := time.Now()
+		queue.RetainCompleted(completedQueueItem("first", now))
+		queue.RetainCompleted(completedQueueItem("second", now.Add(time.Second)))
+		queue.RetainCompleted(completedQueueItem("third", now.Add(2*time.Second)))
+
+		g.Expect(completedItemIDs(queue.CompletedItems(allItems))).To(Equal([]string{"second", "third"}))
+	})
+
+	t.Run("It drops items once the retention expires", func(t *testing.T) {
+		queue := setupQueue(t, g, &v1willow.QueueProperties{CompletedRetention: helpers.PointerOf(10 * time.Millisecond)})
+
+		queue.RetainCompleted(completedQueueItem("item", time.Now()))
+		g.Eventually(func() v1willow.Items { return queue.CompletedItems(allItems) }).Should(BeEmpty())
+		g.Expect(queue.completedOrder.Len()).To(Equal(0))
+	})
+}
+
+// setMaxCompletedItems changes the max number of retained items and returns a func to restore it
+func setMaxCompletedItems(maxItems int) func() {
+	original := maxCompletedItems
+	maxCompletedItems = maxItems
+
+	return func() { maxCompletedItems = original }
+}
+
+func Test_parseDataSchema(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It returns nil when there is no schema", func(t *testing.T) {
+		schema, err := parseDataSchema(nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(schema).To(BeNil())
+	})
+
+	t.Run("It returns an error when the JSON is not a JSON Schema", func(t *testing.T) {
+		schema, err := parseDataSchema([]byte(`["type"]`))
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("is not a valid JSON Schema"))
+		g.Expect(schema).To(BeNil())
+	})
+
+	t.Run("It parses a JSON Schema", func(t *testing.T) {
+		schema, err := parseDataSchema([]byte(`{"type": "object", "required": ["commit"]}`))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(schema.Required).To(ConsistOf("commit"))
+	})
+}
+
+func Test_memoryQueue_Idle(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It is never idle with an IdleTTL of 0", func(t *testing.T) {
+		queue := setupQueue(t, g, &v1willow.QueueProperties{})
+
+		g.Expect(queue.Idle(time.Now().Add(24 * time.Hour))).To(BeFalse())
+	})
+
+	t.Run("It is idle once there is no activity for the IdleTTL", func(t *testing.T) {
+		queue := setupQueue(t, g, &v1willow.QueueProperties{IdleTTL: helpers.PointerOf(time.Minute)})
+
+		g.Expect(queue.Idle(time.Now())).To(BeFalse())
+		g.Expect(queue.Idle(time.Now().Add(time.Minute))).To(BeTrue())
+	})
+
+	t.Run("It resets the IdleTTL when there is activity", func(t *testing.T) {
+		queue := setupQueue(t, g, &v1willow.QueueProperties{IdleTTL: helpers.PointerOf(50 * time.Millisecond)})
+		g.Eventually(func() bool { return queue.Idle(time.Now()) }).Should(BeTrue())
+
+		queue.RecordActivity()
+		g.Expect(queue.Idle(time.Now())).To(BeFalse())
+		g.Expect(queue.Idle(time.Now().Add(50 * time.Millisecond))).To(BeTrue())
+	})
+
+	t.Run("It is not idle while a client is waiting to dequeue", func(t *testing.T) {
+		queue := setupQueue(t, g, &v1willow.QueueProperties{IdleTTL: helpers.PointerOf(time.Minute)})
+
+		queue.DequeueStarted()
+		queue.DequeueStarted()
+		g.Expect(queue.Idle(time.Now().Add(time.Hour))).To(BeFalse())
+
+		queue.DequeueStopped()
+		g.Expect(queue.Idle(time.Now().Add(time.Hour))).To(BeFalse())
+
+		queue.DequeueStopped()
+		g.Expect(queue.Idle(time.Now().Add(time.Hour))).To(BeTrue())
+	})
+}
@@ -7,6 +7,7 @@ import (
 	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
 	"github.com/DanLavine/willow/pkg/models/datatypes"
 
+	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
 	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
 )
 
@@ -18,13 +19,26 @@ type QueuesClient interface {
 	UpdateQueue(ctx context.Context, queueName string, queueUpdate *v1willow.QueueProperties) *errors.ServerError
 	DeleteQueue(ctx context.Context, queueName string) *errors.ServerError
 
+	// Export operations
+	ExportQueues(ctx context.Context, exportQuery *v1willow.ExportQuery) (*v1willow.QueueExport, *errors.ServerError)
+	ImportQueues(ctx context.Context, queueExport *v1willow.QueueExport) *errors.ServerError
+
+	// Limiter operations
+	LimiterCounters(ctx context.Context) (v1limiter.Counters, *errors.ServerError)
+
 	// Channel operations
 	QueryChannels(ctx context.Context, queueName string, query *queryassociatedaction.AssociatedActionQuery) (v1willow.Channels, *errors.ServerError)
 	DeleteChannel(ctx context.Context, queueName string, channelKeyValues datatypes.KeyValues) *errors.ServerError
+	DiagnoseChannels(ctx context.Context, queueName string, query *queryassociatedaction.AssociatedActionQuery) (v1willow.ChannelDiagnoses, *errors.ServerError)
 
 	// Item operations
-	Enqueue(ctx context.Context, queueName string, enqueueItem *v1willow.Item) *errors.ServerError
+	Enqueue(ctx context.Context, queueName string, enqueueItem *v1willow.Item) (*v1willow.ItemState, *errors.ServerError)
 	Dequeue(cancelContext context.Context, queueName string, dequeueQuery *queryassociatedaction.AssociatedActionQuery) (*v1willow.Item, func(), func(), *errors.ServerError)
+	DequeueQueues(cancelContext context.Context, dequeueQueues *v1willow.DequeueQueues) (*v1willow.Item, func(), func(), *errors.ServerError)
 	Ack(ctx context.Context, queueName string, ack *v1willow.ACK) *errors.ServerError
-	Heartbeat(ctx context.Context, queueName string, heartbeat *v1willow.Heartbeat) *errors.ServerError
+	Heartbeat(ctx context.Context, queueName string, heartbeat *v1willow.Heartbeat) (*v1willow.HeartbeatResponse, *errors.ServerError)
+	Heartbeats(ctx context.Context, heartbeats *v1willow.Heartbeats) *v1willow.HeartbeatsResponse
+	Cancel(ctx context.Context, queueName string, cancel *v1willow.Cancel) *errors.ServerError
+	DeleteItems(ctx context.Context, queueName string, itemDelete *v1willow.ItemDelete) *errors.ServerError
+	QueryItems(ctx context.Context, queueName string, itemQuery *v1willow.ItemQuery) (v1willow.Items, *errors.ServerError)
 }
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/DanLavine/willow/internal/datastructures/btree"
 	"github.com/DanLavine/willow/internal/helpers"
 	"github.com/DanLavine/willow/internal/middleware"
+	"github.com/DanLavine/willow/internal/reporting"
 	"github.com/DanLavine/willow/pkg/models/api/common/errors"
 	v1 "github.com/DanLavine/willow/pkg/models/api/common/v1"
 	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
@@ -15,6 +17,7 @@ import (
 	"go.uber.org/zap"
 
 	queuechannels "github.com/DanLavine/willow/internal/willow/brokers/queue_channels"
+	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
 	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
 )
 
@@ -22,7 +25,13 @@ func errorMissingQueueName(name string) *errors.ServerError {
 	return &errors.ServerError{Message: fmt.Sprintf("failed to find queue '%s' by name", name), StatusCode: http.StatusNotFound}
 }
 
+// idleQueuesInterval is how often the queues are checked for being idle
+var idleQueuesInterval = time.Second
+
 type queueClientLocal struct {
+	// logger used for any background operations, such as deleting idle queues
+	logger *zap.Logger
+
 	// queue constructor for creating and managing queues
 	queueConstructor QueueConstructor
 
@@ -35,13 +44,14 @@ type queueClientLocal struct {
 	limiterRuleID string
 }
 
-func NewLocalQueueClient(queueConstructor QueueConstructor, queueChannelsClient queuechannels.QueueChannelsClient, limiterRuleID string) *queueClientLocal {
+func NewLocalQueueClient(logger *zap.Logger, queueConstructor QueueConstructor, queueChannelsClient queuechannels.QueueChannelsClient, limiterRuleID string) *queueClientLocal {
 	tree, err := btree.NewThreadSafe(2)
 	if err != nil {
 		panic(err)
 	}
 
 	return &queueClientLocal{
+		logger:              logger.Named("queue_client"),
 		queueConstructor:    queueConstructor,
 		queues:              tree,
 		queueChannelsClient: queueChannelsClient,
@@ -49,6 +59,63 @@ func NewLocalQueueClient(queueConstructor QueueConstructor, queueChannelsClient
 	}
 }
 
+// Execute periodically deletes any queues that have been idle for longer than their IdleTTL
+func (qcl *queueClientLocal) Execute(ctx context.Context) error {
+	ticker := time.NewTicker(idleQueuesInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			qcl.deleteIdleQueues(reporting.StripedContext(qcl.logger))
+		}
+	}
+}
+
+// deleteIdleQueues destroys any queues with no items and no activity for the queue's IdleTTL
+func (qcl *queueClientLocal) deleteIdleQueues(ctx context.Context) {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "deleteIdleQueues")
+
+	// find the idle queues first, the channels can't be checked while iterating the queues
+	idleQueues := []string{}
+	bTreeOnIterate := func(key datatypes.EncapsulatedValue, item any) bool {
+		if item.(Queue).Idle(time.Now()) {
+			idleQueues = append(idleQueues, key.Data.(string))
+		}
+
+		return true
+	}
+
+	if err := qcl.queues.Find(datatypes.Any(), v1.TypeRestrictions{MinDataType: datatypes.MinDataType, MaxDataType: datatypes.MaxDataType}, bTreeOnIterate); err != nil {
+		logger.Error("error listing queues from tree", zap.Error(err))
+		return
+	}
+
+	for _, queueName := range idleQueues {
+		deleted := false
+		canDelete := func(queue Queue) bool {
+			// the queue could have been used since it was found. Empty channels are always removed, so any channels mean there are items
+			if !queue.Idle(time.Now()) || len(qcl.queueChannelsClient.Channels(ctx, queueName, &queryassociatedaction.AssociatedActionQuery{})) != 0 {
+				return false
+			}
+
+			deleted = true
+			return true
+		}
+
+		if err := qcl.deleteQueue(ctx, queueName, canDelete); err != nil {
+			logger.Warn("failed to delete idle queue", zap.String("queue_name", queueName), zap.Error(err))
+			continue
+		}
+
+		if deleted {
+			logger.Info("deleted idle queue", zap.String("queue_name", queueName), zap.String("reason", "queue had no items and no enqueue or dequeue activity for the IdleTTL"))
+		}
+	}
+}
+
 // Create the main queue and setup the limts on the Limiter service
 func (qcl *queueClientLocal) CreateQueue(ctx context.Context, queueCreate *v1willow.Queue) *errors.ServerError {
 	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "CreateQueue")
@@ -61,6 +128,14 @@ func (qcl *queueClientLocal) CreateQueue(ctx context.Context, queueCreate *v1wil
 			return nil
 		}
 
+		qcl.queueChannelsClient.SetChannelWeights(*queueCreate.Spec.DBDefinition.Name, queue.ChannelWeights())
+		qcl.queueChannelsClient.SetPriorityAging(*queueCreate.Spec.DBDefinition.Name, queue.PriorityAging())
+		if createQueueError = qcl.queueChannelsClient.SetMirror(ctx, *queueCreate.Spec.DBDefinition.Name, queueProperties(queue)); createQueueError != nil {
+			// cleanup the queue's limits since it is not saved
+			_ = queue.Destroy(ctx, qcl.limiterRuleID, *queueCreate.Spec.DBDefinition.Name)
+			return nil
+		}
+
 		return queue
 	}
 
@@ -81,6 +156,22 @@ func (qcl *queueClientLocal) CreateQueue(ctx context.Context, queueCreate *v1wil
 	return createQueueError
 }
 
+// queueProperties reports all the properties a queue is currently configured with
+func queueProperties(queue Queue) *v1willow.QueueProperties {
+	return &v1willow.QueueProperties{
+		MaxItems:           helpers.PointerOf(queue.ConfiguredLimit()),
+		MaxRunDuration:     helpers.PointerOf(queue.MaxRunDuration()),
+		CompletedRetention: helpers.PointerOf(queue.CompletedRetention()),
+		IdempotencyWindow:  helpers.PointerOf(queue.IdempotencyWindow()),
+		MaxPayloadSize:     helpers.PointerOf(queue.MaxPayloadSize()),
+		DataSchema:         queue.DataSchema(),
+		IdleTTL:            helpers.PointerOf(queue.IdleTTL()),
+		ChannelWeights:     queue.ChannelWeights(),
+		PriorityAging:      queue.PriorityAging(),
+		Mirror:             queue.Mirror(),
+	}
+}
+
 func (qcl *queueClientLocal) ListQueues(ctx context.Context) (v1willow.Queues, *errors.ServerError) {
 	_, logger := middleware.GetNamedMiddlewareLogger(ctx, "ListQueues")
 
@@ -93,12 +184,11 @@ func (qcl *queueClientLocal) ListQueues(ctx context.Context) (v1willow.Queues, *
 				DBDefinition: &v1willow.QueueDBDefinition{
 					Name: helpers.PointerOf[string](key.Data.(string)),
 				},
-				Properties: &v1willow.QueueProperties{
-					MaxItems: helpers.PointerOf(queue.ConfiguredLimit()),
-				},
+				Properties: queueProperties(queue),
 			},
 			State: &v1willow.QueueState{
 				Deleting: false,
+				Mirror:   qcl.queueChannelsClient.MirrorState(key.Data.(string)),
 			},
 		})
 
@@ -126,12 +216,11 @@ func (qcl *queueClientLocal) GetQueue(ctx context.Context, queueName string) (*v
 				DBDefinition: &v1willow.QueueDBDefinition{
 					Name: helpers.PointerOf[string](queueName),
 				},
-				Properties: &v1willow.QueueProperties{
-					MaxItems: helpers.PointerOf(willowQueue.ConfiguredLimit()),
-				},
+				Properties: queueProperties(willowQueue),
 			},
 			State: &v1willow.QueueState{
 				Deleting: false,
+				Mirror:   qcl.queueChannelsClient.MirrorState(queueName),
 			},
 		}
 
@@ -161,7 +250,13 @@ func (qcl *queueClientLocal) UpdateQueue(ctx context.Context, queueName string,
 	updateQueueError := errorMissingQueueName(queueName)
 
 	bTreeOnFind := func(key datatypes.EncapsulatedValue, item any) bool {
-		updateQueueError = item.(Queue).Update(ctx, qcl.limiterRuleID, queueUpdate)
+		queue := item.(Queue)
+		if updateQueueError = queue.Update(ctx, qcl.limiterRuleID, queueUpdate); updateQueueError == nil {
+			qcl.queueChannelsClient.SetChannelWeights(queueName, queue.ChannelWeights())
+			qcl.queueChannelsClient.SetPriorityAging(queueName, queue.PriorityAging())
+			updateQueueError = qcl.queueChannelsClient.SetMirror(ctx, queueName, queueProperties(queue))
+		}
+
 		return false
 	}
 
@@ -180,11 +275,29 @@ func (qcl *queueClientLocal) UpdateQueue(ctx context.Context, queueName string,
 }
 
 func (qcl *queueClientLocal) DeleteQueue(ctx context.Context, queueName string) *errors.ServerError {
-	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "DeleteQueue")
+	ctx, _ = middleware.GetNamedMiddlewareLogger(ctx, "DeleteQueue")
+
+	return qcl.deleteQueue(ctx, queueName, nil)
+}
+
+//	PARAMETERS:
+//	- queueName - name of the queue to delete
+//	- canDelete - optional check that the queue can still be deleted, once no other operations are using the queue
+//
+//	RETURNS:
+//	- *errors.ServerError - any errors destroying the queue
+//
+// deleteQueue destroys the queue's channels, Limiter override and the queue itself
+func (qcl *queueClientLocal) deleteQueue(ctx context.Context, queueName string, canDelete func(queue Queue) bool) *errors.ServerError {
+	logger := middleware.GetMiddlewareLogger(ctx)
 
 	var deleteQueueError *errors.ServerError
 	destroyQueue := func(key datatypes.EncapsulatedValue, item any) bool {
 		queue := item.(Queue)
+		if canDelete != nil && !canDelete(queue) {
+			return false
+		}
+
 		if deleteQueueError = qcl.queueChannelsClient.DestroyChannelsForQueue(ctx, queueName); deleteQueueError == nil {
 			if deleteQueueError = queue.Destroy(ctx, qcl.limiterRuleID, queueName); deleteQueueError == nil {
 				return true
@@ -194,6 +307,13 @@ func (qcl *queueClientLocal) DeleteQueue(ctx context.Context, queueName string)
 		return false
 	}
 
+	// clients waiting to dequeue hold the queue in the tree, so they must be canceled before the queue can be destroyed.
+	// Idle queues have no waiting clients, so there is nothing to cancel
+	if canDelete == nil {
+		qcl.queueChannelsClient.StartDestroyingQueue(queueName)
+		defer qcl.queueChannelsClient.StopDestroyingQueue(queueName)
+	}
+
 	if err := qcl.queues.Destroy(datatypes.String(queueName), destroyQueue); err != nil {
 		switch err {
 		case btree.ErrorKeyDestroying:
@@ -231,22 +351,82 @@ func (qcl *queueClientLocal) QueryChannels(ctx context.Context, queueName string
 	return channels, nil
 }
 
-func (qcl *queueClientLocal) Enqueue(ctx context.Context, queueName string, enqueueItem *v1willow.Item) *errors.ServerError {
+func (qcl *queueClientLocal) DiagnoseChannels(ctx context.Context, queueName string, query *queryassociatedaction.AssociatedActionQuery) (v1willow.ChannelDiagnoses, *errors.ServerError) {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "DiagnoseChannels")
+	diagnoseErr := errorMissingQueueName(queueName)
+
+	var channelDiagnoses v1willow.ChannelDiagnoses
+	onIterate := func(key datatypes.EncapsulatedValue, item any) bool {
+		channelDiagnoses, diagnoseErr = qcl.queueChannelsClient.DiagnoseChannels(ctx, queueName, query)
+		return false
+	}
+
+	if err := qcl.queues.Find(datatypes.String(queueName), v1.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onIterate); err != nil {
+		switch err {
+		case btree.ErrorKeyDestroying:
+			logger.Warn("failed to diagnose channels. Queue by that name is currenly destroying")
+			return nil, &errors.ServerError{Message: fmt.Sprintf("Queue with name '%s' is currently being destroyed", queueName), StatusCode: http.StatusConflict}
+		default:
+			logger.Error("failed to find the queue in the tree for some reason", zap.Error(err))
+			return nil, errorMissingQueueName(queueName)
+		}
+	}
+
+	return channelDiagnoses, diagnoseErr
+}
+
+func (qcl *queueClientLocal) Enqueue(ctx context.Context, queueName string, enqueueItem *v1willow.Item) (*v1willow.ItemState, *errors.ServerError) {
 	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "Enqueue")
 	enqueueQueueError := errorMissingQueueName(queueName)
 
 	// nothing for the item to do. but use the bTree as a guard to ensure no delete operations are happening at the same time
+	var itemState *v1willow.ItemState
 	onFind := func(key datatypes.EncapsulatedValue, item any) bool {
-		enqueueQueueError = qcl.queueChannelsClient.EnqueueQueueItem(ctx, queueName, enqueueItem)
+		queue := item.(Queue)
+		queue.RecordActivity()
+
+		// reject any items that would fail to be decoded by the consumers
+		if err := queue.ValidateItem(enqueueItem); err != nil {
+			enqueueQueueError = errors.ServerErrorModelRequestValidation(&errors.ModelError{Field: "Spec", Child: &errors.ModelError{Field: "Properties", Child: err}})
+			return false
+		}
+
+		// apply the queue's default max run duration when the item does not set one
+		if enqueueItem.Spec.Properties.MaxRunDuration == nil {
+			if maxRunDuration := queue.MaxRunDuration(); maxRunDuration > 0 {
+				enqueueItem.Spec.Properties.MaxRunDuration = &maxRunDuration
+			}
+		}
+
+		enqueue := func() (*v1willow.ItemState, *errors.ServerError) {
+			return qcl.queueChannelsClient.EnqueueQueueItem(ctx, queueName, enqueueItem)
+		}
+
+		if enqueueItem.Spec.Properties.IdempotencyKey == nil {
+			itemState, enqueueQueueError = enqueue()
+			return false
+		}
+
+		if queue.IdempotencyWindow() <= 0 {
+			enqueueQueueError = &errors.ServerError{Message: fmt.Sprintf("Queue '%s' does not accept idempotency keys. The queue's IdempotencyWindow must be set", queueName), StatusCode: http.StatusBadRequest}
+			return false
+		}
+
+		var existingItem *v1willow.Item
+		if itemState, existingItem, enqueueQueueError = queue.EnqueueIdempotent(enqueueItem, enqueue); existingItem != nil {
+			logger.Debug("item with the same idempotency key already exists", zap.String("item_id", existingItem.State.ID))
+			itemState = qcl.idempotentItemState(ctx, queueName, queue, existingItem)
+		}
+
 		return false
 	}
 
 	if err := qcl.queues.Find(datatypes.String(queueName), v1.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onFind); err != nil {
 		logger.Error("failed to find the queue in the tree for some reason", zap.Error(err))
-		return errors.InternalServerError
+		return nil, errors.InternalServerError
 	}
 
-	return enqueueQueueError
+	return itemState, enqueueQueueError
 }
 
 func (qcl *queueClientLocal) Dequeue(ctx context.Context, queueName string, dequeueQuery *queryassociatedaction.AssociatedActionQuery) (*v1willow.Item, func(), func(), *errors.ServerError) {
@@ -258,6 +438,10 @@ func (qcl *queueClientLocal) Dequeue(ctx context.Context, queueName string, dequ
 	var onSuccess func()
 	var onFailure func()
 	onFind := func(key datatypes.EncapsulatedValue, item any) bool {
+		queue := item.(Queue)
+		queue.DequeueStarted()
+		defer queue.DequeueStopped()
+
 		dequeueItem, onSuccess, onFailure, dequeueQueueError = qcl.queueChannelsClient.DequeueQueueItem(ctx, queueName, dequeueQuery)
 		return false
 	}
@@ -270,6 +454,64 @@ func (qcl *queueClientLocal) Dequeue(ctx context.Context, queueName string, dequ
 	return dequeueItem, onSuccess, onFailure, dequeueQueueError
 }
 
+// DequeueQueues blocks until an item is available from any of the queues that match the request. When a queue is selected
+// by multiple entries, the first matching entry's channel query is used
+func (qcl *queueClientLocal) DequeueQueues(ctx context.Context, dequeueQueues *v1willow.DequeueQueues) (*v1willow.Item, func(), func(), *errors.ServerError) {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "DequeueQueues")
+
+	// find all the queues that match the request. Name patterns are only resolved once, so queues created
+	// while this client is waiting are not dequeued from
+	queueQueries := map[string]*queryassociatedaction.AssociatedActionQuery{}
+	matchedQueues := []Queue{}
+	bTreeOnIterate := func(key datatypes.EncapsulatedValue, item any) bool {
+		queueName := key.Data.(string)
+
+		for _, dequeueQueue := range dequeueQueues.Queues {
+			if dequeueQueue.MatchQueueName(queueName) {
+				// the queue must not be idle before the tree guard is released, or it could be deleted for being idle
+				// before this client starts waiting on it
+				queue := item.(Queue)
+				queue.DequeueStarted()
+
+				queueQueries[queueName] = dequeueQueue.ChannelQuery
+				matchedQueues = append(matchedQueues, queue)
+				break
+			}
+		}
+
+		return true
+	}
+
+	// the matched queues are not idle while this client is waiting
+	defer func() {
+		for _, queue := range matchedQueues {
+			queue.DequeueStopped()
+		}
+	}()
+
+	if err := qcl.queues.Find(datatypes.Any(), v1.TypeRestrictions{MinDataType: datatypes.MinDataType, MaxDataType: datatypes.MaxDataType}, bTreeOnIterate); err != nil {
+		logger.Error("error listing queues from tree", zap.Error(err))
+		return nil, nil, nil, errors.InternalServerError
+	}
+
+	// any explicitly named queues must exist
+	for _, dequeueQueue := range dequeueQueues.Queues {
+		if dequeueQueue.Name != nil {
+			if _, ok := queueQueries[*dequeueQueue.Name]; !ok {
+				return nil, nil, nil, errorMissingQueueName(*dequeueQueue.Name)
+			}
+		}
+	}
+
+	if len(queueQueries) == 0 {
+		return nil, nil, nil, &errors.ServerError{Message: "failed to find any queues that match the name patterns", StatusCode: http.StatusNotFound}
+	}
+
+	// NOTE: the queues are not used as a guard here like a single Dequeue. Holding multiple finds on the tree at once
+	// could deadlock with a queue being created, so the channels client skips any queues that are destroyed while waiting
+	return qcl.queueChannelsClient.DequeueQueueItems(ctx, queueQueries, dequeueQueues.HeadersFilter)
+}
+
 func (qcl *queueClientLocal) DeleteChannel(ctx context.Context, queueName string, channelKeyValues datatypes.KeyValues) *errors.ServerError {
 	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "DeleteChannel")
 	deleteChannelsError := errorMissingQueueName(queueName)
@@ -291,9 +533,15 @@ func (qcl *queueClientLocal) Ack(ctx context.Context, queueName string, ack *v1w
 	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "Ack")
 	ackErr := errorMissingQueueName(queueName)
 
-	// nothing for the item to do. but use the bTree as a guard to ensure no delete operations are happening at the same time
+	// use the bTree as a guard to ensure no delete operations are happening at the same time
 	onFind := func(key datatypes.EncapsulatedValue, item any) bool {
-		ackErr = qcl.queueChannelsClient.ACK(ctx, queueName, ack)
+		item.(Queue).RecordActivity()
+
+		var completedItem *v1willow.Item
+		if completedItem, ackErr = qcl.queueChannelsClient.ACK(ctx, queueName, ack); completedItem != nil {
+			item.(Queue).RetainCompleted(completedItem)
+		}
+
 		return false
 	}
 
@@ -311,13 +559,14 @@ func (qcl *queueClientLocal) Ack(ctx context.Context, queueName string, ack *v1w
 	return ackErr
 }
 
-func (qcl *queueClientLocal) Heartbeat(ctx context.Context, queueName string, heartbeat *v1willow.Heartbeat) *errors.ServerError {
+func (qcl *queueClientLocal) Heartbeat(ctx context.Context, queueName string, heartbeat *v1willow.Heartbeat) (*v1willow.HeartbeatResponse, *errors.ServerError) {
 	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "Heartbeat")
 	heartbeatErr := errorMissingQueueName(queueName)
 
 	// nothing for the item to do. but use the bTree as a guard to ensure no delete operations are happening at the same time
+	var heartbeatResponse *v1willow.HeartbeatResponse
 	onFind := func(key datatypes.EncapsulatedValue, item any) bool {
-		heartbeatErr = qcl.queueChannelsClient.Heartbeat(ctx, queueName, heartbeat)
+		heartbeatResponse, heartbeatErr = qcl.queueChannelsClient.Heartbeat(ctx, queueName, heartbeat)
 		return false
 	}
 
@@ -325,12 +574,365 @@ func (qcl *queueClientLocal) Heartbeat(ctx context.Context, queueName string, he
 		switch err {
 		case btree.ErrorKeyDestroying:
 			logger.Warn("failed to update queue. Queue by that name is currenly destroying")
-			return &errors.ServerError{Message: fmt.Sprintf("Queue with name '%s' is currently being destroyed. Refusing to update the queue", queueName), StatusCode: http.StatusConflict}
+			return nil, &errors.ServerError{Message: fmt.Sprintf("Queue with name '%s' is currently being destroyed. Refusing to update the queue", queueName), StatusCode: http.StatusConflict}
+		default:
+			logger.Error("failed to find the queue in the tree for some reason", zap.Error(err))
+			return nil, errors.InternalServerError
+		}
+	}
+
+	return heartbeatResponse, heartbeatErr
+}
+
+// Heartbeats processes every heartbeat in the batch independently. A failure for one item is recorded in
+// its result and does not stop the rest of the batch
+func (qcl *queueClientLocal) Heartbeats(ctx context.Context, heartbeats *v1willow.Heartbeats) *v1willow.HeartbeatsResponse {
+	ctx, _ = middleware.GetNamedMiddlewareLogger(ctx, "Heartbeats")
+
+	heartbeatsResponse := &v1willow.HeartbeatsResponse{Results: make([]*v1willow.HeartbeatResult, 0, len(heartbeats.Heartbeats))}
+	for _, queueHeartbeat := range heartbeats.Heartbeats {
+		heartbeatResponse, heartbeatErr := qcl.Heartbeat(ctx, queueHeartbeat.QueueName, queueHeartbeat.Heartbeat)
+		if heartbeatErr != nil {
+			heartbeatsResponse.Results = append(heartbeatsResponse.Results, &v1willow.HeartbeatResult{Error: &errors.Error{Message: heartbeatErr.Message}})
+		} else {
+			heartbeatsResponse.Results = append(heartbeatsResponse.Results, &v1willow.HeartbeatResult{Cancel: heartbeatResponse.Cancel})
+		}
+	}
+
+	return heartbeatsResponse
+}
+
+func (qcl *queueClientLocal) Cancel(ctx context.Context, queueName string, cancel *v1willow.Cancel) *errors.ServerError {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "Cancel")
+	cancelErr := errorMissingQueueName(queueName)
+
+	// nothing for the item to do. but use the bTree as a guard to ensure no delete operations are happening at the same time
+	onFind := func(key datatypes.EncapsulatedValue, item any) bool {
+		cancelErr = qcl.queueChannelsClient.Cancel(ctx, queueName, cancel)
+		return false
+	}
+
+	if err := qcl.queues.Find(datatypes.String(queueName), v1.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onFind); err != nil {
+		switch err {
+		case btree.ErrorKeyDestroying:
+			logger.Warn("failed to cancel item. Queue by that name is currenly destroying")
+			return &errors.ServerError{Message: fmt.Sprintf("Queue with name '%s' is currently being destroyed. Refusing to cancel the item since it is being destroyed too", queueName), StatusCode: http.StatusConflict}
 		default:
 			logger.Error("failed to find the queue in the tree for some reason", zap.Error(err))
 			return errors.InternalServerError
 		}
 	}
 
-	return heartbeatErr
+	return cancelErr
+}
+
+func (qcl *queueClientLocal) DeleteItems(ctx context.Context, queueName string, itemDelete *v1willow.ItemDelete) *errors.ServerError {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "DeleteItems")
+	deleteErr := errorMissingQueueName(queueName)
+
+	// nothing for the item to do. but use the bTree as a guard to ensure no delete operations are happening at the same time
+	onFind := func(key datatypes.EncapsulatedValue, item any) bool {
+		deleteErr = qcl.queueChannelsClient.DeleteItems(ctx, queueName, itemDelete)
+		return false
+	}
+
+	if err := qcl.queues.Find(datatypes.String(queueName), v1.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onFind); err != nil {
+		switch err {
+		case btree.ErrorKeyDestroying:
+			logger.Warn("failed to delete items. Queue by that name is currenly destroying")
+			return &errors.ServerError{Message: fmt.Sprintf("Queue with name '%s' is currently being destroyed. Refusing to delete the items since they are being destroyed too", queueName), StatusCode: http.StatusConflict}
+		default:
+			logger.Error("failed to find the queue in the tree for some reason", zap.Error(err))
+			return errors.InternalServerError
+		}
+	}
+
+	return deleteErr
+}
+
+func (qcl *queueClientLocal) QueryItems(ctx context.Context, queueName string, itemQuery *v1willow.ItemQuery) (v1willow.Items, *errors.ServerError) {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "QueryItems")
+	queryErr := errorMissingQueueName(queueName)
+	var items v1willow.Items
+
+	// nothing for the item to do. but use the bTree as a guard to ensure no delete operations are happening at the same time
+	onFind := func(key datatypes.EncapsulatedValue, item any) bool {
+		queue := item.(Queue)
+		queryErr = nil
+
+		// resolve the idempotency key to the item it was used for
+		if itemQuery.IdempotencyKey != nil {
+			existingItem := queue.IdempotentItem(*itemQuery.IdempotencyKey)
+			if existingItem == nil || (itemQuery.ItemID != nil && *itemQuery.ItemID != existingItem.State.ID) {
+				items = v1willow.Items{}
+				return false
+			}
+
+			itemQuery = &v1willow.ItemQuery{
+				ChannelQuery:     itemQuery.ChannelQuery,
+				ItemID:           &existingItem.State.ID,
+				IncludeCompleted: itemQuery.IncludeCompleted,
+			}
+		}
+
+		items = qcl.queueChannelsClient.Items(ctx, queueName, itemQuery)
+		if itemQuery.IncludeCompleted {
+			items = append(items, queue.CompletedItems(itemQuery)...)
+		}
+
+		return false
+	}
+
+	if err := qcl.queues.Find(datatypes.String(queueName), v1.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onFind); err != nil {
+		switch err {
+		case btree.ErrorKeyDestroying:
+			logger.Warn("failed to query items. Queue by that name is currenly destroying")
+			return nil, &errors.ServerError{Message: fmt.Sprintf("Queue with name '%s' is currently being destroyed", queueName), StatusCode: http.StatusConflict}
+		default:
+			logger.Error("failed to find the queue in the tree for some reason", zap.Error(err))
+			return nil, errors.InternalServerError
+		}
+	}
+
+	return items, queryErr
+}
+
+// idempotentItemState returns the current state of an item that was already enqueued with the same IdempotencyKey
+func (qcl *queueClientLocal) idempotentItemState(ctx context.Context, queueName string, queue Queue, existingItem *v1willow.Item) *v1willow.ItemState {
+	itemQuery := &v1willow.ItemQuery{
+		ChannelQuery: queryassociatedaction.KeyValuesToExactAssociatedActionQuery(existingItem.Spec.DBDefinition.KeyValues),
+		ItemID:       &existingItem.State.ID,
+	}
+
+	// the item could still be enqueued, processing, or completed and retained by the queue
+	items := qcl.queueChannelsClient.Items(ctx, queueName, itemQuery)
+	items = append(items, queue.CompletedItems(itemQuery)...)
+
+	itemState := &v1willow.ItemState{ID: existingItem.State.ID, QueueName: queueName}
+	if len(items) > 0 {
+		// copy the state since completed items are shared with the queue
+		currentState := *items[0].State
+		itemState = &currentState
+	}
+	itemState.Duplicate = true
+
+	return itemState
+}
+
+//	PARAMETERS:
+//	- ctx - context with the logger for the request
+//	- exportQuery - which queues to export
+//
+//	RETURNS:
+//	- *v1willow.QueueExport - versioned document with the properties, channels and items for each queue
+//	- *errors.ServerError - error if the requested queue does not exist
+//
+// ExportQueues reports the full state of one or all queues, that can be imported on another Willow service
+func (qcl *queueClientLocal) ExportQueues(ctx context.Context, exportQuery *v1willow.ExportQuery) (*v1willow.QueueExport, *errors.ServerError) {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "ExportQueues")
+	queueExport := &v1willow.QueueExport{Version: v1willow.QueueExportVersion}
+
+	onFind := func(key datatypes.EncapsulatedValue, item any) bool {
+		queue := item.(Queue)
+		queueName := key.Data.(string)
+
+		exportedQueue := &v1willow.ExportedQueue{
+			Name:       queueName,
+			Properties: queueProperties(queue),
+			Channels:   qcl.queueChannelsClient.ExportChannels(ctx, queueName),
+		}
+
+		if exportQuery.IncludeCompleted {
+			exportedQueue.CompletedItems = queue.CompletedItems(&v1willow.ItemQuery{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}})
+		}
+
+		queueExport.Queues = append(queueExport.Queues, exportedQueue)
+		return true
+	}
+
+	var err error
+	if exportQuery.QueueName != nil {
+		err = qcl.queues.Find(datatypes.String(*exportQuery.QueueName), v1.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onFind)
+	} else {
+		err = qcl.queues.Find(datatypes.Any(), v1.TypeRestrictions{MinDataType: datatypes.MinDataType, MaxDataType: datatypes.MaxDataType}, onFind)
+	}
+
+	if err != nil {
+		switch {
+		case err == btree.ErrorKeyDestroying && exportQuery.QueueName != nil:
+			logger.Warn("failed to export queue. Queue by that name is currenly destroying")
+			return nil, &errors.ServerError{Message: fmt.Sprintf("Queue with name '%s' is currently being destroyed", *exportQuery.QueueName), StatusCode: http.StatusConflict}
+		default:
+			logger.Error("failed to export queues from the tree", zap.Error(err))
+			return nil, errors.InternalServerError
+		}
+	}
+
+	if exportQuery.QueueName != nil && len(queueExport.Queues) == 0 {
+		return nil, errorMissingQueueName(*exportQuery.QueueName)
+	}
+
+	return queueExport, nil
+}
+
+//	PARAMETERS:
+//	- ctx - context with the logger for the request
+//	- queueExport - document from the export api
+//
+//	RETURNS:
+//	- *errors.ServerError - error if any of the queues already exist or the items could not be enqueued
+//
+// ImportQueues recreates all queues from an export. Creating each queue re-establishes its Limiter override and
+// each imported item is counted on the Limiter's '_willow_enqueued' counters
+func (qcl *queueClientLocal) ImportQueues(ctx context.Context, queueExport *v1willow.QueueExport) *errors.ServerError {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "ImportQueues")
+
+	// 1. ensure none of the queues exist, so a conflict is reported before anything is imported
+	for _, exportedQueue := range queueExport.Queues {
+		exists := false
+		onFind := func(_ datatypes.EncapsulatedValue, _ any) bool {
+			exists = true
+			return false
+		}
+
+		if err := qcl.queues.Find(datatypes.String(exportedQueue.Name), v1.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onFind); err != nil && err != btree.ErrorKeyDestroying {
+			logger.Error("failed to find the queue in the tree for some reason", zap.Error(err))
+			return errors.InternalServerError
+		}
+
+		if exists {
+			return &errors.ServerError{Message: fmt.Sprintf("Queue already exists with name '%s'", exportedQueue.Name), StatusCode: http.StatusConflict}
+		}
+	}
+
+	// 2. create each queue and enqueue all the items. On any error, the queues created by this import are deleted
+	// again along with any items already imported, so the import can be retried
+	importedQueues := []string{}
+	rollback := func() {
+		for _, queueName := range importedQueues {
+			if err := qcl.deleteQueue(ctx, queueName, nil); err != nil {
+				logger.Error("failed to delete a partially imported queue", zap.String("queue_name", queueName), zap.Error(err))
+			}
+		}
+	}
+
+	for queueIndex, exportedQueue := range queueExport.Queues {
+		createQueue := &v1willow.Queue{
+			Spec: &v1willow.QueueSpec{
+				DBDefinition: &v1willow.QueueDBDefinition{Name: &exportedQueue.Name},
+				Properties:   exportedQueue.Properties,
+			},
+		}
+		if err := qcl.CreateQueue(ctx, createQueue); err != nil {
+			rollback()
+			return err
+		}
+		importedQueues = append(importedQueues, exportedQueue.Name)
+
+		importError := errorMissingQueueName(exportedQueue.Name)
+		onFind := func(_ datatypes.EncapsulatedValue, item any) bool {
+			queue := item.(Queue)
+
+			// imported items must satisfy the same rules as items enqueued to the queue
+			if importError = validateImportedItems(queue, queueIndex, exportedQueue); importError != nil {
+				return false
+			}
+
+			for _, exportedChannel := range exportedQueue.Channels {
+				if importError = qcl.queueChannelsClient.ImportChannel(ctx, exportedQueue.Name, exportedChannel); importError != nil {
+					return false
+				}
+
+				// enqueues with the same IdempotencyKey return the imported item
+				for _, exportedItem := range exportedChannel.Items {
+					if exportedItem.Properties.IdempotencyKey != nil {
+						queue.RegisterIdempotentItem(importedItem(exportedChannel, exportedItem), exportedItem.ID)
+					}
+				}
+			}
+
+			for _, completedItem := range exportedQueue.CompletedItems {
+				queue.RetainCompleted(completedItem)
+			}
+
+			queue.RecordActivity()
+			return false
+		}
+
+		if err := qcl.queues.Find(datatypes.String(exportedQueue.Name), v1.TypeRestrictions{MinDataType: datatypes.T_string, MaxDataType: datatypes.T_string}, onFind); err != nil {
+			rollback()
+
+			switch err {
+			case btree.ErrorKeyDestroying:
+				logger.Warn("failed to import queue. Queue by that name is currenly destroying")
+				return &errors.ServerError{Message: fmt.Sprintf("Queue with name '%s' is currently being destroyed", exportedQueue.Name), StatusCode: http.StatusConflict}
+			default:
+				logger.Error("failed to find the queue in the tree for some reason", zap.Error(err))
+				return errors.InternalServerError
+			}
+		}
+
+		if importError != nil {
+			rollback()
+			return importError
+		}
+	}
+
+	return nil
+}
+
+// validateImportedItems ensures every exported item satisfies the queue's MaxPayloadSize, DataSchema and IdempotencyWindow
+func validateImportedItems(queue Queue, queueIndex int, exportedQueue *v1willow.ExportedQueue) *errors.ServerError {
+	for channelIndex, exportedChannel := range exportedQueue.Channels {
+		for itemIndex, exportedItem := range exportedChannel.Items {
+			if err := queue.ValidateItem(importedItem(exportedChannel, exportedItem)); err != nil {
+				itemField := fmt.Sprintf("Queues[%d].Channels[%d].Items[%d]", queueIndex, channelIndex, itemIndex)
+				return errors.ServerErrorModelRequestValidation(&errors.ModelError{Field: itemField, Child: &errors.ModelError{Field: "Properties", Child: err}})
+			}
+
+			if exportedItem.Properties.IdempotencyKey != nil && queue.IdempotencyWindow() <= 0 {
+				return &errors.ServerError{Message: fmt.Sprintf("Queue '%s' does not accept idempotency keys. The queue's IdempotencyWindow must be set", exportedQueue.Name), StatusCode: http.StatusBadRequest}
+			}
+		}
+	}
+
+	return nil
+}
+
+// importedItem converts an exported item to the item that is enqueued to the channel
+func importedItem(exportedChannel *v1willow.ExportedChannel, exportedItem *v1willow.ExportedItem) *v1willow.Item {
+	return &v1willow.Item{
+		Spec: &v1willow.ItemSpec{
+			DBDefinition: &v1willow.ItemDBDefinition{
+				KeyValues: exportedChannel.KeyValues,
+			},
+			Properties: exportedItem.Properties,
+		},
+	}
+}
+
+//	PARAMETERS:
+//	- ctx - context with the logger for the request
+//
+//	RETURNS:
+//	- v1limiter.Counters - the 'enqueued' and 'running' counters the Limiter should have for every queue
+//	- *errors.ServerError - error if the queues could not be read
+//
+// LimiterCounters reports the Limiter counters for all items Willow currently holds. Queues that are being
+// destroyed are not reported, since all of their counters are being removed
+func (qcl *queueClientLocal) LimiterCounters(ctx context.Context) (v1limiter.Counters, *errors.ServerError) {
+	ctx, logger := middleware.GetNamedMiddlewareLogger(ctx, "LimiterCounters")
+	counters := v1limiter.Counters{}
+
+	onFind := func(key datatypes.EncapsulatedValue, _ any) bool {
+		counters = append(counters, qcl.queueChannelsClient.LimiterCounters(ctx, key.Data.(string))...)
+		return true
+	}
+
+	if err := qcl.queues.Find(datatypes.Any(), v1.TypeRestrictions{MinDataType: datatypes.MinDataType, MaxDataType: datatypes.MaxDataType}, onFind); err != nil {
+		logger.Error("failed to read the queues from the tree", zap.Error(err))
+		return nil, errors.InternalServerError
+	}
+
+	return counters, nil
 }
@@ -0,0 +1,209 @@
+package queues
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DanLavine/willow/internal/helpers"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/constructor"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/outbox"
+	"github.com/DanLavine/willow/pkg/clients/limiter_client/limiterclientfakes"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+	"github.com/DanLavine/willow/testhelpers"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	queuechannels "github.com/DanLavine/willow/internal/willow/brokers/queue_channels"
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+
+	. "github.com/onsi/gomega"
+)
+
+func setupQueueClient(t *testing.T, g *WithT) *queueClientLocal {
+	// setup the limiter client to always pass
+	mockController := gomock.NewController(t)
+	fakeLimiterClient := limiterclientfakes.NewMockLimiterClient(mockController)
+	fakeLimiterClient.EXPECT().QueryOverrides(gomock.Any(), gomock.Any(), gomock.Any()).Return(v1limiter.Overrides{}, nil).AnyTimes()
+	fakeLimiterClient.EXPECT().CreateOverride(gomock.Any(), gomock.Any(), gomock.Any()).Return(&v1limiter.Override{}, nil).AnyTimes()
+	fakeLimiterClient.EXPECT().DeleteOverride(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	fakeLimiterClient.EXPECT().SetCounters(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	queueChannelsConstructor, err := constructor.NewQueueChannelConstructor("memory", testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, outbox.New(zap.NewNop(), fakeLimiterClient))
+	g.Expect(err).ToNot(HaveOccurred())
+	queueChannelsClient := queuechannels.NewLocalQueueChannelsClient(queueChannelsConstructor, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = queueChannelsClient.Execute(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		g.Eventually(done).Should(BeClosed())
+	})
+
+	queueConstructor, err := NewQueueConstructor("memory", fakeLimiterClient)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return NewLocalQueueClient(zap.NewNop(), queueConstructor, queueChannelsClient, "willow rule")
+}
+
+func idleQueue(g *WithT, queueClient *queueClientLocal, idleTTL time.Duration) {
+	queue := &v1willow.Queue{
+		Spec: &v1willow.QueueSpec{
+			DBDefinition: &v1willow.QueueDBDefinition{Name: helpers.PointerOf("test queue")},
+			Properties: &v1willow.QueueProperties{
+				MaxItems: helpers.PointerOf[int64](5),
+				IdleTTL:  helpers.PointerOf(idleTTL),
+			},
+		},
+	}
+	g.Expect(queue.ValidateSpecOnly()).ToNot(HaveOccurred())
+	g.Expect(queueClient.CreateQueue(testhelpers.NewContextWithMiddlewareSetup(), queue)).To(BeNil())
+}
+
+func queueNames(g *WithT, queueClient *queueClientLocal) []string {
+	queues, err := queueClient.ListQueues(testhelpers.NewContextWithMiddlewareSetup())
+	g.Expect(err).To(BeNil())
+
+	names := []string{}
+	for _, queue := range queues {
+		names = append(names, *queue.Spec.DBDefinition.Name)
+	}
+
+	return names
+}
+
+func Test_queueClientLocal_deleteIdleQueues(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It deletes a queue once it has been idle for the IdleTTL", func(t *testing.T) {
+		queueClient := setupQueueClient(t, g)
+		idleQueue(g, queueClient, 20*time.Millisecond)
+
+		queueClient.deleteIdleQueues(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(queueNames(g, queueClient)).To(ConsistOf("test queue"))
+
+		time.Sleep(30 * time.Millisecond)
+		queueClient.deleteIdleQueues(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(queueNames(g, queueClient)).To(BeEmpty())
+	})
+
+	t.Run("It never deletes a queue with an IdleTTL of 0", func(t *testing.T) {
+		queueClient := setupQueueClient(t, g)
+		idleQueue(g, queueClient, 0)
+
+		time.Sleep(30 * time.Millisecond)
+		queueClient.deleteIdleQueues(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(queueNames(g, queueClient)).To(ConsistOf("test queue"))
+	})
+
+	t.Run("It does not delete a queue that has channels with items", func(t *testing.T) {
+		queueClient := setupQueueClient(t, g)
+		idleQueue(g, queueClient, 20*time.Millisecond)
+
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{"one": datatypes.Int(1)},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(`data`),
+					Updateable:      helpers.PointerOf(true),
+					RetryAttempts:   helpers.PointerOf[uint64](0),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		g.Expect(enqueueItem.ValidateSpecOnly()).ToNot(HaveOccurred())
+
+		_, err := queueClient.Enqueue(testhelpers.NewContextWithMiddlewareSetup(), "test queue", enqueueItem)
+		g.Expect(err).To(BeNil())
+
+		time.Sleep(30 * time.Millisecond)
+		queueClient.deleteIdleQueues(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(queueNames(g, queueClient)).To(ConsistOf("test queue"))
+	})
+
+	t.Run("It does not delete a queue while a client is waiting to dequeue", func(t *testing.T) {
+		queueClient := setupQueueClient(t, g)
+		idleQueue(g, queueClient, 20*time.Millisecond)
+
+		ctx, cancel := context.WithCancel(testhelpers.NewContextWithMiddlewareSetup())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _, _, _ = queueClient.Dequeue(ctx, "test queue", &queryassociatedaction.AssociatedActionQuery{})
+		}()
+
+		time.Sleep(30 * time.Millisecond)
+		queueClient.deleteIdleQueues(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(queueNames(g, queueClient)).To(ConsistOf("test queue"))
+
+		// the queue can be deleted once the client stops waiting
+		cancel()
+		g.Eventually(done).Should(BeClosed())
+
+		time.Sleep(30 * time.Millisecond)
+		queueClient.deleteIdleQueues(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(queueNames(g, queueClient)).To(BeEmpty())
+	})
+	t.Run("It does not delete a queue while a client is waiting to dequeue from multiple queues", func(t *testing.T) {
+		queueClient := setupQueueClient(t, g)
+		idleQueue(g, queueClient, 20*time.Millisecond)
+
+		dequeueQueues := &v1willow.DequeueQueues{
+			Queues: []*v1willow.DequeueQueue{
+				{NamePattern: helpers.PointerOf("test *"), ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}},
+			},
+		}
+		g.Expect(dequeueQueues.Validate()).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(testhelpers.NewContextWithMiddlewareSetup())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _, _, _ = queueClient.DequeueQueues(ctx, dequeueQueues)
+		}()
+
+		time.Sleep(30 * time.Millisecond)
+		queueClient.deleteIdleQueues(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(queueNames(g, queueClient)).To(ConsistOf("test queue"))
+
+		// the queue can be deleted once the client stops waiting
+		cancel()
+		g.Eventually(done).Should(BeClosed())
+
+		time.Sleep(30 * time.Millisecond)
+		queueClient.deleteIdleQueues(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(queueNames(g, queueClient)).To(BeEmpty())
+	})
+
+	t.Run("It deletes a queue once a dequeue from multiple queues fails to find all the queues", func(t *testing.T) {
+		queueClient := setupQueueClient(t, g)
+		idleQueue(g, queueClient, 20*time.Millisecond)
+
+		dequeueQueues := &v1willow.DequeueQueues{
+			Queues: []*v1willow.DequeueQueue{
+				{Name: helpers.PointerOf("test queue"), ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}},
+				{Name: helpers.PointerOf("missing queue"), ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}},
+			},
+		}
+		g.Expect(dequeueQueues.Validate()).ToNot(HaveOccurred())
+
+		_, _, _, err := queueClient.DequeueQueues(testhelpers.NewContextWithMiddlewareSetup(), dequeueQueues)
+		g.Expect(err).ToNot(BeNil())
+		g.Expect(err.StatusCode).To(Equal(http.StatusNotFound))
+
+		time.Sleep(30 * time.Millisecond)
+		queueClient.deleteIdleQueues(testhelpers.NewContextWithMiddlewareSetup())
+		g.Expect(queueNames(g, queueClient)).To(BeEmpty())
+	})
+}
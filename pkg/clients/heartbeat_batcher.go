@@ -0,0 +1,182 @@
+package clients
+
+import (
+	"sync"
+	"time"
+)
+
+// HeartbeatBatcher coalesces the heartbeats for any number of entries into a single request per tick. Each entry
+// is heartbeat at least 3 times before its timeout would be reached. When one entry is due, all other entries that
+// are close to being due are sent in the same batch
+type HeartbeatBatcher[T comparable] struct {
+	lock    *sync.Mutex
+	running bool
+	wakeup  chan struct{}
+	entries map[T]*heartbeatEntry
+
+	// sendBatch heartbeats all the entries and returns the entries that successfully heartbeat
+	sendBatch func(batch []T) []T
+
+	// expire is called when an entry fails to heartbeat for its entire timeout
+	expire func(entry T)
+}
+
+type heartbeatEntry struct {
+	// timeout - 10% to account for network delays
+	adjustedTimeout time.Duration
+	// time between each heartbeat
+	interval time.Duration
+
+	lastHeartbeat time.Time
+	nextHeartbeat time.Time
+}
+
+//	PARAMETERS:
+//	- sendBatch - sends a single request for all the entries and returns the entries that successfully heartbeat
+//	- expire - called when an entry has not successfully heartbeat for its entire timeout. The entry is already removed
+//
+//	RETURNS:
+//	- *HeartbeatBatcher - thread safe batcher that can be shared by any number of entries
+//
+// NewHeartbeatBatcher creates a batcher that only runs a background process while it has entries to heartbeat
+func NewHeartbeatBatcher[T comparable](sendBatch func(batch []T) []T, expire func(entry T)) *HeartbeatBatcher[T] {
+	return &HeartbeatBatcher[T]{
+		lock:      new(sync.Mutex),
+		wakeup:    make(chan struct{}, 1),
+		entries:   map[T]*heartbeatEntry{},
+		sendBatch: sendBatch,
+		expire:    expire,
+	}
+}
+
+//	PARAMETERS:
+//	- entry - entry to start heartbeating
+//	- timeout - timeout for the entry on the remote service
+//
+// Add an entry to be heartbeat untill it is removed or expires
+func (hb *HeartbeatBatcher[T]) Add(entry T, timeout time.Duration) {
+	hb.lock.Lock()
+	defer hb.lock.Unlock()
+
+	// set the interval to be ((timeout - 10%) /3). This way we try and heartbeat at least 3 times before a failure occurs
+	now := time.Now()
+	adjustedTimeout := timeout - (timeout / 10)
+	hb.entries[entry] = &heartbeatEntry{
+		adjustedTimeout: adjustedTimeout,
+		interval:        adjustedTimeout / 3,
+		lastHeartbeat:   now,
+		nextHeartbeat:   now.Add(adjustedTimeout / 3),
+	}
+
+	if !hb.running {
+		hb.running = true
+		go hb.run()
+	} else {
+		hb.notify()
+	}
+}
+
+//	PARAMETERS:
+//	- entry - entry to stop heartbeating
+//
+// Remove an entry so it is no longer heartbeat. Entries that are part of an in flight batch can still be reported as sent
+func (hb *HeartbeatBatcher[T]) Remove(entry T) {
+	hb.lock.Lock()
+	defer hb.lock.Unlock()
+
+	delete(hb.entries, entry)
+}
+
+func (hb *HeartbeatBatcher[T]) notify() {
+	select {
+	case hb.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func (hb *HeartbeatBatcher[T]) run() {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		batch, expired, nextWakeup, ok := hb.collect()
+		for _, entry := range expired {
+			hb.expire(entry)
+		}
+
+		if !ok {
+			return
+		}
+
+		if len(batch) != 0 {
+			heartbeated := hb.sendBatch(batch)
+			hb.heartbeated(heartbeated)
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(time.Until(nextWakeup))
+
+		select {
+		case <-timer.C:
+		case <-hb.wakeup:
+		}
+	}
+}
+
+// collect all entries that should be sent in the next batch and remove any expired entries
+func (hb *HeartbeatBatcher[T]) collect() ([]T, []T, time.Time, bool) {
+	hb.lock.Lock()
+	defer hb.lock.Unlock()
+
+	now := time.Now()
+	nextWakeup := now.Add(time.Hour)
+	var batch, expired []T
+
+	for entry, heartbeatEntry := range hb.entries {
+		expiration := heartbeatEntry.lastHeartbeat.Add(heartbeatEntry.adjustedTimeout)
+		if !now.Before(expiration) {
+			delete(hb.entries, entry)
+			expired = append(expired, entry)
+			continue
+		}
+
+		// coalesce any entries that are within half of their interval from being due
+		if !heartbeatEntry.nextHeartbeat.After(now.Add(heartbeatEntry.interval / 2)) {
+			batch = append(batch, entry)
+			heartbeatEntry.nextHeartbeat = now.Add(heartbeatEntry.interval)
+		}
+
+		if heartbeatEntry.nextHeartbeat.Before(nextWakeup) {
+			nextWakeup = heartbeatEntry.nextHeartbeat
+		}
+		if expiration.Before(nextWakeup) {
+			nextWakeup = expiration
+		}
+	}
+
+	// stop running when there is nothing left to heartbeat. Add will start a new process
+	if len(hb.entries) == 0 {
+		hb.running = false
+		return nil, expired, nextWakeup, false
+	}
+
+	return batch, expired, nextWakeup, true
+}
+
+// record the time for all entries that successfully heartbeat
+func (hb *HeartbeatBatcher[T]) heartbeated(entries []T) {
+	hb.lock.Lock()
+	defer hb.lock.Unlock()
+
+	now := time.Now()
+	for _, entry := range entries {
+		if heartbeatEntry, ok := hb.entries[entry]; ok {
+			heartbeatEntry.lastHeartbeat = now
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package clients
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+type testBatches struct {
+	lock    *sync.Mutex
+	batches [][]string
+	expired []string
+}
+
+func newTestBatches() *testBatches {
+	return &testBatches{lock: new(sync.Mutex)}
+}
+
+func (tb *testBatches) sendBatch(succeed bool) func(batch []string) []string {
+	return func(batch []string) []string {
+		tb.lock.Lock()
+		defer tb.lock.Unlock()
+
+		tb.batches = append(tb.batches, batch)
+		if succeed {
+			return batch
+		}
+
+		return nil
+	}
+}
+
+func (tb *testBatches) expire(entry string) {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+
+	tb.expired = append(tb.expired, entry)
+}
+
+func (tb *testBatches) Batches() [][]string {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+
+	return append([][]string{}, tb.batches...)
+}
+
+func (tb *testBatches) Expired() []string {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+
+	return append([]string{}, tb.expired...)
+}
+
+func TestHeartbeatBatcher(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("It sends the heartbeats for all entries in a single batch", func(t *testing.T) {
+		testBatches := newTestBatches()
+		heartbeatBatcher := NewHeartbeatBatcher(testBatches.sendBatch(true), testBatches.expire)
+		defer heartbeatBatcher.Remove("one")
+		defer heartbeatBatcher.Remove("two")
+		defer heartbeatBatcher.Remove("three")
+
+		heartbeatBatcher.Add("one", 300*time.Millisecond)
+		heartbeatBatcher.Add("two", 300*time.Millisecond)
+		heartbeatBatcher.Add("three", 300*time.Millisecond)
+
+		g.Eventually(testBatches.Batches).Should(ContainElement(ConsistOf("one", "two", "three")))
+		g.Consistently(testBatches.Expired, 500*time.Millisecond).Should(BeEmpty())
+	})
+
+	t.Run("It stops sending heartbeats for removed entries", func(t *testing.T) {
+		testBatches := newTestBatches()
+		heartbeatBatcher := NewHeartbeatBatcher(testBatches.sendBatch(true), testBatches.expire)
+		defer heartbeatBatcher.Remove("one")
+
+		heartbeatBatcher.Add("one", 300*time.Millisecond)
+		heartbeatBatcher.Add("two", 300*time.Millisecond)
+		g.Eventually(testBatches.Batches).Should(ContainElement(ConsistOf("one", "two")))
+
+		heartbeatBatcher.Remove("two")
+		g.Eventually(testBatches.Batches).Should(ContainElement(ConsistOf("one")))
+		g.Expect(testBatches.Expired()).To(BeEmpty())
+	})
+
+	t.Run("It expires entries that do not successfully heartbeat for their timeout", func(t *testing.T) {
+		testBatches := newTestBatches()
+		heartbeatBatcher := NewHeartbeatBatcher(testBatches.sendBatch(false), testBatches.expire)
+
+		heartbeatBatcher.Add("one", 200*time.Millisecond)
+
+		g.Eventually(testBatches.Expired).Should(ConsistOf("one"))
+		g.Expect(len(testBatches.Batches())).To(BeNumerically(">=", 2))
+	})
+
+	t.Run("It can add entries again after all entries were removed", func(t *testing.T) {
+		testBatches := newTestBatches()
+		heartbeatBatcher := NewHeartbeatBatcher(testBatches.sendBatch(false), testBatches.expire)
+
+		heartbeatBatcher.Add("one", 100*time.Millisecond)
+		g.Eventually(testBatches.Expired).Should(ConsistOf("one"))
+
+		heartbeatBatcher.Add("two", 100*time.Millisecond)
+		g.Eventually(testBatches.Expired).Should(ConsistOf("one", "two"))
+	})
+}
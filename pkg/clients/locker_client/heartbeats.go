@@ -0,0 +1,87 @@
+package lockerclient
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/DanLavine/willow/pkg/models/api"
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+	v1locker "github.com/DanLavine/willow/pkg/models/api/locker/v1"
+)
+
+//	PARAMETERS:
+//	- locks - all locks that are due for a heartbeat
+//
+//	RETURNS:
+//	- []*lock - locks that successfully heartbeat
+//
+// heartbeatLocks sends a single request for all locks obtained from this client that need to heartbeat
+func (lc *LockClient) heartbeatLocks(locks []*lock) []*lock {
+	lockHeartbeats := &v1locker.LockHeartbeats{Heartbeats: make([]*v1locker.LockHeartbeat, 0, len(locks))}
+	for _, lock := range locks {
+		lockHeartbeats.Heartbeats = append(lockHeartbeats.Heartbeats, &v1locker.LockHeartbeat{LockID: lock.lockID, SessionID: lock.sessionID})
+	}
+
+	forwardErrors := func(err error) []*lock {
+		for _, lock := range locks {
+			lock.forwardHeartbeatError(err)
+		}
+
+		return nil
+	}
+
+	lockHeartbeatsData, err := api.ModelEncodeRequest(lockHeartbeats)
+	if err != nil {
+		return forwardErrors(fmt.Errorf("failed to encode heartbeat request: %w", err))
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/v1/locks/heartbeat", lc.url), bytes.NewBuffer(lockHeartbeatsData))
+	if err != nil {
+		return forwardErrors(fmt.Errorf("failed to setup heartbeat request: %w", err))
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	resp, err := lc.client.Do(req)
+	if err != nil {
+		return forwardErrors(fmt.Errorf("failed to heartbeat: %w", err))
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		lockHeartbeatsResponse := &v1locker.LockHeartbeatsResponse{}
+		if err := api.ModelDecodeResponse(resp, lockHeartbeatsResponse); err != nil {
+			return forwardErrors(err)
+		}
+
+		if len(lockHeartbeatsResponse.Results) != len(locks) {
+			return forwardErrors(fmt.Errorf("received %d heartbeat results for %d locks", len(lockHeartbeatsResponse.Results), len(locks)))
+		}
+
+		heartbeated := make([]*lock, 0, len(locks))
+		for index, lockHeartbeatResult := range lockHeartbeatsResponse.Results {
+			lock := locks[index]
+
+			// the lock or session id is no longer valid, so release the lock
+			if lockHeartbeatResult.Error != nil {
+				lock.forwardHeartbeatError(lockHeartbeatResult.Error)
+				lock.lost()
+				continue
+			}
+
+			heartbeated = append(heartbeated, lock)
+		}
+
+		return heartbeated
+	case http.StatusBadRequest:
+		// there was an error with the request body
+		apiError := &errors.Error{}
+		if err := api.ModelDecodeResponse(resp, apiError); err != nil {
+			return forwardErrors(err)
+		}
+
+		return forwardErrors(apiError)
+	default:
+		return forwardErrors(fmt.Errorf("received an unexpected status code: %d", resp.StatusCode))
+	}
+}
@@ -41,16 +41,17 @@ type lock struct {
 	done     chan struct{}
 
 	// used to ensure only 1 delete operation proccesses
-	heartbeating chan struct{}
-	released     *atomic.Bool
+	released *atomic.Bool
 
 	// remote server client configuration
-	url    string
-	client *http.Client
+	url        string
+	client     *http.Client
+	heartbeats *clients.HeartbeatBatcher[*lock]
 
 	// record error callback if configured. Can be used to monitor any unexpeded errors
 	// with the remote service and record them
-	releaseLockCallback func() // cleans up the tree in the locker client
+	heartbeatErrorCallback func(err error)
+	releaseLockCallback    func() // cleans up the tree in the locker client
 
 	// Lock unique IDs created by the service
 	lockID    string
@@ -60,96 +61,27 @@ type lock struct {
 	timeout time.Duration
 }
 
-func newLock(lockResponse *v1locker.Lock, url string, client *http.Client, heartbeatErrorCallback func(err error), releaseLockCallback func()) *lock {
+func newLock(lockResponse *v1locker.Lock, url string, client *http.Client, heartbeats *clients.HeartbeatBatcher[*lock], heartbeatErrorCallback func(err error), releaseLockCallback func()) *lock {
 	lock := &lock{
 		doneOnce: new(sync.Once),
 		done:     make(chan struct{}),
 
-		heartbeating: make(chan struct{}),
-		released:     new(atomic.Bool),
+		released: new(atomic.Bool),
 
-		client: client,
-		url:    url,
+		client:     client,
+		url:        url,
+		heartbeats: heartbeats,
 
-		releaseLockCallback: releaseLockCallback,
+		heartbeatErrorCallback: heartbeatErrorCallback,
+		releaseLockCallback:    releaseLockCallback,
 
 		lockID:    lockResponse.State.LockID,
 		sessionID: lockResponse.State.SessionID,
 		timeout:   *lockResponse.Spec.Properties.Timeout,
 	}
 
-	go func() {
-		defer func() {
-			lock.stop()
-			lock.released.Store(true)
-			close(lock.heartbeating)
-		}()
-
-		// set ticker to be ((timeout - 10%) /3). This way we try and heartbeat at least 3 times before a failure occurs
-		adjustedTimeout := *lockResponse.Spec.Properties.Timeout - (*lockResponse.Spec.Properties.Timeout / 10)
-		ticker := time.NewTicker(adjustedTimeout / 3)
-		timeoutTicker := time.NewTicker(lock.timeout)
-
-		for {
-			select {
-			case <-lock.done:
-				// release was called. can just escape
-				return
-			case <-timeoutTicker.C:
-				// the timeout was reached. can just escape
-				return
-			case <-ticker.C:
-				lockClaimData, err := api.ModelEncodeRequest(&v1locker.LockClaim{SessionID: lockResponse.State.SessionID})
-				if err != nil {
-					heartbeatErrorCallback(fmt.Errorf("failed to encode heartbeat request: %w", err))
-					return
-				}
-
-				req, err := http.NewRequest("POST", fmt.Sprintf("%s/v1/locks/%s/heartbeat", url, lockResponse.State.LockID), bytes.NewBuffer(lockClaimData))
-				if err != nil {
-					if heartbeatErrorCallback != nil {
-						heartbeatErrorCallback(fmt.Errorf("failed to setup heartbeat request: %w", err))
-					}
-					return
-				}
-				req.Header.Add("Content-Type", "application/json")
-
-				resp, err := client.Do(req)
-				if err != nil {
-					if heartbeatErrorCallback != nil {
-						heartbeatErrorCallback(fmt.Errorf("failed to heartbeat: %w", err))
-					}
-
-					continue
-				}
-
-				switch resp.StatusCode {
-				case http.StatusOK:
-					// this is the success case and the heartbeat passed
-					timeoutTicker.Reset(lock.timeout)
-				case http.StatusConflict, http.StatusBadRequest:
-					// there was an error with the request body or session id
-					apiError := &errors.Error{}
-					if err := api.ModelDecodeResponse(resp, apiError); err != nil {
-						if heartbeatErrorCallback != nil {
-							heartbeatErrorCallback(err)
-						}
-					} else {
-						if heartbeatErrorCallback != nil {
-							heartbeatErrorCallback(apiError)
-						}
-					}
-
-					// release the lock
-					return
-				default:
-					if heartbeatErrorCallback != nil {
-						heartbeatErrorCallback(fmt.Errorf("received an unexpected status code: %d", resp.StatusCode))
-					}
-				}
-			}
-		}
-	}()
+	// heartbeats for all locks from the same client are sent together
+	heartbeats.Add(lock, lock.timeout)
 
 	return lock
 }
@@ -168,9 +100,6 @@ func (l *lock) Release(ctx context.Context) error {
 		// stop heartbeating
 		l.stop()
 
-		// wait for the heartbeat process to stop
-		<-l.heartbeating
-
 		// Delete Lock request
 		lockClaimData, err := api.ModelEncodeRequest(&v1locker.LockClaim{SessionID: l.sessionID})
 		if err != nil {
@@ -216,9 +145,32 @@ func (l *lock) Done() <-chan struct{} {
 	return l.done
 }
 
+// lost is called when the lock could not heartbeat and is no longer held by the client
+func (l *lock) lost() {
+	l.released.Store(true)
+	l.stop()
+}
+
+// report a heartbeat error only if the lock has not already been released
+func (l *lock) forwardHeartbeatError(err error) {
+	if l.heartbeatErrorCallback == nil {
+		return
+	}
+
+	select {
+	case <-l.done:
+		// nothing to do here. race between release and heartbeat
+	default:
+		l.heartbeatErrorCallback(err)
+	}
+}
+
 // close the release chan only once
 func (l *lock) stop() {
 	l.doneOnce.Do(func() {
+		// stop heartbeating
+		l.heartbeats.Remove(l)
+
 		// remove the item from the client's BTree
 		l.releaseLockCallback()
 
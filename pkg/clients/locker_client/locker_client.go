@@ -55,6 +55,9 @@ type LockClient struct {
 
 	// each item in the locks tree's value is a lock
 	locks btreeassociated.BTreeAssociated
+
+	// sends the heartbeats for all obtained locks in a single request
+	heartbeats *clients.HeartbeatBatcher[*lock]
 }
 
 //	PARAMS
@@ -77,6 +80,7 @@ func NewLockClient(cfg *clients.Config) (*LockClient, error) {
 		client: httpClient,
 		locks:  btreeassociated.NewThreadSafe(),
 	}
+	lockerClient.heartbeats = clients.NewHeartbeatBatcher(lockerClient.heartbeatLocks, (*lock).lost)
 
 	return lockerClient, nil
 }
@@ -182,9 +186,9 @@ func (lc *LockClient) ObtainLock(ctx context.Context, lockRequest *v1locker.Lock
 					errCallback := func(err error) {
 						heartbeatErrorCallback(lockRequest.Spec.DBDefinition.KeyValues, err)
 					}
-					returnLock = newLock(createLockResponse, lc.url, lc.client, errCallback, lostLockWrapper)
+					returnLock = newLock(createLockResponse, lc.url, lc.client, lc.heartbeats, errCallback, lostLockWrapper)
 				} else {
-					returnLock = newLock(createLockResponse, lc.url, lc.client, nil, lostLockWrapper)
+					returnLock = newLock(createLockResponse, lc.url, lc.client, lc.heartbeats, nil, lostLockWrapper)
 				}
 
 				return returnLock
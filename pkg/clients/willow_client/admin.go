@@ -0,0 +1,145 @@
+package willowclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/DanLavine/willow/pkg/clients"
+	"github.com/DanLavine/willow/pkg/models/api"
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+)
+
+//	PARAMETERS:
+//	- exportQuery - which queues to export
+//
+//	RETURNS:
+//	- *v1willow.QueueExport - versioned document with the properties, channels and items for each queue
+//	- error - error exporting the queues
+//
+// ExportQueues dumps the full state of one or all queues, that can be provided to ImportQueues on another Willow service
+func (wc *WillowClient) ExportQueues(ctx context.Context, exportQuery *v1willow.ExportQuery) (*v1willow.QueueExport, error) {
+	// encode the request
+	data, err := api.ModelEncodeRequest(exportQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	// setup and make the request
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/v1/admin/export", wc.url), bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	clients.AddHeadersFromContext(req, ctx)
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse the response
+	switch resp.StatusCode {
+	case http.StatusOK:
+		queueExport := &v1willow.QueueExport{}
+		if err := api.ModelDecodeResponse(resp, queueExport); err != nil {
+			return nil, err
+		}
+
+		return queueExport, nil
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError:
+		apiError := &errors.Error{}
+		if err := api.ModelDecodeResponse(resp, apiError); err != nil {
+			return nil, err
+		}
+
+		return nil, apiError
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+//	PARAMETERS:
+//	- queueExport - document returned from ExportQueues
+//
+//	RETURNS:
+//	- error - error importing the queues
+//
+// ImportQueues recreates all queues and their items from an export. This will return an error if any of the
+// queue names already exist
+func (wc *WillowClient) ImportQueues(ctx context.Context, queueExport *v1willow.QueueExport) error {
+	// encode the request
+	data, err := api.ModelEncodeRequest(queueExport)
+	if err != nil {
+		return err
+	}
+
+	// setup and make the request
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/v1/admin/import", wc.url), bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	clients.AddHeadersFromContext(req, ctx)
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	// parse the response
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return nil
+	case http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError:
+		apiError := &errors.Error{}
+		if err := api.ModelDecodeResponse(resp, apiError); err != nil {
+			return err
+		}
+
+		return apiError
+	default:
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+//	RETURNS:
+//	- *v1willow.LimiterReconcile - every Limiter counter that was corrected
+//	- error - error reconciling the counters. A conflict is returned when Limiter updates are still being retried
+//
+// ReconcileLimiter compares the Limiter's counters with the items Willow holds and sets any that drifted. Counters
+// for channels that no longer exist are removed
+func (wc *WillowClient) ReconcileLimiter(ctx context.Context) (*v1willow.LimiterReconcile, error) {
+	// setup and make the request
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/v1/admin/reconcile", wc.url), nil)
+	if err != nil {
+		return nil, err
+	}
+	clients.AddHeadersFromContext(req, ctx)
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse the response
+	switch resp.StatusCode {
+	case http.StatusOK:
+		limiterReconcile := &v1willow.LimiterReconcile{}
+		if err := api.ModelDecodeResponse(resp, limiterReconcile); err != nil {
+			return nil, err
+		}
+
+		return limiterReconcile, nil
+	case http.StatusConflict, http.StatusInternalServerError:
+		apiError := &errors.Error{}
+		if err := api.ModelDecodeResponse(resp, apiError); err != nil {
+			return nil, err
+		}
+
+		return nil, apiError
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
@@ -22,18 +22,71 @@ import (
 //	- headers (optional) - any headers to apply to the request
 //
 //	RETURNS:
+//	- *v1willow.ItemState - state of the item that was created or updated. The ID can be used to cancel the item.
+//	                        When the item's IdempotencyKey was already used, the existing item's state is returned with Duplicate set
 //	- error - error creating the queue
 //
 // EnqueueQueueItem enqueus an item to the proper channel for clients to dequeue and process
-func (wc *WillowClient) EnqueueQueueItem(ctx context.Context, queueName string, item *v1willow.Item) error {
+func (wc *WillowClient) EnqueueQueueItem(ctx context.Context, queueName string, item *v1willow.Item) (*v1willow.ItemState, error) {
 	// encode the request
 	data, err := api.ObjectEncodeRequest(item)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// setup and make the request
 	req, err := http.NewRequest("POST", fmt.Sprintf("%s/v1/queues/%s/channels/items", wc.url, queueName), bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	clients.AddHeadersFromContext(req, ctx)
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse the response
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusOK:
+		// StatusOK is returned when an item with the same IdempotencyKey already exists
+		itemState := &v1willow.ItemState{}
+		if err := api.ModelDecodeResponse(resp, itemState); err != nil {
+			return nil, err
+		}
+
+		return itemState, nil
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError:
+		apiError := &errors.Error{}
+		if err := api.ModelDecodeResponse(resp, apiError); err != nil {
+			return nil, err
+		}
+
+		return nil, apiError
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+//	PARAMETERS:
+//	- queueName - name of the queue the processing item belongs to
+//	- cancel - ID and channel key values of the item to cancel
+//	- headers (optional) - any headers to apply to the request
+//
+//	RETURNS:
+//	- error - error canceling the item
+//
+// CancelQueueItem requests that a processing item is stopped. The client processing the item is informed
+// through the heartbeat responses and the item will not be retried
+func (wc *WillowClient) CancelQueueItem(ctx context.Context, queueName string, cancel *v1willow.Cancel) error {
+	// encode the request
+	data, err := api.ModelEncodeRequest(cancel)
+	if err != nil {
+		return err
+	}
+
+	// setup and make the request
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/v1/queues/%s/channels/items/cancel", wc.url, queueName), bytes.NewBuffer(data))
 	if err != nil {
 		return err
 	}
@@ -46,7 +99,7 @@ func (wc *WillowClient) EnqueueQueueItem(ctx context.Context, queueName string,
 
 	// parse the response
 	switch resp.StatusCode {
-	case http.StatusCreated:
+	case http.StatusOK:
 		return nil
 	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError:
 		apiError := &errors.Error{}
@@ -60,6 +113,108 @@ func (wc *WillowClient) EnqueueQueueItem(ctx context.Context, queueName string,
 	}
 }
 
+//	PARAMETERS:
+//	- queueName - name of the queue the items belong to
+//	- itemDelete - headers of the items and the key values of their channel
+//	- headers (optional) - any headers to apply to the request
+//
+//	RETURNS:
+//	- error - error deleting the items. ErrItemsNotFound if the queue, channel or any items with the headers do not exist
+//
+// DeleteQueueItems removes all enqueued and processing items with the headers. Deleted items are never retried and
+// a client processing a deleted item fails its next heartbeat or ACK
+func (wc *WillowClient) DeleteQueueItems(ctx context.Context, queueName string, itemDelete *v1willow.ItemDelete) error {
+	// encode the request
+	data, err := api.ModelEncodeRequest(itemDelete)
+	if err != nil {
+		return err
+	}
+
+	// setup and make the request
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/v1/queues/%s/channels/items/delete", wc.url, queueName), bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	clients.AddHeadersFromContext(req, ctx)
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	// parse the response
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError:
+		apiError := &errors.Error{}
+		if err := api.ModelDecodeResponse(resp, apiError); err != nil {
+			return err
+		}
+
+		return apiError
+	case http.StatusNotFound:
+		apiError := &errors.Error{}
+		if err := api.ModelDecodeResponse(resp, apiError); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("%w: %s", ErrItemsNotFound, apiError.Message)
+	default:
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+//	PARAMETERS:
+//	- queueName - name of the queue to inspect
+//	- itemQuery - query for the channels and optional item ID to inspect
+//	- headers (optional) - any headers to apply to the request
+//
+//	RETURNS:
+//	- v1willow.Items - all enqueued and processing items that match the query
+//	- error - error querying the items
+//
+// QueryQueueItems inspects the items in a queue's channels, including any progress reported by processing clients
+func (wc *WillowClient) QueryQueueItems(ctx context.Context, queueName string, itemQuery *v1willow.ItemQuery) (v1willow.Items, error) {
+	// encode the request
+	data, err := api.ModelEncodeRequest(itemQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	// setup and make the request
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/v1/queues/%s/channels/items/query", wc.url, queueName), bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	clients.AddHeadersFromContext(req, ctx)
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse the response
+	switch resp.StatusCode {
+	case http.StatusOK:
+		items := v1willow.Items{}
+		if err := api.ModelDecodeResponse(resp, &items); err != nil {
+			return nil, err
+		}
+
+		return items, nil
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError:
+		apiError := &errors.Error{}
+		if err := api.ModelDecodeResponse(resp, apiError); err != nil {
+			return nil, err
+		}
+
+		return nil, apiError
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
 //	PARAMETERS:
 //	- cancelContext - context to cancel the dequeue operation if nothing has been received
 //	- queueName - name of the queue to dequeue from
@@ -98,7 +253,173 @@ func (wc *WillowClient) DequeueQueueItem(ctx context.Context, queueName string,
 			return nil, err
 		}
 
-		return newItem(wc.url, wc.client, queueName, dequeueItem), nil
+		return newItem(wc.url, wc.client, wc.heartbeats, queueName, dequeueItem), nil
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError:
+		apiError := &errors.Error{}
+		if err := api.ModelDecodeResponse(resp, apiError); err != nil {
+			return nil, err
+		}
+
+		return nil, apiError
+	case http.StatusGone:
+		apiError := &errors.Error{}
+		if err := api.ModelDecodeResponse(resp, apiError); err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%w: %s", ErrDeleted, apiError.Message)
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+//	PARAMETERS:
+//	- cancelContext - context to cancel the dequeue operation if nothing has been received
+//	- dequeueQueues - queue names or name patterns with the channel queries to dequeue from
+//	- headers (optional) - any headers to apply to the request
+//
+//	RETURNS:
+//	- item - item that will automatically be setup to heartbeat as long as the client is processing
+//	- error - error dequeuing an item
+//
+// DequeueQueueItems retrieves the first available item from any of the queues that match the request. The
+// queue the item was dequeued from is recorded on the item's State.QueueName
+func (wc *WillowClient) DequeueQueueItems(ctx context.Context, dequeueQueues *v1willow.DequeueQueues) (*Item, error) {
+	// encode the request
+	data, err := api.ModelEncodeRequest(dequeueQueues)
+	if err != nil {
+		return nil, err
+	}
+
+	// setup and make the request
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/v1/items/dequeue", wc.url), bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	clients.AddHeadersFromContext(req, ctx)
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse the response
+	switch resp.StatusCode {
+	case http.StatusOK:
+		dequeueItem := &v1willow.Item{}
+		if err := api.ModelDecodeResponse(resp, dequeueItem); err != nil {
+			return nil, err
+		}
+
+		return newItem(wc.url, wc.client, wc.heartbeats, dequeueItem.State.QueueName, dequeueItem), nil
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError:
+		apiError := &errors.Error{}
+		if err := api.ModelDecodeResponse(resp, apiError); err != nil {
+			return nil, err
+		}
+
+		return nil, apiError
+	case http.StatusGone:
+		apiError := &errors.Error{}
+		if err := api.ModelDecodeResponse(resp, apiError); err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%w: %s", ErrDeleted, apiError.Message)
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+//	PARAMETERS:
+//	- queueName - name of the queue to inspect
+//	- query - query to select the queue's channels
+//	- headers (optional) - any headers to apply to the request
+//
+//	RETURNS:
+//	- v1willow.Channels - all channels that match the query
+//	- error - error querying the channels
+//
+// QueryQueueChannels inspects a queue's channels, including each channel's fair share Weight and Deficit
+func (wc *WillowClient) QueryQueueChannels(ctx context.Context, queueName string, query *queryassociatedaction.AssociatedActionQuery) (v1willow.Channels, error) {
+	// encode the request
+	data, err := api.ModelEncodeRequest(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// setup and make the request
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/v1/queues/%s/channels", wc.url, queueName), bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	clients.AddHeadersFromContext(req, ctx)
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse the response
+	switch resp.StatusCode {
+	case http.StatusOK:
+		channels := v1willow.Channels{}
+		if err := api.ModelDecodeResponse(resp, &channels); err != nil {
+			return nil, err
+		}
+
+		return channels, nil
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError:
+		apiError := &errors.Error{}
+		if err := api.ModelDecodeResponse(resp, apiError); err != nil {
+			return nil, err
+		}
+
+		return nil, apiError
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+//	PARAMETERS:
+//	- queueName - name of the queue to inspect
+//	- query - query to select the queue's channels
+//	- headers (optional) - any headers to apply to the request
+//
+//	RETURNS:
+//	- v1willow.ChannelDiagnoses - diagnosis for all channels that match the query. Empty when no channels match
+//	- error - error diagnosing the channels
+//
+// DiagnoseQueueChannels explains why a dequeue with the same query is not receiving any items. Each channel reports
+// the number of enqueued items and any Limiter Rules or Overrides currently blocking the channel
+func (wc *WillowClient) DiagnoseQueueChannels(ctx context.Context, queueName string, query *queryassociatedaction.AssociatedActionQuery) (v1willow.ChannelDiagnoses, error) {
+	// encode the request
+	data, err := api.ModelEncodeRequest(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// setup and make the request
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/v1/queues/%s/channels/diagnose", wc.url, queueName), bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	clients.AddHeadersFromContext(req, ctx)
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse the response
+	switch resp.StatusCode {
+	case http.StatusOK:
+		channelDiagnoses := v1willow.ChannelDiagnoses{}
+		if err := api.ModelDecodeResponse(resp, &channelDiagnoses); err != nil {
+			return nil, err
+		}
+
+		return channelDiagnoses, nil
 	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError:
 		apiError := &errors.Error{}
 		if err := api.ModelDecodeResponse(resp, apiError); err != nil {
@@ -0,0 +1,101 @@
+package willowclient
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// Codec encodes values into an item's Data and decodes them when the item is dequeued
+type Codec[T any] interface {
+	// ContentType is recorded in the item's Content-Type header, so consumers know how the Data was encoded
+	ContentType() string
+
+	// Encode a value into an item's Data
+	Encode(value T) ([]byte, error)
+
+	// Decode an item's Data into a value
+	Decode(data []byte) (T, error)
+}
+
+// JSONCodec encodes values with encoding/json
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) ContentType() string { return "application/json" }
+
+func (JSONCodec[T]) Encode(value T) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+
+	return value, err
+}
+
+// GobCodec encodes values with encoding/gob. Each item is encoded on its own, so the type information is
+// included with every item
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) ContentType() string { return "application/x-gob" }
+
+func (GobCodec[T]) Encode(value T) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	if err := gob.NewEncoder(buffer).Encode(value); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (GobCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+
+	return value, err
+}
+
+// BinaryMessage is implemented by the pointer of any type that can encode itself into a binary format. This
+// matches the Marshal and Unmarshal methods generated for protobuf messages by gogo/protobuf or vtprotobuf
+type BinaryMessage[T any] interface {
+	*T
+
+	Marshal() ([]byte, error)
+	Unmarshal(data []byte) error
+}
+
+// BinaryCodec encodes values with their own Marshal and Unmarshal methods
+type BinaryCodec[T any, PT BinaryMessage[T]] struct{}
+
+func (BinaryCodec[T, PT]) ContentType() string { return "application/octet-stream" }
+
+func (BinaryCodec[T, PT]) Encode(value T) ([]byte, error) {
+	return PT(&value).Marshal()
+}
+
+func (BinaryCodec[T, PT]) Decode(data []byte) (T, error) {
+	var value T
+	err := PT(&value).Unmarshal(data)
+
+	return value, err
+}
+
+// DecodeError is returned when a dequeued item's Data cannot be decoded. The item has already been ACKed as a
+// failure with the error as the reason
+type DecodeError struct {
+	// ContentType of the codec that failed to decode the item
+	ContentType string
+
+	// Err from the codec
+	Err error
+}
+
+func (decodeError *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode item with the '%s' codec: %s", decodeError.ContentType, decodeError.Err.Error())
+}
+
+func (decodeError *DecodeError) Unwrap() error {
+	return decodeError.Err
+}
@@ -0,0 +1,291 @@
+package willowclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+)
+
+// ErrItemCanceled is the error handlers receive from their context when a producer canceled the item. Canceled
+// items are always ACKed as a failure
+var ErrItemCanceled = fmt.Errorf("item was canceled")
+
+// ErrItemLost is the error handlers receive from their context when the item failed to heartbeat and is no longer
+// processing on the service
+var ErrItemLost = fmt.Errorf("item failed to heartbeat")
+
+// dequeueRetryDelay is how long a consumer waits before dequeuing again after a dequeue request failed
+var dequeueRetryDelay = time.Second
+
+// ConsumerConfig defines how a Consumer processes items
+type ConsumerConfig struct {
+	// QueueName to dequeue items from
+	QueueName string
+
+	// ChannelQuery selects which of the queue's channels to dequeue items from
+	ChannelQuery *queryassociatedaction.AssociatedActionQuery
+
+	// Concurrency is the number of items that can be processed at once. Defaults to 1
+	Concurrency int
+
+	// Handler processes a single item. Returning nil ACKs the item as passed, any error or panic ACKs the item
+	// as failed with the error recorded as the reason. The context is canceled when the item is canceled, fails to heartbeat, or the DrainTimeout is reached
+	Handler func(ctx context.Context, item *Item) error
+
+	// RateLimit is an optional maximum number of items dequeued per second across all workers
+	RateLimit float64
+
+	// DrainTimeout is an optional maximum time to wait for processing items on shutdown before canceling the
+	// handlers' contexts. When 0, the consumer waits for every handler to return
+	DrainTimeout time.Duration
+
+	// ErrorCallback is an optional callback for any dequeue, heartbeat or ACK errors. Mainly used for logging and
+	// can be called from multiple goroutines at once
+	ErrorCallback func(err error)
+}
+
+//	RETURNS:
+//	- error - error describing any missing or invalid fields
+//
+// Validate ensures the ConsumerConfig has all required fields set
+func (cfg *ConsumerConfig) Validate() error {
+	if cfg.QueueName == "" {
+		return fmt.Errorf("ConsumerConfig.QueueName cannot be empty")
+	}
+
+	if cfg.ChannelQuery == nil {
+		return fmt.Errorf("ConsumerConfig.ChannelQuery cannot be nil")
+	} else if err := cfg.ChannelQuery.Validate(); err != nil {
+		return fmt.Errorf("ConsumerConfig.ChannelQuery is invalid: %w", err)
+	}
+
+	if cfg.Concurrency < 0 {
+		return fmt.Errorf("ConsumerConfig.Concurrency cannot be negative")
+	}
+
+	if cfg.Handler == nil {
+		return fmt.Errorf("ConsumerConfig.Handler cannot be nil")
+	}
+
+	if cfg.RateLimit < 0 {
+		return fmt.Errorf("ConsumerConfig.RateLimit cannot be negative")
+	}
+
+	if cfg.DrainTimeout < 0 {
+		return fmt.Errorf("ConsumerConfig.DrainTimeout cannot be negative")
+	}
+
+	return nil
+}
+
+// Consumer runs a pool of workers that dequeue items, process them with the configured handler and ACK the results
+type Consumer struct {
+	client WillowServiceClient
+	cfg    *ConsumerConfig
+
+	// shared across all workers when rate limiting is configured
+	rateLimit *time.Ticker
+}
+
+//	PARAMETERS:
+//	- client - client used to dequeue the items
+//	- cfg - configuration for how to process the items
+//
+//	RETURNS:
+//	- *Consumer - consumer that can be started with Run
+//	- error - error validating the configuration
+//
+// NewConsumer creates a new worker-pool consumer for a single queue
+func NewConsumer(client WillowServiceClient, cfg *ConsumerConfig) (*Consumer, error) {
+	if client == nil {
+		return nil, fmt.Errorf("client cannot be nil")
+	}
+
+	if cfg == nil {
+		return nil, fmt.Errorf("cfg cannot be nil")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Concurrency == 0 {
+		cfg.Concurrency = 1
+	}
+
+	return &Consumer{
+		client: client,
+		cfg:    cfg,
+	}, nil
+}
+
+//	PARAMETERS:
+//	- ctx - context to stop dequeuing new items. Once closed, the consumer drains any items that are processing
+//
+//	RETURNS:
+//	- error - ErrDeleted if the queue or channel was deleted. Otherwise nil after a graceful drain
+//
+// Run blocks while processing items until the context is closed or the queue is deleted
+func (c *Consumer) Run(ctx context.Context) error {
+	if c.cfg.RateLimit > 0 {
+		c.rateLimit = time.NewTicker(time.Duration(float64(time.Second) / c.cfg.RateLimit))
+		defer c.rateLimit.Stop()
+	}
+
+	// handlers are not canceled with the Run context so they can drain gracefully
+	dequeueCtx, stopDequeuing := context.WithCancel(ctx)
+	defer stopDequeuing()
+	handlerCtx, cancelHandlers := context.WithCancel(context.Background())
+	defer cancelHandlers()
+
+	var runErr error
+	runErrOnce := new(sync.Once)
+
+	workers := new(sync.WaitGroup)
+	for i := 0; i < c.cfg.Concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+
+			if err := c.work(dequeueCtx, handlerCtx); err != nil {
+				// the queue is gone, so every worker should stop
+				runErrOnce.Do(func() { runErr = err })
+				stopDequeuing()
+			}
+		}()
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		workers.Wait()
+	}()
+
+	select {
+	case <-drained:
+		return runErr
+	case <-dequeueCtx.Done():
+	}
+
+	if c.cfg.DrainTimeout != 0 {
+		timer := time.NewTimer(c.cfg.DrainTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-drained:
+			return runErr
+		case <-timer.C:
+			cancelHandlers()
+		}
+	}
+
+	<-drained
+	return runErr
+}
+
+// work dequeues and processes items untill the dequeue context is closed
+func (c *Consumer) work(dequeueCtx, handlerCtx context.Context) error {
+	for {
+		if c.rateLimit != nil {
+			select {
+			case <-dequeueCtx.Done():
+				return nil
+			case <-c.rateLimit.C:
+			}
+		}
+
+		item, err := c.client.DequeueQueueItem(dequeueCtx, c.cfg.QueueName, c.cfg.ChannelQuery)
+		if err != nil {
+			select {
+			case <-dequeueCtx.Done():
+				return nil
+			default:
+			}
+
+			if errors.Is(err, ErrDeleted) {
+				return err
+			}
+
+			c.forwardError(fmt.Errorf("failed to dequeue an item: %w", err))
+
+			select {
+			case <-dequeueCtx.Done():
+				return nil
+			case <-time.After(dequeueRetryDelay):
+				continue
+			}
+		}
+
+		c.process(handlerCtx, item)
+	}
+}
+
+// process a single item and ACK the result
+func (c *Consumer) process(handlerCtx context.Context, item *Item) {
+	item.SetHeartbeatErrorCallback(c.forwardError)
+
+	ctx, cancel := context.WithCancelCause(handlerCtx)
+	defer cancel(nil)
+
+	// cancel the handler when the item can no longer be processed
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-item.Canceled():
+			cancel(ErrItemCanceled)
+		case <-item.Done():
+			cancel(ErrItemLost)
+		}
+	}()
+
+	err := c.handle(ctx, item)
+
+	select {
+	case <-item.Done():
+		// item is no longer processing, so there is nothing to ACK
+		c.forwardError(fmt.Errorf("item failed to heartbeat before the handler finished"))
+		return
+	default:
+	}
+
+	// canceled items are always reported as a failure
+	select {
+	case <-item.Canceled():
+		if err == nil {
+			err = ErrItemCanceled
+		}
+	default:
+	}
+
+	var ackErr error
+	if err == nil {
+		ackErr = item.ACK(context.Background(), true)
+	} else {
+		ackErr = item.ACKFailure(context.Background(), err.Error())
+	}
+
+	if ackErr != nil {
+		c.forwardError(fmt.Errorf("failed to ACK the item: %w", ackErr))
+	}
+}
+
+// handle calls the configured handler and records any panics as an error
+func (c *Consumer) handle(ctx context.Context, item *Item) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+
+	return c.cfg.Handler(ctx, item)
+}
+
+func (c *Consumer) forwardError(err error) {
+	if c.cfg.ErrorCallback != nil {
+		c.cfg.ErrorCallback(err)
+	}
+}
@@ -0,0 +1,327 @@
+package willowclient_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DanLavine/willow/internal/helpers"
+	"github.com/DanLavine/willow/pkg/clients"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+	"github.com/DanLavine/willow/testhelpers/testwillow"
+
+	willowclient "github.com/DanLavine/willow/pkg/clients/willow_client"
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+
+	. "github.com/onsi/gomega"
+)
+
+func setupQueue(t *testing.T, g *WithT, retryAttempts uint64, itemCount int) *willowclient.WillowClient {
+	server := testwillow.Start(t, g)
+
+	willowClient, err := willowclient.NewWillowClient(&clients.Config{URL: server.URL})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	createQueue := &v1willow.Queue{
+		Spec: &v1willow.QueueSpec{
+			DBDefinition: &v1willow.QueueDBDefinition{
+				Name: helpers.PointerOf("test queue"),
+			},
+			Properties: &v1willow.QueueProperties{
+				MaxItems: helpers.PointerOf[int64](100),
+			},
+		},
+	}
+	g.Expect(willowClient.CreateQueue(context.Background(), createQueue)).ToNot(HaveOccurred())
+
+	for i := 0; i < itemCount; i++ {
+		enqueueItem := &v1willow.Item{
+			Spec: &v1willow.ItemSpec{
+				DBDefinition: &v1willow.ItemDBDefinition{
+					KeyValues: datatypes.KeyValues{
+						"one": datatypes.Int(i),
+					},
+				},
+				Properties: &v1willow.ItemProperties{
+					Data:            []byte(fmt.Sprintf("item %d", i)),
+					Updateable:      helpers.PointerOf(false),
+					RetryAttempts:   helpers.PointerOf(retryAttempts),
+					RetryPosition:   helpers.PointerOf("front"),
+					TimeoutDuration: helpers.PointerOf(time.Second),
+				},
+			},
+		}
+		_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", enqueueItem)
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	return willowClient
+}
+
+func queuedItems(g *WithT, willowClient *willowclient.WillowClient) func() int {
+	return func() int {
+		items, err := willowClient.QueryQueueItems(context.Background(), "test queue", &v1willow.ItemQuery{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return len(items)
+	}
+}
+
+func TestConsumer_New(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	willowClient, err := willowclient.NewWillowClient(&clients.Config{URL: "http://127.0.0.1:8080"})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	t.Run("It returns an error if the config is not valid", func(t *testing.T) {
+		consumer, err := willowclient.NewConsumer(willowClient, &willowclient.ConsumerConfig{QueueName: "test queue"})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(Equal("ConsumerConfig.ChannelQuery cannot be nil"))
+		g.Expect(consumer).To(BeNil())
+	})
+
+	t.Run("It returns an error if the handler is not set", func(t *testing.T) {
+		consumer, err := willowclient.NewConsumer(willowClient, &willowclient.ConsumerConfig{QueueName: "test queue", ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(Equal("ConsumerConfig.Handler cannot be nil"))
+		g.Expect(consumer).To(BeNil())
+	})
+}
+
+func TestConsumer_Run(t *testing.T) {
+	t.Run("It processes items concurrently and ACKs them as passed", func(t *testing.T) {
+		g := NewWithT(t)
+		willowClient := setupQueue(t, g, 0, 6)
+
+		running := new(atomic.Int64)
+		maxRunning := new(atomic.Int64)
+		lock := new(sync.Mutex)
+		var processed []string
+
+		consumer, err := willowclient.NewConsumer(willowClient, &willowclient.ConsumerConfig{
+			QueueName:    "test queue",
+			ChannelQuery: &queryassociatedaction.AssociatedActionQuery{},
+			Concurrency:  3,
+			Handler: func(ctx context.Context, item *willowclient.Item) error {
+				current := running.Add(1)
+				defer running.Add(-1)
+
+				for {
+					maxSeen := maxRunning.Load()
+					if current <= maxSeen || maxRunning.CompareAndSwap(maxSeen, current) {
+						break
+					}
+				}
+				time.Sleep(100 * time.Millisecond)
+
+				lock.Lock()
+				defer lock.Unlock()
+				processed = append(processed, string(item.Data()))
+
+				return nil
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		runErr := make(chan error)
+		go func() { runErr <- consumer.Run(ctx) }()
+
+		g.Eventually(queuedItems(g, willowClient), 2*time.Second).Should(Equal(0))
+		g.Expect(maxRunning.Load()).To(BeNumerically("<=", 3))
+		g.Expect(maxRunning.Load()).To(BeNumerically(">", 1))
+
+		cancel()
+		g.Eventually(runErr).Should(Receive(BeNil()))
+
+		lock.Lock()
+		defer lock.Unlock()
+		g.Expect(processed).To(ConsistOf("item 0", "item 1", "item 2", "item 3", "item 4", "item 5"))
+	})
+
+	t.Run("It ACKs items as failed when the handler returns an error or panics", func(t *testing.T) {
+		g := NewWithT(t)
+		willowClient := setupQueue(t, g, 2, 1)
+
+		attempts := new(atomic.Int64)
+		errorCallbacks := new(atomic.Int64)
+		consumer, err := willowclient.NewConsumer(willowClient, &willowclient.ConsumerConfig{
+			QueueName:    "test queue",
+			ChannelQuery: &queryassociatedaction.AssociatedActionQuery{},
+			Handler: func(ctx context.Context, item *willowclient.Item) error {
+				switch attempts.Add(1) {
+				case 1:
+					return fmt.Errorf("failed to process")
+				case 2:
+					panic("bad handler")
+				default:
+					return nil
+				}
+			},
+			ErrorCallback: func(err error) {
+				errorCallbacks.Add(1)
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { _ = consumer.Run(ctx) }()
+
+		g.Eventually(queuedItems(g, willowClient), 2*time.Second).Should(Equal(0))
+		g.Expect(attempts.Load()).To(Equal(int64(3)))
+		g.Expect(errorCallbacks.Load()).To(Equal(int64(0)))
+	})
+
+	t.Run("It cancels the handler's context when the item is canceled and does not retry the item", func(t *testing.T) {
+		g := NewWithT(t)
+		willowClient := setupQueue(t, g, 2, 1)
+
+		attempts := new(atomic.Int64)
+		handlerErr := make(chan error, 1)
+		consumer, err := willowclient.NewConsumer(willowClient, &willowclient.ConsumerConfig{
+			QueueName:    "test queue",
+			ChannelQuery: &queryassociatedaction.AssociatedActionQuery{},
+			Handler: func(ctx context.Context, item *willowclient.Item) error {
+				attempts.Add(1)
+				<-ctx.Done()
+				handlerErr <- context.Cause(ctx)
+
+				return nil
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { _ = consumer.Run(ctx) }()
+
+		// wait for the item to start processing
+		var items v1willow.Items
+		g.Eventually(func() bool {
+			items, err = willowClient.QueryQueueItems(context.Background(), "test queue", &v1willow.ItemQuery{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}})
+			g.Expect(err).ToNot(HaveOccurred())
+			return len(items) == 1 && items[0].State.Processing
+		}).Should(BeTrue())
+
+		cancelItem := &v1willow.Cancel{ItemID: items[0].State.ID, KeyValues: datatypes.KeyValues{"one": datatypes.Int(0)}}
+		g.Expect(willowClient.CancelQueueItem(context.Background(), "test queue", cancelItem)).ToNot(HaveOccurred())
+
+		g.Eventually(handlerErr, 2*time.Second).Should(Receive(Equal(willowclient.ErrItemCanceled)))
+		g.Eventually(queuedItems(g, willowClient)).Should(Equal(0))
+		g.Consistently(attempts.Load).Should(Equal(int64(1)))
+	})
+
+	t.Run("It drains the processing items before returning on shutdown", func(t *testing.T) {
+		g := NewWithT(t)
+		willowClient := setupQueue(t, g, 2, 1)
+
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		consumer, err := willowclient.NewConsumer(willowClient, &willowclient.ConsumerConfig{
+			QueueName:    "test queue",
+			ChannelQuery: &queryassociatedaction.AssociatedActionQuery{},
+			Handler: func(ctx context.Context, item *willowclient.Item) error {
+				close(started)
+				<-finish
+				return ctx.Err()
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		runErr := make(chan error)
+		go func() { runErr <- consumer.Run(ctx) }()
+
+		g.Eventually(started).Should(BeClosed())
+		cancel()
+		g.Consistently(runErr).ShouldNot(Receive())
+
+		// the handler's context is still valid so the item passes
+		close(finish)
+		g.Eventually(runErr).Should(Receive(BeNil()))
+		g.Expect(queuedItems(g, willowClient)()).To(Equal(0))
+	})
+
+	t.Run("It cancels the processing handlers after the DrainTimeout", func(t *testing.T) {
+		g := NewWithT(t)
+		willowClient := setupQueue(t, g, 0, 1)
+
+		started := make(chan struct{})
+		consumer, err := willowclient.NewConsumer(willowClient, &willowclient.ConsumerConfig{
+			QueueName:    "test queue",
+			ChannelQuery: &queryassociatedaction.AssociatedActionQuery{},
+			DrainTimeout: 100 * time.Millisecond,
+			Handler: func(ctx context.Context, item *willowclient.Item) error {
+				close(started)
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		runErr := make(chan error)
+		go func() { runErr <- consumer.Run(ctx) }()
+
+		g.Eventually(started).Should(BeClosed())
+		cancel()
+		g.Eventually(runErr).Should(Receive(BeNil()))
+	})
+
+	t.Run("It limits the rate that items are dequeued", func(t *testing.T) {
+		g := NewWithT(t)
+		willowClient := setupQueue(t, g, 0, 3)
+
+		consumer, err := willowclient.NewConsumer(willowClient, &willowclient.ConsumerConfig{
+			QueueName:    "test queue",
+			ChannelQuery: &queryassociatedaction.AssociatedActionQuery{},
+			Concurrency:  3,
+			RateLimit:    5,
+			Handler: func(ctx context.Context, item *willowclient.Item) error {
+				return nil
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		start := time.Now()
+		go func() { _ = consumer.Run(ctx) }()
+
+		g.Eventually(queuedItems(g, willowClient), 2*time.Second, 10*time.Millisecond).Should(Equal(0))
+		g.Expect(time.Since(start)).To(BeNumerically(">=", 500*time.Millisecond))
+	})
+
+	t.Run("It returns ErrDeleted when the queue is deleted", func(t *testing.T) {
+		g := NewWithT(t)
+		willowClient := setupQueue(t, g, 0, 0)
+
+		consumer, err := willowclient.NewConsumer(willowClient, &willowclient.ConsumerConfig{
+			QueueName:    "test queue",
+			ChannelQuery: &queryassociatedaction.AssociatedActionQuery{},
+			Concurrency:  2,
+			Handler: func(ctx context.Context, item *willowclient.Item) error {
+				return nil
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		runErr := make(chan error)
+		go func() { runErr <- consumer.Run(context.Background()) }()
+
+		// wait for the consumer to be waiting on the queue
+		time.Sleep(100 * time.Millisecond)
+		g.Expect(willowClient.DeleteQueue(context.Background(), "test queue")).ToNot(HaveOccurred())
+
+		var err2 error
+		g.Eventually(runErr, 2*time.Second).Should(Receive(&err2))
+		g.Expect(errors.Is(err2, willowclient.ErrDeleted)).To(BeTrue())
+	})
+}
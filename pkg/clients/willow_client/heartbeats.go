@@ -0,0 +1,105 @@
+package willowclient
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/DanLavine/willow/pkg/clients"
+	"github.com/DanLavine/willow/pkg/models/api"
+
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+)
+
+//	PARAMETERS:
+//	- items - all items that are due for a heartbeat
+//
+//	RETURNS:
+//	- []*Item - items that successfully heartbeat
+//
+// heartbeatItems sends a single request for all items dequeued from this client that need to heartbeat
+func (wc *WillowClient) heartbeatItems(items []*Item) []*Item {
+	heartbeats := &v1willow.Heartbeats{Heartbeats: make([]*v1willow.QueueHeartbeat, 0, len(items))}
+	progresses := make([]*v1willow.ItemProgress, 0, len(items))
+	for _, item := range items {
+		progress := item.pendingProgress()
+		progresses = append(progresses, progress)
+
+		heartbeats.Heartbeats = append(heartbeats.Heartbeats, &v1willow.QueueHeartbeat{
+			QueueName: item.queueName,
+			Heartbeat: &v1willow.Heartbeat{
+				ItemID:    item.itemID,
+				KeyValues: item.keyValues,
+				Progress:  progress,
+			},
+		})
+	}
+
+	forwardErrors := func(err error) []*Item {
+		for _, item := range items {
+			item.forwardHeartbeatError(err)
+		}
+
+		return nil
+	}
+
+	data, err := api.ModelEncodeRequest(heartbeats)
+	if err != nil {
+		return forwardErrors(err)
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/v1/items/heartbeat", wc.url), bytes.NewBuffer(data))
+	if err != nil {
+		return forwardErrors(err)
+	}
+	clients.AddHeadersFromContext(req, nil)
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return forwardErrors(err)
+	}
+
+	// parse the response
+	switch resp.StatusCode {
+	case http.StatusOK:
+		heartbeatsResponse := &v1willow.HeartbeatsResponse{}
+		if err := api.ModelDecodeResponse(resp, heartbeatsResponse); err != nil {
+			return forwardErrors(err)
+		}
+
+		if len(heartbeatsResponse.Results) != len(items) {
+			return forwardErrors(fmt.Errorf("received %d heartbeat results for %d items", len(heartbeatsResponse.Results), len(items)))
+		}
+
+		heartbeated := make([]*Item, 0, len(items))
+		for index, heartbeatResult := range heartbeatsResponse.Results {
+			item := items[index]
+
+			// faild to heartbeat for some reason
+			if heartbeatResult.Error != nil {
+				item.forwardHeartbeatError(heartbeatResult.Error)
+				continue
+			}
+
+			heartbeated = append(heartbeated, item)
+			item.sentProgress(progresses[index])
+
+			// a producer or admin requested that the item stops processing
+			if heartbeatResult.Cancel {
+				item.cancel()
+			}
+		}
+
+		return heartbeated
+	case http.StatusBadRequest, http.StatusInternalServerError:
+		apiError := &errors.Error{}
+		if err := api.ModelDecodeResponse(resp, apiError); err != nil {
+			return forwardErrors(err)
+		}
+
+		return forwardErrors(apiError)
+	default:
+		return forwardErrors(fmt.Errorf("unexpected status code while heartbeating: %d", resp.StatusCode))
+	}
+}
@@ -19,10 +19,18 @@ import (
 type WillowItem interface {
 	Done() <-chan struct{}
 
+	Canceled() <-chan struct{}
+
 	SetHeartbeatErrorCallback(callback func(err error))
 
+	ReportProgress(progress *v1willow.ItemProgress) error
+
 	Data() []byte
 
+	Headers() map[string]string
+
+	QueueName() string
+
 	Ack(passed bool) error
 }
 
@@ -30,10 +38,15 @@ type Item struct {
 	doneOnce *sync.Once
 	done     chan struct{}
 
-	url    string
-	client *http.Client
+	canceledOnce *sync.Once
+	canceled     chan struct{}
+
+	url        string
+	client     *http.Client
+	heartbeats *clients.HeartbeatBatcher[*Item]
 
 	data             []byte
+	headers          map[string]string
 	itemID           string
 	keyValues        datatypes.KeyValues
 	queueName        string
@@ -41,104 +54,38 @@ type Item struct {
 
 	heartbeatErrorLock     *sync.RWMutex
 	heartbeatErrorCallback func(err error)
+
+	// progress that has not yet been sent with a heartbeat
+	progressLock *sync.Mutex
+	progress     *v1willow.ItemProgress
 }
 
-func newItem(url string, client *http.Client, queueName string, dequeueItem *v1willow.Item) *Item {
+func newItem(url string, client *http.Client, heartbeats *clients.HeartbeatBatcher[*Item], queueName string, dequeueItem *v1willow.Item) *Item {
 	item := &Item{
 		doneOnce: new(sync.Once),
 		done:     make(chan struct{}),
 
-		url:    url,
-		client: client,
+		canceledOnce: new(sync.Once),
+		canceled:     make(chan struct{}),
+
+		url:        url,
+		client:     client,
+		heartbeats: heartbeats,
 
 		data:             dequeueItem.Spec.Properties.Data,
+		headers:          dequeueItem.Spec.Properties.Headers,
 		itemID:           dequeueItem.State.ID,
 		keyValues:        dequeueItem.Spec.DBDefinition.KeyValues,
 		queueName:        queueName,
 		heartbeatTimeout: *dequeueItem.Spec.Properties.TimeoutDuration,
 
 		heartbeatErrorLock: new(sync.RWMutex),
+
+		progressLock: new(sync.Mutex),
 	}
 
-	go func() {
-		defer item.stop()
-
-		// set ticker to be ((timeout - 10%) /3). This way we try and heartbeat at least 3 times before a failure occurs
-		lastHeartbeat := time.Now()
-		adjustedTimeout := item.heartbeatTimeout - (item.heartbeatTimeout / 10)
-		ticker := time.NewTicker(adjustedTimeout / 3)
-
-		for {
-			select {
-			case <-item.done:
-				// ack was called can just escape
-				return
-			case <-ticker.C:
-				// in this case, we know that we have timed out and don't need to heartbeat anymore
-				if time.Since(lastHeartbeat) >= adjustedTimeout {
-					return
-				}
-
-				data, err := api.ModelEncodeRequest(v1willow.Heartbeat{
-					ItemID:    item.itemID,
-					KeyValues: item.keyValues,
-				})
-				if err != nil {
-					item.forwardError(err)
-					continue
-				}
-
-				req, err := http.NewRequest(
-					"POST",
-					fmt.Sprintf("%s/v1/queues/%s/channels/items/heartbeat", item.url, item.queueName),
-					bytes.NewBuffer(data),
-				)
-				if err != nil {
-					item.forwardError(err)
-					continue
-				}
-
-				resp, err := client.Do(req)
-				// error making the request. This should not happen
-				if err != nil {
-					select {
-					case <-item.done:
-						//nothing to do here. race between ack and heartbeat
-					default:
-						item.forwardError(err)
-						continue
-					}
-				}
-
-				// parse the response
-				switch resp.StatusCode {
-				case http.StatusOK:
-					// sent a heartbeat
-					lastHeartbeat = time.Now()
-				case http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError:
-					// faild to heartbeat for some reason
-					apiError := &errors.Error{}
-					if err := api.ModelDecodeResponse(resp, apiError); err != nil {
-						select {
-						case <-item.done:
-							//nothing to do here. race between ack and heartbeat
-						default:
-							item.forwardError(err)
-						}
-					} else {
-						select {
-						case <-item.done:
-							//nothing to do here. race between ack and heartbeat
-						default:
-							item.forwardError(apiError)
-						}
-					}
-				default:
-					item.forwardError(fmt.Errorf("unexpected status code while heartbeating: %d", resp.StatusCode))
-				}
-			}
-		}
-	}()
+	// heartbeats for all items from the same client are sent together
+	heartbeats.Add(item, item.heartbeatTimeout)
 
 	return item
 }
@@ -156,6 +103,37 @@ func (item *Item) Done() <-chan struct{} {
 	return item.done
 }
 
+// Canceled is closed when the service reports that the item was canceled. The client should stop processing
+// and ACK the item as a failure. Heartbeats continue to be sent untill the item is ACKed
+func (item *Item) Canceled() <-chan struct{} {
+	return item.canceled
+}
+
+//	PARAMETERS:
+//	- progress - progress to report for the item. Replaces any previously reported progress
+//
+//	RETURNS:
+//	- error - error validating the progress
+//
+// ReportProgress records the progress for the item which is sent to the service on the next automatic heartbeat.
+// If multiple reports happen between heartbeats, only the latest progress is sent
+func (item *Item) ReportProgress(progress *v1willow.ItemProgress) error {
+	if progress == nil {
+		return fmt.Errorf("progress cannot be nil")
+	}
+
+	if err := progress.Validate(); err != nil {
+		return err
+	}
+
+	item.progressLock.Lock()
+	defer item.progressLock.Unlock()
+
+	item.progress = progress
+
+	return nil
+}
+
 //	PARAMETERS:
 //	- passed - true iff the item successfully processed and can be removed from the remote queue. If false,
 //	           the item might be retried for processing
@@ -166,14 +144,49 @@ func (item *Item) Done() <-chan struct{} {
 //
 // ACK an item to inform the service that it successfully processed, or needs to be retried
 func (item *Item) ACK(ctx context.Context, passed bool) error {
-	item.stop()
+	return item.ACKWithResult(ctx, passed, nil)
+}
 
-	// encode the request
-	data, err := api.ModelEncodeRequest(v1willow.ACK{
+//	PARAMETERS:
+//	- passed - true iff the item successfully processed and can be removed from the remote queue. If false,
+//	           the item might be retried for processing
+//	- result - optional result of processing the item. Recorded when the queue retains completed items
+//	- headers (optional) - any headers to apply to the request
+//
+//	RETURNS:
+//	- error - error acking the item
+//
+// ACKWithResult is the same as ACK, but also records the result of processing the item
+func (item *Item) ACKWithResult(ctx context.Context, passed bool, result []byte) error {
+	return item.ack(ctx, v1willow.ACK{
 		ItemID:    item.itemID,
 		KeyValues: item.keyValues,
 		Passed:    passed,
+		Result:    result,
 	})
+}
+
+//	PARAMETERS:
+//	- reason - reason the item failed processing. Recorded on the item's attempt
+//
+//	RETURNS:
+//	- error - error acking the item
+//
+// ACKFailure is the same as ACK with passed set to false, but also records why the item failed
+func (item *Item) ACKFailure(ctx context.Context, reason string) error {
+	return item.ack(ctx, v1willow.ACK{
+		ItemID:    item.itemID,
+		KeyValues: item.keyValues,
+		Passed:    false,
+		Reason:    reason,
+	})
+}
+
+func (item *Item) ack(ctx context.Context, ack v1willow.ACK) error {
+	item.stop()
+
+	// encode the request
+	data, err := api.ModelEncodeRequest(ack)
 	if err != nil {
 		return err
 	}
@@ -218,6 +231,16 @@ func (item *Item) Data() []byte {
 	return item.data
 }
 
+// get the headers that describe the dequeued item's data
+func (item *Item) Headers() map[string]string {
+	return item.headers
+}
+
+// get the name of the queue the item was dequeued from
+func (item *Item) QueueName() string {
+	return item.queueName
+}
+
 func (item *Item) forwardError(err error) {
 	item.heartbeatErrorLock.RLock()
 	defer item.heartbeatErrorLock.RUnlock()
@@ -227,12 +250,46 @@ func (item *Item) forwardError(err error) {
 	}
 }
 
+func (item *Item) pendingProgress() *v1willow.ItemProgress {
+	item.progressLock.Lock()
+	defer item.progressLock.Unlock()
+
+	return item.progress
+}
+
+// only clear the progress if no new progress was reported while the heartbeat was in flight
+func (item *Item) sentProgress(progress *v1willow.ItemProgress) {
+	item.progressLock.Lock()
+	defer item.progressLock.Unlock()
+
+	if item.progress == progress {
+		item.progress = nil
+	}
+}
+
+func (item *Item) cancel() {
+	item.canceledOnce.Do(func() {
+		close(item.canceled)
+	})
+}
+
 func (item *Item) stop() {
 	item.doneOnce.Do(func() {
+		item.heartbeats.Remove(item)
 		close(item.done)
 	})
 }
 
+// report a heartbeat error only if the item has not already been ACKed
+func (item *Item) forwardHeartbeatError(err error) {
+	select {
+	case <-item.done:
+		//nothing to do here. race between ack and heartbeat
+	default:
+		item.forwardError(err)
+	}
+}
+
 // What I think could be useful for a reload  on a process that has stopped for an update and restarted. I.E K8S node's would still
 // have docker images for running JOBS that could be picked up and restart heartbeating that they are still processing. In this case
 // the joibs would have a long time to run, but that is to be expected in those use cases
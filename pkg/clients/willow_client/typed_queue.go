@@ -0,0 +1,166 @@
+package willowclient
+
+import (
+	"context"
+	"fmt"
+
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+)
+
+// TypedItem is a dequeued item with its Data already decoded
+type TypedItem[T any] struct {
+	*Item
+
+	// Value decoded from the item's Data
+	Value T
+}
+
+// TypedQueue wraps a single queue to enqueue and dequeue values of a specific type with a codec
+type TypedQueue[T any] struct {
+	client    WillowServiceClient
+	queueName string
+	codec     Codec[T]
+}
+
+//	PARAMETERS:
+//	- client - client used to enqueue and dequeue the items
+//	- queueName - name of the queue to use
+//	- codec - codec to encode and decode the item's Data
+//
+//	RETURNS:
+//	- *TypedQueue - queue that can be shared by any number of goroutines
+//	- error - error with any of the parameters
+//
+// NewTypedQueue creates a new typed wrapper for a queue
+func NewTypedQueue[T any](client WillowServiceClient, queueName string, codec Codec[T]) (*TypedQueue[T], error) {
+	if client == nil {
+		return nil, fmt.Errorf("client cannot be nil")
+	}
+
+	if queueName == "" {
+		return nil, fmt.Errorf("queueName cannot be empty")
+	}
+
+	if codec == nil {
+		return nil, fmt.Errorf("codec cannot be nil")
+	}
+
+	return &TypedQueue[T]{
+		client:    client,
+		queueName: queueName,
+		codec:     codec,
+	}, nil
+}
+
+//	PARAMETERS:
+//	- item - item to enqueue. Any Data is replaced by the encoded value and the item itself is not modified
+//	- value - value to encode into the item's Data
+//
+//	RETURNS:
+//	- *v1willow.ItemState - state of the enqueued item
+//	- error - error encoding the value or enqueuing the item
+//
+// Enqueue encodes the value into the item's Data and records the codec's content type in the item's Headers
+func (tq *TypedQueue[T]) Enqueue(ctx context.Context, item *v1willow.Item, value T) (*v1willow.ItemState, error) {
+	if item == nil || item.Spec == nil || item.Spec.Properties == nil {
+		return nil, fmt.Errorf("item.Spec.Properties cannot be nil")
+	}
+
+	data, err := tq.codec.Encode(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the item with the '%s' codec: %w", tq.codec.ContentType(), err)
+	}
+
+	// copy the item so the caller's item can be reused for other values
+	properties := *item.Spec.Properties
+	properties.Data = data
+	properties.Headers = map[string]string{}
+	for key, value := range item.Spec.Properties.Headers {
+		properties.Headers[key] = value
+	}
+	properties.Headers[v1willow.HeaderContentType] = tq.codec.ContentType()
+
+	spec := *item.Spec
+	spec.Properties = &properties
+
+	enqueueItem := *item
+	enqueueItem.Spec = &spec
+
+	return tq.client.EnqueueQueueItem(ctx, tq.queueName, &enqueueItem)
+}
+
+//	PARAMETERS:
+//	- ctx - context to cancel the dequeue operation if nothing has been received
+//	- query - query to be applied to any channels on the queue for items to process
+//
+//	RETURNS:
+//	- *TypedItem - item with the decoded value that will automatically heartbeat as long as the client is processing
+//	- error - error dequeuing the item. A *DecodeError when the item could not be decoded and was ACKed as a failure
+//
+// Dequeue an item and decode its Data
+func (tq *TypedQueue[T]) Dequeue(ctx context.Context, query *queryassociatedaction.AssociatedActionQuery) (*TypedItem[T], error) {
+	item, err := tq.client.DequeueQueueItem(ctx, tq.queueName, query)
+	if err != nil {
+		return nil, err
+	}
+
+	typedItem, decodeErr := tq.decode(item)
+	if decodeErr != nil {
+		if ackErr := item.ACKFailure(ctx, decodeErr.Error()); ackErr != nil {
+			return nil, fmt.Errorf("%w. Also failed to ACK the item: %w", decodeErr, ackErr)
+		}
+
+		return nil, decodeErr
+	}
+
+	return typedItem, nil
+}
+
+//	PARAMETERS:
+//	- cfg - configuration for the consumer. The QueueName and Handler are set from the typed queue
+//	- handler - processes a single item with the decoded value
+//
+//	RETURNS:
+//	- *Consumer - consumer that can be started with Run
+//	- error - error validating the configuration
+//
+// NewConsumer creates a worker-pool consumer that decodes each item before calling the handler. Items that cannot be
+// decoded are ACKed as a failure with the decode error as the reason, without calling the handler
+func (tq *TypedQueue[T]) NewConsumer(cfg *ConsumerConfig, handler func(ctx context.Context, item *TypedItem[T]) error) (*Consumer, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("cfg cannot be nil")
+	}
+
+	if handler == nil {
+		return nil, fmt.Errorf("handler cannot be nil")
+	}
+
+	consumerCfg := *cfg
+	consumerCfg.QueueName = tq.queueName
+	consumerCfg.Handler = func(ctx context.Context, item *Item) error {
+		typedItem, err := tq.decode(item)
+		if err != nil {
+			return err
+		}
+
+		return handler(ctx, typedItem)
+	}
+
+	return NewConsumer(tq.client, &consumerCfg)
+}
+
+func (tq *TypedQueue[T]) decode(item *Item) (*TypedItem[T], *DecodeError) {
+	// items enqueued without a content type are still attempted to be decoded
+	properties := &v1willow.ItemProperties{Headers: item.Headers()}
+	if contentType := properties.ContentType(); contentType != "" && contentType != tq.codec.ContentType() {
+		return nil, &DecodeError{ContentType: tq.codec.ContentType(), Err: fmt.Errorf("item has the Content-Type '%s'", contentType)}
+	}
+
+	value, err := tq.codec.Decode(item.Data())
+	if err != nil {
+		return nil, &DecodeError{ContentType: tq.codec.ContentType(), Err: err}
+	}
+
+	return &TypedItem[T]{Item: item, Value: value}, nil
+}
@@ -0,0 +1,309 @@
+package willowclient_test
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DanLavine/willow/internal/helpers"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+
+	willowclient "github.com/DanLavine/willow/pkg/clients/willow_client"
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+	v1willow "github.com/DanLavine/willow/pkg/models/api/willow/v1"
+
+	. "github.com/onsi/gomega"
+)
+
+type testOrder struct {
+	ID       string
+	Quantity int
+}
+
+// testPoint implements its own binary encoding, the same as generated protobuf messages
+type testPoint struct {
+	X, Y int32
+}
+
+func (p *testPoint) Marshal() ([]byte, error) {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data[0:4], uint32(p.X))
+	binary.BigEndian.PutUint32(data[4:8], uint32(p.Y))
+
+	return data, nil
+}
+
+func (p *testPoint) Unmarshal(data []byte) error {
+	if len(data) != 8 {
+		return fmt.Errorf("expected 8 bytes, received %d", len(data))
+	}
+
+	p.X = int32(binary.BigEndian.Uint32(data[0:4]))
+	p.Y = int32(binary.BigEndian.Uint32(data[4:8]))
+
+	return nil
+}
+
+func typedTestItem(retryAttempts uint64) *v1willow.Item {
+	return &v1willow.Item{
+		Spec: &v1willow.ItemSpec{
+			DBDefinition: &v1willow.ItemDBDefinition{
+				KeyValues: datatypes.KeyValues{
+					"one": datatypes.Int(1),
+				},
+			},
+			Properties: &v1willow.ItemProperties{
+				Updateable:      helpers.PointerOf(false),
+				RetryAttempts:   helpers.PointerOf(retryAttempts),
+				RetryPosition:   helpers.PointerOf("front"),
+				TimeoutDuration: helpers.PointerOf(time.Second),
+			},
+		},
+	}
+}
+
+func TestCodecs(t *testing.T) {
+	t.Run("It can encode and decode values with the JSON codec", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		codec := willowclient.JSONCodec[testOrder]{}
+		g.Expect(codec.ContentType()).To(Equal("application/json"))
+
+		data, err := codec.Encode(testOrder{ID: "abc", Quantity: 3})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		value, err := codec.Decode(data)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(value).To(Equal(testOrder{ID: "abc", Quantity: 3}))
+	})
+
+	t.Run("It can encode and decode values with the gob codec", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		codec := willowclient.GobCodec[testOrder]{}
+		g.Expect(codec.ContentType()).To(Equal("application/x-gob"))
+
+		data, err := codec.Encode(testOrder{ID: "abc", Quantity: 3})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		value, err := codec.Decode(data)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(value).To(Equal(testOrder{ID: "abc", Quantity: 3}))
+	})
+
+	t.Run("It can encode and decode values with the binary codec", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		codec := willowclient.BinaryCodec[testPoint, *testPoint]{}
+		g.Expect(codec.ContentType()).To(Equal("application/octet-stream"))
+
+		data, err := codec.Encode(testPoint{X: -4, Y: 12})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		value, err := codec.Decode(data)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(value).To(Equal(testPoint{X: -4, Y: 12}))
+	})
+
+	t.Run("It returns an error when the binary codec fails to decode", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		_, err := willowclient.BinaryCodec[testPoint, *testPoint]{}.Decode([]byte("bad"))
+		g.Expect(err).To(MatchError("expected 8 bytes, received 3"))
+	})
+}
+
+func TestTypedQueue_New(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	willowClient := setupQueue(t, g, 0, 0)
+
+	t.Run("It returns an error when the client is nil", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		typedQueue, err := willowclient.NewTypedQueue[testOrder](nil, "test queue", willowclient.JSONCodec[testOrder]{})
+		g.Expect(err).To(MatchError("client cannot be nil"))
+		g.Expect(typedQueue).To(BeNil())
+	})
+
+	t.Run("It returns an error when the queue name is empty", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		typedQueue, err := willowclient.NewTypedQueue[testOrder](willowClient, "", willowclient.JSONCodec[testOrder]{})
+		g.Expect(err).To(MatchError("queueName cannot be empty"))
+		g.Expect(typedQueue).To(BeNil())
+	})
+
+	t.Run("It returns an error when the codec is nil", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		typedQueue, err := willowclient.NewTypedQueue[testOrder](willowClient, "test queue", nil)
+		g.Expect(err).To(MatchError("codec cannot be nil"))
+		g.Expect(typedQueue).To(BeNil())
+	})
+}
+
+func TestTypedQueue_EnqueueDequeue(t *testing.T) {
+	t.Run("It encodes and decodes values with every codec", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		willowClient := setupQueue(t, g, 0, 0)
+
+		jsonQueue, err := willowclient.NewTypedQueue[testOrder](willowClient, "test queue", willowclient.JSONCodec[testOrder]{})
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = jsonQueue.Enqueue(context.Background(), typedTestItem(0), testOrder{ID: "json", Quantity: 1})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		jsonItem, err := jsonQueue.Dequeue(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(jsonItem.Value).To(Equal(testOrder{ID: "json", Quantity: 1}))
+		g.Expect(jsonItem.Headers()).To(HaveKeyWithValue("Content-Type", "application/json"))
+		g.Expect(jsonItem.ACK(context.Background(), true)).ToNot(HaveOccurred())
+
+		gobQueue, err := willowclient.NewTypedQueue[testOrder](willowClient, "test queue", willowclient.GobCodec[testOrder]{})
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = gobQueue.Enqueue(context.Background(), typedTestItem(0), testOrder{ID: "gob", Quantity: 2})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		gobItem, err := gobQueue.Dequeue(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(gobItem.Value).To(Equal(testOrder{ID: "gob", Quantity: 2}))
+		g.Expect(gobItem.ACK(context.Background(), true)).ToNot(HaveOccurred())
+
+		binaryQueue, err := willowclient.NewTypedQueue[testPoint](willowClient, "test queue", willowclient.BinaryCodec[testPoint, *testPoint]{})
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = binaryQueue.Enqueue(context.Background(), typedTestItem(0), testPoint{X: 7, Y: -7})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		binaryItem, err := binaryQueue.Dequeue(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(binaryItem.Value).To(Equal(testPoint{X: 7, Y: -7}))
+		g.Expect(binaryItem.ACK(context.Background(), true)).ToNot(HaveOccurred())
+	})
+
+	t.Run("It does not modify the item passed to Enqueue", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		willowClient := setupQueue(t, g, 0, 0)
+
+		typedQueue, err := willowclient.NewTypedQueue[testOrder](willowClient, "test queue", willowclient.JSONCodec[testOrder]{})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		item := typedTestItem(0)
+		item.Spec.Properties.Headers = map[string]string{"schema": "v2"}
+
+		_, err = typedQueue.Enqueue(context.Background(), item, testOrder{ID: "abc"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(item.Spec.Properties.Data).To(BeNil())
+		g.Expect(item.Spec.Properties.Headers).To(Equal(map[string]string{"schema": "v2"}))
+
+		typedItem, err := typedQueue.Dequeue(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(typedItem.Headers()).To(Equal(map[string]string{"schema": "v2", "Content-Type": "application/json"}))
+		g.Expect(typedItem.ACK(context.Background(), true)).ToNot(HaveOccurred())
+	})
+
+	t.Run("It ACKs items that fail to decode as a failure with the reason", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		willowClient := setupQueue(t, g, 1, 0)
+
+		item := typedTestItem(1)
+		item.Spec.Properties.Data = []byte("not json")
+		_, err := willowClient.EnqueueQueueItem(context.Background(), "test queue", item)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		typedQueue, err := willowclient.NewTypedQueue[testOrder](willowClient, "test queue", willowclient.JSONCodec[testOrder]{})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		typedItem, err := typedQueue.Dequeue(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(typedItem).To(BeNil())
+
+		var decodeErr *willowclient.DecodeError
+		g.Expect(err).To(BeAssignableToTypeOf(decodeErr))
+		g.Expect(err.Error()).To(ContainSubstring("failed to decode item with the 'application/json' codec"))
+
+		items, err := willowClient.QueryQueueItems(context.Background(), "test queue", &v1willow.ItemQuery{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(items)).To(Equal(1))
+		g.Expect(len(items[0].State.Attempts)).To(Equal(1))
+		g.Expect(items[0].State.Attempts[0].Result).To(Equal(v1willow.ItemAttemptFailed))
+		g.Expect(items[0].State.Attempts[0].Reason).To(ContainSubstring("failed to decode item with the 'application/json' codec"))
+	})
+
+	t.Run("It ACKs items with a different content type as a failure", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		willowClient := setupQueue(t, g, 1, 0)
+
+		gobQueue, err := willowclient.NewTypedQueue[testOrder](willowClient, "test queue", willowclient.GobCodec[testOrder]{})
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = gobQueue.Enqueue(context.Background(), typedTestItem(1), testOrder{ID: "gob"})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		jsonQueue, err := willowclient.NewTypedQueue[testOrder](willowClient, "test queue", willowclient.JSONCodec[testOrder]{})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = jsonQueue.Dequeue(context.Background(), &queryassociatedaction.AssociatedActionQuery{})
+		g.Expect(err).To(MatchError("failed to decode item with the 'application/json' codec: item has the Content-Type 'application/x-gob'"))
+
+		items, err := willowClient.QueryQueueItems(context.Background(), "test queue", &v1willow.ItemQuery{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(items)).To(Equal(1))
+		g.Expect(items[0].State.Attempts[0].Reason).To(Equal("failed to decode item with the 'application/json' codec: item has the Content-Type 'application/x-gob'"))
+	})
+}
+
+func TestTypedQueue_NewConsumer(t *testing.T) {
+	t.Run("It returns an error when the handler is nil", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		willowClient := setupQueue(t, g, 0, 0)
+
+		typedQueue, err := willowclient.NewTypedQueue[testOrder](willowClient, "test queue", willowclient.JSONCodec[testOrder]{})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		consumer, err := typedQueue.NewConsumer(&willowclient.ConsumerConfig{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}}, nil)
+		g.Expect(err).To(MatchError("handler cannot be nil"))
+		g.Expect(consumer).To(BeNil())
+	})
+
+	t.Run("It passes decoded values to the handler and fails items that cannot be decoded", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		willowClient := setupQueue(t, g, 0, 0)
+
+		typedQueue, err := willowclient.NewTypedQueue[testOrder](willowClient, "test queue", willowclient.JSONCodec[testOrder]{})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		badItem := typedTestItem(0)
+		badItem.Spec.Properties.Data = []byte("not json")
+		badItem.Spec.Properties.Headers = map[string]string{"Content-Type": "application/json"}
+		_, err = willowClient.EnqueueQueueItem(context.Background(), "test queue", badItem)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		goodItem := typedTestItem(0)
+		goodItem.Spec.DBDefinition.KeyValues = datatypes.KeyValues{"one": datatypes.Int(2)}
+		_, err = typedQueue.Enqueue(context.Background(), goodItem, testOrder{ID: "good", Quantity: 5})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		received := make(chan testOrder, 2)
+		consumer, err := typedQueue.NewConsumer(
+			&willowclient.ConsumerConfig{ChannelQuery: &queryassociatedaction.AssociatedActionQuery{}},
+			func(ctx context.Context, item *willowclient.TypedItem[testOrder]) error {
+				received <- item.Value
+				return nil
+			},
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- consumer.Run(ctx)
+		}()
+
+		g.Eventually(received).Should(Receive(Equal(testOrder{ID: "good", Quantity: 5})))
+		g.Eventually(queuedItems(g, willowClient)).Should(Equal(0))
+		g.Consistently(received).ShouldNot(Receive())
+
+		cancel()
+		g.Eventually(done).Should(Receive(BeNil()))
+	})
+}
@@ -12,6 +12,14 @@ import (
 	"github.com/DanLavine/willow/pkg/models/datatypes"
 )
 
+// ErrDeleted is returned when dequeuing from a queue or channel that was deleted while waiting for an item. Consumers
+// should stop polling the queue once this is received. Check for it with errors.Is, since the service's reason is included
+var ErrDeleted = fmt.Errorf("queue or channel was deleted")
+
+// ErrItemsNotFound is returned when deleting items from a queue, channel or items that do not exist. Check for it
+// with errors.Is, since the service's reason is included
+var ErrItemsNotFound = fmt.Errorf("queue, channel or items were not found")
+
 // All Client operations for interacting with the Willow Service
 //
 //go:generate mockgen -destination=limiterclientfakes/limiter_client_mock.go -package=limiterclientfakes github.com/DanLavine/willow/pkg/clients/limiter_client WillowClient
@@ -33,11 +41,31 @@ type WillowServiceClient interface {
 
 	// channel operations
 	//// enqueue a new item to a particular queue's channels
-	EnqueueQueueItem(ctx context.Context, queueName string, item *v1willow.Item) error
+	EnqueueQueueItem(ctx context.Context, queueName string, item *v1willow.Item) (*v1willow.ItemState, error)
 	//// dequeue an item from a queue's channels that match the query
 	DequeueQueueItem(ctx context.Context, queueName string, query *queryassociatedaction.AssociatedActionQuery) (*Item, error)
+	//// dequeue an item from any number of queues that match the names or name patterns
+	DequeueQueueItems(ctx context.Context, dequeueQueues *v1willow.DequeueQueues) (*Item, error)
+	//// cancel an item that is currently processing
+	CancelQueueItem(ctx context.Context, queueName string, cancel *v1willow.Cancel) error
+	//// delete all enqueued and processing items with the headers from a channel
+	DeleteQueueItems(ctx context.Context, queueName string, itemDelete *v1willow.ItemDelete) error
+	//// inspect the enqueued and processing items for a queue's channels that match the query
+	QueryQueueItems(ctx context.Context, queueName string, itemQuery *v1willow.ItemQuery) (v1willow.Items, error)
+	//// inspect a queue's channels that match the query
+	QueryQueueChannels(ctx context.Context, queueName string, query *queryassociatedaction.AssociatedActionQuery) (v1willow.Channels, error)
+	//// explain why a dequeue for a queue's channels that match the query is not receiving any items
+	DiagnoseQueueChannels(ctx context.Context, queueName string, query *queryassociatedaction.AssociatedActionQuery) (v1willow.ChannelDiagnoses, error)
 	//// delete a particu;ar channel and all enqueued items
 	DeleteQueueChannel(ctx context.Context, queueName string, channelKeyValues datatypes.KeyValues) error
+
+	// admin operations
+	//// dump the full state of one or all queues
+	ExportQueues(ctx context.Context, exportQuery *v1willow.ExportQuery) (*v1willow.QueueExport, error)
+	//// recreate queues and their items from an export
+	ImportQueues(ctx context.Context, queueExport *v1willow.QueueExport) error
+	//// correct any Limiter counters that drifted from the items Willow holds
+	ReconcileLimiter(ctx context.Context) (*v1willow.LimiterReconcile, error)
 }
 
 // LimiteClient to connect with remote limiter service
@@ -47,6 +75,9 @@ type WillowClient struct {
 
 	// client setup with HTTP or HTTPS certs
 	client *http.Client
+
+	// sends the heartbeats for all dequeued items in a single request
+	heartbeats *clients.HeartbeatBatcher[*Item]
 }
 
 //	PARAMATERS
@@ -63,10 +94,13 @@ func NewWillowClient(cfg *clients.Config) (*WillowClient, error) {
 		return nil, err
 	}
 
-	return &WillowClient{
+	willowClient := &WillowClient{
 		url:    cfg.URL,
 		client: httpClient,
-	}, nil
+	}
+	willowClient.heartbeats = clients.NewHeartbeatBatcher(willowClient.heartbeatItems, (*Item).stop)
+
+	return willowClient, nil
 }
 
 func (wc *WillowClient) Healthy() error {
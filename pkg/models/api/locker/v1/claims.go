@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+)
+
+// LockHeartbeats is used to refresh the heartbeats for any number of held locks in a single request
+type LockHeartbeats struct {
+	// Heartbeats for each of the held locks
+	Heartbeats []*LockHeartbeat
+}
+
+//	RETURNS:
+//	- *errors.ModelError - error describing any possible issues and the steps to rectify them
+//
+// Validate ensures every heartbeat in the batch is valid
+func (lockHeartbeats *LockHeartbeats) Validate() *errors.ModelError {
+	if len(lockHeartbeats.Heartbeats) == 0 {
+		return &errors.ModelError{Field: "Heartbeats", Err: fmt.Errorf("received an empty list")}
+	}
+
+	for index, lockHeartbeat := range lockHeartbeats.Heartbeats {
+		if lockHeartbeat == nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Heartbeats[%d]", index), Err: fmt.Errorf("received a null value")}
+		}
+
+		if err := lockHeartbeat.Validate(); err != nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Heartbeats[%d]", index), Child: err}
+		}
+	}
+
+	return nil
+}
+
+// LockHeartbeat is the same as a LockClaim, but also records the LockID that is normally part of the url
+type LockHeartbeat struct {
+	// LockID for the currently held lock
+	LockID string
+
+	// SessionID for the currently held lock
+	SessionID string
+}
+
+//	RETURNS:
+//	- *errors.ModelError - error describing any possible issues and the steps to rectify them
+//
+// Validate ensures the LockHeartbeat is valid for the held lock
+func (lockHeartbeat *LockHeartbeat) Validate() *errors.ModelError {
+	if lockHeartbeat.LockID == "" {
+		return &errors.ModelError{Field: "LockID", Err: fmt.Errorf("received an empty string")}
+	}
+
+	if lockHeartbeat.SessionID == "" {
+		return &errors.ModelError{Field: "SessionID", Err: fmt.Errorf("received an empty string")}
+	}
+
+	return nil
+}
+
+// LockHeartbeatsResponse records the result for each heartbeat in the same order as the request
+type LockHeartbeatsResponse struct {
+	// Results for each of the heartbeats
+	Results []*LockHeartbeatResult
+}
+
+//	RETURNS:
+//	- *errors.ModelError - error describing any possible issues and the steps to rectify them
+//
+// Validate ensures the LockHeartbeatsResponse has all required fields set
+func (lockHeartbeatsResponse *LockHeartbeatsResponse) Validate() *errors.ModelError {
+	for index, lockHeartbeatResult := range lockHeartbeatsResponse.Results {
+		if lockHeartbeatResult == nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Results[%d]", index), Err: fmt.Errorf("received a null value")}
+		}
+	}
+
+	return nil
+}
+
+// LockHeartbeatResult is the result of a single heartbeat
+type LockHeartbeatResult struct {
+	// Error is set when the heartbeat failed. When set, the lock is no longer held by the client
+	Error *errors.Error `json:"Error,omitempty"`
+}
@@ -67,6 +67,12 @@ type ChannelState struct {
 
 	// Total numbe of items that are currently processing
 	ProcessingItems int64
+
+	// Weight of the channel when the queue has ChannelWeights
+	Weight int64
+
+	// Remaining number of items the channel can dequeue in the current fair share round
+	Deficit int64
 }
 
 func (channelState *ChannelState) Validate() *errors.ModelError {
@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+)
+
+// ChannelDiagnoses explains why a dequeue request for a queue's channels is not receiving any items. When there
+// are no ChannelDiagnoses, then no channels matched the dequeue query
+type ChannelDiagnoses []*ChannelDiagnosis
+
+//	RETURNS:
+//	- error - any errors encountered with the response object
+//
+// Validate is used to ensure that all ChannelDiagnoses have the required fields set
+func (channelDiagnoses ChannelDiagnoses) Validate() *errors.ModelError {
+	for index, channelDiagnosis := range channelDiagnoses {
+		if channelDiagnosis == nil {
+			return &errors.ModelError{Field: fmt.Sprintf("[%d]", index), Err: fmt.Errorf("received a null value")}
+		}
+
+		if err := channelDiagnosis.Validate(); err != nil {
+			return &errors.ModelError{Field: fmt.Sprintf("[%d]", index), Child: err}
+		}
+	}
+
+	return nil
+}
+
+// ChannelDiagnosis is the current state of a single channel and anything that prevents it from being dequeued
+type ChannelDiagnosis struct {
+	// KeyValues that define the channel
+	KeyValues datatypes.TypedKeyValues `json:"KeyValues,omitempty"`
+
+	// Total number of enqueued items that are not processing. When 0, there is nothing to dequeue
+	EnqueuedItems int64
+
+	// Total number of items that are currently processing
+	ProcessingItems int64
+
+	// Limiter Rules and Overrides that are currently at their limit for the channel's '_willow_running' key values.
+	// When there are any BlockingLimits, no items will be dequeued from the channel
+	BlockingLimits BlockingLimits `json:"BlockingLimits,omitempty"`
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the response object
+//
+// Validate is used to ensure that the ChannelDiagnosis has all required fields set
+func (channelDiagnosis *ChannelDiagnosis) Validate() *errors.ModelError {
+	if err := channelDiagnosis.KeyValues.Validate(datatypes.MinDataType, datatypes.MaxWithoutAnyDataType); err != nil {
+		return &errors.ModelError{Field: "KeyValues", Child: err}
+	}
+
+	if err := channelDiagnosis.BlockingLimits.Validate(); err != nil {
+		return &errors.ModelError{Field: "BlockingLimits", Child: err}
+	}
+
+	return nil
+}
+
+// BlockingLimits are all the Limiter Rules and Overrides preventing a channel from being dequeued
+type BlockingLimits []*BlockingLimit
+
+//	RETURNS:
+//	- error - any errors encountered with the response object
+//
+// Validate is used to ensure that all BlockingLimits have the required fields set
+func (blockingLimits BlockingLimits) Validate() *errors.ModelError {
+	for index, blockingLimit := range blockingLimits {
+		if blockingLimit == nil {
+			return &errors.ModelError{Field: fmt.Sprintf("[%d]", index), Err: fmt.Errorf("received a null value")}
+		}
+
+		if blockingLimit.RuleID == "" {
+			return &errors.ModelError{Field: fmt.Sprintf("[%d].RuleID", index), Err: fmt.Errorf("received an empty string")}
+		}
+	}
+
+	return nil
+}
+
+// BlockingLimit is a single Limiter Rule or Override that is at its limit
+type BlockingLimit struct {
+	// ID of the Limiter Rule that is at its limit
+	RuleID string
+
+	// ID of the Rule's Override that is at its limit. Empty when the Rule's own limit is reached
+	OverrideID string `json:"OverrideID,omitempty"`
+
+	// Limit set on the Rule or Override
+	Limit int64
+
+	// Current total of all Counters that count against the Limit for the channel
+	Count int64
+}
@@ -0,0 +1,111 @@
+package v1
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+)
+
+type DequeueQueues struct {
+	// Queues to dequeue an item from. The first item available from any of the queues is returned
+	Queues []*DequeueQueue `json:"Queues,omitempty"`
+
+	// Optional filter for the item's Headers. Only items that have all of the headers with the same values
+	// are dequeued. This allows a client to skip items it does not know how to decode
+	HeadersFilter map[string]string `json:"HeadersFilter,omitempty"`
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the request object
+//
+// Validate is used to ensure that the dequeue request has all required fields set
+func (dequeueQueues DequeueQueues) Validate() *errors.ModelError {
+	if len(dequeueQueues.Queues) == 0 {
+		return &errors.ModelError{Field: "Queues", Err: fmt.Errorf("received an empty list")}
+	}
+
+	for index, dequeueQueue := range dequeueQueues.Queues {
+		if dequeueQueue == nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Queues[%d]", index), Err: fmt.Errorf("received a null value")}
+		}
+
+		if err := dequeueQueue.Validate(); err != nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Queues[%d]", index), Child: err}
+		}
+	}
+
+	for key := range dequeueQueues.HeadersFilter {
+		if key == "" {
+			return &errors.ModelError{Field: "HeadersFilter", Err: fmt.Errorf("contains an empty key")}
+		}
+	}
+
+	return nil
+}
+
+type DequeueQueue struct {
+	// Name of a single queue to dequeue from. Cannot be set with NamePattern
+	Name *string `json:"Name,omitempty"`
+
+	// NamePattern is a glob pattern used to match any number of queue names. Cannot be set with Name.
+	// The syntax is the same as Go's path.Match. I.E 'build-*'. The pattern is only matched against the queues
+	// that exist when the dequeue request is received. Queues created while the request waits are not dequeued from
+	NamePattern *string `json:"NamePattern,omitempty"`
+
+	// ChannelQuery to match any channels for in the selected queues
+	ChannelQuery *queryassociatedaction.AssociatedActionQuery `json:"ChannelQuery,omitempty"`
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the request object
+//
+// Validate is used to ensure that a single queue selection has all required fields set
+func (dequeueQueue *DequeueQueue) Validate() *errors.ModelError {
+	switch {
+	case dequeueQueue.Name == nil && dequeueQueue.NamePattern == nil:
+		return &errors.ModelError{Err: fmt.Errorf("requires either Name or NamePattern to be set")}
+	case dequeueQueue.Name != nil && dequeueQueue.NamePattern != nil:
+		return &errors.ModelError{Err: fmt.Errorf("cannot set both Name and NamePattern")}
+	case dequeueQueue.Name != nil:
+		if *dequeueQueue.Name == "" {
+			return &errors.ModelError{Field: "Name", Err: fmt.Errorf("received an empty string")}
+		}
+	default:
+		if *dequeueQueue.NamePattern == "" {
+			return &errors.ModelError{Field: "NamePattern", Err: fmt.Errorf("received an empty string")}
+		}
+
+		if _, err := path.Match(*dequeueQueue.NamePattern, ""); err != nil {
+			return &errors.ModelError{Field: "NamePattern", Err: err}
+		}
+	}
+
+	if dequeueQueue.ChannelQuery == nil {
+		return &errors.ModelError{Field: "ChannelQuery", Err: fmt.Errorf("received a null value")}
+	} else {
+		if err := dequeueQueue.ChannelQuery.Validate(); err != nil {
+			return &errors.ModelError{Field: "ChannelQuery", Child: err}
+		}
+	}
+
+	return nil
+}
+
+//	PARAMETERS:
+//	- queueName - name of the queue to check
+//
+//	RETURNS:
+//	- bool - true iff the queue name is selected by the Name or NamePattern
+//
+// MatchQueueName is used to check if a queue is selected for dequeuing
+func (dequeueQueue *DequeueQueue) MatchQueueName(queueName string) bool {
+	if dequeueQueue.Name != nil {
+		return *dequeueQueue.Name == queueName
+	}
+
+	matched, _ := path.Match(*dequeueQueue.NamePattern, queueName)
+	return matched
+}
@@ -0,0 +1,59 @@
+package v1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+)
+
+const (
+	// HealthStatusOK is reported when Willow is running and all Limiter counters are up to date
+	HealthStatusOK = "ok"
+
+	// HealthStatusLimiterOutOfSync is reported when Willow is running, but some Limiter counter updates failed
+	// and are still being retried
+	HealthStatusLimiterOutOfSync = "limiter out of sync"
+)
+
+// Health is the response for the health api
+type Health struct {
+	// Status is one of HealthStatusOK or HealthStatusLimiterOutOfSync
+	Status string
+
+	// LimiterOutbox reports any counter updates that are waiting to be retried on the Limiter
+	LimiterOutbox *LimiterOutboxState
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the response object
+//
+// Validate is used to ensure that the Health has all required fields set
+func (health *Health) Validate() *errors.ModelError {
+	switch health.Status {
+	case HealthStatusOK, HealthStatusLimiterOutOfSync:
+	default:
+		return &errors.ModelError{Field: "Status", Err: fmt.Errorf("unknown status '%s'", health.Status)}
+	}
+
+	if health.LimiterOutbox == nil {
+		return &errors.ModelError{Field: "LimiterOutbox", Err: fmt.Errorf("received a null value")}
+	}
+
+	return nil
+}
+
+// LimiterOutboxState reports how far the Limiter's counters are out of sync with Willow
+type LimiterOutboxState struct {
+	// Number of counters with updates waiting to be retried
+	PendingUpdates int64
+
+	// How long the oldest pending update has been waiting
+	OutOfSyncDuration time.Duration `json:"OutOfSyncDuration,omitempty"`
+
+	// Total number of times updates have been retried
+	Retries uint64
+
+	// Last error received from the Limiter when an update failed
+	LastError string `json:"LastError,omitempty"`
+}
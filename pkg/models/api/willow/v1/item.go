@@ -2,6 +2,8 @@ package v1
 
 import (
 	"fmt"
+	"mime"
+	"strings"
 	"time"
 
 	"github.com/DanLavine/willow/pkg/models/api/common/errors"
@@ -100,6 +102,10 @@ type ItemProperties struct {
 	// Raw data that the end user clients know how to parse
 	Data []byte
 
+	// Optional headers that describe the Data, such as the content type or schema version. Headers are
+	// never parsed by Willow, but can be used to filter which items a client dequeues
+	Headers map[string]string `json:"Headers,omitempty"`
+
 	// If the item can be updated on another request
 	Updateable *bool `json:"Updateable,omitempty"`
 
@@ -111,6 +117,26 @@ type ItemProperties struct {
 
 	// How long to wait for heartbeats untill the item is considered failed
 	TimeoutDuration *time.Duration `json:"TimeoutDuration,omitempty"`
+
+	// Optional max amount of time an item can be processed for, even when heartbeats are received. When
+	// not set, the queue's MaxRunDuration is used. 0 means there is no limit
+	MaxRunDuration *time.Duration `json:"MaxRunDuration,omitempty"`
+
+	// Optional key to deduplicate enqueue requests. When an item with the same key was enqueued to the
+	// queue within the queue's IdempotencyWindow, the existing item is returned instead of enqueuing a new item
+	IdempotencyKey *string `json:"IdempotencyKey,omitempty"`
+
+	// Optional key to replace an item that is still enqueued in the same channel. When an enqueued item
+	// has the same key, its details are replaced with this item instead of enqueuing a new item
+	DedupKey *string `json:"DedupKey,omitempty"`
+
+	// When replacing an item by the DedupKey, also move the item to the back of the channel.
+	// By default, the replaced item keeps its current position
+	DedupMoveToBack *bool `json:"DedupMoveToBack,omitempty"`
+
+	// Optional priority of the item in its channel. Items with a higher priority are dequeued before items
+	// with a lower priority, and items with the same priority are dequeued in order. Defaults to 0
+	Priority *int64 `json:"Priority,omitempty"`
 }
 
 func (itemProperties *ItemProperties) Validate() *errors.ModelError {
@@ -118,6 +144,12 @@ func (itemProperties *ItemProperties) Validate() *errors.ModelError {
 		return &errors.ModelError{Field: "Data", Err: fmt.Errorf("received a value of 0 bytes")}
 	}
 
+	for key := range itemProperties.Headers {
+		if key == "" {
+			return &errors.ModelError{Field: "Headers", Err: fmt.Errorf("contains an empty key")}
+		}
+	}
+
 	if itemProperties.Updateable == nil {
 		return &errors.ModelError{Field: "Updateable", Err: fmt.Errorf("received a null value")}
 	}
@@ -141,12 +173,104 @@ func (itemProperties *ItemProperties) Validate() *errors.ModelError {
 		return &errors.ModelError{Field: "TimeoutDuration", Err: fmt.Errorf("received a null value")}
 	}
 
+	if itemProperties.MaxRunDuration != nil && *itemProperties.MaxRunDuration < 0 {
+		return &errors.ModelError{Field: "MaxRunDuration", Err: fmt.Errorf("cannot be negative")}
+	}
+
+	if itemProperties.IdempotencyKey != nil && *itemProperties.IdempotencyKey == "" {
+		return &errors.ModelError{Field: "IdempotencyKey", Err: fmt.Errorf("is an empty string")}
+	}
+
+	if itemProperties.DedupKey != nil && *itemProperties.DedupKey == "" {
+		return &errors.ModelError{Field: "DedupKey", Err: fmt.Errorf("is an empty string")}
+	}
+
+	if itemProperties.DedupMoveToBack != nil && itemProperties.DedupKey == nil {
+		return &errors.ModelError{Field: "DedupMoveToBack", Err: fmt.Errorf("requires the DedupKey to be set")}
+	}
+
 	return nil
 }
 
+const (
+	// HeaderContentType is the item header that describes how the item's Data is encoded
+	HeaderContentType = "Content-Type"
+
+	// ContentTypeJSON is the content type for items with JSON Data
+	ContentTypeJSON = "application/json"
+)
+
+// ContentType returns the media type of the item's Content-Type header, without any parameters. The header's key
+// is matched case insensitively. "" is returned when the header is not set
+func (itemProperties *ItemProperties) ContentType() string {
+	for key, value := range itemProperties.Headers {
+		if strings.EqualFold(key, HeaderContentType) {
+			mediaType, _, err := mime.ParseMediaType(value)
+			if err != nil {
+				return strings.ToLower(strings.TrimSpace(value))
+			}
+
+			return mediaType
+		}
+	}
+
+	return ""
+}
+
 type ItemState struct {
 	// ID of the item that needs to be heartbeat and acked
 	ID string `json:"ID"`
+
+	// QueueName the item belongs to. Used to route ACK and heartbeat requests when dequeuing from multiple queues
+	QueueName string `json:"QueueName,omitempty"`
+
+	// Processing is true when a client has dequeued the item and is currently heartbeating it
+	Processing bool `json:"Processing,omitempty"`
+
+	// Progress is the last progress reported through a heartbeat by the client processing the item
+	Progress *ItemProgress `json:"Progress,omitempty"`
+
+	// Attempts records each time the item was dequeued and how the attempt finished
+	Attempts []*ItemAttempt `json:"Attempts,omitempty"`
+
+	// Completed is set when the item was successfully processed and is retained by the queue
+	Completed *ItemCompleted `json:"Completed,omitempty"`
+
+	// Duplicate is set on an enqueue response when an item with the same IdempotencyKey already existed
+	Duplicate bool `json:"Duplicate,omitempty"`
+
+	// EffectivePriority is the item's Priority plus any boost from the queue's PriorityAging for how long the
+	// item has been waiting. Only set for items that are enqueued
+	EffectivePriority *int64 `json:"EffectivePriority,omitempty"`
+}
+
+const (
+	ItemAttemptPassed   = "passed"
+	ItemAttemptFailed   = "failed"
+	ItemAttemptTimedOut = "timed out"
+	ItemAttemptCanceled = "canceled"
+)
+
+type ItemAttempt struct {
+	// StartTime is when the item was dequeued by a client
+	StartTime time.Time `json:"StartTime"`
+
+	// EndTime is when the attempt finished. Not set while the item is processing
+	EndTime *time.Time `json:"EndTime,omitempty"`
+
+	// Result of the attempt. One of [passed | failed | timed out | canceled]. Not set while the item is processing
+	Result string `json:"Result,omitempty"`
+
+	// Reason the attempt failed, as reported by the client that processed the item
+	Reason string `json:"Reason,omitempty"`
+}
+
+type ItemCompleted struct {
+	// CompletedTime is when the item was successfully acked
+	CompletedTime time.Time `json:"CompletedTime"`
+
+	// Result is the optional payload that was provided with the successful ACK
+	Result []byte `json:"Result,omitempty"`
 }
 
 func (itemState *ItemState) Validate() *errors.ModelError {
@@ -154,5 +278,11 @@ func (itemState *ItemState) Validate() *errors.ModelError {
 		return &errors.ModelError{Field: "ID", Err: fmt.Errorf("is the empty string")}
 	}
 
+	if itemState.Progress != nil {
+		if err := itemState.Progress.Validate(); err != nil {
+			return &errors.ModelError{Field: "Progress", Child: err}
+		}
+	}
+
 	return nil
 }
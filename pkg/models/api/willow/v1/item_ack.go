@@ -16,6 +16,12 @@ type ACK struct {
 
 	// Indicate a success or failure of the message
 	Passed bool
+
+	// Optional result of processing the item. Recorded with the completed item when the queue retains completed items
+	Result []byte `json:"Result,omitempty"`
+
+	// Optional reason the item failed processing. Recorded on the item's attempt. Can only be set when Passed is false
+	Reason string `json:"Reason,omitempty"`
 }
 
 //	RETURNS:
@@ -31,5 +37,9 @@ func (ack ACK) Validate() *errors.ModelError {
 		return &errors.ModelError{Field: "KeyValues", Child: err}
 	}
 
+	if ack.Passed && ack.Reason != "" {
+		return &errors.ModelError{Field: "Reason", Err: fmt.Errorf("can only be set when Passed is false")}
+	}
+
 	return nil
 }
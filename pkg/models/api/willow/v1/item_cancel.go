@@ -0,0 +1,32 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+)
+
+type Cancel struct {
+	// ID of the processing item to cancel
+	ItemID string
+
+	// KeyValues for the channel
+	KeyValues datatypes.TypedKeyValues
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the response object
+//
+// Validate is used to ensure that cancel request has all required fields set
+func (cancel Cancel) Validate() *errors.ModelError {
+	if cancel.ItemID == "" {
+		return &errors.ModelError{Field: "ItemID", Err: fmt.Errorf("is an empty string")}
+	}
+
+	if err := cancel.KeyValues.Validate(datatypes.MinDataType, datatypes.MaxWithoutAnyDataType); err != nil {
+		return &errors.ModelError{Field: "KeyValues", Child: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+)
+
+type ItemDelete struct {
+	// Headers that an item must have with the same values to be deleted
+	Headers map[string]string
+
+	// KeyValues for the channel
+	KeyValues datatypes.TypedKeyValues
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the response object
+//
+// Validate is used to ensure that delete request has all required fields set
+func (itemDelete ItemDelete) Validate() *errors.ModelError {
+	if len(itemDelete.Headers) == 0 {
+		return &errors.ModelError{Field: "Headers", Err: fmt.Errorf("requires at least one header")}
+	}
+
+	for key := range itemDelete.Headers {
+		if key == "" {
+			return &errors.ModelError{Field: "Headers", Err: fmt.Errorf("contains an empty key")}
+		}
+	}
+
+	if err := itemDelete.KeyValues.Validate(datatypes.MinDataType, datatypes.MaxWithoutAnyDataType); err != nil {
+		return &errors.ModelError{Field: "KeyValues", Child: err}
+	}
+
+	return nil
+}
@@ -13,6 +13,9 @@ type Heartbeat struct {
 
 	// KeyValues for the channel
 	KeyValues datatypes.TypedKeyValues
+
+	// Progress is optional and replaces the last reported progress for the item
+	Progress *ItemProgress `json:"Progress,omitempty"`
 }
 
 //	RETURNS:
@@ -28,5 +31,25 @@ func (heartbeat Heartbeat) Validate() *errors.ModelError {
 		return &errors.ModelError{Field: "KeyValues", Child: err}
 	}
 
+	if heartbeat.Progress != nil {
+		if err := heartbeat.Progress.Validate(); err != nil {
+			return &errors.ModelError{Field: "Progress", Child: err}
+		}
+	}
+
+	return nil
+}
+
+type HeartbeatResponse struct {
+	// Cancel is set to true when a cancel request was made for the processing item. Clients should
+	// stop processing the item and ACK it as a failure. Canceled items are never retried
+	Cancel bool
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the response object
+//
+// Validate is used to ensure that heartbeat response has all required fields set
+func (heartbeatResponse HeartbeatResponse) Validate() *errors.ModelError {
 	return nil
 }
@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+)
+
+type Heartbeats struct {
+	// Heartbeats for any number of processing items across any number of queues
+	Heartbeats []*QueueHeartbeat
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the request object
+//
+// Validate is used to ensure that every heartbeat in the batch has all required fields set
+func (heartbeats Heartbeats) Validate() *errors.ModelError {
+	if len(heartbeats.Heartbeats) == 0 {
+		return &errors.ModelError{Field: "Heartbeats", Err: fmt.Errorf("received an empty list")}
+	}
+
+	for index, queueHeartbeat := range heartbeats.Heartbeats {
+		if queueHeartbeat == nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Heartbeats[%d]", index), Err: fmt.Errorf("received a null value")}
+		}
+
+		if err := queueHeartbeat.Validate(); err != nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Heartbeats[%d]", index), Child: err}
+		}
+	}
+
+	return nil
+}
+
+type QueueHeartbeat struct {
+	// QueueName the item was dequeued from
+	QueueName string
+
+	// Heartbeat for the processing item
+	Heartbeat *Heartbeat
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the request object
+//
+// Validate is used to ensure that a single heartbeat in the batch has all required fields set
+func (queueHeartbeat *QueueHeartbeat) Validate() *errors.ModelError {
+	if queueHeartbeat.QueueName == "" {
+		return &errors.ModelError{Field: "QueueName", Err: fmt.Errorf("is an empty string")}
+	}
+
+	if queueHeartbeat.Heartbeat == nil {
+		return &errors.ModelError{Field: "Heartbeat", Err: fmt.Errorf("received a null value")}
+	} else {
+		if err := queueHeartbeat.Heartbeat.Validate(); err != nil {
+			return &errors.ModelError{Field: "Heartbeat", Child: err}
+		}
+	}
+
+	return nil
+}
+
+type HeartbeatsResponse struct {
+	// Results for each heartbeat in the same order as the request
+	Results []*HeartbeatResult
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the response object
+//
+// Validate is used to ensure that the heartbeats response has all required fields set
+func (heartbeatsResponse HeartbeatsResponse) Validate() *errors.ModelError {
+	for index, heartbeatResult := range heartbeatsResponse.Results {
+		if heartbeatResult == nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Results[%d]", index), Err: fmt.Errorf("received a null value")}
+		}
+	}
+
+	return nil
+}
+
+type HeartbeatResult struct {
+	// Cancel is set to true when a cancel request was made for the processing item. Same as the HeartbeatResponse
+	Cancel bool `json:"Cancel,omitempty"`
+
+	// Error is set when the heartbeat for the item failed. I.E. the item could not be found
+	Error *errors.Error `json:"Error,omitempty"`
+}
@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+)
+
+type ItemProgress struct {
+	// Percent complete for the item. Must be between [0, 100]
+	Percent *float64 `json:"Percent,omitempty"`
+
+	// Status is a short human readable description of what the item is currently doing
+	Status *string `json:"Status,omitempty"`
+
+	// KeyValues are any arbitrary details the client wants to report about the item
+	KeyValues datatypes.TypedKeyValues `json:"KeyValues,omitempty"`
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the progress object
+//
+// Validate is used to ensure that the progress has valid values for all set fields
+func (itemProgress *ItemProgress) Validate() *errors.ModelError {
+	if itemProgress.Percent != nil {
+		if *itemProgress.Percent < 0 || *itemProgress.Percent > 100 {
+			return &errors.ModelError{Field: "Percent", Err: fmt.Errorf("must be between [0, 100], but received '%v'", *itemProgress.Percent)}
+		}
+	}
+
+	if len(itemProgress.KeyValues) != 0 {
+		if err := itemProgress.KeyValues.Validate(datatypes.MinDataType, datatypes.MaxWithoutAnyDataType); err != nil {
+			return &errors.ModelError{Field: "KeyValues", Child: err}
+		}
+	}
+
+	return nil
+}
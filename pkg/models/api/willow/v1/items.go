@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
+)
+
+type Items []*Item
+
+//	RETURNS:
+//	- error - any errors encountered with the response object
+//
+// Validate is used to ensure that all items have the required fields set
+func (i Items) Validate() *errors.ModelError {
+	if len(i) == 0 {
+		return nil
+	}
+
+	for index, singleItem := range i {
+		if err := singleItem.Validate(); err != nil {
+			return &errors.ModelError{Field: fmt.Sprintf("[%d]", index), Child: err}
+		}
+	}
+
+	return nil
+}
+
+type ItemQuery struct {
+	// ChannelQuery selects all the channels to inspect the items for
+	ChannelQuery *queryassociatedaction.AssociatedActionQuery `json:"ChannelQuery,omitempty"`
+
+	// ItemID is an optional filter to only return a single item
+	ItemID *string `json:"ItemID,omitempty"`
+
+	// IdempotencyKey is an optional filter to only return the item enqueued with the key
+	IdempotencyKey *string `json:"IdempotencyKey,omitempty"`
+
+	// IncludeCompleted also returns any completed items that are still retained by the queue
+	IncludeCompleted bool `json:"IncludeCompleted,omitempty"`
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the request object
+//
+// Validate is used to ensure that the item query has all required fields set
+func (itemQuery ItemQuery) Validate() *errors.ModelError {
+	if itemQuery.ChannelQuery == nil {
+		return &errors.ModelError{Field: "ChannelQuery", Err: fmt.Errorf("received a null value")}
+	} else {
+		if err := itemQuery.ChannelQuery.Validate(); err != nil {
+			return &errors.ModelError{Field: "ChannelQuery", Child: err}
+		}
+	}
+
+	if itemQuery.ItemID != nil && *itemQuery.ItemID == "" {
+		return &errors.ModelError{Field: "ItemID", Err: fmt.Errorf("is an empty string")}
+	}
+
+	if itemQuery.IdempotencyKey != nil && *itemQuery.IdempotencyKey == "" {
+		return &errors.ModelError{Field: "IdempotencyKey", Err: fmt.Errorf("is an empty string")}
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+)
+
+// LimiterReconcile is the response for the reconcile api and reports every Limiter counter that was corrected
+type LimiterReconcile struct {
+	// Corrections made to the Limiter's counters. Empty when the Limiter already matched Willow
+	Corrections []*LimiterCorrection `json:"Corrections,omitempty"`
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the response object
+//
+// Validate is used to ensure that the LimiterReconcile has all required fields set
+func (limiterReconcile *LimiterReconcile) Validate() *errors.ModelError {
+	for index, correction := range limiterReconcile.Corrections {
+		if correction == nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Corrections[%d]", index), Err: fmt.Errorf("received a null value")}
+		}
+
+		if err := correction.Validate(); err != nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Corrections[%d]", index), Child: err}
+		}
+	}
+
+	return nil
+}
+
+// LimiterCorrection is a single Limiter counter that did not match the items Willow holds
+type LimiterCorrection struct {
+	// KeyValues of the counter on the Limiter
+	KeyValues datatypes.TypedKeyValues
+
+	// LimiterCount is what the Limiter reported before the correction
+	LimiterCount int64
+
+	// WillowCount is what the counter was set to. When 0, the counter was removed from the Limiter
+	WillowCount int64
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the response object
+//
+// Validate is used to ensure that the LimiterCorrection has all required fields set
+func (limiterCorrection *LimiterCorrection) Validate() *errors.ModelError {
+	if err := limiterCorrection.KeyValues.Validate(datatypes.MinDataType, datatypes.MaxWithoutAnyDataType); err != nil {
+		return &errors.ModelError{Field: "KeyValues", Child: err}
+	}
+
+	if limiterCorrection.WillowCount < 0 {
+		return &errors.ModelError{Field: "WillowCount", Err: fmt.Errorf("can not be negative")}
+	}
+
+	return nil
+}
+
+// LimiterReconcileState reports the background reconciliation of the Limiter's counters
+type LimiterReconcileState struct {
+	// Total number of times the counters were reconciled
+	Runs uint64
+
+	// Total number of counters that were corrected
+	Corrections uint64
+}
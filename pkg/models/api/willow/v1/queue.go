@@ -1,9 +1,15 @@
 package v1
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+
+	queryassociatedaction "github.com/DanLavine/willow/pkg/models/api/common/v1/query_associated_action"
 )
 
 type Queue struct {
@@ -103,6 +109,43 @@ type QueueProperties struct {
 	// Max size of the queue's eneueued and running items combined
 	// -1 means unlimited
 	MaxItems *int64 `json:"MaxItems,omitempty"`
+
+	// Optional default max amount of time any item can be processed for, when the item
+	// does not set its own MaxRunDuration. 0 means there is no limit
+	MaxRunDuration *time.Duration `json:"MaxRunDuration,omitempty"`
+
+	// Optional amount of time to retain items after they have been successfully processed. Retained
+	// items can be queried, but do not count towards MaxItems. 0 means completed items are not retained.
+	// Retained items are kept in memory, so each queue retains at most 10,000 items and drops the oldest first
+	CompletedRetention *time.Duration `json:"CompletedRetention,omitempty"`
+
+	// Optional amount of time an item's IdempotencyKey is remembered for after the item is enqueued. Items
+	// can only be enqueued with an IdempotencyKey when this is set. 0 means idempotency keys are not accepted
+	IdempotencyWindow *time.Duration `json:"IdempotencyWindow,omitempty"`
+
+	// Optional max size in bytes of an item's Data. 0 means there is no limit
+	MaxPayloadSize *int64 `json:"MaxPayloadSize,omitempty"`
+
+	// Optional JSON Schema that an item's Data must satisfy when the item's Content-Type header is
+	// 'application/json'. Only local '$ref' values, such as '#/definitions/commit', are allowed
+	DataSchema json.RawMessage `json:"DataSchema,omitempty"`
+
+	// Optional amount of time a queue can have no items and no enqueue or dequeue activity before it is
+	// automatically deleted. 0 means the queue is never deleted automatically
+	IdleTTL *time.Duration `json:"IdleTTL,omitempty"`
+
+	// Optional weights for channels that match a query. Dequeues hand out items approximately in proportion
+	// to the weights among channels that currently have enqueued items. The first matching weight is used
+	// for a channel, and channels that do not match any weight have a weight of 1
+	ChannelWeights []*ChannelWeight `json:"ChannelWeights,omitempty"`
+
+	// Optional policy to raise the effective priority of enqueued items the longer they wait. This ensures
+	// items with a low Priority are eventually dequeued when there are always items with a higher Priority
+	PriorityAging *PriorityAging `json:"PriorityAging,omitempty"`
+
+	// Optional mirror that receives every enqueue, update, ACK and dead letter operation for the queue's items.
+	// This keeps a queue on another Willow service as a warm standby
+	Mirror *QueueMirror `json:"Mirror,omitempty"`
 }
 
 func (queueProperties *QueueProperties) Validate() *errors.ModelError {
@@ -110,11 +153,238 @@ func (queueProperties *QueueProperties) Validate() *errors.ModelError {
 		return &errors.ModelError{Field: "MaxItems", Err: fmt.Errorf("recevied a null value")}
 	}
 
+	if queueProperties.MaxRunDuration != nil && *queueProperties.MaxRunDuration < 0 {
+		return &errors.ModelError{Field: "MaxRunDuration", Err: fmt.Errorf("cannot be negative")}
+	}
+
+	if queueProperties.CompletedRetention != nil && *queueProperties.CompletedRetention < 0 {
+		return &errors.ModelError{Field: "CompletedRetention", Err: fmt.Errorf("cannot be negative")}
+	}
+
+	if queueProperties.IdempotencyWindow != nil && *queueProperties.IdempotencyWindow < 0 {
+		return &errors.ModelError{Field: "IdempotencyWindow", Err: fmt.Errorf("cannot be negative")}
+	}
+
+	if queueProperties.MaxPayloadSize != nil && *queueProperties.MaxPayloadSize < 0 {
+		return &errors.ModelError{Field: "MaxPayloadSize", Err: fmt.Errorf("cannot be negative")}
+	}
+
+	if queueProperties.IdleTTL != nil && *queueProperties.IdleTTL < 0 {
+		return &errors.ModelError{Field: "IdleTTL", Err: fmt.Errorf("cannot be negative")}
+	}
+
+	if len(queueProperties.DataSchema) != 0 {
+		if err := queueProperties.validateDataSchema(); err != nil {
+			return &errors.ModelError{Field: "DataSchema", Err: err}
+		}
+	}
+
+	for index, channelWeight := range queueProperties.ChannelWeights {
+		if channelWeight == nil {
+			return &errors.ModelError{Field: fmt.Sprintf("ChannelWeights[%d]", index), Err: fmt.Errorf("received a null value")}
+		}
+
+		if err := channelWeight.Validate(); err != nil {
+			return &errors.ModelError{Field: fmt.Sprintf("ChannelWeights[%d]", index), Child: err}
+		}
+	}
+
+	if queueProperties.PriorityAging != nil {
+		if err := queueProperties.PriorityAging.Validate(); err != nil {
+			return &errors.ModelError{Field: "PriorityAging", Child: err}
+		}
+	}
+
+	if queueProperties.Mirror != nil {
+		if err := queueProperties.Mirror.Validate(); err != nil {
+			return &errors.ModelError{Field: "Mirror", Child: err}
+		}
+	}
+
+	return nil
+}
+
+type PriorityAging struct {
+	// How long an item must wait to have its effective priority raised by 1
+	Interval *time.Duration `json:"Interval,omitempty"`
+
+	// Max amount an item's effective priority can be raised by
+	MaxBoost *int64 `json:"MaxBoost,omitempty"`
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the request object
+//
+// Validate is used to ensure that the priority aging policy has all required fields set
+func (priorityAging *PriorityAging) Validate() *errors.ModelError {
+	if priorityAging.Interval == nil {
+		return &errors.ModelError{Field: "Interval", Err: fmt.Errorf("received a null value")}
+	} else if *priorityAging.Interval <= 0 {
+		return &errors.ModelError{Field: "Interval", Err: fmt.Errorf("must be greater than 0")}
+	}
+
+	if priorityAging.MaxBoost == nil {
+		return &errors.ModelError{Field: "MaxBoost", Err: fmt.Errorf("received a null value")}
+	} else if *priorityAging.MaxBoost <= 0 {
+		return &errors.ModelError{Field: "MaxBoost", Err: fmt.Errorf("must be greater than 0")}
+	}
+
+	return nil
+}
+
+//	PARAMETERS:
+//	- priority - base priority of the item
+//	- waited - how long the item has been enqueued for
+//
+//	RETURNS:
+//	- int64 - priority raised by 1 for every Interval waited, up to the MaxBoost
+//
+// EffectivePriority calculates the priority for an item that has been waiting
+func (priorityAging *PriorityAging) EffectivePriority(priority int64, waited time.Duration) int64 {
+	if priorityAging == nil || waited <= 0 {
+		return priority
+	}
+
+	boost := int64(waited / *priorityAging.Interval)
+	if boost > *priorityAging.MaxBoost {
+		boost = *priorityAging.MaxBoost
+	}
+
+	return priority + boost
+}
+
+// MirrorItemIDHeader is set on every item enqueued to a mirror with the item's ID in the original queue
+const MirrorItemIDHeader = "_willow_mirror_item_id"
+
+// QueueMirror is another Willow service that all enqueued, updated, acked and dead lettered items
+// are streamed to
+type QueueMirror struct {
+	// URL of the Willow service to mirror the queue to. I.E. 'https://standby-willow:8080'
+	URL *string `json:"URL,omitempty"`
+
+	// Optional name of the queue on the mirror. Defaults to the same name as this queue
+	QueueName *string `json:"QueueName,omitempty"`
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the request object
+//
+// Validate is used to ensure that the queue mirror has all required fields set
+func (queueMirror *QueueMirror) Validate() *errors.ModelError {
+	if queueMirror.URL == nil {
+		return &errors.ModelError{Field: "URL", Err: fmt.Errorf("received a null value")}
+	} else {
+		mirrorURL, err := url.Parse(*queueMirror.URL)
+		if err != nil {
+			return &errors.ModelError{Field: "URL", Err: err}
+		}
+
+		if (mirrorURL.Scheme != "http" && mirrorURL.Scheme != "https") || mirrorURL.Host == "" {
+			return &errors.ModelError{Field: "URL", Err: fmt.Errorf("must be an absolute 'http' or 'https' url, but received '%s'", *queueMirror.URL)}
+		}
+	}
+
+	if queueMirror.QueueName != nil && *queueMirror.QueueName == "" {
+		return &errors.ModelError{Field: "QueueName", Err: fmt.Errorf("received an empty string")}
+	}
+
+	return nil
+}
+
+type ChannelWeight struct {
+	// ChannelQuery to match any channels that receive the Weight
+	ChannelQuery *queryassociatedaction.AssociatedActionQuery `json:"ChannelQuery,omitempty"`
+
+	// Weight of the matched channels compared to all other channels in the queue. Must be greater than 0
+	Weight *int64 `json:"Weight,omitempty"`
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the request object
+//
+// Validate is used to ensure that a channel weight has all required fields set
+func (channelWeight *ChannelWeight) Validate() *errors.ModelError {
+	if channelWeight.ChannelQuery == nil {
+		return &errors.ModelError{Field: "ChannelQuery", Err: fmt.Errorf("received a null value")}
+	} else {
+		if err := channelWeight.ChannelQuery.Validate(); err != nil {
+			return &errors.ModelError{Field: "ChannelQuery", Child: err}
+		}
+	}
+
+	if channelWeight.Weight == nil {
+		return &errors.ModelError{Field: "Weight", Err: fmt.Errorf("received a null value")}
+	} else if *channelWeight.Weight <= 0 {
+		return &errors.ModelError{Field: "Weight", Err: fmt.Errorf("must be greater than 0")}
+	}
+
+	return nil
+}
+
+//	RETURNS:
+//	- error - any errors with the DataSchema
+//
+// validateDataSchema ensures the DataSchema is valid JSON that only contains local references
+func (queueProperties *QueueProperties) validateDataSchema() error {
+	if !json.Valid(queueProperties.DataSchema) {
+		return fmt.Errorf("is not valid JSON")
+	}
+
+	var rawSchema any
+	if err := json.Unmarshal(queueProperties.DataSchema, &rawSchema); err != nil {
+		return fmt.Errorf("is not valid JSON: %w", err)
+	}
+
+	return validateSchemaRefs(rawSchema)
+}
+
+// validateSchemaRefs ensures a schema never references a remote document, which would be downloaded when validating
+func validateSchemaRefs(rawSchema any) error {
+	switch value := rawSchema.(type) {
+	case map[string]any:
+		for key, child := range value {
+			if key == "$ref" {
+				if ref, ok := child.(string); !ok || !strings.HasPrefix(ref, "#") {
+					return fmt.Errorf("'$ref' must be a local reference starting with '#', but received '%v'", child)
+				}
+			}
+
+			if err := validateSchemaRefs(child); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, child := range value {
+			if err := validateSchemaRefs(child); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
 type QueueState struct {
 	Deleting bool
+
+	// State of the queue's Mirror. Only set when the queue has a Mirror
+	Mirror *QueueMirrorState `json:"Mirror,omitempty"`
+}
+
+type QueueMirrorState struct {
+	// CatchingUp is set when operations could not be sent to the mirror. Instead of replaying each operation,
+	// the mirror is brought up to date with all the queue's current items once it is available again
+	CatchingUp bool
+
+	// Lag is the number of operations waiting to be sent to the mirror
+	Lag int64
+
+	// LagDuration is how long the oldest change that has not been sent to the mirror has been waiting, or how
+	// long the mirror has been catching up
+	LagDuration time.Duration
+
+	// LastError is the last error encountered sending operations to the mirror. Empty once the mirror is caught up
+	LastError string `json:"LastError,omitempty"`
 }
 
 func (queueState *QueueState) Validate() *errors.ModelError {
@@ -0,0 +1,183 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/DanLavine/willow/pkg/models/api/common/errors"
+	"github.com/DanLavine/willow/pkg/models/datatypes"
+)
+
+// QueueExportVersion is the current version of the QueueExport document. Imports only accept the same version
+const QueueExportVersion int64 = 1
+
+// ExportQuery selects which queues to export
+type ExportQuery struct {
+	// QueueName is an optional filter to only export a single queue. When not set, all queues are exported
+	QueueName *string `json:"QueueName,omitempty"`
+
+	// IncludeCompleted also exports any completed items that are still retained by each queue
+	IncludeCompleted bool `json:"IncludeCompleted,omitempty"`
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the request object
+//
+// Validate is used to ensure that the export query has all required fields set
+func (exportQuery *ExportQuery) Validate() *errors.ModelError {
+	if exportQuery.QueueName != nil && *exportQuery.QueueName == "" {
+		return &errors.ModelError{Field: "QueueName", Err: fmt.Errorf("is an empty string")}
+	}
+
+	return nil
+}
+
+// QueueExport is a versioned document with the full state of any number of queues. It is returned from the
+// export api and can be provided to the import api on another Willow service to recreate the queues
+type QueueExport struct {
+	// Version of the document. Must be the QueueExportVersion
+	Version int64
+
+	// Queues that were exported
+	Queues []*ExportedQueue `json:"Queues,omitempty"`
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the request or response object
+//
+// Validate is used to ensure that the queue export has all required fields set
+func (queueExport *QueueExport) Validate() *errors.ModelError {
+	if queueExport.Version != QueueExportVersion {
+		return &errors.ModelError{Field: "Version", Err: fmt.Errorf("unsupported version %d, expected version %d", queueExport.Version, QueueExportVersion)}
+	}
+
+	names := map[string]struct{}{}
+	for index, exportedQueue := range queueExport.Queues {
+		if exportedQueue == nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Queues[%d]", index), Err: fmt.Errorf("received a null value")}
+		}
+
+		if err := exportedQueue.Validate(); err != nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Queues[%d]", index), Child: err}
+		}
+
+		if _, ok := names[exportedQueue.Name]; ok {
+			return &errors.ModelError{Field: fmt.Sprintf("Queues[%d].Name", index), Err: fmt.Errorf("'%s' is exported more than once", exportedQueue.Name)}
+		}
+		names[exportedQueue.Name] = struct{}{}
+	}
+
+	return nil
+}
+
+// ExportedQueue is the full state of a single queue
+type ExportedQueue struct {
+	// Name of the queue
+	Name string
+
+	// Properties the queue is configured with
+	Properties *QueueProperties
+
+	// All channels that have items
+	Channels []*ExportedChannel `json:"Channels,omitempty"`
+
+	// Completed items that are retained by the queue. Only set when the export requested completed items
+	CompletedItems Items `json:"CompletedItems,omitempty"`
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the request or response object
+//
+// Validate is used to ensure that the exported queue has all required fields set
+func (exportedQueue *ExportedQueue) Validate() *errors.ModelError {
+	if exportedQueue.Name == "" {
+		return &errors.ModelError{Field: "Name", Err: fmt.Errorf("is an empty string")}
+	}
+
+	if exportedQueue.Properties == nil {
+		return &errors.ModelError{Field: "Properties", Err: fmt.Errorf("received a null value")}
+	} else {
+		if err := exportedQueue.Properties.Validate(); err != nil {
+			return &errors.ModelError{Field: "Properties", Child: err}
+		}
+	}
+
+	for index, exportedChannel := range exportedQueue.Channels {
+		if exportedChannel == nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Channels[%d]", index), Err: fmt.Errorf("received a null value")}
+		}
+
+		if err := exportedChannel.Validate(); err != nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Channels[%d]", index), Child: err}
+		}
+	}
+
+	if err := exportedQueue.CompletedItems.Validate(); err != nil {
+		return &errors.ModelError{Field: "CompletedItems", Child: err}
+	}
+
+	return nil
+}
+
+// ExportedChannel is a single channel and all of its items
+type ExportedChannel struct {
+	// KeyValues that define the channel
+	KeyValues datatypes.TypedKeyValues `json:"KeyValues,omitempty"`
+
+	// Items in the order they will be dequeued. Items that were processing are exported first, since the client
+	// processing them can not heartbeat or ack them on another service
+	Items []*ExportedItem `json:"Items,omitempty"`
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the request or response object
+//
+// Validate is used to ensure that the exported channel has all required fields set
+func (exportedChannel *ExportedChannel) Validate() *errors.ModelError {
+	if err := exportedChannel.KeyValues.Validate(datatypes.MinDataType, datatypes.MaxWithoutAnyDataType); err != nil {
+		return &errors.ModelError{Field: "KeyValues", Child: err}
+	}
+
+	for index, exportedItem := range exportedChannel.Items {
+		if exportedItem == nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Items[%d]", index), Err: fmt.Errorf("received a null value")}
+		}
+
+		if err := exportedItem.Validate(); err != nil {
+			return &errors.ModelError{Field: fmt.Sprintf("Items[%d]", index), Child: err}
+		}
+	}
+
+	return nil
+}
+
+// ExportedItem is a single enqueued item
+type ExportedItem struct {
+	// ID of the item. The same ID is used when the item is imported
+	ID string
+
+	// Properties the item was enqueued with
+	Properties *ItemProperties
+
+	// Number of failed attempts to process the item so far
+	RetryCount uint64 `json:"RetryCount,omitempty"`
+}
+
+//	RETURNS:
+//	- error - any errors encountered with the request or response object
+//
+// Validate is used to ensure that the exported item has all required fields set
+func (exportedItem *ExportedItem) Validate() *errors.ModelError {
+	if exportedItem.ID == "" {
+		return &errors.ModelError{Field: "ID", Err: fmt.Errorf("is an empty string")}
+	}
+
+	if exportedItem.Properties == nil {
+		return &errors.ModelError{Field: "Properties", Err: fmt.Errorf("received a null value")}
+	} else {
+		if err := exportedItem.Properties.Validate(); err != nil {
+			return &errors.ModelError{Field: "Properties", Child: err}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package testhelpers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DanLavine/willow/internal/heartbeater"
+)
+
+// NewRunningHeartbeats creates a heartbeat manager that processes timeouts until the test finishes
+func NewRunningHeartbeats(t testing.TB) *heartbeater.Manager {
+	heartbeats := heartbeater.NewManager(heartbeater.DefaultTick)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = heartbeats.Execute(ctx)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return heartbeats
+}
@@ -0,0 +1,82 @@
+package testwillow
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DanLavine/goasync"
+	"github.com/DanLavine/urlrouter"
+	"github.com/DanLavine/willow/internal/willow/api/v1/handlers"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/constructor"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/outbox"
+	"github.com/DanLavine/willow/internal/willow/brokers/queue_channels/reconcile"
+	"github.com/DanLavine/willow/internal/willow/brokers/queues"
+	"github.com/DanLavine/willow/pkg/clients"
+	"github.com/DanLavine/willow/pkg/clients/limiter_client/limiterclientfakes"
+	"github.com/DanLavine/willow/testhelpers"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	v1router "github.com/DanLavine/willow/internal/willow/api/v1/router"
+	queuechannels "github.com/DanLavine/willow/internal/willow/brokers/queue_channels"
+	v1limiter "github.com/DanLavine/willow/pkg/models/api/limiter/v1"
+
+	. "github.com/onsi/gomega"
+)
+
+// Start runs the Willow service in process with a Limiter that never limits any items. The service is
+// shutdown when the test finishes
+func Start(t *testing.T, g *WithT) *httptest.Server {
+	logger := zap.NewNop()
+
+	// setup the limiter client to always pass
+	mockController := gomock.NewController(t)
+	fakeLimiterClient := limiterclientfakes.NewMockLimiterClient(mockController)
+	fakeLimiterClient.EXPECT().MatchRules(gomock.Any(), gomock.Any()).Return(v1limiter.Rules{}, nil).AnyTimes()
+	fakeLimiterClient.EXPECT().QueryCounters(gomock.Any(), gomock.Any()).Return(v1limiter.Counters{}, nil).AnyTimes()
+	fakeLimiterClient.EXPECT().QueryOverrides(gomock.Any(), gomock.Any(), gomock.Any()).Return(v1limiter.Overrides{}, nil).AnyTimes()
+	fakeLimiterClient.EXPECT().CreateOverride(gomock.Any(), gomock.Any(), gomock.Any()).Return(&v1limiter.Override{}, nil).AnyTimes()
+	fakeLimiterClient.EXPECT().UpdateOverride(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	fakeLimiterClient.EXPECT().DeleteOverride(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	fakeLimiterClient.EXPECT().UpdateCounter(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	fakeLimiterClient.EXPECT().SetCounters(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	taskManager := goasync.NewTaskManager(goasync.StrictConfig())
+
+	limiterOutbox := outbox.New(logger, fakeLimiterClient)
+	taskManager.AddExecuteTask("limiter outbox", limiterOutbox)
+
+	queueChannelsConstructor, err := constructor.NewQueueChannelConstructor("memory", testhelpers.NewRunningHeartbeats(t), fakeLimiterClient, limiterOutbox)
+	g.Expect(err).ToNot(HaveOccurred())
+	queueChannelsClient := queuechannels.NewLocalQueueChannelsClient(queueChannelsConstructor, &clients.Config{})
+	taskManager.AddExecuteTask("queue channels client", queueChannelsClient)
+
+	queueConstructor, err := queues.NewQueueConstructor("memory", fakeLimiterClient)
+	g.Expect(err).ToNot(HaveOccurred())
+	queueClient := queues.NewLocalQueueClient(logger, queueConstructor, queueChannelsClient, "willow rule")
+	taskManager.AddExecuteTask("queue client", queueClient)
+
+	limiterReconciler := reconcile.New(logger, fakeLimiterClient, limiterOutbox, time.Hour, queueClient.LimiterCounters)
+
+	willowMux := urlrouter.New()
+	v1router.AddV1WillowRoutes(logger, willowMux, handlers.NewV1QueueHandler(queueClient, "willow rule", limiterReconciler))
+	server := httptest.NewServer(willowMux)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = taskManager.Run(ctx)
+	}()
+
+	// stop the service first so any blocking dequeue requests are released before closing the server
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		server.Close()
+	})
+
+	return server
+}